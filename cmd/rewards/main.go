@@ -9,8 +9,10 @@ import (
 	"beacon-rewards/internal/server"
 	"beacon-rewards/internal/utils"
 	"context"
+	"maps"
 	"os"
 	"os/signal"
+	"slices"
 	"syscall"
 
 	"log/slog"
@@ -58,6 +60,10 @@ func main() {
 		slog.Error("Failed to load configuration", "error", err)
 		os.Exit(1)
 	}
+	if err := cfg.Validate(); err != nil {
+		slog.Error("Invalid configuration", "error", err)
+		os.Exit(1)
+	}
 
 	genesisTimestamp, err := beacon.FetchGenesisTimestamp(context.Background(), cfg.BeaconNodeURL, cfg.RequestTimeout)
 	if err != nil {
@@ -65,7 +71,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	utils.SetGenesisTimestamp(genesisTimestamp)
+	utils.SetGweiAsString(cfg.JSONBigIntAsString)
 	logConfig(cfg, genesisTimestamp)
 
 	var doraDB *dora.DB
@@ -77,6 +83,8 @@ func main() {
 
 	// Create rewards service
 	rewardsService := rewards.NewService(cfg)
+	// The server and Dora lookups take their epochs from the service's chain.
+	rewardsService.SetChain(utils.NewChainConfig(genesisTimestamp, cfg.SecondsPerSlot, cfg.SlotsPerEpoch))
 	// Attach Dora DB so service can sum effective balances
 	rewardsService.SetDoraDB(doraDB)
 	if err := rewardsService.Start(); err != nil {
@@ -115,14 +123,65 @@ func logConfig(cfg *config.Config, genesisTimestamp int64) {
 	args := []any{
 		"listen_address", cfg.ListenAddress(),
 		"cache_reset_interval", cfg.CacheResetInterval,
+		"cache_reset_jitter", cfg.CacheResetJitter,
+		"warmup_from_history", cfg.WarmupFromHistory,
 		"epoch_check_interval", cfg.EpochCheckInterval,
 		"backfill_concurrency", cfg.BackfillConcurrency,
 		"backfill_lookback", cfg.BackfillLookback,
+		"max_backfill_epochs", cfg.MaxBackfillEpochs,
+		"backfill_order", cfg.BackfillOrder,
+		"reconcile_enabled", cfg.EnableReconcile,
+		"anomaly_webhook_enabled", cfg.AnomalyWebhookURL != "",
+		"read_only_replica", cfg.ReadOnlyReplica,
+		"shared_state_file", cfg.SharedStateFile,
+		"cache_flush_interval", cfg.CacheFlushInterval,
 		"request_timeout", cfg.RequestTimeout,
+		"db_query_timeout", cfg.DBQueryTimeout,
+		"dora_statement_timeout", cfg.DoraStatementTimeout,
+		"stake_time_basis", cfg.StakeTimeBasis,
+		"read_timeout", cfg.ReadTimeout,
+		"write_timeout", cfg.WriteTimeout,
+		"idle_timeout", cfg.IdleTimeout,
+		"el_reward_method", cfg.ELRewardMethod,
+		"beacon_block_api_version", cfg.BeaconBlockAPIVersion,
+		"altair_epoch", cfg.AltairEpoch,
+		"tracked_validators", len(cfg.TrackedValidators),
+		"min_apr_window_seconds", cfg.MinAPRWindowSeconds,
+		"offline_threshold_epochs", cfg.OfflineThresholdEpochs,
+		"min_apr_active_fraction", cfg.MinAPRActiveFraction,
+		"validator_history_days", cfg.ValidatorHistoryDays,
+		"address_history_days", cfg.AddressHistoryDays,
+		"address_history_max_addresses", cfg.AddressHistoryMaxAddresses,
+		"fine_history_interval", cfg.FineHistoryInterval,
+		"fine_history_retention", cfg.FineHistoryRetention,
+		"retained_epoch_contributions", cfg.RetainedEpochContributions,
+		"failed_epochs_file", cfg.FailedEpochsFile,
+		"balance_beacon_fallback", cfg.BalanceBeaconFallback,
 		"default_api_limit", cfg.DefaultAPILimit,
+		"max_api_limit", cfg.MaxAPILimit,
+		"max_upload_validators", cfg.MaxUploadValidators,
+		"max_staleness", cfg.MaxStaleness,
+		"strict_staleness", cfg.StrictStaleness,
+		"address_query_concurrency", cfg.AddressQueryConcurrency,
+		"address_cache_size", cfg.AddressCacheSize,
+		"rate_limit_max_ips", cfg.RateLimitMaxIPs,
+		"json_bigint_as_string", cfg.JSONBigIntAsString,
+		"error_format", cfg.ErrorFormat,
 		"depositor_labels_file", cfg.DepositorLabelsFile,
+		"fee_recipients_file", cfg.FeeRecipientsFile,
+		"name_resolver_enabled", cfg.NameResolverURL != "",
 		"frontend_enabled", cfg.EnableFrontend,
+		"templates_dir", cfg.TemplatesDir,
+		"access_log_fields", cfg.AccessLogFields,
+		"enabled_endpoints", slices.Sorted(maps.Keys(cfg.EnabledEndpoints)),
+		"disabled_endpoints", slices.Sorted(maps.Keys(cfg.DisabledEndpoints)),
+		"admin_enabled", cfg.AdminToken != "",
+		"maintenance_mode", cfg.MaintenanceMode,
 		"genesis_timestamp", genesisTimestamp,
+		"seconds_per_slot", cfg.SecondsPerSlot,
+		"slots_per_epoch", cfg.SlotsPerEpoch,
+		"activation_churn_limit_gwei", cfg.ActivationChurnLimitGwei,
+		"max_activation_churn_limit_gwei", cfg.MaxActivationChurnLimitGwei,
 	}
 
 	slog.Info("Configuration loaded", args...)
@@ -16,36 +16,35 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/deposits/top-deposits": {
-            "get": {
+        "/admin/maintenance": {
+            "post": {
+                "description": "While enabled, every endpoint except /health and /admin/* returns 503 with {\"status\":\"maintenance\"}. Requires Authorization: Bearer \u003cADMIN_TOKEN\u003e.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Deposits"
+                    "Admin"
                 ],
-                "summary": "aggregates deposit amounts \u0026\u0026 validator counts by depositor (tx sender) and returns top N by validator counts.",
+                "summary": "Toggle maintenance mode",
                 "parameters": [
-                    {
-                        "type": "integer",
-                        "default": 100,
-                        "description": "Number of results to return",
-                        "name": "limit",
-                        "in": "query"
-                    },
                     {
                         "type": "string",
-                        "default": "total_deposit",
-                        "description": "Sort field (total_deposit,depositor_address,withdrawal_address,validators_total, slashed, voluntary_exited, active, total_active_effective_balance)",
-                        "name": "sort_by",
-                        "in": "query"
+                        "description": "Bearer \u003cADMIN_TOKEN\u003e",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
                     },
                     {
-                        "type": "string",
-                        "default": "desc",
-                        "description": "Sort order (asc|desc)",
-                        "name": "order",
-                        "in": "query"
+                        "description": "Desired state",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.MaintenanceRequest"
+                        }
                     }
                 ],
                 "responses": {
@@ -53,11 +52,13 @@ const docTemplate = `{
                         "description": "OK",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": true
+                            "additionalProperties": {
+                                "type": "boolean"
+                            }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -65,8 +66,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "503": {
-                        "description": "Service Unavailable",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -77,48 +78,55 @@ const docTemplate = `{
                 }
             }
         },
-        "/deposits/top-withdrawals": {
-            "get": {
+        "/admin/nodes": {
+            "post": {
+                "description": "Replaces the beacon node list and/or the execution node used for syncing. Each new node must answer a quick connectivity probe (/eth/v1/node/version, eth_chainId) or nothing is changed. Requests already in flight finish on the old nodes. Returns the active set. Requires Authorization: Bearer \u003cADMIN_TOKEN\u003e.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Deposits"
+                    "Admin"
                 ],
-                "summary": "Aggregates deposit totals and validator counts by withdrawal address and returns the top set.",
+                "summary": "Replace the beacon/EL nodes",
                 "parameters": [
-                    {
-                        "type": "integer",
-                        "default": 100,
-                        "description": "Number of results to return",
-                        "name": "limit",
-                        "in": "query"
-                    },
                     {
                         "type": "string",
-                        "default": "total_active_effective_balance",
-                        "description": "Sort field (total_deposit,withdrawal_address,validators_total, slashed, voluntary_exited, active, total_active_effective_balance)",
-                        "name": "sort_by",
-                        "in": "query"
+                        "description": "Bearer \u003cADMIN_TOKEN\u003e",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
                     },
                     {
-                        "type": "string",
-                        "default": "desc",
-                        "description": "Sort order (asc|desc)",
-                        "name": "order",
-                        "in": "query"
+                        "description": "New nodes",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.NodesRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rewards.NodeSet"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": true
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -126,8 +134,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "503": {
-                        "description": "Service Unavailable",
+                    "502": {
+                        "description": "Bad Gateway",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -138,15 +146,32 @@ const docTemplate = `{
                 }
             }
         },
-        "/health": {
-            "get": {
+        "/admin/sync/epochs/{epoch}": {
+            "delete": {
+                "description": "Subtracts a retained epoch's contribution from every total of the current window, or drops a failed epoch from /sync/failed-epochs. Requires Authorization: Bearer \u003cADMIN_TOKEN\u003e.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Health"
+                    "Admin"
+                ],
+                "summary": "Remove an epoch",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer \u003cADMIN_TOKEN\u003e",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Epoch number",
+                        "name": "epoch",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Health check",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -154,38 +179,69 @@ const docTemplate = `{
                             "type": "object",
                             "additionalProperties": true
                         }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
                     }
                 }
             }
         },
-        "/rewards": {
+        "/admin/sync/retry-epoch/{epoch}": {
             "post": {
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Re-processes an epoch listed by /sync/failed-epochs, or one of the last RETAINED_EPOCH_CONTRIBUTIONS processed epochs. A retained epoch's previous contribution is subtracted before the new one is added, so no epoch is counted twice; if processing fails it is left unchanged. Requires Authorization: Bearer \u003cADMIN_TOKEN\u003e.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Rewards"
+                    "Admin"
                 ],
-                "summary": "Get total rewards (EL+CL) for validators from Today's rewards from UTC 0:00 to the present.",
+                "summary": "Re-process an epoch",
                 "parameters": [
                     {
-                        "description": "Validators request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/server.RewardsRequest"
-                        }
+                        "type": "string",
+                        "description": "Bearer \u003cADMIN_TOKEN\u003e",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Epoch number",
+                        "name": "epoch",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/server.RewardsResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
@@ -196,38 +252,75 @@ const docTemplate = `{
                                 "type": "string"
                             }
                         }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
                     }
                 }
             }
         },
-        "/rewards/by-address": {
-            "post": {
-                "description": "Looks up validators funded by withdrawal or deposit address and returns the summed rewards for those validators. Set include_validator_indices query parameter to true to include active validator indices in the response.",
-                "consumes": [
-                    "application/json"
-                ],
+        "/depositors/{address}/validators": {
+            "get": {
+                "description": "Returns the active validators funded by a deposit or withdrawal address, ordered by index, each with its status, total deposit, effective balance and reward in the current window. This is the per-validator breakdown behind POST /rewards/by-address. Pages hold validators with index \u003e= cursor; pass next_cursor back until it is omitted.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Rewards"
+                    "Validators"
                 ],
-                "summary": "Get aggregated validator rewards (EL+CL) per withdrawal or deposit address.",
+                "summary": "List the validators funded by an address with their rewards",
                 "parameters": [
                     {
-                        "description": "Addresses request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/server.AddressRewardsRequest"
-                        }
+                        "type": "string",
+                        "description": "Deposit/withdrawal address or withdrawal credentials",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
                     },
                     {
-                        "type": "boolean",
-                        "default": false,
-                        "description": "Include validator indices in response",
-                        "name": "include_validator_indices",
+                        "type": "integer",
+                        "description": "Lowest validator index of the page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Page size, capped at MAX_API_LIMIT",
+                        "name": "limit",
                         "in": "query"
                     }
                 ],
@@ -235,7 +328,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/server.AddressRewardsResult"
+                            "$ref": "#/definitions/server.DepositorValidatorsResponse"
                         }
                     },
                     "400": {
@@ -247,6 +340,15 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "503": {
                         "description": "Service Unavailable",
                         "schema": {
@@ -259,22 +361,1611 @@ const docTemplate = `{
                 }
             }
         },
-        "/rewards/network": {
+        "/deposits/links": {
             "get": {
-                "description": "Uses cached consensus/execution rewards to calculate global CL/EL totals and a daily APR estimate.",
+                "description": "Pairs each deposit transaction sender with the withdrawal addresses of the validators it funded and counts the validators per pair, for clustering operator identities. Results are bounded by limit (capped at MAX_API_LIMIT).",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Rewards"
+                    "Deposits"
+                ],
+                "summary": "Link depositor addresses to the withdrawal addresses they fund",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Number of pairs to return, capped at MAX_API_LIMIT",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "validator_count",
+                        "description": "Sort field (validator_count, depositor_address, withdrawal_address)",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "desc",
+                        "description": "Sort order (asc|desc)",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
                 ],
-                "summary": "Get total validator rewards for the config window",
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.DepositLinksResponse"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": true
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/deposits/top-deposits": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Deposits"
+                ],
+                "summary": "aggregates deposit amounts \u0026\u0026 validator counts by depositor (tx sender) and returns top N by validator counts.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Number of results to return, capped at MAX_API_LIMIT",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "total_deposit",
+                        "description": "Sort field (total_deposit,depositor_address,withdrawal_address,validators_total, slashed, voluntary_exited, active, total_active_effective_balance)",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "desc",
+                        "description": "Sort order (asc|desc)",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Minimum total deposit, as integer gwei or decimal ETH with an eth suffix (e.g. 100eth)",
+                        "name": "min_deposit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Minimum number of validators",
+                        "name": "min_validators",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.TopDepositsResponse"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/deposits/top-withdrawals": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Deposits"
+                ],
+                "summary": "Aggregates deposit totals and validator counts by withdrawal address and returns the top set.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Number of results to return, capped at MAX_API_LIMIT",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "total_active_effective_balance",
+                        "description": "Sort field (total_deposit,withdrawal_address,validators_total, slashed, voluntary_exited, active, total_active_effective_balance)",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "desc",
+                        "description": "Sort order (asc|desc)",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Minimum number of validators",
+                        "name": "min_validators",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.TopWithdrawalsResponse"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Health check",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/proposers/top": {
+            "get": {
+                "description": "Ranks validators that proposed at least one block by proposer rewards: attestation, sync aggregate and slashing inclusion plus EL transaction fees.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rewards"
+                ],
+                "summary": "Get the top block proposers in the current window",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Number of results to return, capped at MAX_API_LIMIT",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/rewards.ProposerReward"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/rewards": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rewards"
+                ],
+                "summary": "Get total rewards (EL+CL) for validators from Today's rewards from UTC 0:00 to the present.",
+                "parameters": [
+                    {
+                        "description": "Validators request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.RewardsRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated ValidatorReward fields to return (e.g. total_rewards_gwei,project_apr_percent)",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.RewardsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/rewards/apr/distribution": {
+            "get": {
+                "description": "Annualises each validator's rewards in the current window against its effective balance and returns min, max and the 10th/25th/50th/75th/90th percentiles. Validators that earned attestation rewards in less than MIN_APR_ACTIVE_FRACTION of the window's epochs are excluded and counted separately. Percentiles are zero while apr_available is false. The result is rebuilt in the background after each synced epoch, so it may lag the newest epoch by one rebuild; until the first rebuild finishes the endpoint answers 503 with Retry-After.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rewards"
+                ],
+                "summary": "Get the distribution of per-validator APR",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rewards.APRDistribution"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/rewards/by-address": {
+            "post": {
+                "description": "Looks up validators funded by withdrawal or deposit address and returns the summed rewards for those validators. Set include_validator_indices query parameter to true to include active validator indices in the response. Reward and effective-balance totals cover active validators with rewards in the current window; set include_zero_rewards to true to count active validators without rewards as explicit zeros, so total_effective_balance_gwei covers all active_validator_count validators. When NAME_RESOLVER_URL is configured, an address without a 0x or bls: prefix is treated as a name and resolved first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rewards"
+                ],
+                "summary": "Get aggregated validator rewards (EL+CL) per withdrawal or deposit address.",
+                "parameters": [
+                    {
+                        "description": "Addresses request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.AddressRewardsRequest"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Include validator indices in response",
+                        "name": "include_validator_indices",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Include active validators without rewards in the current window as zeros",
+                        "name": "include_zero_rewards",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated AddressRewardsResult fields to return (e.g. address,total_rewards_gwei)",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.AddressRewardsResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/rewards/by-address/at": {
+            "get": {
+                "description": "Returns the CL/EL totals, effective balance and APR an address's validators earned in the completed cache window that started on the given day (UTC+8), as recorded at that window's reset. Only labelled addresses and addresses with validators queried through POST /rewards/by-address within ADDRESS_HISTORY_DAYS are recorded, so an address is available from the window after its first query. Validators are those the address funded when the window closed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rewards"
+                ],
+                "summary": "Get an address's rewards for a past day",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Withdrawal/deposit address or withdrawal credentials",
+                        "name": "address",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Window start day in UTC+8 (YYYY-MM-DD)",
+                        "name": "date",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rewards.AddressWindowRewards"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/rewards/by-address/history": {
+            "get": {
+                "description": "Sums the retained per-validator totals of each completed cache window (one per day) for the validators currently funded by the address, oldest first. The open window is served by POST /rewards/by-address. At most VALIDATOR_HISTORY_DAYS windows are retained.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rewards"
+                ],
+                "summary": "Get the daily reward series for a withdrawal or deposit address",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Withdrawal/deposit address or withdrawal credentials",
+                        "name": "address",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 30,
+                        "description": "Number of most recent windows to return",
+                        "name": "days",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.AddressRewardHistoryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/rewards/by-label/{label}": {
+            "get": {
+                "description": "Combines the validators of every address mapped to the label in the depositor labels file (matched case-insensitively) and returns one aggregated result. Validators reachable from several of those addresses are counted once. Shares the ADDRESS_QUERY_CONCURRENCY limit with POST /rewards/by-address; excess requests get 503 with Retry-After.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rewards"
+                ],
+                "summary": "Get rewards aggregated by depositor label",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Depositor label",
+                        "name": "label",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include validator indices in the response",
+                        "name": "include_validator_indices",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include active validators without rewards in the current window as zeros",
+                        "name": "include_zero_rewards",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated response fields to return",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.AddressRewardsResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/rewards/el": {
+            "get": {
+                "description": "Returns the EL tips captured for blocks numbered [from_block, to_block] in the current window, with their slot and proposer. Blocks before the window, not yet synced, missed, or proposed by untracked validators are absent. The range may span at most MAX_EL_BLOCK_RANGE blocks.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rewards"
+                ],
+                "summary": "Get EL rewards for an execution block range",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "First execution block number (inclusive)",
+                        "name": "from_block",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Last execution block number (inclusive)",
+                        "name": "to_block",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.ELRewardsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/rewards/export": {
+            "get": {
+                "description": "Streams one ValidatorReward per validator in the current window, ordered by index. The default ndjson format writes one JSON object per line; json writes a single array. Effective balances are looked up from Dora in batches when it is available. Each batch is read under the cache lock separately, so an epoch merged mid-export may be reflected only in later batches.\nWith cursor or limit set, one page of validators with index \u003e= cursor is returned as a JSON RewardsExportPage (rewards, limit, next_cursor, as_of_epoch, window_start) instead; pass next_cursor back until it is omitted. Pages are cut from the sorted set of cached indices, which is taken once per merged epoch, so paging is deterministic by index but only best-effort consistent: pages read in different epochs reflect different epochs, as reported by as_of_epoch.",
+                "produces": [
+                    "application/json",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "Rewards"
+                ],
+                "summary": "Export all cached validator rewards",
+                "parameters": [
+                    {
+                        "enum": [
+                            "ndjson",
+                            "json"
+                        ],
+                        "type": "string",
+                        "default": "ndjson",
+                        "description": "Output format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Lowest validator index of the page (cursor mode)",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Page size (cursor mode), capped at MAX_API_LIMIT",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/rewards.ValidatorReward"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/rewards/network": {
+            "get": {
+                "description": "Uses cached consensus/execution rewards to calculate global CL/EL totals and a daily APR estimate.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rewards"
+                ],
+                "summary": "Get total validator rewards for the config window",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Rebuild the snapshot instead of serving the one cached since the last merged epoch (requires Authorization: Bearer \u003cADMIN_TOKEN\u003e)",
+                        "name": "force_recompute",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/rewards/network/fine": {
+            "get": {
+                "description": "Returns the running network snapshot recorded every FINE_HISTORY_INTERVAL synced epochs, oldest first, for epochs in [from, to]. Totals are cumulative since the window start, so they drop back when the cache resets. Entries older than FINE_HISTORY_RETENTION are dropped.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rewards"
+                ],
+                "summary": "Get intra-day network reward totals",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "First epoch (inclusive)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Last epoch (inclusive)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/rewards.FineNetworkSnapshot"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/rewards/network/summary": {
+            "get": {
+                "description": "Returns the current APR, the 31-day average APR, total rewards and active validator count for the current window. Intended for badges and widgets that do not need /rewards/network's history.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rewards"
+                ],
+                "summary": "Get a compact network rewards summary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.NetworkRewardsSummary"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    }
+                }
+            }
+        },
+        "/rewards/range": {
+            "get": {
+                "description": "Returns rewards for validators with indices in [from, to] that have rewards in the current window. The range may span at most MAX_REWARDS_RANGE indices.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rewards"
+                ],
+                "summary": "Get total rewards (EL+CL) for a range of validator indices",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "First validator index (inclusive)",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Last validator index (inclusive)",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Expected cache epoch; 409 when the cache has moved on",
+                        "name": "as_of_epoch",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated ValidatorReward fields to return (e.g. total_rewards_gwei,project_apr_percent)",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.RewardsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/rewards/upload": {
+            "post": {
+                "description": "Accepts a multipart \"file\" field or a plain-text body with one validator index per line. Blank lines and \"#\" comments are skipped and duplicates are dropped; at most MAX_UPLOAD_VALIDATORS distinct indices are accepted. A 400 lists the first unparseable lines. The response matches POST /rewards.",
+                "consumes": [
+                    "multipart/form-data",
+                    "text/plain"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rewards"
+                ],
+                "summary": "Get total rewards (EL+CL) for an uploaded file of validator indices",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Newline-delimited validator indices",
+                        "name": "file",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated ValidatorReward fields to return (e.g. total_rewards_gwei,project_apr_percent)",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.RewardsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/sync/failed-epochs": {
+            "get": {
+                "description": "Lists epochs of the current window that exhausted EPOCH_PROCESS_MAX_RETRIES, oldest first. Their rewards are missing from all totals until re-run with POST /admin/sync/retry-epoch/{epoch}.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Failed epochs",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/rewards.FailedEpoch"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/sync/status": {
+            "get": {
+                "description": "Reports the sync phase (backfill|live|replica), the epoch range being processed and how much is left.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Sync progress",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rewards.SyncStatus"
+                        }
+                    }
+                }
+            }
+        },
+        "/validators/activation-queue": {
+            "get": {
+                "description": "For a validator index, or every pending validator of an address, returns its position among validators eligible for activation and the estimated activation epoch and time. The queue is ordered by activation eligibility epoch, then index. Validators with an assigned activation epoch report it as is; queued ones wait until ACTIVATION_CHURN_LIMIT_GWEI per epoch (derived from the total active balance like the Electra churn when 0) has drained the effective balance ahead of and including them, plus the seed lookahead. Validators that are not yet eligible have no queue position or estimate.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Validators"
+                ],
+                "summary": "Estimate activation-queue position and time",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Validator index",
+                        "name": "index",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Withdrawal/deposit address or withdrawal credentials",
+                        "name": "address",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.ActivationQueueResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/validators/credential-types/by-address": {
+            "get": {
+                "description": "Counts the validators funded by the address by withdrawal credential prefix (0x00 BLS, 0x01 execution, 0x02 compounding), e.g. to follow a 0x01 to 0x02 migration.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Validators"
+                ],
+                "summary": "Count an address's validators per withdrawal credential type",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Withdrawal/deposit address or withdrawal credentials",
+                        "name": "address",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.CredentialTypesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/validators/pending/by-address": {
+            "get": {
+                "description": "Returns validators whose activation epoch is still in the future, with the estimated activation time when an activation epoch has been assigned.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Validators"
+                ],
+                "summary": "List validators pending activation for an address",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Withdrawal/deposit address or withdrawal credentials",
+                        "name": "address",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.PendingValidatorsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/validators/skim-estimate": {
+            "get": {
+                "description": "Uses the validator's current balance and its CL reward rate over the cache window to estimate how many epochs remain until the balance exceeds 32 ETH. Validators without 0x01 credentials are reported as not applicable.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Validators"
+                ],
+                "summary": "Estimate time until the next skim for a 0x01 validator",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Validator index",
+                        "name": "index",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.SkimEstimate"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/validators/{index}/attestation-detail": {
+            "get": {
+                "description": "Sums the validator's source, target and head rewards (net of penalties) over the current window and compares each with the beacon node's ideal_rewards for a perfect validator of the same effective balance, over the epochs it attested in. The effective balance comes from Dora, or DEFAULT_EFFECTIVE_BALANCE_GWEI without it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Validators"
+                ],
+                "summary": "Get a validator's actual vs ideal attestation rewards",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Validator index",
+                        "name": "index",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rewards.AttestationDetail"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/validators/{index}/balance-history": {
+            "get": {
+                "description": "Returns the balance recorded for each epoch in [from_epoch, to_epoch], oldest first. to_epoch defaults to the current epoch and from_epoch to one day earlier; the range may span at most 10000 epochs. Responds 501 when the Dora schema only keeps the latest balance.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Validators"
+                ],
+                "summary": "Get a validator's balance history",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Validator index",
+                        "name": "index",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "First epoch (inclusive)",
+                        "name": "from_epoch",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Last epoch (inclusive)",
+                        "name": "to_epoch",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.BalanceHistoryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/validators/{index}/slashing-estimate": {
+            "get": {
+                "description": "For a slashed validator, reports the slashing epoch (from the block that included the slashing, or derived from the withdrawable epoch when Dora has not indexed it) and estimates the correlation penalty applied 4096 epochs before its withdrawable epoch from the balance slashed network-wide (validators not yet withdrawable) relative to the total active balance. The figure changes as further validators are slashed before the penalty epoch. Unslashed validators are reported with slashed=false.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Validators"
+                ],
+                "summary": "Estimate the remaining slashing penalty of a validator",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Validator index",
+                        "name": "index",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.SlashingEstimate"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/validators/{index}/upcoming-proposals": {
+            "get": {
+                "description": "Checks the beacon node's proposer duties for the current and next epoch and returns the validator's slots that have not started yet, with their start times. Duties are cached per epoch, so only the first query of an epoch reaches the beacon node. Duties further ahead are not known to the chain. When the node does not serve next-epoch duties yet, next_epoch_checked is false and only the current epoch is covered; retry later for the full view.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Validators"
+                ],
+                "summary": "List a validator's upcoming block proposals",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Validator index",
+                        "name": "index",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.UpcomingProposalsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
@@ -282,22 +1973,687 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "dora.BalancePoint": {
+            "type": "object",
+            "properties": {
+                "balance": {
+                    "type": "integer"
+                },
+                "effective_balance": {
+                    "type": "integer"
+                },
+                "epoch": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dora.DepositorStat": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "integer"
+                },
+                "depositor_address": {
+                    "type": "string"
+                },
+                "depositor_label": {
+                    "type": "string"
+                },
+                "initial_deposit": {
+                    "description": "InitialDeposit sums each validator's first deposit (new capital); TopUpDeposit sums later\ndeposits to an existing pubkey. Together they equal TotalDeposit.",
+                    "type": "integer"
+                },
+                "slashed": {
+                    "type": "integer"
+                },
+                "top_up_deposit": {
+                    "type": "integer"
+                },
+                "total_active_effective_balance": {
+                    "type": "integer"
+                },
+                "total_deposit": {
+                    "type": "integer"
+                },
+                "validators_total": {
+                    "type": "integer"
+                },
+                "voluntary_exited": {
+                    "type": "integer"
+                },
+                "withdrawal_address": {
+                    "type": "string"
+                }
+            }
+        },
+        "dora.DepositorWithdrawalLink": {
+            "type": "object",
+            "properties": {
+                "depositor_address": {
+                    "type": "string"
+                },
+                "validator_count": {
+                    "type": "integer"
+                },
+                "withdrawal_address": {
+                    "type": "string"
+                }
+            }
+        },
+        "dora.WithdrawalStat": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "integer"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "slashed": {
+                    "type": "integer"
+                },
+                "total_active_effective_balance": {
+                    "type": "integer"
+                },
+                "total_deposit": {
+                    "type": "integer"
+                },
+                "validators_total": {
+                    "type": "integer"
+                },
+                "voluntary_exited": {
+                    "type": "integer"
+                },
+                "withdrawal_address": {
+                    "type": "string"
+                }
+            }
+        },
+        "rewards.APRDistribution": {
+            "type": "object",
+            "properties": {
+                "apr_available": {
+                    "type": "boolean"
+                },
+                "excluded_count": {
+                    "type": "integer"
+                },
+                "max_percent": {
+                    "type": "number"
+                },
+                "min_active_fraction": {
+                    "type": "number"
+                },
+                "min_percent": {
+                    "type": "number"
+                },
+                "p10_percent": {
+                    "type": "number"
+                },
+                "p25_percent": {
+                    "type": "number"
+                },
+                "p50_percent": {
+                    "type": "number"
+                },
+                "p75_percent": {
+                    "type": "number"
+                },
+                "p90_percent": {
+                    "type": "number"
+                },
+                "validator_count": {
+                    "description": "ValidatorCount validators are included; ExcludedCount were active for less than\nMinActiveFraction of the window's epochs.",
+                    "type": "integer"
+                },
+                "window_end": {
+                    "type": "string"
+                },
+                "window_start": {
+                    "type": "string"
+                }
+            }
+        },
+        "rewards.AddressWindowRewards": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "apr_percent": {
+                    "description": "Window rewards annualised against effective_balance_gwei.",
+                    "type": "number"
+                },
+                "cl_rewards_gwei": {
+                    "type": "integer"
+                },
+                "effective_balance_gwei": {
+                    "type": "integer"
+                },
+                "el_rewards_gwei": {
+                    "type": "integer"
+                },
+                "total_rewards_gwei": {
+                    "type": "integer"
+                },
+                "validator_count": {
+                    "description": "Validators with rewards in the window.",
+                    "type": "integer"
+                },
+                "window_end": {
+                    "type": "string"
+                },
+                "window_start": {
+                    "type": "string"
+                }
+            }
+        },
+        "rewards.AttestationComponent": {
+            "type": "object",
+            "properties": {
+                "actual_gwei": {
+                    "description": "Rewards net of penalties.",
+                    "type": "integer"
+                },
+                "efficiency_percent": {
+                    "type": "number"
+                },
+                "ideal_gwei": {
+                    "type": "integer"
+                }
+            }
+        },
+        "rewards.AttestationDetail": {
+            "type": "object",
+            "properties": {
+                "effective_balance_gwei": {
+                    "description": "Balance the ideal is scaled by.",
+                    "type": "integer"
+                },
+                "head": {
+                    "$ref": "#/definitions/rewards.AttestationComponent"
+                },
+                "source": {
+                    "$ref": "#/definitions/rewards.AttestationComponent"
+                },
+                "target": {
+                    "$ref": "#/definitions/rewards.AttestationComponent"
+                },
+                "total": {
+                    "$ref": "#/definitions/rewards.AttestationComponent"
+                },
+                "validator_index": {
+                    "type": "integer"
+                },
+                "window_end": {
+                    "type": "string"
+                },
+                "window_start": {
+                    "type": "string"
+                }
+            }
+        },
+        "rewards.DailyRewards": {
+            "type": "object",
+            "properties": {
+                "cl_rewards_gwei": {
+                    "type": "integer"
+                },
+                "el_rewards_gwei": {
+                    "type": "integer"
+                },
+                "total_rewards_gwei": {
+                    "type": "integer"
+                },
+                "validator_count": {
+                    "description": "Validators with rewards in the window.",
+                    "type": "integer"
+                },
+                "window_end": {
+                    "type": "string"
+                },
+                "window_start": {
+                    "type": "string"
+                }
+            }
+        },
+        "rewards.ELBlockReward": {
+            "type": "object",
+            "properties": {
+                "block_number": {
+                    "type": "integer"
+                },
+                "proposer_index": {
+                    "type": "integer"
+                },
+                "slot": {
+                    "type": "integer"
+                },
+                "tip_gwei": {
+                    "type": "integer"
+                },
+                "tip_wei": {
+                    "description": "Exact decimal amount; TipGwei is truncated.",
+                    "type": "string"
+                }
+            }
+        },
+        "rewards.FailedEpoch": {
+            "type": "object",
+            "properties": {
+                "epoch": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "failed_at": {
+                    "type": "string"
+                },
+                "failures": {
+                    "description": "Times the epoch exhausted its retries, including admin re-runs.",
+                    "type": "integer"
+                }
+            }
+        },
+        "rewards.FineNetworkSnapshot": {
+            "type": "object",
+            "properties": {
+                "active_validator_count": {
+                    "type": "integer"
+                },
+                "apr_available": {
+                    "type": "boolean"
+                },
+                "cl_rewards_gwei": {
+                    "type": "integer"
+                },
+                "efficiency_percent": {
+                    "description": "EfficiencyPercent is network attestation rewards over the ideal for the window's effective balance.",
+                    "type": "number"
+                },
+                "el_rewards_gwei": {
+                    "type": "integer"
+                },
+                "epoch": {
+                    "type": "integer"
+                },
+                "from_history": {
+                    "description": "FromHistory is set on the stored snapshot served while the cache warms up after a start (see\nWARMUP_FROM_HISTORY); it describes that earlier window, not the current one.",
+                    "type": "boolean"
+                },
+                "inactivity_leak_gwei": {
+                    "type": "integer"
+                },
+                "incomplete_epochs": {
+                    "description": "IncompleteEpochs counts epochs whose attestation rewards covered fewer validators than Dora\nreported active; totals are then slightly low. Always 0 without Dora or when scoped.",
+                    "type": "integer"
+                },
+                "project_apr_percent": {
+                    "description": "ProjectAprPercent uses the time-weighted balance when available; SimpleAprPercent always uses\nthe point-in-time TotalEffectiveBalanceGwei and is kept for comparison.",
+                    "type": "number"
+                },
+                "scoped": {
+                    "description": "Scoped is set when only TRACKED_VALIDATORS are synced; totals then cover that subset only.",
+                    "type": "boolean"
+                },
+                "simple_apr_percent": {
+                    "type": "number"
+                },
+                "time_weighted_effective_balance_gwei": {
+                    "description": "TimeWeightedEffectiveBalanceGwei averages effective balance over the window, weighting each\nvalidator by the fraction of the window it was active. Zero when Dora is unavailable.",
+                    "type": "integer"
+                },
+                "total_effective_balance_gwei": {
+                    "type": "integer"
+                },
+                "total_rewards_gwei": {
+                    "type": "integer"
+                },
+                "tracked_validator_count": {
+                    "type": "integer"
+                },
+                "window_duration_seconds": {
+                    "type": "number"
+                },
+                "window_end": {
+                    "type": "string"
+                },
+                "window_end_epoch": {
+                    "type": "integer"
+                },
+                "window_start": {
+                    "type": "string"
+                },
+                "window_start_epoch": {
+                    "description": "WindowStartEpoch is the first epoch of the window and WindowEndEpoch the newest synced one,\nboth inclusive; they are equal while no epoch of the window has been synced.",
+                    "type": "integer"
+                }
+            }
+        },
+        "rewards.NodeSet": {
+            "type": "object",
+            "properties": {
+                "beacon_node_urls": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "execution_node_url": {
+                    "type": "string"
+                }
+            }
+        },
+        "rewards.ProposerReward": {
+            "type": "object",
+            "properties": {
+                "blocks_proposed": {
+                    "type": "integer"
+                },
+                "cl_proposer_rewards_gwei": {
+                    "description": "Attestation, sync aggregate and slashing inclusion rewards from the proposed blocks.",
+                    "type": "integer"
+                },
+                "el_rewards_gwei": {
+                    "description": "Transaction fee rewards from the proposed blocks.",
+                    "type": "integer"
+                },
+                "total_proposer_rewards_gwei": {
+                    "type": "integer"
+                },
+                "validator_index": {
+                    "type": "integer"
+                }
+            }
+        },
+        "rewards.SyncStatus": {
+            "type": "object",
+            "properties": {
+                "current_epoch": {
+                    "type": "integer"
+                },
+                "epochs_remaining": {
+                    "type": "integer"
+                },
+                "percent_complete": {
+                    "type": "number"
+                },
+                "phase": {
+                    "type": "string"
+                },
+                "start_epoch": {
+                    "type": "integer"
+                },
+                "target_epoch": {
+                    "type": "integer"
+                }
+            }
+        },
+        "rewards.UpcomingProposal": {
+            "type": "object",
+            "properties": {
+                "epoch": {
+                    "type": "integer"
+                },
+                "slot": {
+                    "type": "integer"
+                },
+                "time": {
+                    "description": "When the slot starts.",
+                    "type": "string"
+                }
+            }
+        },
         "rewards.ValidatorReward": {
             "type": "object",
             "properties": {
+                "cl_penalties_gwei": {
+                    "description": "All CL penalty components, as a positive amount.",
+                    "type": "integer"
+                },
+                "cl_rewards_gwei": {
+                    "description": "Net of penalties: GrossClRewardsGwei - ClPenaltiesGwei.",
+                    "type": "integer"
+                },
+                "effective_balance_gwei": {
+                    "type": "integer"
+                },
+                "efficiency_percent": {
+                    "description": "EfficiencyPercent is attestation rewards as a percentage of the ideal for this effective balance.",
+                    "type": "number"
+                },
+                "el_rewards_gwei": {
+                    "type": "integer"
+                },
+                "epochs_since_active": {
+                    "type": "integer"
+                },
+                "gross_cl_rewards_gwei": {
+                    "description": "CL reward components before penalties.",
+                    "type": "integer"
+                },
+                "inactivity_leak_gwei": {
+                    "description": "Negative component already netted into ClRewardsGwei.",
+                    "type": "integer"
+                },
+                "last_active_epoch": {
+                    "description": "LastActiveEpoch is the newest synced epoch with a positive attestation reward, possibly from an\nearlier window; unset when none was seen since the process started.",
+                    "type": "integer"
+                },
+                "offline": {
+                    "description": "Offline is set after more than OFFLINE_THRESHOLD_EPOCHS synced epochs without attestation rewards.",
+                    "type": "boolean"
+                },
+                "project_apr_percent": {
+                    "type": "number"
+                },
+                "sync_slots_missed": {
+                    "type": "integer"
+                },
+                "sync_slots_participated": {
+                    "description": "Sync committee slots in the window where the validator was rewarded (participated) or penalized (missed).",
+                    "type": "integer"
+                },
+                "total_rewards_gwei": {
+                    "type": "integer"
+                },
+                "validator_index": {
+                    "type": "integer"
+                }
+            }
+        },
+        "server.ActivationQueueEstimate": {
+            "type": "object",
+            "properties": {
+                "estimated_activation_epoch": {
+                    "description": "The estimate is omitted while the validator is not yet eligible for activation, and so not queued.",
+                    "type": "integer"
+                },
+                "estimated_activation_time": {
+                    "type": "string"
+                },
+                "queue_position": {
+                    "description": "1-based among all eligible validators pending activation.",
+                    "type": "integer"
+                },
+                "scheduled": {
+                    "description": "Scheduled is set once the chain has assigned the activation epoch; the estimate is then exact.",
+                    "type": "boolean"
+                },
+                "validator_index": {
+                    "type": "integer"
+                }
+            }
+        },
+        "server.ActivationQueueResponse": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "churn_limit_gwei": {
+                    "description": "Balance activated per epoch assumed by the estimates.",
+                    "type": "integer"
+                },
+                "epoch": {
+                    "type": "integer"
+                },
+                "queue_length": {
+                    "type": "integer"
+                },
+                "validators": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.ActivationQueueEstimate"
+                    }
+                }
+            }
+        },
+        "server.AddressRewardHistoryResponse": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "days": {
+                    "type": "integer"
+                },
+                "history": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rewards.DailyRewards"
+                    }
+                },
+                "validator_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "server.AddressRewardsRequest": {
+            "type": "object",
+            "required": [
+                "address"
+            ],
+            "properties": {
+                "address": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.AddressRewardsResult": {
+            "type": "object",
+            "properties": {
+                "active_validator_count": {
+                    "type": "integer"
+                },
+                "address": {
+                    "type": "string"
+                },
+                "addresses": {
+                    "description": "Addresses lists the addresses combined by GET /rewards/by-label; Address then holds the label.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "balance_change_gwei": {
+                    "description": "BalanceChangeGwei is the change in effective balance since the previous cache window closed,\nsummed over the validators whose balance was recorded then; a drop points at penalties or an\ninactivity leak. Omitted until a window with recorded balances is retained.",
+                    "type": "integer"
+                },
+                "cl_penalties_gwei": {
+                    "type": "integer"
+                },
                 "cl_rewards_gwei": {
                     "type": "integer"
                 },
-                "effective_balance_gwei": {
+                "depositor_label": {
+                    "type": "string"
+                },
+                "el_rewards_gwei": {
+                    "type": "integer"
+                },
+                "estimate_apr_source": {
+                    "description": "\"validator_set\" or \"network\"",
+                    "type": "string"
+                },
+                "estimated_history_rewards_31d_gwei": {
+                    "type": "number"
+                },
+                "fee_recipient_el_rewards_gwei": {
+                    "description": "FeeRecipientElRewardsGwei is EL income that other validators sent to the fee recipients in\nFeeRecipients, which FEE_RECIPIENTS_FILE maps to this address; ElRewardsGwei and\nTotalRewardsGwei already include it.",
+                    "type": "integer"
+                },
+                "fee_recipients": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "gross_cl_rewards_gwei": {
+                    "type": "integer"
+                },
+                "inactivity_leak_gwei": {
+                    "type": "integer"
+                },
+                "pending_validator_count": {
+                    "type": "integer"
+                },
+                "total_effective_balance_gwei": {
+                    "type": "integer"
+                },
+                "total_rewards_gwei": {
+                    "type": "integer"
+                },
+                "validator_credential_types": {
+                    "description": "ValidatorCredentialTypes maps each validator index to its withdrawal credential prefix\n(\"0x00\", \"0x01\" or \"0x02\"); only set with include_validator_indices.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "validator_indices": {
+                    "description": "Set, possibly to [], only with include_validator_indices.",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "weighted_average_stake_time(seconds)": {
+                    "type": "integer"
+                },
+                "weighted_average_time_since_activation_seconds": {
+                    "description": "Effective-balance-weighted stake time of the active validators from each basis;\nWeightedAverageStakeTime repeats the one selected by STAKE_TIME_BASIS.",
+                    "type": "integer"
+                },
+                "weighted_average_time_since_deposit_seconds": {
+                    "type": "integer"
+                },
+                "window_end": {
+                    "type": "string"
+                },
+                "window_start": {
+                    "type": "string"
+                },
+                "zero_reward_validator_count": {
+                    "description": "ZeroRewardValidatorCount is the number of active validators without rewards in the current\nwindow. It is only reported with include_zero_rewards, which also adds their effective balance\nto TotalEffectiveBalanceGwei so it covers every active validator.",
                     "type": "integer"
+                }
+            }
+        },
+        "server.BalanceHistoryResponse": {
+            "type": "object",
+            "properties": {
+                "balances": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dora.BalancePoint"
+                    }
                 },
-                "el_rewards_gwei": {
+                "from_epoch": {
                     "type": "integer"
                 },
-                "project_apr_percent": {
-                    "type": "number"
-                },
-                "total_rewards_gwei": {
+                "to_epoch": {
                     "type": "integer"
                 },
                 "validator_index": {
@@ -305,51 +2661,162 @@ const docTemplate = `{
                 }
             }
         },
-        "server.AddressRewardsRequest": {
+        "server.CredentialTypesResponse": {
             "type": "object",
-            "required": [
-                "address"
-            ],
             "properties": {
                 "address": {
                     "type": "string"
+                },
+                "counts": {
+                    "description": "Keyed by prefix: \"0x00\", \"0x01\", \"0x02\".",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "validator_count": {
+                    "type": "integer"
                 }
             }
         },
-        "server.AddressRewardsResult": {
+        "server.DepositLinksResponse": {
             "type": "object",
             "properties": {
-                "active_validator_count": {
+                "limit": {
                     "type": "integer"
                 },
-                "address": {
+                "limit_capped": {
+                    "type": "boolean"
+                },
+                "order": {
                     "type": "string"
                 },
-                "cl_rewards_gwei": {
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dora.DepositorWithdrawalLink"
+                    }
+                },
+                "sort_by": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.DepositorValidator": {
+            "type": "object",
+            "properties": {
+                "deposit_gwei": {
                     "type": "integer"
                 },
-                "depositor_label": {
+                "effective_balance_gwei": {
+                    "type": "integer"
+                },
+                "reward": {
+                    "description": "Reward is omitted for validators without rewards in the current window.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/rewards.ValidatorReward"
+                        }
+                    ]
+                },
+                "status": {
+                    "description": "Status is \"active\", or \"exiting\" once an exit epoch has been scheduled.",
                     "type": "string"
                 },
-                "el_rewards_gwei": {
+                "validator_index": {
                     "type": "integer"
+                }
+            }
+        },
+        "server.DepositorValidatorsResponse": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
                 },
-                "estimated_history_rewards_31d_gwei": {
-                    "type": "number"
+                "as_of_epoch": {
+                    "description": "AsOfEpoch and WindowStart identify the cache state the page's rewards were read from.",
+                    "type": "integer"
                 },
-                "total_effective_balance_gwei": {
+                "limit": {
                     "type": "integer"
                 },
-                "total_rewards_gwei": {
+                "next_cursor": {
                     "type": "integer"
                 },
-                "validator_indices": {
+                "total": {
+                    "description": "Active validators of the address across all pages.",
+                    "type": "integer"
+                },
+                "validators": {
                     "type": "array",
                     "items": {
-                        "type": "integer"
+                        "$ref": "#/definitions/server.DepositorValidator"
                     }
                 },
-                "weighted_average_stake_time(seconds)": {
+                "window_start": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.ELRewardsResponse": {
+            "type": "object",
+            "properties": {
+                "block_count": {
+                    "type": "integer"
+                },
+                "blocks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rewards.ELBlockReward"
+                    }
+                },
+                "from_block": {
+                    "type": "integer"
+                },
+                "to_block": {
+                    "type": "integer"
+                },
+                "total_tip_gwei": {
+                    "type": "integer"
+                },
+                "window_end": {
+                    "type": "string"
+                },
+                "window_start": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.MaintenanceRequest": {
+            "type": "object",
+            "required": [
+                "enabled"
+            ],
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "server.NetworkRewardsSummary": {
+            "type": "object",
+            "properties": {
+                "active_validator_count": {
+                    "type": "integer"
+                },
+                "apr_31d_avg": {
+                    "description": "Same outlier-trimmed average the 31-day estimates use; 0 without history.",
+                    "type": "number"
+                },
+                "from_history": {
+                    "description": "FromHistory is set while the cache warms up and the figures are the last stored window's.",
+                    "type": "boolean"
+                },
+                "project_apr_percent": {
+                    "type": "number"
+                },
+                "total_rewards_gwei": {
                     "type": "integer"
                 },
                 "window_end": {
@@ -360,12 +2827,60 @@ const docTemplate = `{
                 }
             }
         },
+        "server.NodesRequest": {
+            "type": "object",
+            "properties": {
+                "beacon_node_url": {
+                    "description": "BeaconNodeURL is a comma-separated list in BEACON_NODE_URL format, weights included.",
+                    "type": "string"
+                },
+                "execution_node_url": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.PendingValidator": {
+            "type": "object",
+            "properties": {
+                "activation_epoch": {
+                    "type": "integer"
+                },
+                "estimated_activation_time": {
+                    "description": "EstimatedActivationTime is omitted while the validator is still queued without an assigned activation epoch.",
+                    "type": "string"
+                },
+                "validator_index": {
+                    "type": "integer"
+                }
+            }
+        },
+        "server.PendingValidatorsResponse": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "validators": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.PendingValidator"
+                    }
+                }
+            }
+        },
         "server.RewardsRequest": {
             "type": "object",
             "required": [
                 "validators"
             ],
             "properties": {
+                "as_of_epoch": {
+                    "description": "AsOfEpoch, when set, must match the epoch the cache has reached; otherwise 409 is returned with\nthe current epoch so a client can re-read a consistent set.",
+                    "type": "integer"
+                },
                 "validators": {
                     "type": "array",
                     "items": {
@@ -377,20 +2892,190 @@ const docTemplate = `{
         "server.RewardsResponse": {
             "type": "object",
             "properties": {
+                "apr_available": {
+                    "type": "boolean"
+                },
+                "as_of_epoch": {
+                    "description": "Latest epoch included in Rewards.",
+                    "type": "integer"
+                },
                 "rewards": {
                     "type": "object",
                     "additionalProperties": {
                         "$ref": "#/definitions/rewards.ValidatorReward"
                     }
                 },
+                "stale": {
+                    "description": "SyncLagSeconds exceeds MAX_STALENESS.",
+                    "type": "boolean"
+                },
+                "sync_lag_seconds": {
+                    "description": "Time since AsOfEpoch ended.",
+                    "type": "integer"
+                },
                 "validator_count": {
                     "type": "integer"
                 },
                 "window_end": {
                     "type": "string"
                 },
+                "window_end_epoch": {
+                    "type": "integer"
+                },
                 "window_start": {
                     "type": "string"
+                },
+                "window_start_epoch": {
+                    "description": "WindowStartEpoch and WindowEndEpoch bound the window in epochs, both inclusive, so clients\nneed not derive them from the times and genesis.",
+                    "type": "integer"
+                }
+            }
+        },
+        "server.SkimEstimate": {
+            "type": "object",
+            "properties": {
+                "applicable": {
+                    "type": "boolean"
+                },
+                "balance_gwei": {
+                    "type": "integer"
+                },
+                "credential_type": {
+                    "type": "string"
+                },
+                "epochs_until_skim": {
+                    "type": "integer"
+                },
+                "estimated_skim_time": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "reward_rate_gwei_per_epoch": {
+                    "type": "number"
+                },
+                "validator_index": {
+                    "type": "integer"
+                }
+            }
+        },
+        "server.SlashingEstimate": {
+            "type": "object",
+            "properties": {
+                "correlation_penalty_epoch": {
+                    "type": "integer"
+                },
+                "correlation_penalty_time": {
+                    "type": "string"
+                },
+                "effective_balance_gwei": {
+                    "type": "integer"
+                },
+                "estimated_remaining_penalty_gwei": {
+                    "type": "integer"
+                },
+                "network_slashed_balance_gwei": {
+                    "description": "Balance of all validators slashed within the vector and the total active balance it is compared with.",
+                    "type": "integer"
+                },
+                "penalty_applied": {
+                    "type": "boolean"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "slashed": {
+                    "type": "boolean"
+                },
+                "slashed_balance_fraction": {
+                    "type": "number"
+                },
+                "slashed_epoch": {
+                    "description": "SlashedEpoch comes from the block that included the slashing. Without it, it is derived from the\nwithdrawable epoch (slashing epoch + 8192), which is too late for validators already exiting.",
+                    "type": "integer"
+                },
+                "total_active_balance_gwei": {
+                    "type": "integer"
+                },
+                "validator_index": {
+                    "type": "integer"
+                }
+            }
+        },
+        "server.TopDepositsResponse": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
+                },
+                "limit_capped": {
+                    "type": "boolean"
+                },
+                "min_validators": {
+                    "description": "Applied min_validators filter; 0 means none.",
+                    "type": "integer"
+                },
+                "order": {
+                    "type": "string"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dora.DepositorStat"
+                    }
+                },
+                "sort_by": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.TopWithdrawalsResponse": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
+                },
+                "limit_capped": {
+                    "type": "boolean"
+                },
+                "min_validators": {
+                    "description": "Applied min_validators filter; 0 means none.",
+                    "type": "integer"
+                },
+                "order": {
+                    "type": "string"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dora.WithdrawalStat"
+                    }
+                },
+                "sort_by": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.UpcomingProposalsResponse": {
+            "type": "object",
+            "properties": {
+                "epoch": {
+                    "description": "Current epoch.",
+                    "type": "integer"
+                },
+                "next_epoch_checked": {
+                    "description": "NextEpochChecked is false when the beacon node did not reveal the next epoch's duties yet;\nonly the rest of the current epoch was checked then.",
+                    "type": "boolean"
+                },
+                "proposals": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rewards.UpcomingProposal"
+                    }
+                },
+                "validator_index": {
+                    "type": "integer"
                 }
             }
         }
@@ -0,0 +1,59 @@
+package beacon
+
+import (
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultEndpointWeight = 1
+	maxEndpointWeight     = 100
+)
+
+// Endpoint is a beacon node base URL with its routing weight.
+type Endpoint struct {
+	URL    string
+	Weight int
+}
+
+// ParseEndpoints splits a comma-separated list of beacon node URLs.
+// A `weight` query parameter (e.g. http://local:5052?weight=9) sets the routing weight and is
+// stripped from the returned URL; endpoints without one default to weight 1.
+func ParseEndpoints(raw string) []Endpoint {
+	var endpoints []Endpoint
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		endpoints = append(endpoints, parseEndpoint(part))
+	}
+	return endpoints
+}
+
+func parseEndpoint(raw string) Endpoint {
+	ep := Endpoint{URL: raw, Weight: defaultEndpointWeight}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ep
+	}
+	q := u.Query()
+	if !q.Has("weight") {
+		return ep
+	}
+
+	w, err := strconv.Atoi(q.Get("weight"))
+	if err != nil || w <= 0 {
+		slog.Warn("Ignoring invalid beacon endpoint weight", "endpoint", raw, "weight", q.Get("weight"))
+		w = defaultEndpointWeight
+	}
+	ep.Weight = min(w, maxEndpointWeight)
+
+	q.Del("weight")
+	u.RawQuery = q.Encode()
+	ep.URL = u.String()
+	return ep
+}
@@ -0,0 +1,29 @@
+package beacon
+
+import "testing"
+
+func TestParseEndpoints(t *testing.T) {
+	got := ParseEndpoints(" http://local:5052?weight=9 , http://backup:5052/?weight=1,http://plain:5052,, http://bad?weight=x")
+	want := []Endpoint{
+		{URL: "http://local:5052", Weight: 9},
+		{URL: "http://backup:5052/", Weight: 1},
+		{URL: "http://plain:5052", Weight: 1},
+		{URL: "http://bad", Weight: 1},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("ParseEndpoints returned %d endpoints, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("endpoint %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseEndpointsKeepsOtherQueryParams(t *testing.T) {
+	got := ParseEndpoints("http://node:5052?token=abc&weight=3")
+	if len(got) != 1 || got[0].URL != "http://node:5052?token=abc" || got[0].Weight != 3 {
+		t.Fatalf("unexpected endpoint: %+v", got)
+	}
+}
@@ -27,11 +27,8 @@ func FetchGenesisTimestamp(ctx context.Context, baseURL string, timeout time.Dur
 	}
 
 	var endpoints []string
-	for _, part := range strings.Split(trimmedURL, ",") {
-		part = strings.TrimSpace(part)
-		if part != "" {
-			endpoints = append(endpoints, part)
-		}
+	for _, ep := range ParseEndpoints(trimmedURL) {
+		endpoints = append(endpoints, ep.URL)
 	}
 	if len(endpoints) == 0 {
 		return 0, errors.New("beacon node URL is empty")
@@ -0,0 +1,33 @@
+package beacon
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CheckNode verifies that a beacon node answers /eth/v1/node/version within timeout.
+func CheckNode(ctx context.Context, baseURL string, timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = defaultRequestTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	endpoint := strings.TrimSuffix(strings.TrimSpace(baseURL), "/") + "/eth/v1/node/version"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("%s: create request: %w", baseURL, err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: request node version: %w", baseURL, err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: node version request failed: %s", baseURL, resp.Status)
+	}
+	return nil
+}
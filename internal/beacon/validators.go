@@ -0,0 +1,120 @@
+package beacon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// validatorBatchSize caps the ids sent per state validators request; nodes reject very large id lists.
+const validatorBatchSize = 100
+
+// FetchEffectiveBalances returns the head-state effective balance (gwei) of each requested validator.
+// It POSTs the indices in batches to /eth/v1/beacon/states/head/validators, trying each endpoint in
+// baseURL in turn. Indices the node does not know are absent from the result.
+func FetchEffectiveBalances(ctx context.Context, baseURL string, timeout time.Duration, indices []uint64) (map[uint64]int64, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var endpoints []string
+	for _, ep := range ParseEndpoints(strings.TrimSpace(baseURL)) {
+		endpoints = append(endpoints, ep.URL)
+	}
+	if len(endpoints) == 0 {
+		return nil, errors.New("beacon node URL is empty")
+	}
+
+	if timeout <= 0 {
+		timeout = defaultRequestTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	balances := make(map[uint64]int64, len(indices))
+	for start := 0; start < len(indices); start += validatorBatchSize {
+		batch := indices[start:min(start+validatorBatchSize, len(indices))]
+		ids := make([]string, len(batch))
+		for i, idx := range batch {
+			ids[i] = strconv.FormatUint(idx, 10)
+		}
+
+		var errs []error
+		fetched := false
+		for _, endpointBase := range endpoints {
+			if err := fetchValidatorBatch(ctx, endpointBase, ids, balances); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			fetched = true
+			break
+		}
+		if !fetched {
+			return nil, errors.Join(errs...)
+		}
+	}
+	return balances, nil
+}
+
+func fetchValidatorBatch(ctx context.Context, endpointBase string, ids []string, balances map[uint64]int64) error {
+	body, err := json.Marshal(struct {
+		IDs []string `json:"ids"`
+	}{ids})
+	if err != nil {
+		return fmt.Errorf("%s: encode request: %w", endpointBase, err)
+	}
+
+	endpoint := strings.TrimSuffix(endpointBase, "/") + "/eth/v1/beacon/states/head/validators"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%s: create request: %w", endpointBase, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: request validators: %w", endpointBase, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		if s := strings.TrimSpace(string(msg)); s != "" {
+			return fmt.Errorf("%s: validators request failed: %s: %s", endpointBase, resp.Status, s)
+		}
+		return fmt.Errorf("%s: validators request failed: %s", endpointBase, resp.Status)
+	}
+
+	var payload struct {
+		Data []struct {
+			Index     string `json:"index"`
+			Validator struct {
+				EffectiveBalance string `json:"effective_balance"`
+			} `json:"validator"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return fmt.Errorf("%s: decode response: %w", endpointBase, err)
+	}
+
+	for _, v := range payload.Data {
+		idx, err := strconv.ParseUint(v.Index, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%s: parse validator index %q: %w", endpointBase, v.Index, err)
+		}
+		eb, err := strconv.ParseInt(v.Validator.EffectiveBalance, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%s: parse effective_balance %q: %w", endpointBase, v.Validator.EffectiveBalance, err)
+		}
+		balances[idx] = eb
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package beacon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFetchEffectiveBalancesBatchesAndFallsBack(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(down.Close)
+
+	var requests int
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/eth/v1/beacon/states/head/validators" {
+			http.NotFound(w, r)
+			return
+		}
+		requests++
+		var body struct {
+			IDs []string `json:"ids"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		if len(body.IDs) > validatorBatchSize {
+			t.Errorf("batch of %d ids exceeds %d", len(body.IDs), validatorBatchSize)
+		}
+		var data []string
+		for _, id := range body.IDs {
+			if id == "7" {
+				continue // unknown to the node
+			}
+			data = append(data, fmt.Sprintf(`{"index":%q,"validator":{"effective_balance":"31000000000"}}`, id))
+		}
+		fmt.Fprintf(w, `{"data":[%s]}`, strings.Join(data, ","))
+	}))
+	t.Cleanup(up.Close)
+
+	indices := make([]uint64, 0, 150)
+	for i := uint64(0); i < 150; i++ {
+		indices = append(indices, i)
+	}
+
+	balances, err := FetchEffectiveBalances(context.Background(), down.URL+","+up.URL, time.Second, indices)
+	if err != nil {
+		t.Fatalf("FetchEffectiveBalances returned error: %v", err)
+	}
+	if requests != 2 {
+		t.Fatalf("requests = %d, want 2 batches", requests)
+	}
+	if len(balances) != 149 || balances[149] != 31_000_000_000 {
+		t.Fatalf("unexpected balances: %d entries, [149]=%d", len(balances), balances[149])
+	}
+	if _, ok := balances[7]; ok {
+		t.Fatalf("validator unknown to the node should be absent")
+	}
+
+	if _, err := FetchEffectiveBalances(context.Background(), down.URL, time.Second, indices); err == nil {
+		t.Fatalf("expected an error when every endpoint fails")
+	}
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -33,3 +34,331 @@ func TestLoadBackfillLookbackNegative(t *testing.T) {
 		t.Fatalf("expected error for negative backfill lookback")
 	}
 }
+
+func TestLoadMinAPRWindowSeconds(t *testing.T) {
+	cfg, err := LoadFromEnv(func(key string) string {
+		if key == "MIN_APR_WINDOW_SECONDS" {
+			return "600"
+		}
+		return ""
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.MinAPRWindowSeconds != 600 {
+		t.Fatalf("MinAPRWindowSeconds = %d, want 600", cfg.MinAPRWindowSeconds)
+	}
+
+	if _, err := LoadFromEnv(func(key string) string {
+		if key == "MIN_APR_WINDOW_SECONDS" {
+			return "-1"
+		}
+		return ""
+	}); err == nil {
+		t.Fatalf("expected error for negative MIN_APR_WINDOW_SECONDS")
+	}
+}
+
+func TestLoadELRewardMethod(t *testing.T) {
+	cfg, err := LoadFromEnv(func(string) string { return "" })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.ELRewardMethod != ELRewardMethodLibrary {
+		t.Fatalf("default ELRewardMethod = %q, want %q", cfg.ELRewardMethod, ELRewardMethodLibrary)
+	}
+
+	cfg, err = LoadFromEnv(func(key string) string {
+		if key == "EL_REWARD_METHOD" {
+			return ELRewardMethodReceipts
+		}
+		return ""
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.ELRewardMethod != ELRewardMethodReceipts {
+		t.Fatalf("ELRewardMethod = %q, want %q", cfg.ELRewardMethod, ELRewardMethodReceipts)
+	}
+
+	if _, err := LoadFromEnv(func(key string) string {
+		if key == "EL_REWARD_METHOD" {
+			return "traces"
+		}
+		return ""
+	}); err == nil {
+		t.Fatalf("expected error for unknown EL_REWARD_METHOD")
+	}
+}
+
+func TestLoadBeaconBlockAPIVersion(t *testing.T) {
+	if cfg := DefaultConfig(); cfg.BeaconBlockAPIVersion != BeaconBlockAPIV1 {
+		t.Fatalf("default BeaconBlockAPIVersion = %q, want %q", cfg.BeaconBlockAPIVersion, BeaconBlockAPIV1)
+	}
+	cfg, err := LoadFromEnv(func(key string) string {
+		if key == "BEACON_BLOCK_API_VERSION" {
+			return BeaconBlockAPIV2
+		}
+		return ""
+	})
+	if err != nil || cfg.BeaconBlockAPIVersion != BeaconBlockAPIV2 {
+		t.Fatalf("LoadFromEnv = %v, %v; want BeaconBlockAPIVersion %q", cfg, err, BeaconBlockAPIV2)
+	}
+	if _, err := LoadFromEnv(func(key string) string {
+		if key == "BEACON_BLOCK_API_VERSION" {
+			return "v3"
+		}
+		return ""
+	}); err == nil {
+		t.Fatalf("expected error for unknown BEACON_BLOCK_API_VERSION")
+	}
+}
+
+func TestLoadBackfillOrder(t *testing.T) {
+	cfg, err := LoadFromEnv(func(key string) string {
+		if key == "BACKFILL_ORDER" {
+			return BackfillOrderDesc
+		}
+		return ""
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.BackfillOrder != BackfillOrderDesc {
+		t.Fatalf("BackfillOrder = %q, want %q", cfg.BackfillOrder, BackfillOrderDesc)
+	}
+
+	if _, err := LoadFromEnv(func(key string) string {
+		if key == "BACKFILL_ORDER" {
+			return "random"
+		}
+		return ""
+	}); err == nil {
+		t.Fatalf("expected error for unknown BACKFILL_ORDER")
+	}
+}
+
+func TestLoadAccessLogFields(t *testing.T) {
+	cfg := DefaultConfig()
+	if len(cfg.AccessLogFields) != len(AccessLogFieldNames) {
+		t.Fatalf("default access log fields = %v, want all of %v", cfg.AccessLogFields, AccessLogFieldNames)
+	}
+
+	cfg, err := LoadFromEnv(func(key string) string {
+		if key == "ACCESS_LOG_FIELDS" {
+			return "status, bytes,,request_id,bytes"
+		}
+		return ""
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Join(cfg.AccessLogFields, ","); got != "status,bytes,request_id" {
+		t.Fatalf("AccessLogFields = %q", got)
+	}
+
+	for _, raw := range []string{"status,cookies", " , "} {
+		if _, err := LoadFromEnv(func(key string) string {
+			if key == "ACCESS_LOG_FIELDS" {
+				return raw
+			}
+			return ""
+		}); err == nil {
+			t.Fatalf("expected error for ACCESS_LOG_FIELDS=%q", raw)
+		}
+	}
+}
+
+func TestLoadEndpointFlags(t *testing.T) {
+	env := map[string]string{
+		"ENABLED_ENDPOINTS":  "/rewards, /deposits/top-withdrawals,,/health, get /rewards/range",
+		"DISABLED_ENDPOINTS": "/deposits/top-withdrawals",
+	}
+	cfg, err := LoadFromEnv(func(key string) string { return env[key] })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for route, want := range map[[2]string]bool{
+		{"POST", "/rewards"}:                 true,
+		{"GET", "/health"}:                   true,
+		{"GET", "/deposits/top-withdrawals"}: false, // Disabling wins over enabling.
+		{"GET", "/rewards/range"}:            true,  // Enabled for GET only.
+		{"POST", "/rewards/range"}:           false,
+		{"GET", "/rewards/network"}:          false, // Not in ENABLED_ENDPOINTS.
+	} {
+		if got := cfg.EndpointEnabled(route[0], route[1]); got != want {
+			t.Fatalf("EndpointEnabled(%q, %q) = %v, want %v", route[0], route[1], got, want)
+		}
+	}
+
+	cfg, err = LoadFromEnv(func(key string) string {
+		if key == "DISABLED_ENDPOINTS" {
+			return "/health,/proposers/top"
+		}
+		return ""
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.EndpointEnabled("GET", "/health") || cfg.EndpointEnabled("GET", "/proposers/top") || !cfg.EndpointEnabled("POST", "/rewards") {
+		t.Fatalf("DISABLED_ENDPOINTS should drop only the listed routes and never /health")
+	}
+
+	if _, err := LoadFromEnv(func(key string) string {
+		if key == "ENABLED_ENDPOINTS" {
+			return "rewards"
+		}
+		return ""
+	}); err == nil {
+		t.Fatalf("expected error for a path without a leading slash")
+	}
+	for _, raw := range []string{"FETCH /rewards", "GET /rewards extra"} {
+		if _, err := LoadFromEnv(func(key string) string {
+			if key == "DISABLED_ENDPOINTS" {
+				return raw
+			}
+			return ""
+		}); err == nil {
+			t.Fatalf("expected error for DISABLED_ENDPOINTS=%q", raw)
+		}
+	}
+}
+
+func TestLoadTrackedValidators(t *testing.T) {
+	cfg, err := LoadFromEnv(func(key string) string {
+		if key == "TRACKED_VALIDATORS" {
+			return "12, 7,,12"
+		}
+		return ""
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.TrackedValidators) != 2 || cfg.TrackedValidators[0] != 12 || cfg.TrackedValidators[1] != 7 {
+		t.Fatalf("TrackedValidators = %v, want [12 7]", cfg.TrackedValidators)
+	}
+
+	if _, err := LoadFromEnv(func(key string) string {
+		if key == "TRACKED_VALIDATORS" {
+			return "1,abc"
+		}
+		return ""
+	}); err == nil {
+		t.Fatalf("expected error for invalid TRACKED_VALIDATORS")
+	}
+}
+
+func TestLoadServerTimeouts(t *testing.T) {
+	cfg, err := LoadFromEnv(func(key string) string {
+		switch key {
+		case "SERVER_READ_TIMEOUT":
+			return "5s"
+		case "SERVER_WRITE_TIMEOUT":
+			return "1m"
+		case "SERVER_IDLE_TIMEOUT":
+			return "90s"
+		}
+		return ""
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.ReadTimeout != 5*time.Second || cfg.WriteTimeout != time.Minute || cfg.IdleTimeout != 90*time.Second {
+		t.Fatalf("unexpected timeouts: read=%s write=%s idle=%s", cfg.ReadTimeout, cfg.WriteTimeout, cfg.IdleTimeout)
+	}
+
+	cfg, err = LoadFromEnv(func(key string) string {
+		switch key {
+		case "REQUEST_TIMEOUT":
+			return "30s"
+		case "SERVER_WRITE_TIMEOUT":
+			return "30s"
+		}
+		return ""
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := 30*time.Second + writeTimeoutMargin; cfg.WriteTimeout != want {
+		t.Fatalf("WriteTimeout = %s, want it raised to %s above REQUEST_TIMEOUT", cfg.WriteTimeout, want)
+	}
+}
+
+func TestValidateDefaults(t *testing.T) {
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Fatalf("default config should be valid: %v", err)
+	}
+}
+
+func TestValidateInvariants(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(*Config)
+		want   string
+	}{
+		{"empty beacon node", func(c *Config) { c.BeaconNodeURL = " " }, "BEACON_NODE_URL"},
+		{"empty execution node", func(c *Config) { c.ExecutionNodeURL = "" }, "EXECUTION_NODE_URL"},
+		{"non-positive request timeout", func(c *Config) { c.RequestTimeout = 0 }, "REQUEST_TIMEOUT"},
+		{"non-positive db query timeout", func(c *Config) { c.DBQueryTimeout = 0 }, "DB_QUERY_TIMEOUT"},
+		{"non-positive epoch check interval", func(c *Config) { c.EpochCheckInterval = 0 }, "EPOCH_CHECK_INTERVAL"},
+		{"zero backfill concurrency", func(c *Config) { c.BackfillConcurrency = 0 }, "BACKFILL_CONCURRENCY"},
+		{"excessive backfill concurrency", func(c *Config) { c.BackfillConcurrency = MaxBackfillConcurrency + 1 }, "BACKFILL_CONCURRENCY"},
+		{"negative retries", func(c *Config) { c.EpochProcessMaxRetries = -1 }, "EPOCH_PROCESS_MAX_RETRIES"},
+		{"non-positive base backoff", func(c *Config) { c.EpochProcessBaseBackoff = 0 }, "EPOCH_PROCESS_BASE_BACKOFF"},
+		{"max backoff below base", func(c *Config) {
+			c.EpochProcessBaseBackoff = time.Minute
+			c.EpochProcessMaxBackoff = time.Second
+		}, "EPOCH_PROCESS_MAX_BACKOFF"},
+		{"default limit above max", func(c *Config) { c.DefaultAPILimit = c.MaxAPILimit + 1 }, "DEFAULT_API_LIMIT"},
+		{"replica without shared state", func(c *Config) { c.ReadOnlyReplica = true }, "READ_ONLY_REPLICA"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.mutate(cfg)
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Validate() = %v, want error mentioning %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateReportsAllViolations(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.BeaconNodeURL = ""
+	cfg.BackfillConcurrency = 0
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatalf("expected validation error")
+	}
+	for _, want := range []string{"BEACON_NODE_URL", "BACKFILL_CONCURRENCY"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %q does not mention %s", err, want)
+		}
+	}
+}
+
+func TestListenAddressUnixSocket(t *testing.T) {
+	cfg := DefaultConfig()
+	if got := cfg.ListenAddress(); got != "0.0.0.0:8080" {
+		t.Fatalf("ListenAddress() = %q, want 0.0.0.0:8080", got)
+	}
+	if got := cfg.UnixSocketPath(); got != "" {
+		t.Fatalf("UnixSocketPath() = %q, want empty for TCP", got)
+	}
+
+	cfg.ServerAddress = "unix:/run/rewards.sock"
+	if got := cfg.ListenAddress(); got != "unix:/run/rewards.sock" {
+		t.Fatalf("ListenAddress() = %q, want unix:/run/rewards.sock", got)
+	}
+	if got := cfg.UnixSocketPath(); got != "/run/rewards.sock" {
+		t.Fatalf("UnixSocketPath() = %q, want /run/rewards.sock", got)
+	}
+
+	cfg.ServerAddress = "unix:"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "SERVER_ADDRESS") {
+		t.Fatalf("Validate() = %v, want SERVER_ADDRESS error for empty socket path", err)
+	}
+}
@@ -30,3 +30,48 @@ func NormalizeAddress(address string) (string, error) {
 
 	return "0x" + strings.ToLower(trimmed), nil
 }
+
+// BLSWithdrawalPrefix marks grouping keys of validators still on 0x00 (BLS) withdrawal credentials.
+// Those credentials hash a BLS key, so their last 20 bytes are not an execution address.
+const BLSWithdrawalPrefix = "bls:"
+
+// WithdrawalKey returns the grouping key for 32-byte withdrawal credentials: the execution address
+// for 0x01/0x02 credentials, or BLSWithdrawalPrefix followed by the full credentials for 0x00.
+// It mirrors withdrawalKeySQL.
+func WithdrawalKey(credentials []byte) (string, error) {
+	if len(credentials) != 32 {
+		return "", fmt.Errorf("%w: withdrawal credentials must be 32 bytes", ErrInvalidAddress)
+	}
+	if credentials[0] == 0x00 {
+		return BLSWithdrawalPrefix + "0x" + hex.EncodeToString(credentials), nil
+	}
+	return "0x" + hex.EncodeToString(credentials[12:]), nil
+}
+
+// DecodeWithdrawalCredential parses 0x-prefixed, 32-byte hex withdrawal credentials and returns the
+// credential type byte with its grouping key (see WithdrawalKey). Types above 0x02 are rejected.
+func DecodeWithdrawalCredential(cred string) (byte, string, error) {
+	trimmed := strings.TrimSpace(cred)
+	if !strings.HasPrefix(trimmed, "0x") && !strings.HasPrefix(trimmed, "0X") {
+		return 0, "", fmt.Errorf("%w: withdrawal credentials must be 0x-prefixed", ErrInvalidAddress)
+	}
+	raw, err := hex.DecodeString(trimmed[2:])
+	if err != nil {
+		return 0, "", fmt.Errorf("%w: %s", ErrInvalidAddress, err.Error())
+	}
+	key, err := WithdrawalKey(raw)
+	if err != nil {
+		return 0, "", err
+	}
+	if raw[0] > 0x02 {
+		return 0, "", fmt.Errorf("%w: unsupported withdrawal credential type 0x%02x", ErrInvalidAddress, raw[0])
+	}
+	return raw[0], key, nil
+}
+
+// withdrawalKeySQL renders the WithdrawalKey expression for a bytea credentials column.
+func withdrawalKeySQL(column string) string {
+	return fmt.Sprintf(`CASE WHEN substr(%[1]s, 1, 1) = '\x00'::bytea `+
+		`THEN '%[2]s0x' || encode(%[1]s, 'hex') `+
+		`ELSE '0x' || encode(substr(%[1]s, 13, 20), 'hex') END`, column, BLSWithdrawalPrefix)
+}
@@ -0,0 +1,69 @@
+package dora
+
+import (
+	"beacon-rewards/internal/utils"
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// balanceHistoryTable holds one balance row per validator per epoch. Stock Dora only keeps the latest
+// balance on the validators table, so the table exists only on indexers that record snapshots.
+const balanceHistoryTable = "validator_balances"
+
+// ErrBalanceHistoryUnavailable is returned when the Dora schema has no per-epoch balance snapshots.
+var ErrBalanceHistoryUnavailable = errors.New("dora schema keeps only the latest validator balance; per-epoch balance history is not recorded")
+
+// BalancePoint is a validator's balance at the end of an epoch.
+type BalancePoint struct {
+	Epoch            uint64     `json:"epoch"`
+	Balance          utils.Gwei `json:"balance"`
+	EffectiveBalance utils.Gwei `json:"effective_balance"`
+}
+
+// ValidatorBalanceHistory returns the validator's balance for each recorded epoch in [fromEpoch, toEpoch], oldest first.
+// It returns ErrBalanceHistoryUnavailable when the schema has no balance history table.
+func (d *DB) ValidatorBalanceHistory(ctx context.Context, index, fromEpoch, toEpoch uint64) ([]BalancePoint, error) {
+	if d == nil || d.db == nil {
+		return nil, ErrBalanceHistoryUnavailable
+	}
+
+	var available bool
+	if err := d.db.QueryRowContext(ctx, `SELECT to_regclass($1) IS NOT NULL`, balanceHistoryTable).Scan(&available); err != nil {
+		return nil, err
+	}
+	if !available {
+		return nil, ErrBalanceHistoryUnavailable
+	}
+
+	rows, err := d.db.QueryContext(ctx, `
+SELECT epoch, balance, effective_balance
+FROM `+balanceHistoryTable+`
+WHERE validator_index = $1 AND epoch BETWEEN $2 AND $3
+ORDER BY epoch
+`, int64(index), int64(fromEpoch), int64(toEpoch))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := make([]BalancePoint, 0)
+	for rows.Next() {
+		var (
+			epoch              int64
+			balance, effective sql.NullInt64
+		)
+		if err := rows.Scan(&epoch, &balance, &effective); err != nil {
+			return nil, err
+		}
+		points = append(points, BalancePoint{
+			Epoch:            uint64(epoch),
+			Balance:          utils.Gwei(balance.Int64),
+			EffectiveBalance: utils.Gwei(effective.Int64),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return points, nil
+}
@@ -6,6 +6,8 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"log/slog"
+	"math"
 	"strings"
 	"time"
 
@@ -21,6 +23,10 @@ const (
 // DB wraps a sql.DB for the Dora Postgres database.
 type DB struct {
 	db *sql.DB
+	// replica is an optional read-only connection for heavy aggregate queries; nil routes them to db.
+	replica *sql.DB
+	// statementTimeout bounds leaderboard queries on the Postgres side; zero leaves them unbounded.
+	statementTimeout time.Duration
 }
 
 // New creates a new DB connection using the provided config.
@@ -41,14 +47,57 @@ func New(cfg *config.Config) (*DB, error) {
 		_ = db.Close()
 		return nil, err
 	}
-	return &DB{db: db}, nil
+
+	out := &DB{db: db, statementTimeout: cfg.DoraStatementTimeout}
+	if replicaDSN := cfg.DoraPGReplicaURL; replicaDSN != "" {
+		replica, err := openReplica(replicaDSN)
+		if err != nil {
+			// The replica only offloads aggregates; the primary can still serve them.
+			slog.Warn("Failed to connect to Dora replica; heavy queries will use the primary", "error", err)
+		} else {
+			out.replica = replica
+		}
+	}
+	return out, nil
+}
+
+// NewFromConn wraps an open connection, such as a sqlmock one in tests of the packages using DB.
+func NewFromConn(db *sql.DB) *DB {
+	return &DB{db: db}
+}
+
+func openReplica(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return db, nil
 }
 
-// Close closes the database connection.
+// Close closes the database connections.
 func (d *DB) Close() {
-	if d != nil && d.db != nil {
+	if d == nil {
+		return
+	}
+	if d.db != nil {
 		_ = d.db.Close()
 	}
+	if d.replica != nil {
+		_ = d.replica.Close()
+	}
+}
+
+// analyticsDB returns the connection used for expensive aggregate queries: the replica when
+// configured, otherwise the primary. Latency-sensitive lookups always use d.db.
+func (d *DB) analyticsDB() *sql.DB {
+	if d.replica != nil {
+		return d.replica
+	}
+	return d.db
 }
 
 // WithdrawalStat represents aggregated deposits for a withdrawal address.
@@ -63,17 +112,21 @@ type DepositorStat struct {
 	DepositorAddress  string `json:"depositor_address"`
 	DepositorLabel    string `json:"depositor_label,omitempty"`
 	WithdrawalAddress string `json:"withdrawal_address"`
+	// InitialDeposit sums each validator's first deposit (new capital); TopUpDeposit sums later
+	// deposits to an existing pubkey. Together they equal TotalDeposit.
+	InitialDeposit utils.Gwei `json:"initial_deposit"`
+	TopUpDeposit   utils.Gwei `json:"top_up_deposit"`
 	ValidatorStatus
 }
 
 // ValidatorStatus captures validator status counts shared by depositor/withdrawal stats.
 type ValidatorStatus struct {
-	TotalDeposit                int64 `json:"total_deposit"`
-	TotalActiveEffectiveBalance int64 `json:"total_active_effective_balance"`
-	ValidatorsTotal             int64 `json:"validators_total"`
-	Slashed                     int64 `json:"slashed"`
-	VoluntaryExited             int64 `json:"voluntary_exited"`
-	Active                      int64 `json:"active"`
+	TotalDeposit                utils.Gwei `json:"total_deposit"`
+	TotalActiveEffectiveBalance utils.Gwei `json:"total_active_effective_balance"`
+	ValidatorsTotal             int64      `json:"validators_total"`
+	Slashed                     int64      `json:"slashed"`
+	VoluntaryExited             int64      `json:"voluntary_exited"`
+	Active                      int64      `json:"active"`
 }
 
 // ValidatorLifecycle captures the activation and exit epochs for a validator.
@@ -89,17 +142,19 @@ type ValidatorDetail struct {
 	ActivationEpoch  uint64
 	ExitEpoch        uint64
 	TotalDepositGwei int64
+	// CredentialType is the withdrawal credential prefix: "0x00" (BLS), "0x01" or "0x02" (compounding).
+	CredentialType string
 }
 
 // TopWithdrawalAddresses aggregates deposits by normalized withdrawal address and returns top N by amount.
 //
 // Normalization: for withdrawal credentials with prefix 0x01 or 0x02, the execution-layer address is stored
 // in the last 20 bytes of the 32-byte credentials. We group by those last 20 bytes regardless of prefix
-// to treat 0x01 and 0x02 as the same address.
-func (d *DB) TopWithdrawalAddresses(ctx context.Context, limit int, sortBy string, order string) ([]WithdrawalStat, error) {
+// to treat 0x01 and 0x02 as the same address. A positive minValidators drops addresses with fewer validators.
+func (d *DB) TopWithdrawalAddresses(ctx context.Context, limit int, sortBy string, order string, minValidators int64) ([]WithdrawalStat, error) {
 	const baseQuery = `
 SELECT
-  '0x' || encode(substr(v.withdrawal_credentials, 13, 20), 'hex') AS withdrawal_address,
+  %s AS withdrawal_address,
   COALESCE(SUM(d.amount), 0)::bigint AS total_deposit,
   COALESCE(SUM(v.effective_balance) FILTER (WHERE NOT v.slashed AND v.effective_balance > 0), 0)::bigint AS total_active_effective_balance,
   COUNT(DISTINCT v.validator_index) AS validators_total,
@@ -108,12 +163,14 @@ SELECT
   COUNT(DISTINCT v.validator_index) FILTER (WHERE NOT v.slashed AND v.effective_balance > 0) AS active
 FROM validators v  left join deposits d on v.pubkey  = d.publickey 
 GROUP BY withdrawal_address
+%s
 ORDER BY %s %s
 LIMIT $1`
 
-	q := fmt.Sprintf(baseQuery, OrderBy(sortBy), OrderDirection(order))
+	having, args := havingClause(havingMin{"COUNT(DISTINCT v.validator_index)", minValidators})
+	q := fmt.Sprintf(baseQuery, withdrawalKeySQL("v.withdrawal_credentials"), having, OrderBy(sortBy), OrderDirection(order))
 
-	return queryStats(ctx, d.db, limit, q, func(rows *sql.Rows, stat *WithdrawalStat) error {
+	return queryStatsWithTimeout(ctx, d.analyticsDB(), d.statementTimeout, limit, q, func(rows *sql.Rows, stat *WithdrawalStat) error {
 		return rows.Scan(
 			&stat.WithdrawalAddress,
 			&stat.TotalDeposit,
@@ -123,17 +180,20 @@ LIMIT $1`
 			&stat.VoluntaryExited,
 			&stat.Active,
 		)
-	})
+	}, args...)
 }
 
 // TopDepositorAddresses aggregates deposits by transaction sender and returns top N by validator count.
-func (d *DB) TopDepositorAddresses(ctx context.Context, limit int, sortBy string, order string) ([]DepositorStat, error) {
+// A positive minDepositGwei drops depositors whose total deposits are below it, and a positive
+// minValidators those with fewer validators.
+func (d *DB) TopDepositorAddresses(ctx context.Context, limit int, sortBy string, order string, minDepositGwei, minValidators int64) ([]DepositorStat, error) {
 	const baseQuery = `
 WITH depositor_data AS (
   SELECT
     '0x' || encode(dt.tx_sender, 'hex') AS depositor_address,
-    '0x' || encode(substr(COALESCE(v.withdrawal_credentials, dt.withdrawalcredentials), 13, 20), 'hex') AS withdrawal_address,
+    %s AS withdrawal_address,
     dt.amount,
+    ROW_NUMBER() OVER (PARTITION BY dt.publickey ORDER BY dt.deposit_index) = 1 AS is_initial,
     v.effective_balance,
     v.validator_index,
     v.slashed
@@ -144,6 +204,8 @@ SELECT
   depositor_address,
   mode() WITHIN GROUP (ORDER BY withdrawal_address) AS withdrawal_address,
   SUM(amount)::bigint AS total_deposit,
+  COALESCE(SUM(amount) FILTER (WHERE is_initial), 0)::bigint AS initial_deposit,
+  COALESCE(SUM(amount) FILTER (WHERE NOT is_initial), 0)::bigint AS top_up_deposit,
   COALESCE(SUM(effective_balance) FILTER (WHERE NOT slashed AND effective_balance > 0), 0)::bigint AS total_active_effective_balance,
   COUNT(DISTINCT validator_index) AS validators_total,
   COUNT(DISTINCT validator_index) FILTER (WHERE slashed) AS slashed,
@@ -151,25 +213,95 @@ SELECT
   COUNT(DISTINCT validator_index) FILTER (WHERE NOT slashed AND effective_balance > 0) AS active
 FROM depositor_data
 GROUP BY depositor_address
+%s
 ORDER BY %s %s
 LIMIT $1`
 
-	q := fmt.Sprintf(baseQuery, OrderBy(sortBy), OrderDirection(order))
+	having, args := havingClause(
+		havingMin{"SUM(amount)", minDepositGwei},
+		havingMin{"COUNT(DISTINCT validator_index)", minValidators},
+	)
+	q := fmt.Sprintf(baseQuery, withdrawalKeySQL("COALESCE(v.withdrawal_credentials, dt.withdrawalcredentials)"), having, OrderBy(sortBy), OrderDirection(order))
 
-	return queryStats(ctx, d.db, limit, q, func(rows *sql.Rows, stat *DepositorStat) error {
+	return queryStatsWithTimeout(ctx, d.analyticsDB(), d.statementTimeout, limit, q, func(rows *sql.Rows, stat *DepositorStat) error {
 		return rows.Scan(
 			&stat.DepositorAddress,
 			&stat.WithdrawalAddress,
 			&stat.TotalDeposit,
+			&stat.InitialDeposit,
+			&stat.TopUpDeposit,
 			&stat.TotalActiveEffectiveBalance,
 			&stat.ValidatorsTotal,
 			&stat.Slashed,
 			&stat.VoluntaryExited,
 			&stat.Active,
 		)
+	}, args...)
+}
+
+// DepositorWithdrawalLink is one edge of the funding graph: validators whose deposits were sent by
+// DepositorAddress and that withdraw to WithdrawalAddress.
+type DepositorWithdrawalLink struct {
+	DepositorAddress  string `json:"depositor_address"`
+	WithdrawalAddress string `json:"withdrawal_address"`
+	ValidatorCount    int64  `json:"validator_count"`
+}
+
+// DepositorWithdrawalLinks pairs deposit transaction senders with the withdrawal addresses of the
+// validators they funded, counting validators per pair. Pairs are sorted by sortBy (validator_count,
+// depositor_address or withdrawal_address; validator_count otherwise) and bounded by limit.
+func (d *DB) DepositorWithdrawalLinks(ctx context.Context, limit int, sortBy string, order string) ([]DepositorWithdrawalLink, error) {
+	const baseQuery = `
+SELECT
+  '0x' || encode(dt.tx_sender, 'hex') AS depositor_address,
+  %s AS withdrawal_address,
+  COUNT(DISTINCT v.validator_index) AS validator_count
+FROM deposit_txs dt
+JOIN validators v ON dt.publickey = v.pubkey
+GROUP BY depositor_address, withdrawal_address
+ORDER BY %s %s, depositor_address, withdrawal_address
+LIMIT $1`
+
+	q := fmt.Sprintf(baseQuery, withdrawalKeySQL("v.withdrawal_credentials"), linkOrderBy(sortBy), OrderDirection(order))
+
+	return queryStatsWithTimeout(ctx, d.analyticsDB(), d.statementTimeout, limit, q, func(rows *sql.Rows, link *DepositorWithdrawalLink) error {
+		return rows.Scan(&link.DepositorAddress, &link.WithdrawalAddress, &link.ValidatorCount)
 	})
 }
 
+func linkOrderBy(sortBy string) string {
+	switch sortBy {
+	case "depositor_address", "withdrawal_address":
+		return sortBy
+	default:
+		return "validator_count"
+	}
+}
+
+// havingMin is a leaderboard filter keeping groups whose aggregate expr is at least min.
+type havingMin struct {
+	expr string
+	min  int64
+}
+
+// havingClause builds a HAVING clause from the filters with a positive min, binding their values
+// from $2 on since $1 is the limit. It returns an empty clause when no filter applies.
+func havingClause(filters ...havingMin) (string, []any) {
+	var conds []string
+	var args []any
+	for _, f := range filters {
+		if f.min <= 0 {
+			continue
+		}
+		args = append(args, f.min)
+		conds = append(conds, fmt.Sprintf("%s >= $%d", f.expr, len(args)+1))
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return "HAVING " + strings.Join(conds, " AND "), args
+}
+
 func OrderBy(sortBy string) string {
 	switch sortBy {
 	case "depositor_address", "withdrawal_address", "validators_total", "slashed", "voluntary_exited", "active", "total_active_effective_balance":
@@ -188,12 +320,39 @@ func OrderDirection(order string) string {
 	}
 }
 
-func queryStats[T any](ctx context.Context, db *sql.DB, limit int, query string, scan func(*sql.Rows, *T) error) ([]T, error) {
+// queryer is satisfied by both *sql.DB and *sql.Tx.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// queryStatsWithTimeout runs queryStats inside a read-only transaction with a local statement_timeout.
+// Cancelling ctx relies on the driver to stop the backend; the server-side timeout guarantees a runaway
+// aggregate is aborted even if the cancel request never lands.
+func queryStatsWithTimeout[T any](ctx context.Context, db *sql.DB, timeout time.Duration, limit int, query string, scan func(*sql.Rows, *T) error, args ...any) ([]T, error) {
+	if timeout <= 0 {
+		return queryStats(ctx, db, limit, query, scan, args...)
+	}
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	// Nothing is written, so rolling back just ends the transaction and the SET LOCAL with it.
+	defer func() { _ = tx.Rollback() }()
+
+	// SET does not accept bind parameters; the value is an integer we format ourselves.
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", timeout.Milliseconds())); err != nil {
+		return nil, fmt.Errorf("set statement_timeout: %w", err)
+	}
+	return queryStats(ctx, tx, limit, query, scan, args...)
+}
+
+// queryStats binds limit as $1 followed by any extra args as $2, $3, ...
+func queryStats[T any](ctx context.Context, db queryer, limit int, query string, scan func(*sql.Rows, *T) error, args ...any) ([]T, error) {
 	if limit <= 0 {
 		limit = defaultStatsLimit
 	}
 
-	rows, err := db.QueryContext(ctx, query, limit)
+	rows, err := db.QueryContext(ctx, query, append([]any{limit}, args...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -215,8 +374,8 @@ func queryStats[T any](ctx context.Context, db *sql.DB, limit int, query string,
 	return results, nil
 }
 
-// ActiveValidatorsIndexByAddress returns the validator indices funded by the deposit or withdrawal address
-// return []validator_index
+// ActiveValidatorsIndexByAddress returns the validator indices funded by the deposit or withdrawal address,
+// ascending and without duplicates: a validator matched by both addresses is returned once.
 func (d *DB) ActiveValidatorsIndexByAddress(ctx context.Context, addresses string, epoch uint64) ([]uint64, error) {
 	if d == nil || d.db == nil {
 		return nil, nil
@@ -230,11 +389,12 @@ func (d *DB) ActiveValidatorsIndexByAddress(ctx context.Context, addresses strin
 FROM deposit_txs dt
 LEFT JOIN validators v ON dt.publickey = v.pubkey
 WHERE '0x' || encode(dt.tx_sender,'hex') = lower($1) AND v.activation_epoch <= $2 AND v.exit_epoch > $2)
-union all
+union
 (SELECT
   v.validator_index AS validator_index
 FROM validators v
-WHERE '0x' || encode(substr(v.withdrawal_credentials, 13, 20), 'hex') = lower($1) AND v.activation_epoch <= $2 AND v.exit_epoch > $2)
+WHERE `+withdrawalKeySQL("v.withdrawal_credentials")+` = lower($1) AND v.activation_epoch <= $2 AND v.exit_epoch > $2)
+ORDER BY validator_index
 `, addresses, shiftedEpoch)
 	if err != nil {
 		return nil, err
@@ -253,6 +413,53 @@ WHERE '0x' || encode(substr(v.withdrawal_credentials, 13, 20), 'hex') = lower($1
 	return result, nil
 }
 
+// ActiveValidatorIndicesByAddresses resolves many normalized (lower-case) addresses like
+// ActiveValidatorsIndexByAddress in one query. Addresses without active validators are absent from
+// the result.
+func (d *DB) ActiveValidatorIndicesByAddresses(ctx context.Context, addresses []string, epoch uint64) (map[string][]uint64, error) {
+	result := make(map[string][]uint64)
+	if d == nil || d.db == nil || len(addresses) == 0 {
+		return result, nil
+	}
+
+	shiftedEpoch := convertUint64EpochToStorage(epoch)
+
+	rows, err := d.db.QueryContext(ctx, `
+(SELECT
+  '0x' || encode(dt.tx_sender,'hex') AS address,
+  v.validator_index AS validator_index
+FROM deposit_txs dt
+JOIN validators v ON dt.publickey = v.pubkey
+WHERE '0x' || encode(dt.tx_sender,'hex') = ANY($1) AND v.activation_epoch <= $2 AND v.exit_epoch > $2)
+union
+(SELECT
+  `+withdrawalKeySQL("v.withdrawal_credentials")+` AS address,
+  v.validator_index AS validator_index
+FROM validators v
+WHERE `+withdrawalKeySQL("v.withdrawal_credentials")+` = ANY($1) AND v.activation_epoch <= $2 AND v.exit_epoch > $2)
+ORDER BY address, validator_index
+`, pq.Array(addresses), shiftedEpoch)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			address string
+			idx     int64
+		)
+		if err := rows.Scan(&address, &idx); err != nil {
+			return nil, err
+		}
+		result[address] = append(result[address], uint64(idx))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 // ValidatorIndicesByAddress returns validator indices funded by the deposit or withdrawal address, regardless of current active status.
 func (d *DB) ValidatorIndicesByAddress(ctx context.Context, addresses string) ([]uint64, error) {
 	if d == nil || d.db == nil {
@@ -269,7 +476,7 @@ union
 (SELECT
   v.validator_index AS validator_index
 FROM validators v
-WHERE '0x' || encode(substr(v.withdrawal_credentials, 13, 20), 'hex') = lower($1))
+WHERE `+withdrawalKeySQL("v.withdrawal_credentials")+` = lower($1))
 `, addresses)
 	if err != nil {
 		return nil, err
@@ -288,6 +495,25 @@ WHERE '0x' || encode(substr(v.withdrawal_credentials, 13, 20), 'hex') = lower($1
 	return result, nil
 }
 
+// WithdrawalCredentials returns the raw withdrawal credentials of a validator.
+// It returns sql.ErrNoRows when the validator is unknown.
+func (d *DB) WithdrawalCredentials(ctx context.Context, index uint64) ([]byte, error) {
+	if d == nil || d.db == nil {
+		return nil, sql.ErrNoRows
+	}
+
+	var creds []byte
+	err := d.db.QueryRowContext(ctx, `
+SELECT withdrawal_credentials
+FROM validators
+WHERE validator_index = $1
+`, int64(index)).Scan(&creds)
+	if err != nil {
+		return nil, err
+	}
+	return creds, nil
+}
+
 // TODO:optimize EffectiveBalances returns the effective_balance for the requested validator indices.
 func (d *DB) EffectiveBalances(ctx context.Context, indices []uint64) (map[uint64]int64, error) {
 	if d == nil || d.db == nil || len(indices) == 0 {
@@ -410,11 +636,12 @@ SELECT
   v.effective_balance,
   v.activation_epoch,
   v.exit_epoch,
-  COALESCE(SUM(dt.amount), 0)::bigint AS total_deposit
+  COALESCE(SUM(dt.amount), 0)::bigint AS total_deposit,
+  '0x' || encode(substr(v.withdrawal_credentials, 1, 1), 'hex') AS credential_type
 FROM validators v
 LEFT JOIN deposit_txs dt ON dt.publickey = v.pubkey
-WHERE '0x' || encode(substr(v.withdrawal_credentials, 13, 20), 'hex') = lower($1)
-GROUP BY v.validator_index, v.effective_balance, v.activation_epoch, v.exit_epoch
+WHERE `+withdrawalKeySQL("v.withdrawal_credentials")+` = lower($1)
+GROUP BY v.validator_index, v.effective_balance, v.activation_epoch, v.exit_epoch, v.withdrawal_credentials
 `, address)
 	if err != nil {
 		return nil, err
@@ -432,11 +659,12 @@ SELECT
   v.effective_balance,
   v.activation_epoch,
   v.exit_epoch,
-  COALESCE(SUM(dt.amount), 0)::bigint AS total_deposit
+  COALESCE(SUM(dt.amount), 0)::bigint AS total_deposit,
+  '0x' || encode(substr(v.withdrawal_credentials, 1, 1), 'hex') AS credential_type
 FROM deposit_txs dt
 JOIN validators v ON dt.publickey = v.pubkey
 WHERE '0x' || encode(dt.tx_sender,'hex') = lower($1)
-GROUP BY v.validator_index, v.effective_balance, v.activation_epoch, v.exit_epoch
+GROUP BY v.validator_index, v.effective_balance, v.activation_epoch, v.exit_epoch, v.withdrawal_credentials
 `, address)
 	if err != nil {
 		return nil, err
@@ -456,8 +684,9 @@ func scanValidatorDetails(rows *sql.Rows) ([]ValidatorDetail, error) {
 			act      int64
 			exit     int64
 			totalDep int64
+			credType string
 		)
-		if err := rows.Scan(&idx, &eff, &act, &exit, &totalDep); err != nil {
+		if err := rows.Scan(&idx, &eff, &act, &exit, &totalDep, &credType); err != nil {
 			return nil, err
 		}
 		results = append(results, ValidatorDetail{
@@ -466,6 +695,7 @@ func scanValidatorDetails(rows *sql.Rows) ([]ValidatorDetail, error) {
 			ActivationEpoch:  ConvertInt64ToUint64(act),
 			ExitEpoch:        ConvertInt64ToUint64(exit),
 			TotalDepositGwei: totalDep,
+			CredentialType:   credType,
 		})
 	}
 
@@ -526,6 +756,9 @@ GROUP BY v.validator_index
 // ActiveValidatorCount returns the number of validators whose activation/exit epochs indicate an active status.
 // The Dora schema stores epoch fields as int64 values shifted by -2^63 to fit unsigned epochs into signed columns.
 // We convert the requested epoch into the shifted domain so comparisons align with the stored representation.
+// Unset epochs are stored as the shifted FAR_FUTURE_EPOCH (max int64), so queued validators never satisfy
+// activation_epoch <= epoch. Slashed validators remain counted until their exit epoch, matching the
+// consensus definition of an active validator; see ValidatorStatusCounts for a breakdown.
 func (d *DB) ActiveValidatorCount(ctx context.Context, epoch uint64) (int64, error) {
 	if d == nil || d.db == nil {
 		return 0, nil
@@ -544,6 +777,110 @@ WHERE activation_epoch <= $1 AND exit_epoch > $1
 	return count, nil
 }
 
+// ValidatorStatusCounts buckets validators by lifecycle state at an epoch. Buckets are mutually
+// exclusive; Active+Exiting+Slashed equals ActiveValidatorCount.
+type ValidatorStatusCounts struct {
+	Pending int64 `json:"pending"` // deposited, activation epoch not reached (or not yet assigned)
+	Active  int64 `json:"active"`  // active with no exit scheduled
+	Exiting int64 `json:"exiting"` // active, not slashed, exit epoch scheduled
+	Slashed int64 `json:"slashed"` // slashed but not yet exited
+	Exited  int64 `json:"exited"`  // exit epoch reached
+}
+
+// farFutureEpochStorage is FAR_FUTURE_EPOCH (max uint64) in Dora's shifted representation.
+var farFutureEpochStorage = convertUint64EpochToStorage(math.MaxUint64)
+
+// ValidatorStatusCounts returns pending/active/exiting/slashed/exited counts at epoch in a single query.
+func (d *DB) ValidatorStatusCounts(ctx context.Context, epoch uint64) (ValidatorStatusCounts, error) {
+	var counts ValidatorStatusCounts
+	if d == nil || d.db == nil {
+		return counts, nil
+	}
+
+	row := d.db.QueryRowContext(ctx, `
+SELECT
+  COUNT(*) FILTER (WHERE activation_epoch > $1) AS pending,
+  COUNT(*) FILTER (WHERE activation_epoch <= $1 AND exit_epoch > $1 AND NOT slashed AND exit_epoch = $2) AS active,
+  COUNT(*) FILTER (WHERE activation_epoch <= $1 AND exit_epoch > $1 AND NOT slashed AND exit_epoch <> $2) AS exiting,
+  COUNT(*) FILTER (WHERE activation_epoch <= $1 AND exit_epoch > $1 AND slashed) AS slashed,
+  COUNT(*) FILTER (WHERE activation_epoch <= $1 AND exit_epoch <= $1) AS exited
+FROM validators
+`, convertUint64EpochToStorage(epoch), farFutureEpochStorage)
+	if err := row.Scan(&counts.Pending, &counts.Active, &counts.Exiting, &counts.Slashed, &counts.Exited); err != nil {
+		return ValidatorStatusCounts{}, err
+	}
+	return counts, nil
+}
+
+// ActivationQueueLength returns the number of validators in the activation queue at epoch: eligible
+// for activation (activation_eligibility_epoch set) and either still waiting (FAR_FUTURE_EPOCH) or
+// already scheduled for a later epoch. Validators that are not yet eligible are not queued.
+func (d *DB) ActivationQueueLength(ctx context.Context, epoch uint64) (int64, error) {
+	if d == nil || d.db == nil {
+		return 0, nil
+	}
+
+	row := d.db.QueryRowContext(ctx, `
+SELECT COUNT(*)::bigint
+FROM validators
+WHERE activation_epoch > $1 AND activation_eligibility_epoch <> $2
+`, convertUint64EpochToStorage(epoch), farFutureEpochStorage)
+	var count int64
+	if err := row.Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// QueuePosition is a validator's place in the activation queue.
+type QueuePosition struct {
+	Position int64 // 1-based among the queued validators.
+	// BalanceThroughGwei sums the effective balance of the validators ahead of it and its own, which
+	// is what the balance-based (Electra) churn has to drain before it activates.
+	BalanceThroughGwei int64
+}
+
+// ActivationQueuePositions returns the queue position of each given validator that is in the
+// activation queue at epoch (see ActivationQueueLength), ordered like the consensus queue by
+// activation eligibility epoch and then index. Validators that are not queued are absent.
+func (d *DB) ActivationQueuePositions(ctx context.Context, epoch uint64, indices []uint64) (map[uint64]QueuePosition, error) {
+	if d == nil || d.db == nil || len(indices) == 0 {
+		return map[uint64]QueuePosition{}, nil
+	}
+
+	ids := make([]int64, 0, len(indices))
+	for _, idx := range indices {
+		ids = append(ids, int64(idx))
+	}
+	rows, err := d.db.QueryContext(ctx, `
+SELECT validator_index, position, balance_through
+FROM (
+  SELECT validator_index,
+    ROW_NUMBER() OVER queue_order AS position,
+    (SUM(effective_balance) OVER queue_order)::bigint AS balance_through
+  FROM validators
+  WHERE activation_epoch > $1 AND activation_eligibility_epoch <> $3
+  WINDOW queue_order AS (ORDER BY activation_eligibility_epoch, validator_index)
+) queue
+WHERE validator_index = ANY($2)
+`, convertUint64EpochToStorage(epoch), pq.Array(ids), farFutureEpochStorage)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	positions := make(map[uint64]QueuePosition, len(ids))
+	for rows.Next() {
+		var idx int64
+		var p QueuePosition
+		if err := rows.Scan(&idx, &p.Position, &p.BalanceThroughGwei); err != nil {
+			return nil, err
+		}
+		positions[uint64(idx)] = p
+	}
+	return positions, rows.Err()
+}
+
 // TotalEffectiveBalance returns the sum of effective_balance across all validators.
 func (d *DB) TotalEffectiveBalance(ctx context.Context, epoch uint64) (int64, error) {
 	if d == nil || d.db == nil {
@@ -563,9 +900,32 @@ WHERE activation_epoch <= $1 AND exit_epoch > $1
 	return sum, nil
 }
 
+// TimeWeightedEffectiveBalance returns the average total effective balance over [startEpoch, endEpoch),
+// weighting each validator's effective balance by the fraction of the range it was active.
+// Shifted epochs preserve differences, so the active-epoch arithmetic works in the storage domain.
+func (d *DB) TimeWeightedEffectiveBalance(ctx context.Context, startEpoch, endEpoch uint64) (int64, error) {
+	if d == nil || d.db == nil || endEpoch <= startEpoch {
+		return 0, nil
+	}
+
+	row := d.db.QueryRowContext(ctx, `
+SELECT COALESCE(
+  SUM(effective_balance::numeric * (LEAST(exit_epoch, $2) - GREATEST(activation_epoch, $1))) / ($2 - $1),
+  0)::bigint
+FROM validators
+WHERE activation_epoch < $2 AND exit_epoch > $1
+`, convertUint64EpochToStorage(startEpoch), convertUint64EpochToStorage(endEpoch))
+	var avg int64
+	if err := row.Scan(&avg); err != nil {
+		return 0, err
+	}
+	return avg, nil
+}
+
 // GetWeightedAverageStakeTime calculates the weighted average stake time (duration) for the given validator indices.
-// Formula: sum(effective_balance * (now - activation_time)) / sum(effective_balance)
-func (d *DB) GetWeightedAverageStakeTime(ctx context.Context, indices []uint64) (int64, error) {
+// Formula: sum(effective_balance * (now - activation_time)) / sum(effective_balance), with activation
+// times computed on chain.
+func (d *DB) GetWeightedAverageStakeTime(ctx context.Context, chain utils.ChainConfig, indices []uint64) (int64, error) {
 	if d == nil || d.db == nil || len(indices) == 0 {
 		return 0, nil
 	}
@@ -593,8 +953,6 @@ WHERE validator_index = ANY($1) AND activation_epoch IS NOT NULL
 	var totalWeightedSeconds float64
 	var totalWeight float64
 	now := float64(time.Now().Unix())
-	genesis := utils.GenesisTimestamp()
-	secondsPerEpoch := int64(utils.SLOTS_PER_EPOCH * utils.SECONDS_PER_SLOT)
 
 	for rows.Next() {
 		var (
@@ -612,8 +970,7 @@ WHERE validator_index = ANY($1) AND activation_epoch IS NOT NULL
 
 		epoch := ConvertInt64ToUint64(act)
 		// Calculate activation time (start of activation epoch)
-		// time = genesis + epoch * seconds_per_epoch
-		startTime := float64(genesis + int64(epoch)*secondsPerEpoch)
+		startTime := float64(chain.EpochStartTime(epoch).Unix())
 
 		if startTime > now {
 			continue
@@ -637,6 +994,68 @@ WHERE validator_index = ANY($1) AND activation_epoch IS NOT NULL
 	return int64(totalWeightedSeconds / totalWeight), nil
 }
 
+// GetWeightedAverageDepositTime is GetWeightedAverageStakeTime measured from each validator's first
+// deposit instead of its activation, so time spent in the activation queue counts as staked.
+// Formula: sum(effective_balance * (now - first_deposit_block_time)) / sum(effective_balance)
+func (d *DB) GetWeightedAverageDepositTime(ctx context.Context, indices []uint64) (int64, error) {
+	if d == nil || d.db == nil || len(indices) == 0 {
+		return 0, nil
+	}
+
+	unique := make(map[uint64]struct{}, len(indices))
+	ids := make([]int64, 0, len(indices))
+	for _, idx := range indices {
+		if _, exists := unique[idx]; exists {
+			continue
+		}
+		unique[idx] = struct{}{}
+		ids = append(ids, int64(idx))
+	}
+
+	rows, err := d.db.QueryContext(ctx, `
+SELECT v.effective_balance, MIN(dt.block_time)::bigint AS first_deposit_time
+FROM validators v
+JOIN deposit_txs dt ON dt.publickey = v.pubkey
+WHERE v.validator_index = ANY($1)
+GROUP BY v.validator_index, v.effective_balance
+`, pq.Array(ids))
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var totalWeightedSeconds float64
+	var totalWeight float64
+	now := time.Now().Unix()
+
+	for rows.Next() {
+		var (
+			eff         int64
+			depositTime int64
+		)
+		if err := rows.Scan(&eff, &depositTime); err != nil {
+			return 0, err
+		}
+		if eff <= 0 || depositTime <= 0 || depositTime > now {
+			continue
+		}
+
+		weight := float64(eff)
+		totalWeightedSeconds += weight * float64(now-depositTime)
+		totalWeight += weight
+	}
+
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	if totalWeight == 0 {
+		return 0, nil
+	}
+
+	return int64(totalWeightedSeconds / totalWeight), nil
+}
+
 // ConvertInt64ToUint64 reverses the -2^63 shift applied to epoch fields stored in Dora.
 // The database keeps uint64 epochs in signed BIGINT columns by subtracting 2^63.
 // Adding the shift restores the original ordering and range.
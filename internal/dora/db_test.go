@@ -3,9 +3,18 @@ package dora
 import (
 	"context"
 	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 )
 
 func TestNormalizeAddress(t *testing.T) {
@@ -121,3 +130,626 @@ func TestQueryStatsPropagatesScanError(t *testing.T) {
 		t.Fatalf("unmet expectations: %v", err)
 	}
 }
+
+func TestTopWithdrawalAddressesUsesReplica(t *testing.T) {
+	primary, primaryMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	replica, replicaMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	d := &DB{db: primary, replica: replica}
+	t.Cleanup(d.Close)
+
+	columns := []string{"withdrawal_address", "total_deposit", "total_active_effective_balance", "validators_total", "slashed", "voluntary_exited", "active"}
+	replicaMock.ExpectQuery("GROUP BY withdrawal_address").WithArgs(10).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("0xabc", 32, 32, 1, 0, 0, 1))
+
+	stats, err := d.TopWithdrawalAddresses(context.Background(), 10, "", "", 0)
+	if err != nil {
+		t.Fatalf("TopWithdrawalAddresses returned error: %v", err)
+	}
+	if len(stats) != 1 || stats[0].WithdrawalAddress != "0xabc" {
+		t.Fatalf("unexpected stats: %#v", stats)
+	}
+
+	if err := replicaMock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet replica expectations: %v", err)
+	}
+	if err := primaryMock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("primary should not be queried: %v", err)
+	}
+}
+
+func TestTopWithdrawalAddressesSetsStatementTimeout(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	d := &DB{db: db, statementTimeout: 1500 * time.Millisecond}
+	t.Cleanup(d.Close)
+
+	columns := []string{"withdrawal_address", "total_deposit", "total_active_effective_balance", "validators_total", "slashed", "voluntary_exited", "active"}
+	mock.ExpectBegin()
+	mock.ExpectExec("SET LOCAL statement_timeout = 1500").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("GROUP BY withdrawal_address").WithArgs(10).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("0xabc", 32, 32, 1, 0, 0, 1))
+	mock.ExpectRollback()
+
+	stats, err := d.TopWithdrawalAddresses(context.Background(), 10, "", "", 0)
+	if err != nil {
+		t.Fatalf("TopWithdrawalAddresses returned error: %v", err)
+	}
+	if len(stats) != 1 {
+		t.Fatalf("unexpected stats: %#v", stats)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestTopWithdrawalAddressesSortsByActiveEffectiveBalance(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	d := &DB{db: db}
+	t.Cleanup(d.Close)
+
+	columns := []string{"withdrawal_address", "total_deposit", "total_active_effective_balance", "validators_total", "slashed", "voluntary_exited", "active"}
+	mock.ExpectQuery(`SUM\(v\.effective_balance\) FILTER \(WHERE NOT v\.slashed AND v\.effective_balance > 0\), 0\)::bigint AS total_active_effective_balance(?s:.*)ORDER BY total_active_effective_balance ASC`).
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("0xaaa", 64_000_000_000, 32_000_000_000, 2, 0, 1, 1).
+			AddRow("0xbbb", 64_000_000_000, 64_000_000_000, 2, 0, 0, 2))
+
+	stats, err := d.TopWithdrawalAddresses(context.Background(), 10, "total_active_effective_balance", "asc", 0)
+	if err != nil {
+		t.Fatalf("TopWithdrawalAddresses returned error: %v", err)
+	}
+	if len(stats) != 2 || stats[0].TotalActiveEffectiveBalance != 32_000_000_000 || stats[1].TotalActiveEffectiveBalance != 64_000_000_000 {
+		t.Fatalf("stats = %+v, want total_active_effective_balance scanned in query order", stats)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+	if got := OrderBy("total_active_effective_balance"); got != "total_active_effective_balance" {
+		t.Fatalf("OrderBy(total_active_effective_balance) = %q, want it kept rather than falling back", got)
+	}
+}
+
+func TestDepositorWithdrawalLinks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	d := &DB{db: db}
+	t.Cleanup(d.Close)
+
+	mock.ExpectQuery(`GROUP BY depositor_address, withdrawal_address\s+ORDER BY validator_count DESC, depositor_address, withdrawal_address\s+LIMIT \$1`).
+		WithArgs(20).
+		WillReturnRows(sqlmock.NewRows([]string{"depositor_address", "withdrawal_address", "validator_count"}).
+			AddRow("0xaaaa", "0x1111", int64(12)).
+			AddRow("0xaaaa", "0x2222", int64(3)))
+
+	links, err := d.DepositorWithdrawalLinks(context.Background(), 20, "", "")
+	if err != nil {
+		t.Fatalf("DepositorWithdrawalLinks returned error: %v", err)
+	}
+	want := []DepositorWithdrawalLink{
+		{DepositorAddress: "0xaaaa", WithdrawalAddress: "0x1111", ValidatorCount: 12},
+		{DepositorAddress: "0xaaaa", WithdrawalAddress: "0x2222", ValidatorCount: 3},
+	}
+	if !reflect.DeepEqual(links, want) {
+		t.Fatalf("links = %+v, want %+v", links, want)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+	if got := linkOrderBy("total_deposit"); got != "validator_count" {
+		t.Fatalf("linkOrderBy(unknown) = %q, want validator_count", got)
+	}
+}
+
+func TestAnalyticsDBFallsBackToPrimary(t *testing.T) {
+	primary, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	d := &DB{db: primary}
+	t.Cleanup(d.Close)
+
+	if d.analyticsDB() != primary {
+		t.Fatalf("analyticsDB should return the primary when no replica is configured")
+	}
+}
+
+func TestWithdrawalKeyGroupsBLSCredentialsSeparately(t *testing.T) {
+	address := "0988dc1554cf6877508208fff8aab4e5afa11ee3"
+	tests := []struct {
+		name  string
+		creds string
+		want  string
+	}{
+		{name: "0x00 BLS", creds: "00f1a2b3c4d5e6f708091a2b" + address, want: "bls:0x00f1a2b3c4d5e6f708091a2b" + address},
+		{name: "0x01 execution", creds: "010000000000000000000000" + address, want: "0x" + address},
+		{name: "0x02 compounding", creds: "020000000000000000000000" + address, want: "0x" + address},
+	}
+
+	keys := make(map[string]bool)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			creds, err := hex.DecodeString(tt.creds)
+			if err != nil {
+				t.Fatalf("bad fixture: %v", err)
+			}
+			got, err := WithdrawalKey(creds)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("WithdrawalKey(%s) = %s, want %s", tt.creds, got, tt.want)
+			}
+			keys[got] = true
+		})
+	}
+	// 0x01 and 0x02 share a bucket; the BLS credential with the same trailing bytes does not.
+	if len(keys) != 2 {
+		t.Fatalf("expected 2 distinct groups, got %v", keys)
+	}
+
+	if _, err := WithdrawalKey([]byte{0x01}); err == nil {
+		t.Fatalf("expected error for short credentials")
+	}
+}
+
+func TestDecodeWithdrawalCredential(t *testing.T) {
+	address := "0988dc1554cf6877508208fff8aab4e5afa11ee3"
+	tests := []struct {
+		name     string
+		cred     string
+		wantType byte
+		want     string
+		wantErr  bool
+	}{
+		{name: "0x01", cred: "0x010000000000000000000000" + address, wantType: 0x01, want: "0x" + address},
+		{name: "0x02 upper-case prefix", cred: "0X020000000000000000000000" + address, wantType: 0x02, want: "0x" + address},
+		{name: "0x00 BLS", cred: "0x00f1a2b3c4d5e6f708091a2b" + address, wantType: 0x00, want: "bls:0x00f1a2b3c4d5e6f708091a2b" + address},
+		{name: "unsupported type", cred: "0x030000000000000000000000" + address, wantErr: true},
+		{name: "missing prefix", cred: "010000000000000000000000" + address, wantErr: true},
+		{name: "short", cred: "0x" + address, wantErr: true},
+		{name: "bad hex", cred: "0x01000000000000000000000z" + address, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotType, got, err := DecodeWithdrawalCredential(tt.cred)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidAddress) {
+					t.Fatalf("expected ErrInvalidAddress, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if gotType != tt.wantType || got != tt.want {
+				t.Fatalf("DecodeWithdrawalCredential(%s) = (0x%02x, %s), want (0x%02x, %s)", tt.cred, gotType, got, tt.wantType, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithdrawalKeySQLBucketsBLSCredentials(t *testing.T) {
+	expr := withdrawalKeySQL("v.withdrawal_credentials")
+	for _, want := range []string{`substr(v.withdrawal_credentials, 1, 1) = '\x00'::bytea`, `'bls:0x' || encode(v.withdrawal_credentials, 'hex')`, `encode(substr(v.withdrawal_credentials, 13, 20), 'hex')`} {
+		if !strings.Contains(expr, want) {
+			t.Fatalf("withdrawalKeySQL missing %q: %s", want, expr)
+		}
+	}
+}
+
+func TestTimeWeightedEffectiveBalanceUsesShiftedEpochs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	d := &DB{db: db}
+	t.Cleanup(d.Close)
+
+	mock.ExpectQuery("LEAST\\(exit_epoch, \\$2\\) - GREATEST\\(activation_epoch, \\$1\\)").
+		WithArgs(convertUint64EpochToStorage(100), convertUint64EpochToStorage(325)).
+		WillReturnRows(sqlmock.NewRows([]string{"avg"}).AddRow(int64(48_000_000_000)))
+
+	got, err := d.TimeWeightedEffectiveBalance(context.Background(), 100, 325)
+	if err != nil {
+		t.Fatalf("TimeWeightedEffectiveBalance returned error: %v", err)
+	}
+	if got != 48_000_000_000 {
+		t.Fatalf("TimeWeightedEffectiveBalance = %d, want 48000000000", got)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+
+	if got, err := d.TimeWeightedEffectiveBalance(context.Background(), 325, 325); err != nil || got != 0 {
+		t.Fatalf("empty range = (%d, %v), want (0, nil)", got, err)
+	}
+}
+
+func TestTopDepositorAddressesSplitsInitialAndTopUpDeposits(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	d := &DB{db: db}
+	t.Cleanup(d.Close)
+
+	columns := []string{"depositor_address", "withdrawal_address", "total_deposit", "initial_deposit", "top_up_deposit",
+		"total_active_effective_balance", "validators_total", "slashed", "voluntary_exited", "active"}
+	mock.ExpectQuery(`ROW_NUMBER\(\) OVER \(PARTITION BY dt.publickey ORDER BY dt.deposit_index\) = 1 AS is_initial`).
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("0xaaa", "0xbbb", int64(66_000_000_000), int64(64_000_000_000), int64(2_000_000_000), int64(64_000_000_000), 2, 0, 0, 2))
+
+	stats, err := d.TopDepositorAddresses(context.Background(), 5, "", "", 0, 0)
+	if err != nil {
+		t.Fatalf("TopDepositorAddresses returned error: %v", err)
+	}
+	if len(stats) != 1 {
+		t.Fatalf("unexpected stats: %#v", stats)
+	}
+	got := stats[0]
+	if got.InitialDeposit != 64_000_000_000 || got.TopUpDeposit != 2_000_000_000 {
+		t.Fatalf("initial/top-up = %d/%d, want 64000000000/2000000000", got.InitialDeposit, got.TopUpDeposit)
+	}
+	if got.InitialDeposit+got.TopUpDeposit != got.TotalDeposit {
+		t.Fatalf("initial + top-up should equal total deposit: %+v", got)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestTopDepositorAddressesFiltersByMinDeposit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	d := &DB{db: db}
+	t.Cleanup(d.Close)
+
+	columns := []string{"depositor_address", "withdrawal_address", "total_deposit", "initial_deposit", "top_up_deposit",
+		"total_active_effective_balance", "validators_total", "slashed", "voluntary_exited", "active"}
+	mock.ExpectQuery(`GROUP BY depositor_address\s+HAVING SUM\(amount\) >= \$2\s+ORDER BY`).
+		WithArgs(5, int64(100_000_000_000)).
+		WillReturnRows(sqlmock.NewRows(columns))
+
+	if _, err := d.TopDepositorAddresses(context.Background(), 5, "", "", 100_000_000_000, 0); err != nil {
+		t.Fatalf("TopDepositorAddresses returned error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestLeaderboardsFilterByMinValidators(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	d := &DB{db: db}
+	t.Cleanup(d.Close)
+
+	withdrawalColumns := []string{"withdrawal_address", "total_deposit", "total_active_effective_balance",
+		"validators_total", "slashed", "voluntary_exited", "active"}
+	mock.ExpectQuery(`GROUP BY withdrawal_address\s+HAVING COUNT\(DISTINCT v\.validator_index\) >= \$2\s+ORDER BY`).
+		WithArgs(10, int64(3)).
+		WillReturnRows(sqlmock.NewRows(withdrawalColumns).
+			AddRow("0xaaaa", int64(96_000_000_000), int64(96_000_000_000), int64(3), int64(0), int64(0), int64(3)))
+
+	stats, err := d.TopWithdrawalAddresses(context.Background(), 10, "", "", 3)
+	if err != nil {
+		t.Fatalf("TopWithdrawalAddresses returned error: %v", err)
+	}
+	if len(stats) != 1 || stats[0].ValidatorsTotal != 3 {
+		t.Fatalf("stats = %+v, want the one address with 3 validators", stats)
+	}
+
+	// Both depositor filters combine, each bound to its own placeholder.
+	depositorColumns := []string{"depositor_address", "withdrawal_address", "total_deposit", "initial_deposit", "top_up_deposit",
+		"total_active_effective_balance", "validators_total", "slashed", "voluntary_exited", "active"}
+	mock.ExpectQuery(`HAVING SUM\(amount\) >= \$2 AND COUNT\(DISTINCT validator_index\) >= \$3\s+ORDER BY`).
+		WithArgs(5, int64(100_000_000_000), int64(2)).
+		WillReturnRows(sqlmock.NewRows(depositorColumns))
+
+	if _, err := d.TopDepositorAddresses(context.Background(), 5, "", "", 100_000_000_000, 2); err != nil {
+		t.Fatalf("TopDepositorAddresses returned error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestValidatorStatusCountsUsesShiftedSentinels(t *testing.T) {
+	if got := ConvertInt64ToUint64(farFutureEpochStorage); got != math.MaxUint64 {
+		t.Fatalf("far-future sentinel round trip = %d, want max uint64", got)
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	d := &DB{db: db}
+	t.Cleanup(d.Close)
+
+	const epoch = 1000
+	mock.ExpectQuery("COUNT\\(\\*\\) FILTER \\(WHERE activation_epoch > \\$1\\) AS pending").
+		WithArgs(convertUint64EpochToStorage(epoch), convertUint64EpochToStorage(math.MaxUint64)).
+		WillReturnRows(sqlmock.NewRows([]string{"pending", "active", "exiting", "slashed", "exited"}).
+			AddRow(3, 100, 4, 2, 7))
+
+	counts, err := d.ValidatorStatusCounts(context.Background(), epoch)
+	if err != nil {
+		t.Fatalf("ValidatorStatusCounts returned error: %v", err)
+	}
+	want := ValidatorStatusCounts{Pending: 3, Active: 100, Exiting: 4, Slashed: 2, Exited: 7}
+	if counts != want {
+		t.Fatalf("counts = %+v, want %+v", counts, want)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestValidatorBalanceHistory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	d := &DB{db: db}
+	t.Cleanup(d.Close)
+
+	mock.ExpectQuery(`SELECT to_regclass\(\$1\) IS NOT NULL`).
+		WithArgs("validator_balances").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	if _, err := d.ValidatorBalanceHistory(context.Background(), 7, 100, 200); !errors.Is(err, ErrBalanceHistoryUnavailable) {
+		t.Fatalf("missing table error = %v, want ErrBalanceHistoryUnavailable", err)
+	}
+
+	mock.ExpectQuery(`SELECT to_regclass\(\$1\) IS NOT NULL`).
+		WithArgs("validator_balances").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery(`FROM validator_balances\s+WHERE validator_index = \$1 AND epoch BETWEEN \$2 AND \$3\s+ORDER BY epoch`).
+		WithArgs(int64(7), int64(100), int64(200)).
+		WillReturnRows(sqlmock.NewRows([]string{"epoch", "balance", "effective_balance"}).
+			AddRow(int64(100), int64(32_000_100_000), int64(32_000_000_000)).
+			AddRow(int64(101), int64(32_000_200_000), int64(32_000_000_000)))
+
+	points, err := d.ValidatorBalanceHistory(context.Background(), 7, 100, 200)
+	if err != nil {
+		t.Fatalf("ValidatorBalanceHistory returned error: %v", err)
+	}
+	if len(points) != 2 || points[0].Epoch != 100 || points[1].Balance != 32_000_200_000 {
+		t.Fatalf("unexpected balance history: %+v", points)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestValidatorSlashing(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	d := &DB{db: db}
+	t.Cleanup(d.Close)
+
+	mock.ExpectQuery(`SELECT v.slashed, v.effective_balance, v.withdrawable_epoch,\s+\(SELECT MIN\(s.slot_number\) FROM slashings s`).
+		WithArgs(int64(9)).
+		WillReturnRows(sqlmock.NewRows([]string{"slashed", "effective_balance", "withdrawable_epoch", "slashed_slot"}).
+			AddRow(true, int64(31_000_000_000), convertUint64EpochToStorage(20_000), int64(377_856)))
+	mock.ExpectQuery(`SELECT v.slashed`).
+		WithArgs(int64(10)).
+		WillReturnRows(sqlmock.NewRows([]string{"slashed", "effective_balance", "withdrawable_epoch", "slashed_slot"}).
+			AddRow(false, int64(32_000_000_000), convertUint64EpochToStorage(math.MaxUint64), nil))
+	mock.ExpectQuery(`WHERE slashed AND withdrawable_epoch > \$1`).
+		WithArgs(convertUint64EpochToStorage(12_000)).
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(int64(64_000_000_000)))
+
+	v, err := d.ValidatorSlashing(context.Background(), 9)
+	if err != nil {
+		t.Fatalf("ValidatorSlashing returned error: %v", err)
+	}
+	if !v.Slashed || v.EffectiveBalance != 31_000_000_000 || v.WithdrawableEpoch != 20_000 || v.SlashedSlot == nil || *v.SlashedSlot != 377_856 {
+		t.Fatalf("unexpected slashing state: %+v", v)
+	}
+	if v, err := d.ValidatorSlashing(context.Background(), 10); err != nil || v.Slashed || v.SlashedSlot != nil {
+		t.Fatalf("unslashed validator = %+v, %v; want no slashing slot", v, err)
+	}
+	if sum, err := d.RecentlySlashedBalance(context.Background(), 12_000); err != nil || sum != 64_000_000_000 {
+		t.Fatalf("RecentlySlashedBalance = (%d, %v), want 64000000000", sum, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestValidatorDetailsByAddressReportsCredentialTypes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	d := &DB{db: db}
+	t.Cleanup(d.Close)
+
+	far := convertUint64EpochToStorage(math.MaxUint64)
+	mock.ExpectQuery(`AS credential_type\s+FROM validators v`).
+		WithArgs("0x0988dc1554cf6877508208fff8aab4e5afa11ee3").
+		WillReturnRows(sqlmock.NewRows([]string{"validator_index", "effective_balance", "activation_epoch", "exit_epoch", "total_deposit", "credential_type"}).
+			AddRow(int64(1), int64(32_000_000_000), int64(10), far, int64(32_000_000_000), "0x01").
+			AddRow(int64(2), int64(64_000_000_000), int64(10), far, int64(64_000_000_000), "0x02").
+			AddRow(int64(3), int64(32_000_000_000), int64(10), far, int64(32_000_000_000), "0x00"))
+
+	details, err := d.ValidatorDetailsByAddress(context.Background(), "0x0988dc1554cf6877508208fff8aab4e5afa11ee3")
+	if err != nil {
+		t.Fatalf("ValidatorDetailsByAddress returned error: %v", err)
+	}
+	got := make([]string, 0, len(details))
+	for _, v := range details {
+		got = append(got, v.CredentialType)
+	}
+	if want := []string{"0x01", "0x02", "0x00"}; !slices.Equal(got, want) {
+		t.Fatalf("credential types = %v, want %v", got, want)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestGetWeightedAverageDepositTime(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	d := &DB{db: db}
+	t.Cleanup(d.Close)
+
+	now := time.Now().Unix()
+	mock.ExpectQuery(`JOIN deposit_txs dt ON dt.publickey = v.pubkey`).
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"effective_balance", "first_deposit_time"}).
+			AddRow(int64(32_000_000_000), now-100).
+			AddRow(int64(96_000_000_000), now-300).
+			AddRow(int64(32_000_000_000), now+3600)) // Clock skew: ignored.
+
+	// Duplicate indices are queried once.
+	got, err := d.GetWeightedAverageDepositTime(context.Background(), []uint64{1, 2, 2, 3})
+	if err != nil {
+		t.Fatalf("GetWeightedAverageDepositTime returned error: %v", err)
+	}
+	// (32*100 + 96*300) / 128 = 250, allowing for the clock ticking during the test.
+	if got < 250 || got > 252 {
+		t.Fatalf("weighted average deposit time = %d, want ~250", got)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestActivationQueue(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	d := &DB{db: db}
+	t.Cleanup(d.Close)
+
+	const epoch = 1000
+	// Only eligible validators are queued, in order of eligibility.
+	mock.ExpectQuery("FROM validators\\s+WHERE activation_epoch > \\$1 AND activation_eligibility_epoch <> \\$2").
+		WithArgs(convertUint64EpochToStorage(epoch), farFutureEpochStorage).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(42))
+	mock.ExpectQuery("WINDOW queue_order AS \\(ORDER BY activation_eligibility_epoch, validator_index\\)").
+		WithArgs(convertUint64EpochToStorage(epoch), pq.Array([]int64{7, 9}), farFutureEpochStorage).
+		WillReturnRows(sqlmock.NewRows([]string{"validator_index", "position", "balance_through"}).AddRow(9, 30, 960_000_000_000))
+
+	length, err := d.ActivationQueueLength(context.Background(), epoch)
+	if err != nil || length != 42 {
+		t.Fatalf("ActivationQueueLength = (%d, %v), want 42", length, err)
+	}
+	positions, err := d.ActivationQueuePositions(context.Background(), epoch, []uint64{7, 9})
+	if err != nil {
+		t.Fatalf("ActivationQueuePositions returned error: %v", err)
+	}
+	if len(positions) != 1 || positions[9] != (QueuePosition{Position: 30, BalanceThroughGwei: 960_000_000_000}) {
+		t.Fatalf("positions = %v, want only 9 at 30 behind 960 ETH", positions)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestActiveValidatorsIndexByAddressUnionsBothMatches(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	d := &DB{db: db}
+	t.Cleanup(d.Close)
+
+	// UNION (not UNION ALL) lets Postgres drop a validator matched by both the deposit and withdrawal side.
+	const epoch = 1000
+	mock.ExpectQuery("\\)\\s+union\\s+\\(SELECT[\\s\\S]+ORDER BY validator_index").
+		WithArgs("0xabc", convertUint64EpochToStorage(epoch)).
+		WillReturnRows(sqlmock.NewRows([]string{"validator_index"}).AddRow(3).AddRow(7))
+
+	indices, err := d.ActiveValidatorsIndexByAddress(context.Background(), "0xabc", epoch)
+	if err != nil {
+		t.Fatalf("ActiveValidatorsIndexByAddress returned error: %v", err)
+	}
+	if !slices.Equal(indices, []uint64{3, 7}) {
+		t.Fatalf("indices = %v, want [3 7]", indices)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestActiveValidatorIndicesByAddressesGroupsByAddress(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	d := &DB{db: db}
+	t.Cleanup(d.Close)
+
+	const epoch = 1000
+	mock.ExpectQuery("= ANY\\(\\$1\\)[\\s\\S]+ORDER BY address, validator_index").
+		WithArgs(pq.Array([]string{"0xabc", "0xdef", "0x123"}), convertUint64EpochToStorage(epoch)).
+		WillReturnRows(sqlmock.NewRows([]string{"address", "validator_index"}).
+			AddRow("0xabc", 3).AddRow("0xabc", 7).AddRow("0xdef", 5))
+
+	got, err := d.ActiveValidatorIndicesByAddresses(context.Background(), []string{"0xabc", "0xdef", "0x123"}, epoch)
+	if err != nil {
+		t.Fatalf("ActiveValidatorIndicesByAddresses returned error: %v", err)
+	}
+	if len(got) != 2 || !slices.Equal(got["0xabc"], []uint64{3, 7}) || !slices.Equal(got["0xdef"], []uint64{5}) {
+		t.Fatalf("indices = %v, want 0xabc [3 7] and 0xdef [5]", got)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestEmptyLeaderboardsAreArrays(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	d := &DB{db: db}
+	t.Cleanup(d.Close)
+
+	mock.ExpectQuery(`GROUP BY withdrawal_address`).WillReturnRows(sqlmock.NewRows([]string{"withdrawal_address"}))
+	mock.ExpectQuery(`depositor_data`).WillReturnRows(sqlmock.NewRows([]string{"depositor_address"}))
+
+	withdrawals, err := d.TopWithdrawalAddresses(context.Background(), 10, "", "", 0)
+	if err != nil {
+		t.Fatalf("TopWithdrawalAddresses: %v", err)
+	}
+	depositors, err := d.TopDepositorAddresses(context.Background(), 10, "", "", 0, 0)
+	if err != nil {
+		t.Fatalf("TopDepositorAddresses: %v", err)
+	}
+	for name, v := range map[string]any{"withdrawals": withdrawals, "depositors": depositors} {
+		if b, _ := json.Marshal(v); string(b) != "[]" {
+			t.Fatalf("empty %s leaderboard = %s, want []", name, b)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package dora
+
+import (
+	"context"
+	"database/sql"
+)
+
+// ValidatorSlashing is the slashing-related state of a validator.
+type ValidatorSlashing struct {
+	Slashed          bool
+	EffectiveBalance int64
+	// WithdrawableEpoch is at least the slashing epoch plus EPOCHS_PER_SLASHINGS_VECTOR for slashed validators.
+	WithdrawableEpoch uint64
+	// SlashedSlot is the slot of the canonical block that included the slashing, from Dora's slashings
+	// table; nil when Dora has not indexed it.
+	SlashedSlot *uint64
+}
+
+// ValidatorSlashing returns the slashing state of a validator.
+// It returns sql.ErrNoRows when the validator is unknown.
+func (d *DB) ValidatorSlashing(ctx context.Context, index uint64) (ValidatorSlashing, error) {
+	if d == nil || d.db == nil {
+		return ValidatorSlashing{}, sql.ErrNoRows
+	}
+
+	var (
+		v            ValidatorSlashing
+		withdrawable int64
+		slashedSlot  sql.NullInt64
+	)
+	err := d.db.QueryRowContext(ctx, `
+SELECT v.slashed, v.effective_balance, v.withdrawable_epoch,
+	(SELECT MIN(s.slot_number) FROM slashings s WHERE s.validator = v.validator_index AND NOT s.orphaned) AS slashed_slot
+FROM validators v
+WHERE v.validator_index = $1
+`, int64(index)).Scan(&v.Slashed, &v.EffectiveBalance, &withdrawable, &slashedSlot)
+	if err != nil {
+		return ValidatorSlashing{}, err
+	}
+	v.WithdrawableEpoch = ConvertInt64ToUint64(withdrawable)
+	if slashedSlot.Valid {
+		slot := uint64(slashedSlot.Int64)
+		v.SlashedSlot = &slot
+	}
+	return v, nil
+}
+
+// RecentlySlashedBalance sums the effective balance of slashed validators that are not yet withdrawable
+// at epoch, i.e. those still counted in the beacon state's slashings vector.
+func (d *DB) RecentlySlashedBalance(ctx context.Context, epoch uint64) (int64, error) {
+	if d == nil || d.db == nil {
+		return 0, nil
+	}
+
+	var sum int64
+	err := d.db.QueryRowContext(ctx, `
+SELECT COALESCE(SUM(effective_balance), 0)::bigint
+FROM validators
+WHERE slashed AND withdrawable_epoch > $1
+`, convertUint64EpochToStorage(epoch)).Scan(&sum)
+	if err != nil {
+		return 0, err
+	}
+	return sum, nil
+}
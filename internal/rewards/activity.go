@@ -0,0 +1,42 @@
+package rewards
+
+// Offline detection: lastActive remembers, per validator, the newest epoch with a positive
+// attestation reward. Unlike the reward cache it is kept across window resets, so a validator that
+// stopped attesting yesterday is still reported with the epoch it was last seen.
+
+// recordActivityLocked notes which attesters of epoch earned attestation rewards. Epochs may be
+// merged out of order during backfill, so only newer epochs move a validator's last active epoch.
+// Caller must hold cacheMux for writing.
+func (s *Service) recordActivityLocked(epoch uint64, data *epochRewards) {
+	if s.activitySince == 0 || epoch < s.activitySince {
+		s.activitySince = epoch
+	}
+	for _, idx := range data.attesters {
+		inc := data.income[idx]
+		if inc == nil || inc.AttestationSourceReward+inc.AttestationTargetReward+inc.AttestationHeadReward == 0 {
+			continue
+		}
+		if epoch > s.lastActive[idx] {
+			s.lastActive[idx] = epoch
+		}
+	}
+}
+
+// applyActivityLocked fills r's last active epoch and offline flag. A validator never seen active
+// since tracking began is offline once tracking covers more than OFFLINE_THRESHOLD_EPOCHS epochs.
+// Caller must hold cacheMux.
+func (s *Service) applyActivityLocked(r *ValidatorReward) {
+	if s.activitySince == 0 {
+		return
+	}
+	last, seen := s.lastActive[r.ValidatorIndex]
+	since := s.latestSyncEpoch - min(s.activitySince, s.latestSyncEpoch)
+	if seen {
+		last = min(last, s.latestSyncEpoch)
+		since = s.latestSyncEpoch - last
+		r.LastActiveEpoch = &last
+		r.EpochsSinceActive = &since
+	}
+	threshold := s.config.OfflineThresholdEpochs
+	r.Offline = threshold > 0 && since > threshold
+}
@@ -0,0 +1,326 @@
+package rewards
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"math/big"
+	"os"
+	"slices"
+	"time"
+
+	"beacon-rewards/internal/utils"
+)
+
+// The validator history answers "what did the validators this address funds today earn", and only for
+// ValidatorHistoryDays. The address history file answers "what did this address earn on that day": at
+// each cache reset it records one line with the totals of every recorded address over the closing
+// window, summed over the validators the address funded when the window closed.
+//
+// Recording every address would grow with the whole deposit set, so only labelled addresses and
+// addresses queried within the last AddressHistoryDays are recorded, at most
+// AddressHistoryMaxAddresses of the latter (most recently queried first). Each address's last query
+// time is stored with its totals, so tracking survives restarts and an address nobody asks about again
+// drops out after AddressHistoryDays. The file keeps AddressHistoryDays windows and is rewritten on
+// each reset, so its size is bounded by days x recorded addresses.
+
+// addressWindowTotals is one address's rewards over a completed cache window.
+type addressWindowTotals struct {
+	ValidatorCount int   `json:"validator_count"` // Validators of the address with rewards in the window.
+	Cl             int64 `json:"cl"`
+	El             int64 `json:"el"`
+	// EffectiveBalance sums those validators' effective balances (gwei) when the window closed, with
+	// DefaultEffectiveBalanceGwei standing in for missing ones.
+	EffectiveBalance int64 `json:"effective_balance"`
+	// LastQueried carries the address's last query forward; nil for addresses recorded for their label.
+	LastQueried *time.Time `json:"last_queried,omitempty"`
+}
+
+// addressHistoryEntry is one line of the address history file.
+type addressHistoryEntry struct {
+	WindowStart time.Time                      `json:"window_start"`
+	WindowEnd   time.Time                      `json:"window_end"`
+	Addresses   map[string]addressWindowTotals `json:"addresses"`
+}
+
+// recordedAddress is an address to record at a reset, with the validators it funds.
+type recordedAddress struct {
+	validators  []uint64
+	lastQueried *time.Time
+}
+
+// AddressWindowRewards is what an address earned over one completed cache window.
+type AddressWindowRewards struct {
+	Address              string     `json:"address"`
+	WindowStart          time.Time  `json:"window_start"`
+	WindowEnd            time.Time  `json:"window_end"`
+	ValidatorCount       int        `json:"validator_count"` // Validators with rewards in the window.
+	ClRewardsGwei        utils.Gwei `json:"cl_rewards_gwei"`
+	ElRewardsGwei        utils.Gwei `json:"el_rewards_gwei"`
+	TotalRewardsGwei     utils.Gwei `json:"total_rewards_gwei"`
+	EffectiveBalanceGwei int64      `json:"effective_balance_gwei"`
+	AprPercent           float64    `json:"apr_percent"` // Window rewards annualised against effective_balance_gwei.
+}
+
+// AddressHistoryEnabled reports whether per-address window totals are being recorded.
+func (s *Service) AddressHistoryEnabled() bool {
+	return s.addressHistoryPath != "" && s.config.AddressHistoryDays > 0
+}
+
+// queriedAddress is one entry of the queried-address LRU.
+type queriedAddress struct {
+	address string
+	at      time.Time
+}
+
+// TrackAddress notes that a normalized address was queried, so the following resets record its totals.
+// Callers should only track addresses that resolved to validators, so arbitrary lookups cannot evict
+// real ones.
+func (s *Service) TrackAddress(address string) {
+	s.trackAddressAt(address, time.Now())
+}
+
+func (s *Service) trackAddressAt(address string, at time.Time) {
+	limit := s.config.AddressHistoryMaxAddresses
+	if !s.AddressHistoryEnabled() || limit == 0 {
+		return
+	}
+	s.trackedAddrMu.Lock()
+	defer s.trackedAddrMu.Unlock()
+	if el, ok := s.queriedAddresses[address]; ok {
+		el.Value.(*queriedAddress).at = at
+		s.queriedOrder.MoveToFront(el)
+		return
+	}
+	s.queriedAddresses[address] = s.queriedOrder.PushFront(&queriedAddress{address: address, at: at})
+	// Only the most recently queried addresses are recorded, so forget the oldest.
+	for s.queriedOrder.Len() > limit {
+		oldest := s.queriedOrder.Remove(s.queriedOrder.Back()).(*queriedAddress)
+		delete(s.queriedAddresses, oldest.address)
+	}
+}
+
+// SetLabeledAddresses sets normalized addresses recorded at every reset whether or not they are queried.
+func (s *Service) SetLabeledAddresses(addresses []string) {
+	s.trackedAddrMu.Lock()
+	defer s.trackedAddrMu.Unlock()
+	s.labeledAddresses = slices.Clone(addresses)
+}
+
+// AddressRewardsAt returns what the address earned in the completed window that started on date's
+// calendar day (UTC+8). ok is false when no window was recorded for that day or the address was not
+// recorded in it.
+func (s *Service) AddressRewardsAt(address string, date time.Time) (AddressWindowRewards, bool, error) {
+	if !s.AddressHistoryEnabled() {
+		return AddressWindowRewards{}, false, nil
+	}
+	s.historyMu.Lock()
+	defer s.historyMu.Unlock()
+	if err := s.loadAddressHistoryLocked(); err != nil {
+		return AddressWindowRewards{}, false, err
+	}
+
+	for i := len(s.addressHistory) - 1; i >= 0; i-- {
+		e := s.addressHistory[i]
+		if !sameWindowDay(e.WindowStart, date) {
+			continue
+		}
+		totals, ok := e.Addresses[address]
+		if !ok {
+			return AddressWindowRewards{}, false, nil
+		}
+		result := AddressWindowRewards{
+			Address:              address,
+			WindowStart:          e.WindowStart,
+			WindowEnd:            e.WindowEnd,
+			ValidatorCount:       totals.ValidatorCount,
+			ClRewardsGwei:        utils.Gwei(totals.Cl),
+			ElRewardsGwei:        utils.Gwei(totals.El),
+			TotalRewardsGwei:     utils.Gwei(totals.Cl + totals.El),
+			EffectiveBalanceGwei: totals.EffectiveBalance,
+		}
+		if windowSeconds := e.WindowEnd.Sub(e.WindowStart).Seconds(); windowSeconds > 0 && totals.EffectiveBalance > 0 {
+			result.AprPercent = float64(totals.Cl+totals.El) / float64(totals.EffectiveBalance) * (365 * 24 * time.Hour).Seconds() / windowSeconds * 100
+		}
+		return result, true, nil
+	}
+	return AddressWindowRewards{}, false, nil
+}
+
+// recordedAddresses returns the addresses to record for the window closing at now with their last query
+// time: labelled addresses, plus those queried by this process or carried in the last retained window
+// within AddressHistoryDays, capped at AddressHistoryMaxAddresses.
+func (s *Service) recordedAddresses(now time.Time) map[string]*time.Time {
+	queried := make(map[string]time.Time)
+	s.historyMu.Lock()
+	if err := s.loadAddressHistoryLocked(); err != nil {
+		slog.Warn("Failed to load address history", "path", s.addressHistoryPath, "error", err)
+	} else if n := len(s.addressHistory); n > 0 {
+		for addr, totals := range s.addressHistory[n-1].Addresses {
+			if totals.LastQueried != nil {
+				queried[addr] = *totals.LastQueried
+			}
+		}
+	}
+	s.historyMu.Unlock()
+
+	s.trackedAddrMu.Lock()
+	for el := s.queriedOrder.Front(); el != nil; el = el.Next() {
+		q := el.Value.(*queriedAddress)
+		if q.at.After(queried[q.address]) {
+			queried[q.address] = q.at
+		}
+	}
+	labeled := s.labeledAddresses
+	s.trackedAddrMu.Unlock()
+
+	cutoff := now.AddDate(0, 0, -s.config.AddressHistoryDays)
+	recent := make([]string, 0, len(queried))
+	for addr, at := range queried {
+		if at.After(cutoff) {
+			recent = append(recent, addr)
+		}
+	}
+	slices.SortFunc(recent, func(a, b string) int { return queried[b].Compare(queried[a]) })
+	if limit := s.config.AddressHistoryMaxAddresses; len(recent) > limit {
+		recent = recent[:limit]
+	}
+
+	result := make(map[string]*time.Time, len(recent)+len(labeled))
+	for _, addr := range recent {
+		at := queried[addr]
+		result[addr] = &at
+	}
+	for _, addr := range labeled {
+		if _, ok := result[addr]; !ok {
+			result[addr] = nil
+		}
+	}
+	return result
+}
+
+// resolveRecordedAddresses looks up the validators each recorded address funds at the epoch of now.
+// It needs Dora and resolves all of them in one query; addresses without active validators are left out.
+func (s *Service) resolveRecordedAddresses(now time.Time) map[string]recordedAddress {
+	if s.doraDB == nil {
+		return nil
+	}
+	addresses := s.recordedAddresses(now)
+	if len(addresses) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, s.config.DBQueryTimeout)
+	defer cancel()
+	indices, err := s.doraDB.ActiveValidatorIndicesByAddresses(ctx, slices.Collect(maps.Keys(addresses)), s.chain.TimeToEpoch(now))
+	if err != nil {
+		slog.Warn("Failed to resolve validators of recorded addresses; skipping address history", "addresses", len(addresses), "error", err)
+		return nil
+	}
+	result := make(map[string]recordedAddress, len(indices))
+	for addr, validators := range indices {
+		result[addr] = recordedAddress{validators: validators, lastQueried: addresses[addr]}
+	}
+	return result
+}
+
+// addressHistoryEntryOf sums a closed window per recorded address.
+func (s *Service) addressHistoryEntryOf(w *closedWindow, addresses map[string]recordedAddress, balances map[uint64]int64) addressHistoryEntry {
+	entry := addressHistoryEntry{
+		WindowStart: w.snapshot.WindowStart,
+		WindowEnd:   w.snapshot.WindowEnd,
+		Addresses:   make(map[string]addressWindowTotals, len(addresses)),
+	}
+	for addr, rec := range addresses {
+		totals := addressWindowTotals{LastQueried: rec.lastQueried}
+		for _, idx := range rec.validators {
+			income, ok := w.cache[idx]
+			if !ok {
+				continue
+			}
+			totals.ValidatorCount++
+			totals.Cl += income.TotalClRewards()
+			totals.El += new(big.Int).Div(weiBytesToBigInt(income.TxFeeRewardWei), gweiScalar).Int64()
+			if b := balances[idx]; b > 0 {
+				totals.EffectiveBalance += b
+			} else {
+				totals.EffectiveBalance += s.config.DefaultEffectiveBalanceGwei
+			}
+		}
+		entry.Addresses[addr] = totals
+	}
+	return entry
+}
+
+// persistAddressHistory appends entry, drops windows beyond AddressHistoryDays, and rewrites the file.
+func (s *Service) persistAddressHistory(entry addressHistoryEntry) {
+	s.historyMu.Lock()
+	defer s.historyMu.Unlock()
+	if err := s.loadAddressHistoryLocked(); err != nil {
+		slog.Warn("Discarding unreadable address history", "path", s.addressHistoryPath, "error", err)
+		s.addressHistory = nil
+	}
+
+	// Same-day windows replace each other, matching persistSnapshot.
+	if n := len(s.addressHistory); n > 0 && sameWindowDay(s.addressHistory[n-1].WindowStart, entry.WindowStart) {
+		s.addressHistory[n-1] = entry
+	} else {
+		s.addressHistory = append(s.addressHistory, entry)
+	}
+	if keep := s.config.AddressHistoryDays; len(s.addressHistory) > keep {
+		s.addressHistory = append([]addressHistoryEntry(nil), s.addressHistory[len(s.addressHistory)-keep:]...)
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for i := range s.addressHistory {
+		if err := enc.Encode(&s.addressHistory[i]); err != nil {
+			slog.Error("Failed to encode address history", "error", err)
+			return
+		}
+	}
+	if err := writeFileAtomic(s.addressHistoryPath, buf.Bytes()); err != nil {
+		slog.Error("Failed to write address history file", "path", s.addressHistoryPath, "error", err)
+	}
+}
+
+// loadAddressHistoryLocked reads the history file once; caller must hold historyMu.
+func (s *Service) loadAddressHistoryLocked() error {
+	if s.addressHistoryLoaded {
+		return nil
+	}
+	f, err := os.Open(s.addressHistoryPath)
+	if os.IsNotExist(err) {
+		s.addressHistoryLoaded = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var entries []addressHistoryEntry
+	skipped, err := readJSONLines(f, func(line []byte) error {
+		var e addressHistoryEntry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return err
+		}
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("read address history: %w", err)
+	}
+	if skipped > 0 {
+		slog.Warn("Skipped malformed address history lines", "path", s.addressHistoryPath, "skipped", skipped)
+	}
+	if keep := s.config.AddressHistoryDays; len(entries) > keep {
+		entries = entries[len(entries)-keep:]
+	}
+	s.addressHistory = entries
+	s.addressHistoryLoaded = true
+	return nil
+}
@@ -0,0 +1,73 @@
+package rewards
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// The cache checkpoint is a working-set copy of the reward cache, written every CACHE_FLUSH_INTERVAL
+// in the shared state format, so a crash late in the day does not force a re-sync of the whole window.
+// It is distinct from the history files, which record closed windows.
+
+// checkpointEnabled reports whether this instance writes and restores cache checkpoints.
+func (s *Service) checkpointEnabled() bool {
+	return s.config.CacheFlushInterval > 0 && s.config.CacheCheckpointFile != "" && !s.config.ReadOnlyReplica
+}
+
+// writeCheckpoint saves the cache to CACHE_CHECKPOINT_FILE. Only live sync is checkpointed: during
+// backfill epochs finish out of order, and resuming after latestSyncEpoch would skip the gaps.
+func (s *Service) writeCheckpoint() {
+	if !s.checkpointEnabled() || s.SyncStatus().Phase != SyncPhaseLive {
+		return
+	}
+	data, err := s.encodeState()
+	if err == nil {
+		err = writeFileAtomic(s.config.CacheCheckpointFile, data)
+	}
+	if err != nil {
+		slog.Error("Failed to write cache checkpoint", "path", s.config.CacheCheckpointFile, "error", err)
+	}
+}
+
+// restoreCheckpoint loads CACHE_CHECKPOINT_FILE when it belongs to the window that is open at now,
+// and reports whether it did. A checkpoint of an earlier window is ignored: its rewards were closed
+// into history, or lost, at that window's reset.
+func (s *Service) restoreCheckpoint(now time.Time) bool {
+	if !s.checkpointEnabled() {
+		return false
+	}
+	path := s.config.CacheCheckpointFile
+	state, err := readStateFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false
+	}
+	if err != nil {
+		slog.Warn("Ignoring unreadable cache checkpoint", "path", path, "error", err)
+		return false
+	}
+	if !sameWindowDay(state.WindowStart, now) {
+		slog.Info("Ignoring cache checkpoint of a closed window", "path", path, "window_start", state.WindowStart)
+		return false
+	}
+	s.installState(state)
+	s.RecomputeNetworkRewards()
+	slog.Info("Restored cache checkpoint", "path", path, "epoch", state.LatestSyncEpoch,
+		"validators", len(state.Validators), "written_at", state.WrittenAt)
+	return true
+}
+
+// checkpointRoutine writes a checkpoint every CACHE_FLUSH_INTERVAL until the service stops.
+func (s *Service) checkpointRoutine() {
+	ticker := time.NewTicker(s.config.CacheFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.writeCheckpoint()
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package rewards
+
+import (
+	"context"
+	"log/slog"
+)
+
+// epochCompleteness records an epoch whose attestation rewards covered fewer validators than were
+// active, e.g. because the beacon node served partial data after missed slots.
+type epochCompleteness struct {
+	Expected int `json:"expected"` // Active validators per Dora.
+	Received int `json:"received"` // Validators in the attestation rewards response.
+}
+
+// expectedAttesters returns how many validators should have attestation rewards for epoch, or 0
+// when that is unknown: without Dora, or when only TRACKED_VALIDATORS are synced.
+func (s *Service) expectedAttesters(epoch uint64) int {
+	if s.doraDB == nil || s.tracked != nil {
+		return 0
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, s.config.DBQueryTimeout)
+	defer cancel()
+	count, err := s.doraDB.ActiveValidatorCount(ctx, epoch)
+	if err != nil {
+		slog.Debug("Could not load active validator count for completeness check", "epoch", epoch, "error", err)
+		return 0
+	}
+	return int(count)
+}
+
+// recordCompletenessLocked flags epoch when it received fewer attestation rewards than expected, and
+// clears the flag when a re-processed epoch turns out complete. Caller must hold cacheMux for writing.
+func (s *Service) recordCompletenessLocked(epoch uint64, data *epochRewards) {
+	if data.expectedAttesters <= 0 || len(data.attesters) >= data.expectedAttesters {
+		delete(s.incompleteEpochs, epoch)
+		return
+	}
+	s.incompleteEpochs[epoch] = epochCompleteness{Expected: data.expectedAttesters, Received: len(data.attesters)}
+	slog.Warn("Epoch has attestation rewards for fewer validators than are active; totals may be low",
+		"epoch", epoch, "expected", data.expectedAttesters, "received", len(data.attesters))
+}
@@ -0,0 +1,87 @@
+package rewards
+
+import (
+	"math"
+	"math/big"
+	"slices"
+	"time"
+
+	"beacon-rewards/internal/utils"
+)
+
+// APRDistribution summarises per-validator APR across the current window.
+type APRDistribution struct {
+	WindowStart  time.Time `json:"window_start"`
+	WindowEnd    time.Time `json:"window_end"`
+	AprAvailable bool      `json:"apr_available"`
+	// ValidatorCount validators are included; ExcludedCount were active for less than
+	// MinActiveFraction of the window's epochs.
+	ValidatorCount    int     `json:"validator_count"`
+	ExcludedCount     int     `json:"excluded_count"`
+	MinActiveFraction float64 `json:"min_active_fraction"`
+	MinPercent        float64 `json:"min_percent"`
+	P10Percent        float64 `json:"p10_percent"`
+	P25Percent        float64 `json:"p25_percent"`
+	P50Percent        float64 `json:"p50_percent"`
+	P75Percent        float64 `json:"p75_percent"`
+	P90Percent        float64 `json:"p90_percent"`
+	MaxPercent        float64 `json:"max_percent"`
+}
+
+// APRDistribution annualises each cached validator's rewards against its effective balance (falling
+// back to DefaultEffectiveBalanceGwei) and returns percentiles of the result. A validator's active
+// fraction is the share of processed epochs in which it earned attestation rewards; validators below
+// minActiveFraction are counted in ExcludedCount so newly activated ones do not drag the low end.
+func (s *Service) APRDistribution(effectiveBalances map[uint64]int64, minActiveFraction float64) APRDistribution {
+	s.cacheMux.RLock()
+	start, end := s.rewardWindowLocked()
+	dist := APRDistribution{
+		WindowStart:       start,
+		WindowEnd:         end,
+		AprAvailable:      s.aprAvailable(start, end),
+		MinActiveFraction: minActiveFraction,
+	}
+	if !dist.AprAvailable {
+		s.cacheMux.RUnlock()
+		return dist
+	}
+
+	windowSeconds := end.Sub(start).Seconds()
+	aprs := make([]float64, 0, len(s.cache))
+	for idx, income := range s.cache {
+		if s.idealPerIncrementTotal > 0 && s.idealAttestation[idx].total()/s.idealPerIncrementTotal < minActiveFraction {
+			dist.ExcludedCount++
+			continue
+		}
+		balance := effectiveBalances[idx]
+		if balance <= 0 {
+			balance = s.config.DefaultEffectiveBalanceGwei
+		}
+		el := new(big.Int).Div(weiBytesToBigInt(income.TxFeeRewardWei), gweiScalar).Int64()
+		total := income.TotalClRewards() + el
+		aprs = append(aprs, s.projectAPR(utils.Gwei(total), utils.Gwei(balance), windowSeconds))
+	}
+	s.cacheMux.RUnlock()
+
+	dist.ValidatorCount = len(aprs)
+	if len(aprs) == 0 {
+		return dist
+	}
+	slices.Sort(aprs)
+	dist.MinPercent = aprs[0]
+	dist.P10Percent = percentile(aprs, 0.10)
+	dist.P25Percent = percentile(aprs, 0.25)
+	dist.P50Percent = percentile(aprs, 0.50)
+	dist.P75Percent = percentile(aprs, 0.75)
+	dist.P90Percent = percentile(aprs, 0.90)
+	dist.MaxPercent = aprs[len(aprs)-1]
+	return dist
+}
+
+// percentile linearly interpolates the p-th quantile (0 <= p <= 1) of sorted, which must not be empty.
+func percentile(sorted []float64, p float64) float64 {
+	pos := p * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(pos-float64(lo))
+}
@@ -0,0 +1,136 @@
+package rewards
+
+import (
+	"math"
+	"time"
+
+	"beacon-rewards/internal/utils"
+
+	"github.com/gobitfly/eth-rewards/types"
+)
+
+// Efficiency compares a validator's attestation rewards with the ideal rewards the beacon node reports
+// for a perfectly performing validator of the same effective balance. Proposals and sync committee
+// duties are luck-driven and have no ideal counterpart, so they are left out of both sides.
+//
+// The ideal_rewards list is keyed by effective balance rather than validator, and ideal rewards scale
+// linearly with effective balance, so each epoch is reduced to an ideal reward per 1 ETH increment.
+// A validator's window ideal is the sum over the epochs it attested in, times its effective balance.
+// The source, target and head parts are kept apart so GET /validators/:index/attestation-detail can
+// show which duty falls short. An increment is utils.GweiPerEth.
+
+// idealReward is an ideal attestation reward split by duty, per 1 ETH increment.
+type idealReward struct {
+	Source float64 `json:"source"`
+	Target float64 `json:"target"`
+	Head   float64 `json:"head"`
+}
+
+func (r idealReward) total() float64 {
+	return r.Source + r.Target + r.Head
+}
+
+func (r idealReward) add(o idealReward) idealReward {
+	return idealReward{Source: r.Source + o.Source, Target: r.Target + o.Target, Head: r.Head + o.Head}
+}
+
+// sub returns r - o with each part floored at zero.
+func (r idealReward) sub(o idealReward) idealReward {
+	return idealReward{Source: max(r.Source-o.Source, 0), Target: max(r.Target-o.Target, 0), Head: max(r.Head-o.Head, 0)}
+}
+
+// idealRewardPerIncrement returns the epoch's ideal head, source and target rewards per 1 ETH of
+// effective balance, taken from the largest balance listed to minimise rounding. It is zero when none
+// is listed.
+func idealRewardPerIncrement(ideal []*types.IdealAttestationRewardContainer) idealReward {
+	var best *types.IdealAttestationRewardContainer
+	for _, r := range ideal {
+		if r != nil && r.EffectiveBalance >= utils.GweiPerEth && (best == nil || r.EffectiveBalance > best.EffectiveBalance) {
+			best = r
+		}
+	}
+	if best == nil {
+		return idealReward{}
+	}
+	increments := float64(best.EffectiveBalance / utils.GweiPerEth)
+	return idealReward{
+		Source: float64(best.Source) / increments,
+		Target: float64(best.Target) / increments,
+		Head:   float64(best.Head) / increments,
+	}
+}
+
+// attestationNetGwei returns head, source and target rewards net of source and target penalties.
+func attestationNetGwei(income *types.ValidatorEpochIncome) int64 {
+	return int64(income.AttestationHeadReward+income.AttestationSourceReward+income.AttestationTargetReward) -
+		int64(income.AttestationSourcePenalty+income.AttestationTargetPenalty)
+}
+
+// efficiencyPercent returns net as a percentage of ideal, or zero when there is no ideal to compare with.
+func efficiencyPercent(net int64, ideal float64) float64 {
+	if ideal <= 0 {
+		return 0
+	}
+	return float64(net) / ideal * 100
+}
+
+// AttestationComponent compares one attestation duty's rewards with the ideal over the window.
+type AttestationComponent struct {
+	ActualGwei        utils.Gwei `json:"actual_gwei"` // Rewards net of penalties.
+	IdealGwei         utils.Gwei `json:"ideal_gwei"`
+	EfficiencyPercent float64    `json:"efficiency_percent"`
+}
+
+// AttestationDetail breaks a validator's attestation rewards in the current window down by duty.
+type AttestationDetail struct {
+	ValidatorIndex       uint64               `json:"validator_index"`
+	WindowStart          time.Time            `json:"window_start"`
+	WindowEnd            time.Time            `json:"window_end"`
+	EffectiveBalanceGwei utils.Gwei           `json:"effective_balance_gwei"` // Balance the ideal is scaled by.
+	Source               AttestationComponent `json:"source"`
+	Target               AttestationComponent `json:"target"`
+	Head                 AttestationComponent `json:"head"`
+	Total                AttestationComponent `json:"total"`
+}
+
+// AttestationDetail compares the validator's source, target and head rewards in the current window
+// with the ideal for its effective balance (DefaultEffectiveBalanceGwei when not positive). It returns
+// false when the validator has no attestation data in the window.
+func (s *Service) AttestationDetail(index uint64, effectiveBalance int64) (AttestationDetail, bool) {
+	if effectiveBalance <= 0 {
+		effectiveBalance = s.config.DefaultEffectiveBalanceGwei
+	}
+
+	s.cacheMux.RLock()
+	defer s.cacheMux.RUnlock()
+	income, hasIncome := s.cache[index]
+	ideal, hasIdeal := s.idealAttestation[index]
+	if !hasIncome && !hasIdeal {
+		return AttestationDetail{}, false
+	}
+	if income == nil {
+		income = &types.ValidatorEpochIncome{}
+	}
+
+	start, end := s.rewardWindowLocked()
+	increments := float64(effectiveBalance / utils.GweiPerEth)
+	detail := AttestationDetail{
+		ValidatorIndex:       index,
+		WindowStart:          start,
+		WindowEnd:            end,
+		EffectiveBalanceGwei: utils.Gwei(effectiveBalance),
+		Source:               attestationComponent(int64(income.AttestationSourceReward)-int64(income.AttestationSourcePenalty), ideal.Source*increments),
+		Target:               attestationComponent(int64(income.AttestationTargetReward)-int64(income.AttestationTargetPenalty), ideal.Target*increments),
+		Head:                 attestationComponent(int64(income.AttestationHeadReward), ideal.Head*increments),
+	}
+	detail.Total = attestationComponent(attestationNetGwei(income), ideal.total()*increments)
+	return detail, true
+}
+
+func attestationComponent(actual int64, ideal float64) AttestationComponent {
+	return AttestationComponent{
+		ActualGwei:        utils.Gwei(actual),
+		IdealGwei:         utils.Gwei(math.Round(ideal)),
+		EfficiencyPercent: efficiencyPercent(actual, ideal),
+	}
+}
@@ -0,0 +1,178 @@
+package rewards
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"beacon-rewards/internal/config"
+	"beacon-rewards/internal/utils"
+
+	"github.com/gobitfly/eth-rewards/elrewards"
+)
+
+const elRPCTimeout = 30 * time.Second
+
+var elHTTPClient = &http.Client{Timeout: elRPCTimeout}
+
+// executionReward returns the priority fees (wei) earned by the proposer of an execution block,
+// using the source selected by ELRewardMethod. feeRecipient is the block's lower-cased fee recipient
+// when the method already loaded the block header, and empty otherwise.
+func (s *Service) executionReward(blockNumber uint64) (reward *big.Int, feeRecipient string, err error) {
+	if s.config.ELRewardMethod == config.ELRewardMethodReceipts {
+		ctx, cancel := context.WithTimeout(s.ctx, elRPCTimeout)
+		defer cancel()
+		return blockReceiptsReward(ctx, s.executionNodeURL(), blockNumber)
+	}
+	reward, err = elrewards.GetELRewardForBlock(blockNumber, s.executionNodeURL())
+	return reward, "", err
+}
+
+// blockReceiptsReward sums (effectiveGasPrice - baseFee) * gasUsed over eth_getBlockReceipts.
+// Computing the tip per receipt avoids relying on the header gasUsed matching the receipts, which
+// is not guaranteed on chains with non-standard fee markets. The block's lower-cased fee recipient
+// (miner) is returned too, so tracking fee recipients needs no further request.
+func blockReceiptsReward(ctx context.Context, endpoint string, blockNumber uint64) (*big.Int, string, error) {
+	blockTag := "0x" + strconv.FormatUint(blockNumber, 16)
+
+	var block struct {
+		BaseFeePerGas string `json:"baseFeePerGas"`
+		Miner         string `json:"miner"`
+	}
+	if err := callELRPC(ctx, endpoint, "eth_getBlockByNumber", []any{blockTag, false}, &block); err != nil {
+		return nil, "", fmt.Errorf("get block %d: %w", blockNumber, err)
+	}
+	baseFee := big.NewInt(0)
+	if block.BaseFeePerGas != "" { // pre-London blocks have no base fee
+		var err error
+		if baseFee, err = parseHexBig(block.BaseFeePerGas); err != nil {
+			return nil, "", fmt.Errorf("block %d baseFeePerGas: %w", blockNumber, err)
+		}
+	}
+
+	var receipts []struct {
+		EffectiveGasPrice string `json:"effectiveGasPrice"`
+		GasUsed           string `json:"gasUsed"`
+	}
+	if err := callELRPC(ctx, endpoint, "eth_getBlockReceipts", []any{blockTag}, &receipts); err != nil {
+		return nil, "", fmt.Errorf("get receipts for block %d: %w", blockNumber, err)
+	}
+
+	total := big.NewInt(0)
+	for i, r := range receipts {
+		price, err := parseHexBig(r.EffectiveGasPrice)
+		if err != nil {
+			return nil, "", fmt.Errorf("block %d receipt %d effectiveGasPrice: %w", blockNumber, i, err)
+		}
+		gasUsed, err := parseHexBig(r.GasUsed)
+		if err != nil {
+			return nil, "", fmt.Errorf("block %d receipt %d gasUsed: %w", blockNumber, i, err)
+		}
+		tip := new(big.Int).Sub(price, baseFee)
+		total.Add(total, tip.Mul(tip, gasUsed))
+	}
+	return total, strings.ToLower(block.Miner), nil
+}
+
+func callELRPC(ctx context.Context, endpoint, method string, params []any, out any) error {
+	body, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := elHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("http request error: %s", resp.Status)
+	}
+
+	var payload struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return err
+	}
+	if payload.Error != nil {
+		return fmt.Errorf("%s: rpc error %d: %s", method, payload.Error.Code, payload.Error.Message)
+	}
+	if len(payload.Result) == 0 || string(payload.Result) == "null" {
+		return fmt.Errorf("%s: empty result", method)
+	}
+	return json.Unmarshal(payload.Result, out)
+}
+
+func parseHexBig(s string) (*big.Int, error) {
+	trimmed := strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	if trimmed == "" {
+		return nil, fmt.Errorf("invalid hex quantity %q", s)
+	}
+	v, ok := new(big.Int).SetString(trimmed, 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid hex quantity %q", s)
+	}
+	return v, nil
+}
+
+// ELBlockReward is the EL tip captured for one execution block of the current window.
+type ELBlockReward struct {
+	BlockNumber   uint64     `json:"block_number"`
+	Slot          uint64     `json:"slot"`
+	ProposerIndex uint64     `json:"proposer_index"`
+	TipGwei       utils.Gwei `json:"tip_gwei"`
+	TipWei        string     `json:"tip_wei"` // Exact decimal amount; TipGwei is truncated.
+}
+
+// ELRewardsByBlock returns the captured EL tips of blocks numbered [from, to], ordered by block.
+// Only blocks whose proposer is synced are known.
+func (s *Service) ELRewardsByBlock(from, to uint64) []ELBlockReward {
+	s.cacheMux.RLock()
+	blocks := make([]ELBlockReward, 0)
+	for number, b := range s.elBlocks {
+		if number >= from && number <= to {
+			blocks = append(blocks, b)
+		}
+	}
+	s.cacheMux.RUnlock()
+
+	slices.SortFunc(blocks, func(a, b ELBlockReward) int {
+		return cmp.Compare(a.BlockNumber, b.BlockNumber)
+	})
+	return blocks
+}
+
+func newELBlockReward(blockNumber, slot, proposer uint64, tip *big.Int) ELBlockReward {
+	return ELBlockReward{
+		BlockNumber:   blockNumber,
+		Slot:          slot,
+		ProposerIndex: proposer,
+		TipGwei:       utils.Gwei(new(big.Int).Div(tip, gweiScalar).Int64()),
+		TipWei:        tip.String(),
+	}
+}
@@ -0,0 +1,289 @@
+package rewards
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"beacon-rewards/internal/config"
+	"beacon-rewards/internal/utils"
+
+	"github.com/gobitfly/eth-rewards/elrewards"
+)
+
+// Known block: base fee 10 gwei, two legacy transactions paying 12 gwei (21000 gas) and 15 gwei
+// (50000 gas). Expected tips: 2 gwei*21000 + 5 gwei*50000 = 292000 gwei.
+var (
+	fakeELBaseFee  = "0x2540be400" // 10 gwei
+	fakeELReceipts = []map[string]any{
+		{"effectiveGasPrice": "0x2cb417800", "gasUsed": "0x5208", "status": "0x1", "logs": []any{}, "logsBloom": "0x"},
+		{"effectiveGasPrice": "0x37e11d600", "gasUsed": "0xc350", "status": "0x1", "logs": []any{}, "logsBloom": "0x"},
+	}
+	fakeELExpectedTip = new(big.Int).Mul(big.NewInt(292000), big.NewInt(1_000_000_000))
+)
+
+func fakeELTx(nonce, gasPrice string) map[string]any {
+	return map[string]any{
+		"type":     "0x0",
+		"nonce":    nonce,
+		"gasPrice": gasPrice,
+		"gas":      "0x186a0",
+		"to":       "0x0000000000000000000000000000000000000001",
+		"value":    "0x0",
+		"input":    "0x",
+		"v":        "0x1b",
+		"r":        "0x1",
+		"s":        "0x1",
+		"from":     "0x0000000000000000000000000000000000000002",
+	}
+}
+
+func fakeELBlock(fullTx bool) map[string]any {
+	block := map[string]any{
+		"hash":             "0x1111111111111111111111111111111111111111111111111111111111111111",
+		"parentHash":       "0x2222222222222222222222222222222222222222222222222222222222222222",
+		"sha3Uncles":       "0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347",
+		"miner":            "0x0000000000000000000000000000000000000003",
+		"stateRoot":        "0x3333333333333333333333333333333333333333333333333333333333333333",
+		"transactionsRoot": "0x4444444444444444444444444444444444444444444444444444444444444444",
+		"receiptsRoot":     "0x5555555555555555555555555555555555555555555555555555555555555555",
+		"logsBloom":        "0x" + strings.Repeat("00", 256),
+		"difficulty":       "0x0",
+		"number":           "0x10",
+		"gasLimit":         "0x1c9c380",
+		"gasUsed":          "0x11558", // 71000, matches the receipts
+		"timestamp":        "0x64",
+		"extraData":        "0x",
+		"mixHash":          "0x0000000000000000000000000000000000000000000000000000000000000000",
+		"nonce":            "0x0000000000000000",
+		"baseFeePerGas":    fakeELBaseFee,
+		"uncles":           []any{},
+	}
+	if fullTx {
+		block["transactions"] = []any{fakeELTx("0x1", "0x2cb417800"), fakeELTx("0x2", "0x37e11d600")}
+	} else {
+		block["transactions"] = []any{}
+	}
+	return block
+}
+
+type fakeRPCRequest struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+func newFakeELServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	receiptIdx := 0
+	handle := func(req fakeRPCRequest) map[string]any {
+		resp := map[string]any{"jsonrpc": "2.0", "id": req.ID}
+		switch req.Method {
+		case "eth_getBlockByNumber":
+			var fullTx bool
+			if len(req.Params) > 1 {
+				_ = json.Unmarshal(req.Params[1], &fullTx)
+			}
+			resp["result"] = fakeELBlock(fullTx)
+		case "eth_getBlockReceipts":
+			resp["result"] = fakeELReceipts
+		case "eth_getTransactionReceipt":
+			resp["result"] = fakeELReceipts[receiptIdx%len(fakeELReceipts)]
+			receiptIdx++
+		default:
+			resp["error"] = map[string]any{"code": -32601, "message": "method not found"}
+		}
+		return resp
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if len(body) > 0 && body[0] == '[' {
+			var reqs []fakeRPCRequest
+			if err := json.Unmarshal(body, &reqs); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			out := make([]map[string]any, 0, len(reqs))
+			for _, req := range reqs {
+				out = append(out, handle(req))
+			}
+			_ = json.NewEncoder(w).Encode(out)
+			return
+		}
+		var req fakeRPCRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(handle(req))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestELRewardMethodsAgreeOnKnownBlock(t *testing.T) {
+	srv := newFakeELServer(t)
+
+	fromReceipts, _, err := blockReceiptsReward(context.Background(), srv.URL, 16)
+	if err != nil {
+		t.Fatalf("blockReceiptsReward returned error: %v", err)
+	}
+	if fromReceipts.Cmp(fakeELExpectedTip) != 0 {
+		t.Fatalf("receipts method tip = %s, want %s", fromReceipts, fakeELExpectedTip)
+	}
+
+	fromLibrary, err := elrewards.GetELRewardForBlock(16, srv.URL)
+	if err != nil {
+		t.Fatalf("GetELRewardForBlock returned error: %v", err)
+	}
+	if fromLibrary.Cmp(fakeELExpectedTip) != 0 {
+		t.Fatalf("library method tip = %s, want %s", fromLibrary, fakeELExpectedTip)
+	}
+}
+
+func TestBlockReceiptsRewardPropagatesRPCError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"method not found"}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	if _, _, err := blockReceiptsReward(context.Background(), srv.URL, 16); err == nil {
+		t.Fatalf("expected error but got none")
+	}
+}
+
+func TestFeeRecipientRewards(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.FeeRecipientsFile = "fee-recipients.yaml"
+	svc := NewService(cfg)
+
+	// Validator 5 proposed block 16; block 17 came without a recipient and stays unattributed.
+	recipient := "0x0000000000000000000000000000000000000003"
+	data := newEpochRewards()
+	svc.recordFeeRecipient(16, recipient, 5, fakeELExpectedTip, data)
+	svc.recordFeeRecipient(17, "", 5, fakeELExpectedTip, data)
+	svc.cacheMux.Lock()
+	svc.applyEpochLocked(1, data)
+	svc.cacheMux.Unlock()
+
+	got := svc.FeeRecipientRewards([]string{recipient}, nil)
+	if want := utils.Gwei(292000); got[recipient] != want {
+		t.Fatalf("fee recipient rewards = %v, want %d gwei for %s", got, want, recipient)
+	}
+	if got := svc.FeeRecipientRewards([]string{recipient}, map[uint64]struct{}{5: {}}); len(got) != 0 {
+		t.Fatalf("fee recipient rewards excluding the proposer = %v, want none", got)
+	}
+}
+
+func TestFeeRecipientReadFromBeaconBlock(t *testing.T) {
+	var v1Requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/eth/v2/beacon/blocks/64":
+			_, _ = w.Write([]byte(`{"data":{"message":{"body":{"execution_payload":{"block_number":"1234","fee_recipient":"0x00000000000000000000000000000000000000AB"}}}}}`))
+		case "/eth/v1/beacon/blocks/64":
+			v1Requests.Add(1)
+			http.NotFound(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.BeaconNodeURL = srv.URL
+	cfg.FeeRecipientsFile = "fee-recipients.yaml"
+	svc := NewService(cfg)
+
+	// Only the v2 block carries the recipient, so v1 is skipped even with BEACON_BLOCK_API_VERSION=v1.
+	payload, err := svc.executionPayload(64)
+	if err != nil || payload.BlockNumber != 1234 || payload.FeeRecipient != "0x00000000000000000000000000000000000000ab" {
+		t.Fatalf("executionPayload = %+v, %v; want block 1234 and the lower-cased recipient", payload, err)
+	}
+	if got := v1Requests.Load(); got != 0 {
+		t.Fatalf("v1 block requests = %d, want 0", got)
+	}
+	if got := svc.UnsupportedEndpoints(); len(got) != 0 {
+		t.Fatalf("UnsupportedEndpoints() = %v, want none", got)
+	}
+}
+
+func TestReceiptsMethodReusesBlockForFeeRecipient(t *testing.T) {
+	srv := newFakeELServer(t)
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.ExecutionNodeURL = srv.URL
+	cfg.ELRewardMethod = config.ELRewardMethodReceipts
+	cfg.FeeRecipientsFile = "fee-recipients.yaml"
+	svc := NewService(cfg)
+
+	el, recipient, err := svc.executionReward(16)
+	if err != nil || el.Cmp(fakeELExpectedTip) != 0 || recipient != "0x0000000000000000000000000000000000000003" {
+		t.Fatalf("executionReward = %s, %q, %v; want the tip and block 16's miner", el, recipient, err)
+	}
+
+	// A known recipient is attributed without asking the execution node again.
+	srv.Close()
+	data := newEpochRewards()
+	svc.recordFeeRecipient(16, recipient, 5, el, data)
+	svc.cacheMux.Lock()
+	svc.applyEpochLocked(1, data)
+	svc.cacheMux.Unlock()
+	if got := svc.FeeRecipientRewards([]string{recipient}, nil); got[recipient] != utils.Gwei(292000) {
+		t.Fatalf("fee recipient rewards = %v, want 292000 gwei for %s", got, recipient)
+	}
+}
+
+func TestELRewardsByBlock(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.RetainedEpochContributions = 4
+	svc := NewService(cfg)
+
+	data := newEpochRewards()
+	data.elBlocks = []ELBlockReward{
+		newELBlockReward(102, 66, 6, big.NewInt(2_500_000_000)),
+		newELBlockReward(100, 64, 5, fakeELExpectedTip),
+	}
+	svc.cacheMux.Lock()
+	svc.applyEpochLocked(2, data)
+	svc.cacheMux.Unlock()
+
+	got := svc.ELRewardsByBlock(100, 102)
+	if len(got) != 2 || got[0].BlockNumber != 100 || got[1].BlockNumber != 102 {
+		t.Fatalf("blocks = %+v, want 100 and 102 in order", got)
+	}
+	if got[0].TipGwei != 292000 || got[0].TipWei != fakeELExpectedTip.String() || got[0].ProposerIndex != 5 {
+		t.Fatalf("block 100 = %+v, want a 292000 gwei tip from validator 5", got[0])
+	}
+	if got[1].TipGwei != 2 || got[1].TipWei != "2500000000" {
+		t.Fatalf("block 102 = %+v, want 2 gwei (2500000000 wei)", got[1])
+	}
+	if got := svc.ELRewardsByBlock(101, 101); len(got) != 0 {
+		t.Fatalf("blocks in [101, 101] = %+v, want none", got)
+	}
+
+	if err := svc.RemoveEpoch(2); err != nil {
+		t.Fatalf("RemoveEpoch returned error: %v", err)
+	}
+	if got := svc.ELRewardsByBlock(0, 1000); len(got) != 0 {
+		t.Fatalf("blocks after removing the epoch = %+v, want none", got)
+	}
+}
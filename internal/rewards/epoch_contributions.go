@@ -0,0 +1,183 @@
+package rewards
+
+import (
+	"errors"
+	"log/slog"
+	"maps"
+	"math/big"
+	"slices"
+
+	"github.com/gobitfly/eth-rewards/types"
+)
+
+// ErrEpochUnknown is returned when an epoch can be neither re-run nor removed: it did not fail and
+// its contribution is no longer (or was never) retained.
+var ErrEpochUnknown = errors.New("epoch is neither failed nor retained")
+
+// applyEpochLocked adds an epoch's rewards to the cache. When the epoch's previous contribution is
+// retained it is subtracted first, so re-processing an epoch replaces its data instead of adding it
+// twice. Caller must hold cacheMux for writing.
+func (s *Service) applyEpochLocked(epoch uint64, data *epochRewards) {
+	if prev, ok := s.epochContributions[epoch]; ok {
+		s.subtractEpochLocked(prev)
+		slog.Info("Replaced previous contribution of re-processed epoch", "epoch", epoch)
+	}
+
+	for validatorIndex, income := range data.income {
+		s.accumulateRewards(validatorIndex, income)
+	}
+	for validatorIndex, p := range data.syncSlots {
+		s.accumulateSyncSlots(validatorIndex, p)
+	}
+	for validatorIndex, n := range data.proposed {
+		s.blocksProposed[validatorIndex] += n
+	}
+	for _, validatorIndex := range data.attesters {
+		s.idealAttestation[validatorIndex] = s.idealAttestation[validatorIndex].add(data.idealPerIncrement)
+	}
+	s.idealPerIncrementTotal += data.idealPerIncrement.total()
+	for key, wei := range data.feeRecipients {
+		s.recipientEL[key] = addWei(s.recipientEL[key], wei)
+	}
+	for _, b := range data.elBlocks {
+		s.elBlocks[b.BlockNumber] = b
+	}
+	s.recordCompletenessLocked(epoch, data)
+	s.recordActivityLocked(epoch, data)
+
+	if s.config.RetainedEpochContributions <= 0 {
+		return
+	}
+	s.epochContributions[epoch] = data
+	for len(s.epochContributions) > s.config.RetainedEpochContributions {
+		delete(s.epochContributions, slices.Min(slices.Collect(maps.Keys(s.epochContributions))))
+	}
+}
+
+// subtractEpochLocked reverses applyEpochLocked for one epoch, dropping validators left with
+// nothing so they no longer count as active. Caller must hold cacheMux for writing.
+func (s *Service) subtractEpochLocked(data *epochRewards) {
+	for validatorIndex, income := range data.income {
+		existing, ok := s.cache[validatorIndex]
+		if !ok || income == nil {
+			continue
+		}
+		subtractRewards(existing, income)
+		if incomeIsZero(existing) {
+			delete(s.cache, validatorIndex)
+		}
+	}
+	for validatorIndex, p := range data.syncSlots {
+		existing, ok := s.syncSlots[validatorIndex]
+		if !ok {
+			continue
+		}
+		existing.Participated -= min(existing.Participated, p.Participated)
+		existing.Missed -= min(existing.Missed, p.Missed)
+		if existing.Participated == 0 && existing.Missed == 0 {
+			delete(s.syncSlots, validatorIndex)
+		}
+	}
+	for validatorIndex, n := range data.proposed {
+		if s.blocksProposed[validatorIndex] <= n {
+			delete(s.blocksProposed, validatorIndex)
+			continue
+		}
+		s.blocksProposed[validatorIndex] -= n
+	}
+	for _, validatorIndex := range data.attesters {
+		if s.idealAttestation[validatorIndex] = s.idealAttestation[validatorIndex].sub(data.idealPerIncrement); s.idealAttestation[validatorIndex].total() <= 0 {
+			delete(s.idealAttestation, validatorIndex)
+		}
+	}
+	s.idealPerIncrementTotal = max(s.idealPerIncrementTotal-data.idealPerIncrement.total(), 0)
+	for key, wei := range data.feeRecipients {
+		if s.recipientEL[key] = subWei(s.recipientEL[key], wei); s.recipientEL[key] == nil {
+			delete(s.recipientEL, key)
+		}
+	}
+	for _, b := range data.elBlocks {
+		if s.elBlocks[b.BlockNumber].Slot == b.Slot {
+			delete(s.elBlocks, b.BlockNumber)
+		}
+	}
+}
+
+// RemoveEpoch takes an epoch's data out of the current window: a retained contribution is
+// subtracted from every total, and a failed epoch is dropped from the failed set so it is no longer
+// offered for retry.
+func (s *Service) RemoveEpoch(epoch uint64) error {
+	s.cacheMux.Lock()
+	prev, retained := s.epochContributions[epoch]
+	if retained {
+		s.subtractEpochLocked(prev)
+		delete(s.epochContributions, epoch)
+		delete(s.incompleteEpochs, epoch)
+	}
+	s.cacheMux.Unlock()
+
+	s.failedMu.Lock()
+	_, failed := s.failedEpochs[epoch]
+	s.failedMu.Unlock()
+
+	if !retained && !failed {
+		return ErrEpochUnknown
+	}
+	if failed {
+		s.clearFailedEpoch(epoch)
+	}
+	slog.Warn("Removed epoch from the current window", "epoch", epoch, "subtracted", retained)
+	if retained {
+		s.RecomputeNetworkRewards()
+		s.writeSharedState()
+	}
+	return nil
+}
+
+// epochRetained reports whether epoch's contribution is retained and can be replaced.
+func (s *Service) epochRetained(epoch uint64) bool {
+	s.cacheMux.RLock()
+	defer s.cacheMux.RUnlock()
+	_, ok := s.epochContributions[epoch]
+	return ok
+}
+
+func subtractRewards(existing, income *types.ValidatorEpochIncome) {
+	existing.AttestationSourceReward -= min(existing.AttestationSourceReward, income.AttestationSourceReward)
+	existing.AttestationSourcePenalty -= min(existing.AttestationSourcePenalty, income.AttestationSourcePenalty)
+	existing.AttestationTargetReward -= min(existing.AttestationTargetReward, income.AttestationTargetReward)
+	existing.AttestationTargetPenalty -= min(existing.AttestationTargetPenalty, income.AttestationTargetPenalty)
+	existing.AttestationHeadReward -= min(existing.AttestationHeadReward, income.AttestationHeadReward)
+	existing.FinalityDelayPenalty -= min(existing.FinalityDelayPenalty, income.FinalityDelayPenalty)
+	existing.ProposerSlashingInclusionReward -= min(existing.ProposerSlashingInclusionReward, income.ProposerSlashingInclusionReward)
+	existing.ProposerAttestationInclusionReward -= min(existing.ProposerAttestationInclusionReward, income.ProposerAttestationInclusionReward)
+	existing.ProposerSyncInclusionReward -= min(existing.ProposerSyncInclusionReward, income.ProposerSyncInclusionReward)
+	existing.SyncCommitteeReward -= min(existing.SyncCommitteeReward, income.SyncCommitteeReward)
+	existing.SyncCommitteePenalty -= min(existing.SyncCommitteePenalty, income.SyncCommitteePenalty)
+	existing.SlashingReward -= min(existing.SlashingReward, income.SlashingReward)
+	existing.SlashingPenalty -= min(existing.SlashingPenalty, income.SlashingPenalty)
+	existing.ProposalsMissed -= min(existing.ProposalsMissed, income.ProposalsMissed)
+	existing.TxFeeRewardWei = subWei(existing.TxFeeRewardWei, income.TxFeeRewardWei)
+}
+
+// subWei returns a - b, floored at zero.
+func subWei(a, b []byte) []byte {
+	if len(b) == 0 {
+		return a
+	}
+	diff := new(big.Int).Sub(weiBytesToBigInt(a), new(big.Int).SetBytes(b))
+	if diff.Sign() <= 0 {
+		return nil
+	}
+	return diff.Bytes()
+}
+
+func incomeIsZero(i *types.ValidatorEpochIncome) bool {
+	return i.AttestationSourceReward == 0 && i.AttestationSourcePenalty == 0 &&
+		i.AttestationTargetReward == 0 && i.AttestationTargetPenalty == 0 &&
+		i.AttestationHeadReward == 0 && i.FinalityDelayPenalty == 0 &&
+		i.ProposerSlashingInclusionReward == 0 && i.ProposerAttestationInclusionReward == 0 &&
+		i.ProposerSyncInclusionReward == 0 && i.SyncCommitteeReward == 0 && i.SyncCommitteePenalty == 0 &&
+		i.SlashingReward == 0 && i.SlashingPenalty == 0 && i.ProposalsMissed == 0 &&
+		weiBytesToBigInt(i.TxFeeRewardWei).Sign() == 0
+}
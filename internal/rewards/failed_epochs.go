@@ -0,0 +1,175 @@
+package rewards
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"time"
+)
+
+// ErrEpochRetryInProgress is returned by RetryEpoch while another retry of the epoch is running.
+var ErrEpochRetryInProgress = errors.New("epoch retry already in progress")
+
+// FailedEpoch is an epoch whose rewards are missing from the current window because processing
+// gave up after EPOCH_PROCESS_MAX_RETRIES attempts.
+type FailedEpoch struct {
+	Epoch    uint64    `json:"epoch"`
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failed_at"`
+	Failures int       `json:"failures"` // Times the epoch exhausted its retries, including admin re-runs.
+}
+
+// recordFailedEpoch adds epoch to the failed set, or updates it when it failed before.
+func (s *Service) recordFailedEpoch(epoch uint64, err error, now time.Time) {
+	s.failedMu.Lock()
+	defer s.failedMu.Unlock()
+
+	f := s.failedEpochs[epoch]
+	f.Epoch = epoch
+	f.Error = err.Error()
+	f.FailedAt = now.UTC()
+	f.Failures++
+	s.failedEpochs[epoch] = f
+	s.persistFailedEpochsLocked()
+}
+
+// clearFailedEpoch removes epoch from the failed set once it has been processed.
+func (s *Service) clearFailedEpoch(epoch uint64) {
+	s.failedMu.Lock()
+	defer s.failedMu.Unlock()
+
+	if _, ok := s.failedEpochs[epoch]; !ok {
+		return
+	}
+	delete(s.failedEpochs, epoch)
+	s.persistFailedEpochsLocked()
+}
+
+// FailedEpochs lists the epochs whose processing failed in the current window, oldest first.
+func (s *Service) FailedEpochs() []FailedEpoch {
+	s.failedMu.Lock()
+	defer s.failedMu.Unlock()
+	return s.failedEpochListLocked()
+}
+
+func (s *Service) failedEpochListLocked() []FailedEpoch {
+	failed := make([]FailedEpoch, 0, len(s.failedEpochs))
+	for _, epoch := range slices.Sorted(maps.Keys(s.failedEpochs)) {
+		failed = append(failed, s.failedEpochs[epoch])
+	}
+	return failed
+}
+
+// RetryEpoch re-processes a failed epoch, or one whose contribution is retained, and refreshes the
+// network snapshot. A retained contribution is replaced rather than added to. Other epochs return
+// ErrEpochUnknown, since processing an epoch that was already counted would add its rewards twice.
+func (s *Service) RetryEpoch(epoch uint64) error {
+	retained := s.epochRetained(epoch)
+	s.failedMu.Lock()
+	if _, failed := s.failedEpochs[epoch]; !failed && !retained {
+		s.failedMu.Unlock()
+		return ErrEpochUnknown
+	}
+	if _, ok := s.retryingEpochs[epoch]; ok {
+		s.failedMu.Unlock()
+		return ErrEpochRetryInProgress
+	}
+	s.retryingEpochs[epoch] = struct{}{}
+	s.failedMu.Unlock()
+
+	defer func() {
+		s.failedMu.Lock()
+		delete(s.retryingEpochs, epoch)
+		s.failedMu.Unlock()
+	}()
+
+	slog.Info("Re-processing epoch", "epoch", epoch, "replaces_contribution", retained)
+	if err := s.processEpochWithRetry(epoch); err != nil {
+		return fmt.Errorf("epoch %d: %w", epoch, err)
+	}
+	s.RecomputeNetworkRewards()
+	s.writeSharedState()
+	return nil
+}
+
+// resetFailedEpochsLocked forgets every failed epoch when the cache window closes: their rewards
+// belong to the closed window, so re-processing them would credit the new one. It reports whether
+// any were dropped; the caller then rewrites FAILED_EPOCHS_FILE with persistFailedEpochs once it
+// has released cacheMux, which it must hold here.
+func (s *Service) resetFailedEpochsLocked() bool {
+	s.failedMu.Lock()
+	defer s.failedMu.Unlock()
+
+	if len(s.failedEpochs) == 0 {
+		return false
+	}
+	slog.Warn("Dropping failed epochs of the closed cache window; their rewards stay missing from its totals",
+		"epochs", slices.Sorted(maps.Keys(s.failedEpochs)))
+	clear(s.failedEpochs)
+	return true
+}
+
+// loadFailedEpochs restores the failed set from FAILED_EPOCHS_FILE, if configured. Epochs below
+// startEpoch, where the current window's sync begins, belong to a closed window and are dropped.
+func (s *Service) loadFailedEpochs(startEpoch uint64) {
+	if s.failedEpochsPath == "" {
+		return
+	}
+	data, err := os.ReadFile(s.failedEpochsPath)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		slog.Warn("Failed to read failed epochs file", "path", s.failedEpochsPath, "error", err)
+		return
+	}
+	var failed []FailedEpoch
+	if err := json.Unmarshal(data, &failed); err != nil {
+		slog.Warn("Failed to decode failed epochs file", "path", s.failedEpochsPath, "error", err)
+		return
+	}
+
+	s.failedMu.Lock()
+	defer s.failedMu.Unlock()
+	var stale []uint64
+	for _, f := range failed {
+		if f.Epoch < startEpoch {
+			stale = append(stale, f.Epoch)
+			continue
+		}
+		s.failedEpochs[f.Epoch] = f
+	}
+	if len(stale) > 0 {
+		slog.Warn("Dropping failed epochs of a closed cache window", "path", s.failedEpochsPath, "epochs", stale)
+		s.persistFailedEpochsLocked()
+	}
+	if len(s.failedEpochs) > 0 {
+		slog.Info("Loaded failed epochs", "path", s.failedEpochsPath, "count", len(s.failedEpochs))
+	}
+}
+
+// persistFailedEpochs rewrites FAILED_EPOCHS_FILE with the current failed set.
+func (s *Service) persistFailedEpochs() {
+	s.failedMu.Lock()
+	defer s.failedMu.Unlock()
+	s.persistFailedEpochsLocked()
+}
+
+// persistFailedEpochsLocked rewrites FAILED_EPOCHS_FILE atomically; caller must hold failedMu.
+func (s *Service) persistFailedEpochsLocked() {
+	if s.failedEpochsPath == "" {
+		return
+	}
+	data, err := json.Marshal(s.failedEpochListLocked())
+	if err != nil {
+		slog.Error("Failed to encode failed epochs", "error", err)
+		return
+	}
+	if err := writeFileAtomic(s.failedEpochsPath, data); err != nil {
+		slog.Error("Failed to write failed epochs file", "path", s.failedEpochsPath, "error", err)
+	}
+}
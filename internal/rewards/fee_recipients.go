@@ -0,0 +1,72 @@
+package rewards
+
+import (
+	"log/slog"
+	"math/big"
+	"strings"
+
+	"beacon-rewards/internal/utils"
+)
+
+// feeRecipientKey identifies the EL rewards one proposer sent to one fee recipient.
+type feeRecipientKey struct {
+	recipient string // Lower-case 0x address.
+	proposer  uint64
+}
+
+// trackFeeRecipients reports whether EL rewards are also recorded per fee recipient, which is only
+// needed when FEE_RECIPIENTS_FILE maps recipients to owners. The recipient is read from the execution
+// payload of the v2 beacon block, so it costs no execution RPC.
+func (s *Service) trackFeeRecipients() bool {
+	return s.config.FeeRecipientsFile != ""
+}
+
+// recordFeeRecipient attributes a block's EL reward to its fee recipient. Without a recipient the
+// reward is only left unattributed; the proposer is still credited.
+func (s *Service) recordFeeRecipient(blockNumber uint64, recipient string, proposer uint64, el *big.Int, rewards *epochRewards) {
+	if el.Sign() <= 0 {
+		return
+	}
+	if recipient == "" {
+		slog.Debug("Block has no fee recipient", "block", blockNumber)
+		return
+	}
+
+	key := feeRecipientKey{recipient: recipient, proposer: proposer}
+	rewards.mu.Lock()
+	rewards.feeRecipients[key] = addWei(rewards.feeRecipients[key], el.Bytes())
+	rewards.mu.Unlock()
+}
+
+// FeeRecipientRewards returns the EL rewards each of the given fee recipients received in the
+// current window, skipping blocks proposed by validators in exclude (typically those already
+// counted for the address). Recipients are matched case-insensitively; ones without rewards are omitted.
+func (s *Service) FeeRecipientRewards(recipients []string, exclude map[uint64]struct{}) map[string]utils.Gwei {
+	wanted := make(map[string]string, len(recipients))
+	for _, r := range recipients {
+		wanted[strings.ToLower(r)] = r
+	}
+
+	totals := make(map[string]*big.Int)
+	s.cacheMux.RLock()
+	for key, wei := range s.recipientEL {
+		name, ok := wanted[key.recipient]
+		if !ok {
+			continue
+		}
+		if _, skip := exclude[key.proposer]; skip {
+			continue
+		}
+		if totals[name] == nil {
+			totals[name] = new(big.Int)
+		}
+		totals[name].Add(totals[name], weiBytesToBigInt(wei))
+	}
+	s.cacheMux.RUnlock()
+
+	result := make(map[string]utils.Gwei, len(totals))
+	for name, wei := range totals {
+		result[name] = utils.Gwei(wei.Div(wei, gweiScalar).Int64())
+	}
+	return result
+}
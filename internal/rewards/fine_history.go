@@ -0,0 +1,153 @@
+package rewards
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// The network history file only gains an entry when the cache resets, so it cannot show how the
+// window's totals and APR move during the day. With FINE_HISTORY_INTERVAL set, processEpoch also
+// appends the running network snapshot every that many epochs to a separate file, trimmed to
+// FINE_HISTORY_RETENTION so it stays small.
+
+// FineNetworkSnapshot is the running network snapshot after an epoch was merged.
+type FineNetworkSnapshot struct {
+	Epoch uint64 `json:"epoch"`
+	NetworkRewardSnapshot
+}
+
+// FineHistoryEnabled reports whether intra-day network totals are being recorded.
+func (s *Service) FineHistoryEnabled() bool {
+	return s.fineHistoryPath != "" && s.config.FineHistoryInterval > 0
+}
+
+func (s *Service) fineHistoryDue(epoch uint64) bool {
+	return s.FineHistoryEnabled() && epoch%uint64(s.config.FineHistoryInterval) == 0
+}
+
+// appendFineHistory records the current network snapshot for epoch. The file is only read back when
+// it is compacted: once its oldest entry is a quarter of FINE_HISTORY_RETENTION past the retention
+// cutoff, entries for epochs that ended more than FINE_HISTORY_RETENTION before now are dropped. That
+// rewrites the file about four times per retention period instead of on every append; readers hide
+// the expired entries it holds in between.
+func (s *Service) appendFineHistory(epoch uint64, now time.Time) {
+	// Only the cache totals are read under cacheMux; the Dora lookups run after it is released so
+	// they do not stall the next merge or reads.
+	s.cacheMux.RLock()
+	totals := s.networkSnapshotTotalsLocked()
+	s.cacheMux.RUnlock()
+	entry := FineNetworkSnapshot{Epoch: epoch, NetworkRewardSnapshot: *s.completeNetworkSnapshot(totals, now)}
+
+	s.fineHistoryMu.Lock()
+	defer s.fineHistoryMu.Unlock()
+
+	retention := s.config.FineHistoryRetention
+	if !s.fineHistoryScanned || !s.chain.EpochToTime(s.fineHistoryOldest).After(now.Add(-retention-retention/4)) {
+		entries, err := s.readFineHistoryLocked()
+		if err != nil {
+			slog.Warn("Failed to read fine history; appending snapshot", "path", s.fineHistoryPath, "error", err)
+		} else {
+			cutoff := now.Add(-retention)
+			kept := entries[:0]
+			for _, e := range entries {
+				if s.chain.EpochToTime(e.Epoch).After(cutoff) {
+					kept = append(kept, e)
+				}
+			}
+			if len(kept) < len(entries) {
+				kept = append(kept, entry)
+				if err := s.writeFineHistoryLocked(kept); err != nil {
+					slog.Error("Failed to rewrite fine history file", "path", s.fineHistoryPath, "error", err)
+					return
+				}
+				s.fineHistoryOldest, s.fineHistoryScanned = kept[0].Epoch, true
+				return
+			}
+			s.fineHistoryOldest, s.fineHistoryScanned = epoch, true
+			if len(entries) > 0 {
+				s.fineHistoryOldest = entries[0].Epoch
+			}
+		}
+	}
+
+	_ = os.MkdirAll(filepath.Dir(s.fineHistoryPath), 0o755)
+	f, err := os.OpenFile(s.fineHistoryPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		slog.Error("Failed to open fine history file", "path", s.fineHistoryPath, "error", err)
+		return
+	}
+	_ = json.NewEncoder(f).Encode(entry)
+	_ = f.Close()
+}
+
+// FineNetworkHistory returns the recorded snapshots for epochs in [fromEpoch, toEpoch], oldest first.
+func (s *Service) FineNetworkHistory(fromEpoch, toEpoch uint64) ([]FineNetworkSnapshot, error) {
+	result := []FineNetworkSnapshot{}
+	if s.fineHistoryPath == "" {
+		return result, nil
+	}
+	s.fineHistoryMu.Lock()
+	defer s.fineHistoryMu.Unlock()
+
+	entries, err := s.readFineHistoryLocked()
+	if err != nil {
+		return nil, err
+	}
+	// The file is compacted lazily (see appendFineHistory), so measure retention from the newest entry.
+	var cutoff time.Time
+	if n := len(entries); n > 0 {
+		cutoff = s.chain.EpochToTime(entries[n-1].Epoch).Add(-s.config.FineHistoryRetention)
+	}
+	for _, e := range entries {
+		if e.Epoch >= fromEpoch && e.Epoch <= toEpoch && s.chain.EpochToTime(e.Epoch).After(cutoff) {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+// readFineHistoryLocked reads every entry of the fine history file; caller must hold fineHistoryMu.
+func (s *Service) readFineHistoryLocked() ([]FineNetworkSnapshot, error) {
+	f, err := os.Open(s.fineHistoryPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []FineNetworkSnapshot
+	skipped, err := readJSONLines(f, func(line []byte) error {
+		var e FineNetworkSnapshot
+		if err := json.Unmarshal(line, &e); err != nil {
+			return err
+		}
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read fine history: %w", err)
+	}
+	if skipped > 0 {
+		slog.Warn("Skipped malformed fine history lines", "path", s.fineHistoryPath, "skipped", skipped)
+	}
+	return entries, nil
+}
+
+// writeFineHistoryLocked replaces the fine history file atomically; caller must hold fineHistoryMu.
+func (s *Service) writeFineHistoryLocked(entries []FineNetworkSnapshot) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for i := range entries {
+		if err := enc.Encode(&entries[i]); err != nil {
+			return err
+		}
+	}
+	return writeFileAtomic(s.fineHistoryPath, buf.Bytes())
+}
@@ -0,0 +1,90 @@
+package rewards
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	internalbeacon "beacon-rewards/internal/beacon"
+)
+
+// nodeProbeTimeout bounds the connectivity check of each node passed to SetNodes.
+const nodeProbeTimeout = 5 * time.Second
+
+// ErrNodeUnreachable is returned by SetNodes when a proposed node fails its connectivity probe.
+var ErrNodeUnreachable = errors.New("node unreachable")
+
+// NodeSet lists the beacon and execution nodes the service syncs from.
+type NodeSet struct {
+	BeaconNodeURLs   []string `json:"beacon_node_urls"`
+	ExecutionNodeURL string   `json:"execution_node_url"`
+}
+
+// Nodes returns the nodes currently in use.
+func (s *Service) Nodes() NodeSet {
+	set := NodeSet{BeaconNodeURLs: []string{}, ExecutionNodeURL: s.executionNodeURL()}
+	for _, ep := range internalbeacon.ParseEndpoints(s.BeaconNodeURL()) {
+		set.BeaconNodeURLs = append(set.BeaconNodeURLs, ep.URL)
+	}
+	return set
+}
+
+// BeaconNodeURL returns the comma-separated beacon node list in use, in BEACON_NODE_URL format.
+func (s *Service) BeaconNodeURL() string {
+	return s.beaconCL.URLs()
+}
+
+func (s *Service) executionNodeURL() string {
+	s.nodesMu.RLock()
+	defer s.nodesMu.RUnlock()
+	return s.elClient
+}
+
+// SetNodes replaces the beacon node list (BEACON_NODE_URL format, weights allowed) and/or the
+// execution node; an empty argument keeps the current setting. Every new node is probed first and
+// nothing changes unless all of them answer. Requests already in flight finish on the old nodes.
+func (s *Service) SetNodes(ctx context.Context, beaconURLs, executionURL string) (NodeSet, error) {
+	beaconURLs = strings.TrimSpace(beaconURLs)
+	executionURL = strings.TrimSpace(executionURL)
+	if beaconURLs == "" && executionURL == "" {
+		return NodeSet{}, errors.New("no nodes given")
+	}
+
+	var errs []error
+	endpoints := internalbeacon.ParseEndpoints(beaconURLs)
+	if beaconURLs != "" && len(endpoints) == 0 {
+		errs = append(errs, errors.New("beacon node list is empty"))
+	}
+	for _, ep := range endpoints {
+		if err := internalbeacon.CheckNode(ctx, ep.URL, nodeProbeTimeout); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if executionURL != "" {
+		probeCtx, cancel := context.WithTimeout(ctx, nodeProbeTimeout)
+		var chainID string
+		err := callELRPC(probeCtx, executionURL, "eth_chainId", []any{}, &chainID)
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", executionURL, err))
+		}
+	}
+	if len(errs) > 0 {
+		return NodeSet{}, fmt.Errorf("%w: %w", ErrNodeUnreachable, errors.Join(errs...))
+	}
+
+	if beaconURLs != "" {
+		s.beaconCL.Replace(beaconURLs)
+	}
+	if executionURL != "" {
+		s.nodesMu.Lock()
+		s.elClient = executionURL
+		s.nodesMu.Unlock()
+	}
+	nodes := s.Nodes()
+	slog.Info("Replaced sync nodes", "beacon_nodes", nodes.BeaconNodeURLs, "execution_node", nodes.ExecutionNodeURL)
+	return nodes, nil
+}
@@ -1,48 +1,124 @@
 package rewards
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
+	internalbeacon "beacon-rewards/internal/beacon"
+	"beacon-rewards/internal/utils"
+
 	"github.com/gobitfly/eth-rewards/beacon"
 	"github.com/gobitfly/eth-rewards/types"
 )
 
 // NodePool manages multiple beacon clients for load balancing
 type NodePool struct {
-	clients []*beacon.Client
-	counter uint64
+	timeout    time.Duration
+	httpClient *http.Client // Used for requests the eth-rewards client does not offer.
+	mu         sync.RWMutex // Guards urls, endpoints, clients and schedule, which Replace swaps together.
+	urls       string
+	clients    []*beacon.Client
+	endpoints  []string // Base URL of each client.
+	// schedule is one cycle of smooth weighted round robin: each client index appears as often as
+	// its weight, spread out so a heavy node never takes a long run of consecutive requests.
+	schedule []int
+	counter  uint64
 }
 
-// NewNodePool creates a new NodePool from a comma-separated list of URLs
+// NewNodePool creates a new NodePool from a comma-separated list of URLs.
+// URLs may carry a `weight` query parameter (e.g. http://local?weight=9,http://backup?weight=1);
+// without weights every node receives an even share of requests.
 func NewNodePool(urls string, timeout time.Duration) *NodePool {
-	urlList := strings.Split(urls, ",")
-	clients := make([]*beacon.Client, 0, len(urlList))
-	for _, u := range urlList {
-		u = strings.TrimSpace(u)
-		if u != "" {
-			clients = append(clients, beacon.NewClient(u, timeout))
-		}
+	p := &NodePool{timeout: timeout, httpClient: &http.Client{Timeout: timeout}, urls: urls}
+	p.clients, p.endpoints, p.schedule = buildClients(urls, timeout)
+	return p
+}
+
+// Replace swaps the pool's clients for the given URL list. Calls already holding an old client
+// finish against it; only later calls are routed to the new nodes.
+func (p *NodePool) Replace(urls string) {
+	clients, endpoints, schedule := buildClients(urls, p.timeout)
+	p.mu.Lock()
+	p.urls, p.clients, p.endpoints, p.schedule = urls, clients, endpoints, schedule
+	p.mu.Unlock()
+}
+
+// URLs returns the comma-separated URL list the pool was built from.
+func (p *NodePool) URLs() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.urls
+}
+
+func buildClients(urls string, timeout time.Duration) ([]*beacon.Client, []string, []int) {
+	parsed := internalbeacon.ParseEndpoints(urls)
+	clients := make([]*beacon.Client, 0, len(parsed))
+	endpoints := make([]string, 0, len(parsed))
+	weights := make([]int, 0, len(parsed))
+	for _, ep := range parsed {
+		clients = append(clients, beacon.NewClient(ep.URL, timeout))
+		endpoints = append(endpoints, strings.TrimSuffix(ep.URL, "/"))
+		weights = append(weights, ep.Weight)
 	}
+	schedule := smoothSchedule(weights)
 
 	// Ensure at least one client (even if invalid URL, to avoid nil panics on empty config)
 	if len(clients) == 0 {
 		clients = append(clients, beacon.NewClient("", timeout))
+		endpoints = append(endpoints, "")
+		schedule = append(schedule, 0)
 	}
 
-	return &NodePool{
-		clients: clients,
+	return clients, endpoints, schedule
+}
+
+// smoothSchedule returns one cycle of smooth weighted round robin (as in nginx) over weights: every
+// pick adds each weight to its node's current value, takes the highest and subtracts the total from
+// it. Weights 3 and 1 give 0,0,1,0 rather than 0,0,0,1.
+func smoothSchedule(weights []int) []int {
+	total := 0
+	for _, w := range weights {
+		total += w
 	}
+	current := make([]int, len(weights))
+	schedule := make([]int, 0, total)
+	for range total {
+		best := 0
+		for i, w := range weights {
+			current[i] += w
+			if current[i] > current[best] {
+				best = i
+			}
+		}
+		current[best] -= total
+		schedule = append(schedule, best)
+	}
+	return schedule
 }
 
 func (p *NodePool) getClient() *beacon.Client {
+	_, client := p.next()
+	return client
+}
+
+// next picks the next node by weighted round robin and returns its base URL and client.
+func (p *NodePool) next() (string, *beacon.Client) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
 	if len(p.clients) == 0 {
-		return nil
+		return "", nil
 	}
-	// Round robin
-	idx := atomic.AddUint64(&p.counter, 1) % uint64(len(p.clients))
-	return p.clients[idx]
+	idx := atomic.AddUint64(&p.counter, 1) % uint64(len(p.schedule))
+	i := p.schedule[idx]
+	return p.endpoints[i], p.clients[i]
 }
 
 // ProposerAssignments delegates to a client in the pool
@@ -50,16 +126,153 @@ func (p *NodePool) ProposerAssignments(epoch uint64) (*types.EpochProposerAssign
 	return p.getClient().ProposerAssignments(epoch)
 }
 
+// ProposerDuties fetches the epoch's proposer duties bounded by ctx, unlike the eth-rewards client
+// behind ProposerAssignments, so API handlers can fail fast when a node hangs.
+func (p *NodePool) ProposerDuties(ctx context.Context, epoch uint64) (*types.EpochProposerAssignmentsApiResponse, error) {
+	endpoint, _ := p.next()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/eth/v1/validator/duties/proposer/%d", endpoint, epoch), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("http request error: %s", resp.Status)
+	}
+	duties := &types.EpochProposerAssignmentsApiResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(duties); err != nil {
+		return nil, err
+	}
+	return duties, nil
+}
+
+// ProposerAssignmentsForEpoch fetches and validates the epoch's proposer duties and returns the
+// proposer index by slot.
+func (p *NodePool) ProposerAssignmentsForEpoch(chain utils.ChainConfig, epoch uint64) (map[uint64]uint64, error) {
+	assigns, err := p.ProposerAssignments(epoch)
+	if err != nil {
+		return nil, err
+	}
+	return proposersBySlot(chain, epoch, assigns)
+}
+
+// proposersBySlot validates the epoch's proposer duties and maps each slot to its proposer index.
+func proposersBySlot(chain utils.ChainConfig, epoch uint64, assigns *types.EpochProposerAssignmentsApiResponse) (map[uint64]uint64, error) {
+	if err := validateProposerAssignments(chain, epoch, assigns); err != nil {
+		return nil, err
+	}
+	proposers := make(map[uint64]uint64, len(assigns.Data))
+	for _, pa := range assigns.Data {
+		proposers[uint64(pa.Slot)] = uint64(pa.ValidatorIndex)
+	}
+	return proposers, nil
+}
+
 // AttestationRewards delegates to a client in the pool
 func (p *NodePool) AttestationRewards(epoch uint64) (*types.AttestationRewardsApiResponse, error) {
 	return p.getClient().AttestationRewards(epoch)
 }
 
+// AttestationRewardsFor fetches the epoch's attestation rewards for the given validators only, so a
+// node serving a TRACKED_VALIDATORS deployment does not compute them for the whole network. Without
+// indices it asks for every validator like AttestationRewards.
+func (p *NodePool) AttestationRewardsFor(epoch uint64, indices []uint64) (*types.AttestationRewardsApiResponse, error) {
+	if len(indices) == 0 {
+		return p.AttestationRewards(epoch)
+	}
+	ids := make([]string, len(indices))
+	for i, idx := range indices {
+		ids[i] = strconv.FormatUint(idx, 10)
+	}
+	body, err := json.Marshal(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint, _ := p.next()
+	resp, err := p.httpClient.Post(fmt.Sprintf("%s/eth/v1/beacon/rewards/attestations/%d", endpoint, epoch), "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("http request error: %s", resp.Status)
+	}
+	r := &types.AttestationRewardsApiResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
 // ExecutionBlockNumber delegates to a client in the pool
 func (p *NodePool) ExecutionBlockNumber(slot uint64) (uint64, error) {
 	return p.getClient().ExecutionBlockNumber(slot)
 }
 
+// ExecutionBlockNumberV2 reads the slot's execution block number from /eth/v2/beacon/blocks, which
+// nodes keep serving after dropping the deprecated v1 endpoint ExecutionBlockNumber uses. Like the
+// eth-rewards client it returns types.ErrBlockNotFound on a 404 and types.ErrSlotPreMerge for a block
+// without an execution payload.
+func (p *NodePool) ExecutionBlockNumberV2(slot uint64) (uint64, error) {
+	payload, err := p.ExecutionPayloadV2(slot)
+	return payload.BlockNumber, err
+}
+
+// executionPayload is the part of a beacon block's execution payload the sync reads.
+type executionPayload struct {
+	BlockNumber  uint64
+	FeeRecipient string // Lower-case 0x address; empty when the block was read from the v1 endpoint.
+}
+
+// ExecutionPayloadV2 reads the slot's execution block number and fee recipient from
+// /eth/v2/beacon/blocks, returning errors like ExecutionBlockNumberV2.
+func (p *NodePool) ExecutionPayloadV2(slot uint64) (executionPayload, error) {
+	endpoint, _ := p.next()
+	resp, err := p.httpClient.Get(fmt.Sprintf("%s/eth/v2/beacon/blocks/%d", endpoint, slot))
+	if err != nil {
+		return executionPayload{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		if resp.StatusCode == http.StatusNotFound {
+			return executionPayload{}, types.ErrBlockNotFound
+		}
+		return executionPayload{}, fmt.Errorf("http request error: %s", resp.Status)
+	}
+
+	var block struct {
+		Data struct {
+			Message struct {
+				Body struct {
+					ExecutionPayload struct {
+						BlockNumber  string `json:"block_number"`
+						FeeRecipient string `json:"fee_recipient"`
+					} `json:"execution_payload"`
+				} `json:"body"`
+			} `json:"message"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&block); err != nil {
+		return executionPayload{}, err
+	}
+	payload := block.Data.Message.Body.ExecutionPayload
+	if payload.BlockNumber == "" {
+		return executionPayload{}, types.ErrSlotPreMerge
+	}
+	blockNumber, err := strconv.ParseUint(payload.BlockNumber, 10, 64)
+	if err != nil {
+		return executionPayload{}, err
+	}
+	return executionPayload{BlockNumber: blockNumber, FeeRecipient: strings.ToLower(payload.FeeRecipient)}, nil
+}
+
 // SyncCommitteeRewards delegates to a client in the pool
 func (p *NodePool) SyncCommitteeRewards(slot uint64) (*types.SyncCommitteeRewardsApiResponse, error) {
 	return p.getClient().SyncCommitteeRewards(slot)
@@ -69,3 +282,8 @@ func (p *NodePool) SyncCommitteeRewards(slot uint64) (*types.SyncCommitteeReward
 func (p *NodePool) BlockRewards(slot uint64) (*types.BlockRewardsApiResponse, error) {
 	return p.getClient().BlockRewards(slot)
 }
+
+// Balance delegates to a client in the pool
+func (p *NodePool) Balance(slot uint64, validator uint64) (uint64, error) {
+	return p.getClient().Balance(slot, validator)
+}
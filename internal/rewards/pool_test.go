@@ -0,0 +1,156 @@
+package rewards
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"beacon-rewards/internal/config"
+)
+
+func TestNodePoolWeightedRoundRobin(t *testing.T) {
+	pool := NewNodePool("http://local?weight=3,http://backup?weight=1", time.Second)
+	if len(pool.clients) != 2 {
+		t.Fatalf("expected 2 clients, got %d", len(pool.clients))
+	}
+
+	counts := make(map[int]int)
+	for i := 0; i < 400; i++ {
+		c := pool.getClient()
+		for idx, client := range pool.clients {
+			if client == c {
+				counts[idx]++
+			}
+		}
+	}
+	if counts[0] != 300 || counts[1] != 100 {
+		t.Fatalf("unexpected distribution: %v", counts)
+	}
+}
+
+func TestSmoothScheduleInterleaves(t *testing.T) {
+	if got := smoothSchedule([]int{3, 1}); !slices.Equal(got, []int{0, 0, 1, 0}) {
+		t.Fatalf("schedule for weights 3,1 = %v, want [0 0 1 0]", got)
+	}
+	// nginx's reference sequence for weights 5,1,1 is a,a,b,a,c,a,a.
+	if got := smoothSchedule([]int{5, 1, 1}); !slices.Equal(got, []int{0, 0, 1, 0, 2, 0, 0}) {
+		t.Fatalf("schedule for weights 5,1,1 = %v, want [0 0 1 0 2 0 0]", got)
+	}
+}
+
+func TestNodePoolUnweightedIsEven(t *testing.T) {
+	pool := NewNodePool("http://a, http://b", time.Second)
+
+	counts := make(map[int]int)
+	for i := 0; i < 10; i++ {
+		c := pool.getClient()
+		for idx, client := range pool.clients {
+			if client == c {
+				counts[idx]++
+			}
+		}
+	}
+	if counts[0] != 5 || counts[1] != 5 {
+		t.Fatalf("unexpected distribution: %v", counts)
+	}
+}
+
+func TestSetNodesProbesBeforeSwapping(t *testing.T) {
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/eth/v1/node/version":
+			_, _ = w.Write([]byte(`{"data":{"version":"test"}}`))
+		case "/": // JSON-RPC
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x1"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(healthy.Close)
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(down.Close)
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.BeaconNodeURL = "http://old-beacon"
+	cfg.ExecutionNodeURL = "http://old-el"
+	svc := NewService(cfg)
+	inFlight := svc.beaconCL.getClient()
+
+	_, err := svc.SetNodes(context.Background(), healthy.URL+","+down.URL, healthy.URL)
+	if !errors.Is(err, ErrNodeUnreachable) {
+		t.Fatalf("SetNodes with a down node: error = %v, want ErrNodeUnreachable", err)
+	}
+	if nodes := svc.Nodes(); !slices.Equal(nodes.BeaconNodeURLs, []string{"http://old-beacon"}) || nodes.ExecutionNodeURL != "http://old-el" {
+		t.Fatalf("nodes after a failed probe = %+v, want the old ones", nodes)
+	}
+
+	nodes, err := svc.SetNodes(context.Background(), healthy.URL+"?weight=2", healthy.URL)
+	if err != nil {
+		t.Fatalf("SetNodes: %v", err)
+	}
+	if !slices.Equal(nodes.BeaconNodeURLs, []string{healthy.URL}) || nodes.ExecutionNodeURL != healthy.URL {
+		t.Fatalf("active nodes = %+v, want %s for both", nodes, healthy.URL)
+	}
+	if got := svc.beaconCL.getClient(); got == inFlight || len(svc.beaconCL.schedule) != 2 {
+		t.Fatalf("pool not swapped: %d schedule slots", len(svc.beaconCL.schedule))
+	}
+
+	// An empty field keeps that node.
+	if nodes, err := svc.SetNodes(context.Background(), "", healthy.URL); err != nil || len(nodes.BeaconNodeURLs) != 1 {
+		t.Fatalf("SetNodes(execution only) = %+v, %v; want the beacon list kept", nodes, err)
+	}
+}
+
+func TestAttestationRewardsForSendsIndices(t *testing.T) {
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		_, _ = w.Write([]byte(`{"data":{"ideal_rewards":[],"total_rewards":[{"validator_index":"9","head":"1","source":"2","target":"3"}]}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	pool := NewNodePool(srv.URL, time.Second)
+	ar, err := pool.AttestationRewardsFor(2, []uint64{9, 12})
+	if err != nil {
+		t.Fatalf("AttestationRewardsFor: %v", err)
+	}
+	if len(ar.Data.TotalRewards) != 1 || ar.Data.TotalRewards[0].ValidatorIndex != 9 {
+		t.Fatalf("total rewards = %+v, want validator 9", ar.Data.TotalRewards)
+	}
+	if _, err := pool.AttestationRewardsFor(2, nil); err != nil {
+		t.Fatalf("AttestationRewardsFor(nil): %v", err)
+	}
+	if !slices.Equal(bodies, []string{`["9","12"]`, `[]`}) {
+		t.Fatalf("request bodies = %q, want the tracked indices, then every validator", bodies)
+	}
+}
+
+func TestProposerDutiesHonoursContext(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+
+	pool := NewNodePool(srv.URL, time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := pool.ProposerDuties(ctx, 2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ProposerDuties error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("ProposerDuties took %s despite the 50ms deadline", elapsed)
+	}
+}
@@ -0,0 +1,88 @@
+package rewards
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// UpcomingProposal is a block proposal assigned to a validator in a slot that has not started yet.
+type UpcomingProposal struct {
+	Slot  uint64    `json:"slot"`
+	Epoch uint64    `json:"epoch"`
+	Time  time.Time `json:"time"` // When the slot starts.
+}
+
+// UpcomingProposals returns the validator's proposals in the rest of the current epoch and in the
+// next one, in slot order. Beacon nodes only compute duties one epoch ahead and some refuse the next
+// epoch until its dependent root is known; nextEpochKnown is false when those duties were not
+// available, and only the current epoch was checked. An error means the current epoch's duties
+// could not be loaded. Lookups are bounded by ctx and REQUEST_TIMEOUT, and duties are cached per
+// epoch, so only the first query of an epoch reaches the beacon node.
+func (s *Service) UpcomingProposals(ctx context.Context, index uint64, now time.Time) (proposals []UpcomingProposal, nextEpochKnown bool, err error) {
+	current := s.chain.TimeToEpoch(now)
+	proposals = []UpcomingProposal{}
+	for epoch := current; epoch <= current+1; epoch++ {
+		proposers, err := s.proposersForEpoch(ctx, epoch, current)
+		if err != nil {
+			if epoch == current {
+				return nil, false, err
+			}
+			slog.Debug("Next-epoch proposer duties not available", "epoch", epoch, "error", err)
+			return proposals, false, nil
+		}
+		for slot, proposer := range proposers {
+			if proposer != index {
+				continue
+			}
+			start := s.chain.EpochStartTime(epoch).Add(time.Duration((slot-epoch*s.chain.SlotsPerEpoch)*s.chain.SecondsPerSlot) * time.Second)
+			if start.Before(now) {
+				continue
+			}
+			proposals = append(proposals, UpcomingProposal{Slot: slot, Epoch: epoch, Time: start})
+		}
+	}
+	slices.SortFunc(proposals, func(a, b UpcomingProposal) int { return cmp.Compare(a.Slot, b.Slot) })
+	return proposals, true, nil
+}
+
+// proposersForEpoch returns the epoch's proposer index by slot, from the cache when an earlier query
+// loaded it. Entries for epochs before current are dropped on each call. Concurrent misses may fetch the same
+// epoch twice; both get the same duties.
+func (s *Service) proposersForEpoch(ctx context.Context, epoch, current uint64) (map[uint64]uint64, error) {
+	s.proposerDutiesMu.Lock()
+	for e := range s.proposerDuties {
+		if e < current {
+			delete(s.proposerDuties, e)
+		}
+	}
+	proposers, ok := s.proposerDuties[epoch]
+	s.proposerDutiesMu.Unlock()
+	if ok {
+		return proposers, nil
+	}
+
+	if s.config.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.config.RequestTimeout)
+		defer cancel()
+	}
+	assigns, err := s.beaconCL.ProposerDuties(ctx, epoch)
+	if err != nil {
+		return nil, err
+	}
+	proposers, err = proposersBySlot(s.chain, epoch, assigns)
+	if err != nil {
+		return nil, err
+	}
+
+	s.proposerDutiesMu.Lock()
+	defer s.proposerDutiesMu.Unlock()
+	if s.proposerDuties == nil {
+		s.proposerDuties = make(map[uint64]map[uint64]uint64, 2)
+	}
+	s.proposerDuties[epoch] = proposers
+	return proposers, nil
+}
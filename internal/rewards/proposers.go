@@ -0,0 +1,69 @@
+package rewards
+
+import (
+	"cmp"
+	"math/big"
+	"slices"
+
+	"beacon-rewards/internal/utils"
+)
+
+// ProposerReward summarises the rewards a validator earned from the blocks it proposed in the window.
+type ProposerReward struct {
+	ValidatorIndex uint64 `json:"validator_index"`
+	BlocksProposed uint64 `json:"blocks_proposed"`
+	// Attestation, sync aggregate and slashing inclusion rewards from the proposed blocks.
+	ClProposerRewardsGwei utils.Gwei `json:"cl_proposer_rewards_gwei"`
+	// Transaction fee rewards from the proposed blocks.
+	ElRewardsGwei            utils.Gwei `json:"el_rewards_gwei"`
+	TotalProposerRewardsGwei utils.Gwei `json:"total_proposer_rewards_gwei"`
+}
+
+// TopProposers ranks validators with at least one proposed block in the current window by their
+// total proposer rewards, highest first, and returns at most limit of them.
+func (s *Service) TopProposers(limit int) []ProposerReward {
+	s.cacheMux.RLock()
+	defer s.cacheMux.RUnlock()
+
+	result := make([]ProposerReward, 0, len(s.blocksProposed))
+	for idx, blocks := range s.blocksProposed {
+		income := s.cache[idx]
+		if income == nil {
+			continue
+		}
+		cl := int64(income.ProposerAttestationInclusionReward + income.ProposerSyncInclusionReward + income.ProposerSlashingInclusionReward)
+		el := new(big.Int).Div(weiBytesToBigInt(income.TxFeeRewardWei), gweiScalar).Int64()
+		result = append(result, ProposerReward{
+			ValidatorIndex:           idx,
+			BlocksProposed:           blocks,
+			ClProposerRewardsGwei:    utils.Gwei(cl),
+			ElRewardsGwei:            utils.Gwei(el),
+			TotalProposerRewardsGwei: utils.Gwei(cl + el),
+		})
+	}
+
+	slices.SortFunc(result, func(a, b ProposerReward) int {
+		if c := cmp.Compare(b.TotalProposerRewardsGwei, a.TotalProposerRewardsGwei); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ValidatorIndex, b.ValidatorIndex)
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// ProposalCounts returns the blocks proposed and the proposals missed across the current window.
+func (s *Service) ProposalCounts() (proposed, missed uint64) {
+	s.cacheMux.RLock()
+	defer s.cacheMux.RUnlock()
+
+	for _, n := range s.blocksProposed {
+		proposed += n
+	}
+	for _, income := range s.cache {
+		missed += income.ProposalsMissed
+	}
+	return proposed, missed
+}
@@ -0,0 +1,134 @@
+package rewards
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gobitfly/eth-rewards/types"
+)
+
+// ReconcileResult summarises the most recent cache reconciliation run.
+type ReconcileResult struct {
+	CheckedAt   time.Time `json:"checked_at"`
+	Epoch       uint64    `json:"epoch"`
+	Sampled     int       `json:"sampled"`
+	Mismatched  int       `json:"mismatched"`
+	MaxDiffGwei int64     `json:"max_diff_gwei"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// reconcileSample records how much the cached CL rewards of a few validators moved when an epoch
+// was merged, so the delta can later be checked against a fresh beacon query for that epoch.
+type reconcileSample struct {
+	epoch  uint64
+	deltas map[uint64]int64
+}
+
+// cachedClLocked returns the cached CL totals for up to sampleSize validators present in rewards.
+// Caller must hold cacheMux.
+func (s *Service) cachedClLocked(rewards map[uint64]*types.ValidatorEpochIncome, sampleSize int) map[uint64]int64 {
+	before := make(map[uint64]int64, sampleSize)
+	for idx := range rewards {
+		if len(before) >= sampleSize {
+			break
+		}
+		if existing, ok := s.cache[idx]; ok {
+			before[idx] = existing.TotalClRewards()
+		} else {
+			before[idx] = 0
+		}
+	}
+	return before
+}
+
+// recordReconcileSampleLocked stores the per-validator cache delta for epoch. before must come
+// from cachedClLocked taken prior to merging. Caller must hold cacheMux.
+func (s *Service) recordReconcileSampleLocked(epoch uint64, before map[uint64]int64) {
+	sample := &reconcileSample{epoch: epoch, deltas: make(map[uint64]int64, len(before))}
+	for idx, prev := range before {
+		var now int64
+		if income, ok := s.cache[idx]; ok {
+			now = income.TotalClRewards()
+		}
+		sample.deltas[idx] = now - prev
+	}
+
+	s.reconcileMu.Lock()
+	s.reconcileSample = sample
+	s.reconcileMu.Unlock()
+}
+
+// LastReconcile returns the most recent reconciliation result, or nil if none has run.
+func (s *Service) LastReconcile() *ReconcileResult {
+	s.reconcileMu.Lock()
+	defer s.reconcileMu.Unlock()
+	if s.lastReconcile == nil {
+		return nil
+	}
+	result := *s.lastReconcile
+	return &result
+}
+
+func (s *Service) reconcileRoutine() {
+	ticker := time.NewTicker(s.config.ReconcileInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.reconcileOnce()
+		}
+	}
+}
+
+// reconcileOnce re-fetches the last sampled epoch and compares it with the recorded cache delta.
+// Only one epoch is re-queried per run, which keeps the extra node load negligible.
+func (s *Service) reconcileOnce() {
+	s.reconcileMu.Lock()
+	sample := s.reconcileSample
+	s.reconcileMu.Unlock()
+
+	result := ReconcileResult{CheckedAt: time.Now().UTC()}
+	if sample == nil {
+		result.Error = "no epoch sampled yet"
+	} else if fresh, err := s.getRewardsForEpoch(sample.epoch); err != nil {
+		result.Epoch = sample.epoch
+		result.Error = err.Error()
+		slog.Warn("Reconciliation fetch failed", "epoch", sample.epoch, "error", err)
+	} else {
+		result = compareReconcileSample(sample, fresh.income, s.config.ReconcileThresholdGwei)
+		slog.Info("Reconciliation completed", "epoch", result.Epoch, "sampled", result.Sampled,
+			"mismatched", result.Mismatched, "max_diff_gwei", result.MaxDiffGwei)
+	}
+
+	s.reconcileMu.Lock()
+	s.lastReconcile = &result
+	s.reconcileMu.Unlock()
+}
+
+func compareReconcileSample(sample *reconcileSample, fresh map[uint64]*types.ValidatorEpochIncome, thresholdGwei int64) ReconcileResult {
+	result := ReconcileResult{
+		CheckedAt: time.Now().UTC(),
+		Epoch:     sample.epoch,
+		Sampled:   len(sample.deltas),
+	}
+	for idx, cached := range sample.deltas {
+		var want int64
+		if income, ok := fresh[idx]; ok && income != nil {
+			want = income.TotalClRewards()
+		}
+		diff := cached - want
+		if diff < 0 {
+			diff = -diff
+		}
+		result.MaxDiffGwei = max(result.MaxDiffGwei, diff)
+		if diff > thresholdGwei {
+			result.Mismatched++
+			slog.Warn("Cached rewards diverge from beacon node", "epoch", sample.epoch,
+				"validator", idx, "cached_gwei", cached, "fresh_gwei", want, "diff_gwei", diff)
+		}
+	}
+	return result
+}
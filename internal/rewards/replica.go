@@ -0,0 +1,286 @@
+package rewards
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gobitfly/eth-rewards/types"
+)
+
+// sharedStateVersion is bumped whenever sharedState changes incompatibly; replicas ignore files
+// written with another version rather than misreading them.
+const sharedStateVersion = 3
+
+// sharedState is the reward cache as written by a primary to SHARED_STATE_FILE and loaded by
+// READ_ONLY_REPLICA instances. It carries everything reads are computed from, but not the
+// bookkeeping only a syncing instance needs (retained epoch contributions, failed epochs).
+type sharedState struct {
+	Version                int                  `json:"version"`
+	WrittenAt              time.Time            `json:"written_at"`
+	LatestSyncEpoch        uint64               `json:"latest_sync_epoch"`
+	WindowStart            time.Time            `json:"window_start"`
+	IdealPerIncrementTotal float64              `json:"ideal_per_increment_total"`
+	ActivitySince          uint64               `json:"activity_since,omitempty"`
+	Validators             []sharedValidator    `json:"validators"`
+	ELBlocks               []ELBlockReward      `json:"el_blocks,omitempty"`
+	FeeRecipients          []sharedFeeRecipient `json:"fee_recipients,omitempty"`
+	// IncompleteEpochs carries the completeness bookkeeping so replicas and restored checkpoints
+	// report the same incomplete_epochs as the primary.
+	IncompleteEpochs map[uint64]epochCompleteness `json:"incomplete_epochs,omitempty"`
+}
+
+// sharedValidator merges one validator's entries of the parallel cache maps.
+type sharedValidator struct {
+	Index            uint64                      `json:"index"`
+	Income           *types.ValidatorEpochIncome `json:"income,omitempty"`
+	Sync             *SyncParticipation          `json:"sync,omitempty"`
+	BlocksProposed   uint64                      `json:"blocks_proposed,omitempty"`
+	IdealAttestation *idealReward                `json:"ideal_attestation,omitempty"`
+	LastActiveEpoch  uint64                      `json:"last_active_epoch,omitempty"`
+}
+
+type sharedFeeRecipient struct {
+	Recipient string `json:"recipient"`
+	Proposer  uint64 `json:"proposer"`
+	Wei       []byte `json:"wei"`
+}
+
+// writeSharedState publishes the cache to SHARED_STATE_FILE for replicas, replacing the file
+// atomically so a replica never reads a partial write. It is a no-op when no file is configured.
+func (s *Service) writeSharedState() {
+	if s.config.SharedStateFile == "" || s.config.ReadOnlyReplica {
+		return
+	}
+	path := s.config.SharedStateFile
+
+	data, err := s.encodeState()
+	if err != nil {
+		slog.Error("Failed to encode shared state", "error", err)
+		return
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		slog.Error("Failed to write shared state file", "path", path, "error", err)
+	}
+}
+
+// encodeState serializes the cache as a sharedState, as used by SHARED_STATE_FILE and the cache
+// checkpoint.
+func (s *Service) encodeState() ([]byte, error) {
+	state := sharedState{
+		Version:     sharedStateVersion,
+		WrittenAt:   time.Now().UTC(),
+		WindowStart: s.cacheWindowStartTime(),
+	}
+	// Income and sync entries are updated in place, so they are copied under the read lock; the
+	// encoding itself runs after it is released.
+	s.cacheMux.RLock()
+	state.LatestSyncEpoch = s.latestSyncEpoch
+	state.IdealPerIncrementTotal = s.idealPerIncrementTotal
+	state.ActivitySince = s.activitySince
+	byIndex := make(map[uint64]*sharedValidator, len(s.cache))
+	entry := func(idx uint64) *sharedValidator {
+		v, ok := byIndex[idx]
+		if !ok {
+			v = &sharedValidator{Index: idx}
+			byIndex[idx] = v
+		}
+		return v
+	}
+	for idx, income := range s.cache {
+		entry(idx).Income = copyIncome(income)
+	}
+	for idx, p := range s.syncSlots {
+		p := *p
+		entry(idx).Sync = &p
+	}
+	for idx, n := range s.blocksProposed {
+		entry(idx).BlocksProposed = n
+	}
+	for idx, ideal := range s.idealAttestation {
+		entry(idx).IdealAttestation = &ideal
+	}
+	for idx, epoch := range s.lastActive {
+		entry(idx).LastActiveEpoch = epoch
+	}
+	state.Validators = make([]sharedValidator, 0, len(byIndex))
+	for _, v := range byIndex {
+		state.Validators = append(state.Validators, *v)
+	}
+	for _, b := range s.elBlocks {
+		state.ELBlocks = append(state.ELBlocks, b)
+	}
+	for key, wei := range s.recipientEL {
+		state.FeeRecipients = append(state.FeeRecipients, sharedFeeRecipient{Recipient: key.recipient, Proposer: key.proposer, Wei: wei})
+	}
+	state.IncompleteEpochs = maps.Clone(s.incompleteEpochs)
+	s.cacheMux.RUnlock()
+
+	return json.Marshal(state)
+}
+
+// copyIncome returns a copy of income that later in-place accumulation does not change. TxFeeRewardWei
+// is shared: it is always replaced, never modified.
+func copyIncome(income *types.ValidatorEpochIncome) *types.ValidatorEpochIncome {
+	return &types.ValidatorEpochIncome{
+		AttestationSourceReward:            income.AttestationSourceReward,
+		AttestationSourcePenalty:           income.AttestationSourcePenalty,
+		AttestationTargetReward:            income.AttestationTargetReward,
+		AttestationTargetPenalty:           income.AttestationTargetPenalty,
+		AttestationHeadReward:              income.AttestationHeadReward,
+		FinalityDelayPenalty:               income.FinalityDelayPenalty,
+		ProposerSlashingInclusionReward:    income.ProposerSlashingInclusionReward,
+		ProposerAttestationInclusionReward: income.ProposerAttestationInclusionReward,
+		ProposerSyncInclusionReward:        income.ProposerSyncInclusionReward,
+		SyncCommitteeReward:                income.SyncCommitteeReward,
+		SyncCommitteePenalty:               income.SyncCommitteePenalty,
+		SlashingReward:                     income.SlashingReward,
+		SlashingPenalty:                    income.SlashingPenalty,
+		TxFeeRewardWei:                     income.TxFeeRewardWei,
+		ProposalsMissed:                    income.ProposalsMissed,
+	}
+}
+
+// writeFileAtomic replaces path with data through a temporary file in the same directory, so
+// readers never see a partial write.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	_ = os.MkdirAll(dir, 0o755)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// replicaRoutine keeps a read-only replica's cache in step with SHARED_STATE_FILE until the
+// service stops.
+func (s *Service) replicaRoutine() {
+	ticker := time.NewTicker(s.config.SharedStatePollInterval)
+	defer ticker.Stop()
+
+	var lastMod time.Time
+	for {
+		info, err := os.Stat(s.config.SharedStateFile)
+		switch {
+		case err != nil:
+			slog.Warn("Shared state file unavailable", "path", s.config.SharedStateFile, "error", err)
+		case !info.ModTime().Equal(lastMod):
+			if err := s.loadSharedState(); err != nil {
+				slog.Warn("Failed to load shared state", "path", s.config.SharedStateFile, "error", err)
+			} else {
+				lastMod = info.ModTime()
+			}
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// loadSharedState replaces the cache with the contents of SHARED_STATE_FILE.
+func (s *Service) loadSharedState() error {
+	state, err := readStateFile(s.config.SharedStateFile)
+	if err != nil {
+		return err
+	}
+	s.installState(state)
+
+	s.sharedStateWrittenAt.Store(&state.WrittenAt)
+	s.RecomputeNetworkRewards()
+	slog.Info("Loaded shared state", "epoch", state.LatestSyncEpoch, "validators", len(state.Validators), "written_at", state.WrittenAt)
+	return nil
+}
+
+// readStateFile decodes a file written by encodeState, rejecting other versions.
+func readStateFile(path string) (*sharedState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var state sharedState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	if state.Version != sharedStateVersion {
+		return nil, fmt.Errorf("unsupported shared state version %d (want %d)", state.Version, sharedStateVersion)
+	}
+	return &state, nil
+}
+
+// installState replaces the cache with state.
+func (s *Service) installState(state *sharedState) {
+	cache := make(map[uint64]*types.ValidatorEpochIncome, len(state.Validators))
+	syncSlots := make(map[uint64]*SyncParticipation)
+	blocksProposed := make(map[uint64]uint64)
+	idealAttestation := make(map[uint64]idealReward, len(state.Validators))
+	lastActive := make(map[uint64]uint64, len(state.Validators))
+	for _, v := range state.Validators {
+		if v.Income != nil {
+			cache[v.Index] = v.Income
+		}
+		if v.Sync != nil {
+			syncSlots[v.Index] = v.Sync
+		}
+		if v.BlocksProposed > 0 {
+			blocksProposed[v.Index] = v.BlocksProposed
+		}
+		if v.IdealAttestation != nil {
+			idealAttestation[v.Index] = *v.IdealAttestation
+		}
+		if v.LastActiveEpoch > 0 {
+			lastActive[v.Index] = v.LastActiveEpoch
+		}
+	}
+	elBlocks := make(map[uint64]ELBlockReward, len(state.ELBlocks))
+	for _, b := range state.ELBlocks {
+		elBlocks[b.BlockNumber] = b
+	}
+	incompleteEpochs := make(map[uint64]epochCompleteness, len(state.IncompleteEpochs))
+	maps.Copy(incompleteEpochs, state.IncompleteEpochs)
+	recipientEL := make(map[feeRecipientKey][]byte, len(state.FeeRecipients))
+	for _, r := range state.FeeRecipients {
+		recipientEL[feeRecipientKey{recipient: r.Recipient, proposer: r.Proposer}] = r.Wei
+	}
+
+	s.cacheMux.Lock()
+	s.cache = cache
+	s.syncSlots = syncSlots
+	s.blocksProposed = blocksProposed
+	s.idealAttestation = idealAttestation
+	s.idealPerIncrementTotal = state.IdealPerIncrementTotal
+	s.lastActive = lastActive
+	s.activitySince = state.ActivitySince
+	s.elBlocks = elBlocks
+	s.recipientEL = recipientEL
+	s.incompleteEpochs = incompleteEpochs
+	s.latestSyncEpoch = state.LatestSyncEpoch
+	s.setCacheWindowStart(state.WindowStart)
+	s.cacheMux.Unlock()
+}
+
+// SharedStateWrittenAt reports when the primary wrote the state a replica last loaded; zero until
+// the first load and on primaries.
+func (s *Service) SharedStateWrittenAt() time.Time {
+	if t := s.sharedStateWrittenAt.Load(); t != nil {
+		return *t
+	}
+	return time.Time{}
+}
@@ -3,74 +3,238 @@ package rewards
 import (
 	"bufio"
 	"bytes"
+	"compress/gzip"
+	"container/list"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"iter"
 	"log/slog"
+	"maps"
 	"math/big"
+	"math/rand/v2"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"beacon-rewards/internal/config"
 	"beacon-rewards/internal/dora"
 	"beacon-rewards/internal/utils"
 
-	"github.com/gobitfly/eth-rewards/elrewards"
 	"github.com/gobitfly/eth-rewards/types"
 	"golang.org/x/sync/errgroup"
 )
 
-const (
-	defaultEffectiveBalanceGwei int64 = 32_000_000_000
-)
-
 var gweiScalar = big.NewInt(1_000_000_000)
 
+// windowLocation is the zone whose midnight starts each cache window.
+var windowLocation = time.FixedZone("UTC+8", 8*60*60)
+
 // NetworkRewardSnapshot captures aggregated reward totals for all validators within a cache window.
 type NetworkRewardSnapshot struct {
-	WindowStart               time.Time `json:"window_start"`
-	WindowEnd                 time.Time `json:"window_end"`
-	WindowDurationSeconds     float64   `json:"window_duration_seconds"`
-	ActiveValidatorCount      int       `json:"active_validator_count"`
-	ClRewardsGwei             int64     `json:"cl_rewards_gwei"`
-	ElRewardsGwei             int64     `json:"el_rewards_gwei"`
-	TotalRewardsGwei          int64     `json:"total_rewards_gwei"`
-	TotalEffectiveBalanceGwei int64     `json:"total_effective_balance_gwei"`
-	ProjectAprPercent         float64   `json:"project_apr_percent"`
+	WindowStart               time.Time  `json:"window_start"`
+	WindowEnd                 time.Time  `json:"window_end"`
+	WindowDurationSeconds     float64    `json:"window_duration_seconds"`
+	ActiveValidatorCount      int        `json:"active_validator_count"`
+	ClRewardsGwei             utils.Gwei `json:"cl_rewards_gwei"`
+	ElRewardsGwei             utils.Gwei `json:"el_rewards_gwei"`
+	TotalRewardsGwei          utils.Gwei `json:"total_rewards_gwei"`
+	TotalEffectiveBalanceGwei utils.Gwei `json:"total_effective_balance_gwei"`
+	// TimeWeightedEffectiveBalanceGwei averages effective balance over the window, weighting each
+	// validator by the fraction of the window it was active. Zero when Dora is unavailable.
+	TimeWeightedEffectiveBalanceGwei utils.Gwei `json:"time_weighted_effective_balance_gwei"`
+	InactivityLeakGwei               utils.Gwei `json:"inactivity_leak_gwei"`
+	// EfficiencyPercent is network attestation rewards over the ideal for the window's effective balance.
+	EfficiencyPercent float64 `json:"efficiency_percent"`
+	// ProjectAprPercent uses the time-weighted balance when available; SimpleAprPercent always uses
+	// the point-in-time TotalEffectiveBalanceGwei and is kept for comparison.
+	ProjectAprPercent float64 `json:"project_apr_percent"`
+	SimpleAprPercent  float64 `json:"simple_apr_percent"`
+	AprAvailable      bool    `json:"apr_available"`
+	// Scoped is set when only TRACKED_VALIDATORS are synced; totals then cover that subset only.
+	Scoped                bool `json:"scoped"`
+	TrackedValidatorCount int  `json:"tracked_validator_count,omitempty"`
+	// IncompleteEpochs counts epochs whose attestation rewards covered fewer validators than Dora
+	// reported active; totals are then slightly low. Always 0 without Dora or when scoped.
+	IncompleteEpochs int `json:"incomplete_epochs"`
+	// WindowStartEpoch is the first epoch of the window and WindowEndEpoch the newest synced one,
+	// both inclusive; they are equal while no epoch of the window has been synced.
+	WindowStartEpoch uint64 `json:"window_start_epoch"`
+	WindowEndEpoch   uint64 `json:"window_end_epoch"`
+	// FromHistory is set on the stored snapshot served while the cache warms up after a start (see
+	// WARMUP_FROM_HISTORY); it describes that earlier window, not the current one.
+	FromHistory bool `json:"from_history,omitempty"`
 }
 
 // ValidatorReward represents the total reward (EL + CL) for a single validator.
 type ValidatorReward struct {
-	ValidatorIndex       uint64  `json:"validator_index"`
-	ClRewardsGwei        int64   `json:"cl_rewards_gwei"`
-	ElRewardsGwei        int64   `json:"el_rewards_gwei"`
-	TotalRewardsGwei     int64   `json:"total_rewards_gwei"`
-	EffectiveBalanceGwei int64   `json:"effective_balance_gwei"`
-	ProjectAPRPercent    float64 `json:"project_apr_percent"`
+	ValidatorIndex       uint64     `json:"validator_index"`
+	ClRewardsGwei        utils.Gwei `json:"cl_rewards_gwei"` // Net of penalties: GrossClRewardsGwei - ClPenaltiesGwei.
+	ElRewardsGwei        utils.Gwei `json:"el_rewards_gwei"`
+	TotalRewardsGwei     utils.Gwei `json:"total_rewards_gwei"`
+	GrossClRewardsGwei   utils.Gwei `json:"gross_cl_rewards_gwei"` // CL reward components before penalties.
+	ClPenaltiesGwei      utils.Gwei `json:"cl_penalties_gwei"`     // All CL penalty components, as a positive amount.
+	InactivityLeakGwei   utils.Gwei `json:"inactivity_leak_gwei"`  // Negative component already netted into ClRewardsGwei.
+	EffectiveBalanceGwei utils.Gwei `json:"effective_balance_gwei"`
+	ProjectAPRPercent    float64    `json:"project_apr_percent"`
+	// EfficiencyPercent is attestation rewards as a percentage of the ideal for this effective balance.
+	EfficiencyPercent float64 `json:"efficiency_percent"`
+	// Sync committee slots in the window where the validator was rewarded (participated) or penalized (missed).
+	SyncSlotsParticipated uint64 `json:"sync_slots_participated"`
+	SyncSlotsMissed       uint64 `json:"sync_slots_missed"`
+	// LastActiveEpoch is the newest synced epoch with a positive attestation reward, possibly from an
+	// earlier window; unset when none was seen since the process started.
+	LastActiveEpoch   *uint64 `json:"last_active_epoch,omitempty"`
+	EpochsSinceActive *uint64 `json:"epochs_since_active,omitempty"`
+	// Offline is set after more than OFFLINE_THRESHOLD_EPOCHS synced epochs without attestation rewards.
+	Offline bool `json:"offline"`
+}
+
+// SyncParticipation counts a validator's sync committee slots by outcome.
+type SyncParticipation struct {
+	Participated uint64
+	Missed       uint64
+}
+
+// Sync phases reported by SyncStatus.
+const (
+	SyncPhaseBackfill = "backfill"
+	SyncPhaseLive     = "live"
+	// SyncPhaseReplica is reported by READ_ONLY_REPLICA instances, which load the primary's state
+	// instead of syncing.
+	SyncPhaseReplica = "replica"
+)
+
+// SyncStatus reports backfill/live sync progress.
+type SyncStatus struct {
+	Phase           string  `json:"phase"`
+	StartEpoch      uint64  `json:"start_epoch"`
+	CurrentEpoch    uint64  `json:"current_epoch"`
+	TargetEpoch     uint64  `json:"target_epoch"`
+	EpochsRemaining uint64  `json:"epochs_remaining"`
+	PercentComplete float64 `json:"percent_complete"`
 }
 
 // Service manages validator reward statistics
 type Service struct {
 	config   *config.Config
+	chain    utils.ChainConfig // Network the service syncs; see SetChain.
 	beaconCL *NodePool
-	elClient *string
+	nodesMu  sync.RWMutex // Guards elClient, which SetNodes can replace.
+	elClient string
 	doraDB   *dora.DB
 	ctx      context.Context
 	cancel   context.CancelFunc
 
 	// Cache state
 	cache            map[uint64]*types.ValidatorEpochIncome
+	syncSlots        map[uint64]*SyncParticipation // Parallel to cache; only sync committee members have entries.
+	blocksProposed   map[uint64]uint64             // Parallel to cache; only validators that proposed have entries.
 	cacheMux         sync.RWMutex
 	latestSyncEpoch  uint64
 	cacheWindowStart time.Time
 	cacheWindowMu    sync.RWMutex
+	// networkSnapshot caches TotalNetworkRewards until the next epoch is merged; nil until computed.
+	networkSnapshot atomic.Pointer[NetworkRewardSnapshot]
+	// warmupSnapshot is the newest stored snapshot, served by TotalNetworkRewards until the live cache
+	// catches up with it; nil when WARMUP_FROM_HISTORY is off or once caught up.
+	warmupSnapshot atomic.Pointer[NetworkRewardSnapshot]
+	// sortedIndices caches CachedValidatorIndices until the next merged epoch or reset; nil until read.
+	sortedIndices atomic.Pointer[[]uint64]
+	// proposerDuties caches the proposer index by slot for the epochs UpcomingProposals looked up;
+	// duties are fixed once a node reveals them, and only the current and next epoch are kept.
+	proposerDutiesMu sync.Mutex
+	proposerDuties   map[uint64]map[uint64]uint64
+
+	// Ideal attestation reward per 1 ETH increment, summed over the epochs each validator attested in
+	// (idealAttestation) and over all processed epochs (idealPerIncrementTotal); guarded by cacheMux.
+	// See efficiency.go.
+	idealAttestation       map[uint64]idealReward
+	idealPerIncrementTotal float64
+
+	// recipientEL sums EL rewards per fee recipient and proposer when fee recipients are tracked
+	// (see fee_recipients.go); guarded by cacheMux.
+	recipientEL map[feeRecipientKey][]byte
+	// elBlocks tags the window's EL tips with their execution block number, keyed by block number.
+	// It holds one small entry per synced block (about 7200 a day); guarded by cacheMux.
+	elBlocks map[uint64]ELBlockReward
+
+	// epochContributions holds what each of the last RetainedEpochContributions processed epochs
+	// added to the cache, so re-processing or removing one can subtract it; guarded by cacheMux.
+	epochContributions map[uint64]*epochRewards
+	// incompleteEpochs lists the window's epochs with partial attestation rewards (see
+	// completeness.go); guarded by cacheMux.
+	incompleteEpochs map[uint64]epochCompleteness
+
+	// tracked restricts syncing to these validators; nil tracks the whole network.
+	tracked map[uint64]struct{}
+
+	// lastActive maps validators to their newest epoch with a positive attestation reward and
+	// activitySince is the oldest epoch merged since startup; both outlive window resets and are
+	// guarded by cacheMux. See activity.go.
+	lastActive    map[uint64]uint64
+	activitySince uint64
+
+	// resetJitter is how long this process waits after midnight before persisting the closed window,
+	// drawn once from [0, CACHE_RESET_JITTER] so it is stable here but differs between instances.
+	resetJitter time.Duration
 
 	// History state
 	historyPath string
 	historyMu   sync.Mutex
+
+	// Per-validator window totals, guarded by historyMu and loaded lazily from validatorHistoryPath.
+	validatorHistoryPath   string
+	validatorHistory       []validatorHistoryEntry
+	validatorHistoryLoaded bool
+
+	// Per-address window totals (see address_history.go), guarded by historyMu and loaded lazily.
+	addressHistoryPath   string
+	addressHistory       []addressHistoryEntry
+	addressHistoryLoaded bool
+	// Addresses to record at the next reset, guarded by trackedAddrMu. Queried addresses form an LRU
+	// by last query time; the front of queriedOrder is the most recent.
+	trackedAddrMu    sync.Mutex
+	queriedAddresses map[string]*list.Element
+	queriedOrder     *list.List
+	labeledAddresses []string
+
+	// Intra-day network totals (see fine_history.go), guarded by fineHistoryMu. fineHistoryOldest is
+	// the file's first epoch, known once fineHistoryScanned is set by the first append.
+	fineHistoryPath    string
+	fineHistoryMu      sync.Mutex
+	fineHistoryOldest  uint64
+	fineHistoryScanned bool
+
+	// Sync progress state
+	syncMu            sync.RWMutex
+	syncPhase         string
+	syncStartEpoch    uint64
+	backfillTarget    uint64
+	backfillProcessed atomic.Uint64
+
+	// unsupportedEndpoints holds beacon endpoints that answered 404 for slots another endpoint found.
+	unsupportedEndpoints sync.Map
+
+	// Epochs that exhausted their retries in the current window, persisted to failedEpochsPath when set.
+	failedMu         sync.Mutex
+	failedEpochs     map[uint64]FailedEpoch
+	retryingEpochs   map[uint64]struct{}
+	failedEpochsPath string
+
+	// Reconciliation state
+	reconcileMu     sync.Mutex
+	reconcileSample *reconcileSample
+	lastReconcile   *ReconcileResult
+
+	// sharedStateWrittenAt is when the primary wrote the state a replica last loaded (see replica.go).
+	sharedStateWrittenAt atomic.Pointer[time.Time]
 }
 
 // NewService creates a new rewards service
@@ -79,24 +243,68 @@ func NewService(cfg *config.Config) *Service {
 	nodePool := NewNodePool(cfg.BeaconNodeURL, time.Minute*5)
 
 	s := &Service{
-		config:      cfg,
-		beaconCL:    nodePool,
-		elClient:    &cfg.ExecutionNodeURL,
-		cache:       make(map[uint64]*types.ValidatorEpochIncome),
-		historyPath: strings.TrimSpace(cfg.RewardsHistoryFile),
-		ctx:         ctx,
-		cancel:      cancel,
+		config:           cfg,
+		chain:            utils.NewChainConfig(0, cfg.SecondsPerSlot, cfg.SlotsPerEpoch),
+		beaconCL:         nodePool,
+		elClient:         cfg.ExecutionNodeURL,
+		cache:            make(map[uint64]*types.ValidatorEpochIncome),
+		syncSlots:        make(map[uint64]*SyncParticipation),
+		idealAttestation: make(map[uint64]idealReward),
+		blocksProposed:   make(map[uint64]uint64),
+		historyPath:      strings.TrimSpace(cfg.RewardsHistoryFile),
+		ctx:              ctx,
+		cancel:           cancel,
+
+		validatorHistoryPath: strings.TrimSpace(cfg.ValidatorHistoryFile),
+		addressHistoryPath:   strings.TrimSpace(cfg.AddressHistoryFile),
+		queriedAddresses:     make(map[string]*list.Element),
+		queriedOrder:         list.New(),
+		fineHistoryPath:      strings.TrimSpace(cfg.FineHistoryFile),
+		epochContributions:   make(map[uint64]*epochRewards),
+		incompleteEpochs:     make(map[uint64]epochCompleteness),
+		lastActive:           make(map[uint64]uint64),
+		recipientEL:          make(map[feeRecipientKey][]byte),
+		elBlocks:             make(map[uint64]ELBlockReward),
+		failedEpochs:         make(map[uint64]FailedEpoch),
+		retryingEpochs:       make(map[uint64]struct{}),
+		failedEpochsPath:     strings.TrimSpace(cfg.FailedEpochsFile),
+	}
+	if cfg.CacheResetJitter > 0 {
+		s.resetJitter = rand.N(cfg.CacheResetJitter + 1)
+	}
+
+	if len(cfg.TrackedValidators) > 0 {
+		s.tracked = make(map[uint64]struct{}, len(cfg.TrackedValidators))
+		for _, idx := range cfg.TrackedValidators {
+			s.tracked[idx] = struct{}{}
+		}
 	}
 
 	// Default cache window start to today 00:00 UTC+8
-	loc := time.FixedZone("UTC+8", 8*60*60)
+	loc := windowLocation
 	now := time.Now().In(loc)
 	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
 	s.setCacheWindowStart(midnight)
 
+	if cfg.WarmupFromHistory && !cfg.ReadOnlyReplica {
+		s.loadWarmupSnapshot()
+	}
+
 	return s
 }
 
+// SetChain sets the network the service computes epochs for; until then it uses the default genesis
+// with the configured slot duration and epoch length. One process can run a service per network.
+// Call it before Start.
+func (s *Service) SetChain(chain utils.ChainConfig) {
+	s.chain = chain
+}
+
+// Chain returns the network parameters the service computes epochs with.
+func (s *Service) Chain() utils.ChainConfig {
+	return s.chain
+}
+
 // SetDoraDB attaches a Dora DB handle for effective balance lookups (optional).
 func (s *Service) SetDoraDB(db *dora.DB) {
 	s.doraDB = db
@@ -106,10 +314,30 @@ func (s *Service) SetDoraDB(db *dora.DB) {
 func (s *Service) Start() error {
 	slog.Info("Starting rewards service")
 
+	if s.config.ReadOnlyReplica {
+		slog.Info("Running as read-only replica", "shared_state_file", s.config.SharedStateFile)
+		s.setSyncPhase(SyncPhaseReplica, 0, 0)
+		go s.replicaRoutine()
+		return nil
+	}
+
 	startEpoch := s.startEpoch(time.Now())
+	s.loadFailedEpochs(startEpoch)
+	if s.restoreCheckpoint(time.Now()) {
+		// Epochs up to the checkpoint are already counted; syncing them again would add them twice.
+		s.cacheMux.RLock()
+		startEpoch = max(startEpoch, s.latestSyncEpoch+1)
+		s.cacheMux.RUnlock()
+	}
 
 	go s.syncRoutine(startEpoch)
 	go s.cacheResetTimerWithClock(time.Now)
+	if s.checkpointEnabled() {
+		go s.checkpointRoutine()
+	}
+	if s.config.EnableReconcile {
+		go s.reconcileRoutine()
+	}
 
 	return nil
 }
@@ -117,17 +345,18 @@ func (s *Service) Start() error {
 func (s *Service) startEpoch(now time.Time) uint64 {
 	if s.config.BackfillLookback > 0 {
 		startTime := now.Add(-s.config.BackfillLookback)
-		return utils.TimeToEpoch(startTime)
+		return s.chain.TimeToEpoch(startTime)
 	}
 
 	// Default: Start from cache window start (00:00 UTC+8)
-	return utils.TimeToEpoch(s.cacheWindowStartTime())
+	return s.chain.TimeToEpoch(s.cacheWindowStartTime())
 }
 
 // Stop gracefully stops the service
 func (s *Service) Stop() {
 	slog.Info("Stopping rewards service")
 	s.cancel()
+	s.writeCheckpoint()
 }
 
 // ---------------------------------------------------------------------
@@ -137,16 +366,20 @@ func (s *Service) Stop() {
 func (s *Service) syncRoutine(startEpoch uint64) {
 	// 1. Backfill Phase
 	// Process from startEpoch up to (latest_completed - 2)
-	latestEpoch := utils.TimeToEpoch(time.Now())
-	if latestEpoch > 2 {
-		latestEpoch -= 2
-	} else {
-		latestEpoch = 0
+	latestEpoch := safeHeadEpoch(s.chain, time.Now())
+	if clamped, ok := clampBackfillRange(startEpoch, latestEpoch, s.config.MaxBackfillEpochs); ok {
+		slog.Warn("Backfill range exceeds MAX_BACKFILL_EPOCHS; skipping oldest epochs",
+			"requestedFrom", startEpoch, "clampedFrom", clamped, "to", latestEpoch,
+			"maxBackfillEpochs", s.config.MaxBackfillEpochs)
+		startEpoch = clamped
 	}
+	s.setSyncPhase(SyncPhaseBackfill, startEpoch, latestEpoch)
 
 	if startEpoch <= latestEpoch {
 		slog.Info("Starting backfill", "from", startEpoch, "to", latestEpoch)
 		s.runBackfill(startEpoch, latestEpoch)
+		s.RecomputeNetworkRewards()
+		s.writeSharedState()
 		slog.Info("Backfill completed")
 	} else {
 		slog.Warn("Backfill skipped", "startEpoch", startEpoch, "latestEpoch", latestEpoch)
@@ -154,9 +387,19 @@ func (s *Service) syncRoutine(startEpoch uint64) {
 
 	// 2. Live Sync Phase
 	// Continues strictly from latestSyncEpoch + 1
+	s.setSyncPhase(SyncPhaseLive, startEpoch, latestEpoch)
 	s.runLiveSync()
 }
 
+// clampBackfillRange limits [from, to] to the most recent maxEpochs epochs. It returns the new
+// start epoch and true when the range was clamped. A maxEpochs of zero disables the cap.
+func clampBackfillRange(from, to, maxEpochs uint64) (uint64, bool) {
+	if maxEpochs == 0 || from > to || to-from+1 <= maxEpochs {
+		return from, false
+	}
+	return to - maxEpochs + 1, true
+}
+
 func (s *Service) runBackfill(from, to uint64) {
 	g, ctx := errgroup.WithContext(s.ctx)
 	g.SetLimit(s.config.BackfillConcurrency)
@@ -167,7 +410,7 @@ func (s *Service) runBackfill(from, to uint64) {
 	// Producer
 	go func() {
 		defer close(epochs)
-		for e := from; e <= to; e++ {
+		for e := range backfillEpochs(from, to, s.config.BackfillOrder) {
 			select {
 			case epochs <- e:
 			case <-ctx.Done():
@@ -184,6 +427,7 @@ func (s *Service) runBackfill(from, to uint64) {
 					// In backfill, we log error but don't abort the whole group unless critical
 					slog.Error("Backfill epoch failed after retries", "epoch", epoch, "error", err)
 				}
+				s.backfillProcessed.Add(1)
 			}
 			return nil
 		})
@@ -191,6 +435,26 @@ func (s *Service) runBackfill(from, to uint64) {
 	_ = g.Wait()
 }
 
+// backfillEpochs yields [from, to] in the configured order. Either order hands live sync a true
+// high-water mark: processEpoch only ever raises latestSyncEpoch, and live sync starts once every
+// backfill worker has returned.
+func backfillEpochs(from, to uint64, order string) iter.Seq[uint64] {
+	return func(yield func(uint64) bool) {
+		if from > to {
+			return
+		}
+		for i := uint64(0); i <= to-from; i++ {
+			e := from + i
+			if order == config.BackfillOrderDesc {
+				e = to - i
+			}
+			if !yield(e) {
+				return
+			}
+		}
+	}
+}
+
 func (s *Service) runLiveSync() {
 	ticker := time.NewTicker(s.config.EpochCheckInterval)
 	defer ticker.Stop()
@@ -204,17 +468,17 @@ func (s *Service) runLiveSync() {
 		s.cacheMux.RUnlock()
 
 		// Check if we can process nextEpoch (now - 2)
-		chainHead := utils.TimeToEpoch(time.Now())
-		safeHead := uint64(0)
-		if chainHead > 2 {
-			safeHead = chainHead - 2
-		}
+		safeHead := safeHeadEpoch(s.chain, time.Now())
 		// sync from cached nextEpoch to safeHead
 		for epoch := nextEpoch; epoch <= safeHead; epoch++ {
 			if err := s.processEpochWithRetry(epoch); err != nil {
 				slog.Error("Live sync epoch failed after retries", "epoch", epoch, "error", err)
 			}
 		}
+		if nextEpoch <= safeHead {
+			s.RecomputeNetworkRewards()
+			s.writeSharedState()
+		}
 
 		select {
 		case <-s.ctx.Done():
@@ -224,6 +488,72 @@ func (s *Service) runLiveSync() {
 	}
 }
 
+// safeHeadEpoch returns the newest epoch considered final enough to process (head - 2).
+func safeHeadEpoch(chain utils.ChainConfig, now time.Time) uint64 {
+	chainHead := chain.TimeToEpoch(now)
+	if chainHead > 2 {
+		return chainHead - 2
+	}
+	return 0
+}
+
+func (s *Service) setSyncPhase(phase string, startEpoch, target uint64) {
+	s.syncMu.Lock()
+	s.syncPhase = phase
+	s.syncStartEpoch = startEpoch
+	s.backfillTarget = target
+	s.syncMu.Unlock()
+}
+
+// SyncStatus reports how far the backfill or live sync has progressed.
+// During backfill, progress counts finished epochs since workers complete them out of order.
+func (s *Service) SyncStatus() SyncStatus {
+	s.syncMu.RLock()
+	phase := s.syncPhase
+	startEpoch := s.syncStartEpoch
+	target := s.backfillTarget
+	s.syncMu.RUnlock()
+
+	s.cacheMux.RLock()
+	current := s.latestSyncEpoch
+	s.cacheMux.RUnlock()
+
+	status := SyncStatus{
+		Phase:        phase,
+		StartEpoch:   startEpoch,
+		CurrentEpoch: current,
+		TargetEpoch:  target,
+	}
+	if phase == "" {
+		status.Phase = SyncPhaseBackfill
+	}
+
+	if status.Phase == SyncPhaseReplica {
+		return status
+	}
+	if status.Phase == SyncPhaseLive {
+		status.TargetEpoch = safeHeadEpoch(s.chain, time.Now())
+		if status.TargetEpoch > current {
+			status.EpochsRemaining = status.TargetEpoch - current
+		}
+		status.PercentComplete = 100
+		if status.EpochsRemaining > 0 && current >= startEpoch {
+			done := current - startEpoch + 1
+			status.PercentComplete = 100 * float64(done) / float64(done+status.EpochsRemaining)
+		}
+		return status
+	}
+
+	if target < startEpoch {
+		return status
+	}
+	total := target - startEpoch + 1
+	processed := min(s.backfillProcessed.Load(), total)
+	status.EpochsRemaining = total - processed
+	status.PercentComplete = 100 * float64(processed) / float64(total)
+	return status
+}
+
 func (s *Service) processEpochWithRetry(epoch uint64) error {
 	var err error
 	backoff := time.Second
@@ -237,33 +567,59 @@ func (s *Service) processEpochWithRetry(epoch uint64) error {
 			return s.ctx.Err()
 		}
 		if err = s.processEpoch(epoch); err == nil {
+			s.clearFailedEpoch(epoch)
 			return nil
 		}
 		slog.Warn("Epoch processing failed", "epoch", epoch, "attempt", i+1, "error", err)
 		time.Sleep(backoff)
 		backoff *= 2
 	}
+	// A failed re-run of a retained epoch leaves its earlier contribution in place, so nothing is missing.
+	if !s.epochRetained(epoch) {
+		s.recordFailedEpoch(epoch, err, time.Now())
+	}
 	return err
 }
 
 func (s *Service) processEpoch(epoch uint64) error {
 	startTime := time.Now()
-	rewards, err := s.getRewardsForEpoch(epoch)
+	epochData, err := s.getRewardsForEpoch(epoch)
 	if err != nil {
 		return err
 	}
 
+	if s.mergeEpoch(epoch, epochData) && s.fineHistoryDue(epoch) {
+		s.appendFineHistory(epoch, time.Now())
+	}
+
+	slog.Info("Processed epoch", "epoch", epoch, "validators", len(epochData.income), "duration", time.Since(startTime))
+	return nil
+}
+
+// mergeEpoch adds a fetched epoch to the window and reports whether it advanced latestSyncEpoch.
+func (s *Service) mergeEpoch(epoch uint64, data *epochRewards) bool {
 	s.cacheMux.Lock()
-	for validatorIndex, income := range rewards {
-		s.accumulateRewards(validatorIndex, income)
+	defer s.cacheMux.Unlock()
+
+	// Sample only the newest epoch so reconciliation checks what live sync just merged.
+	var before map[uint64]int64
+	if s.config.EnableReconcile && epoch > s.latestSyncEpoch {
+		before = s.cachedClLocked(data.income, s.config.ReconcileSampleSize)
 	}
-	if epoch > s.latestSyncEpoch {
-		s.latestSyncEpoch = epoch
+	s.applyEpochLocked(epoch, data)
+	if before != nil {
+		s.recordReconcileSampleLocked(epoch, before)
 	}
-	s.cacheMux.Unlock()
+	// The cached snapshot and index set no longer cover the window; the next read rebuilds them, so
+	// a backfill serves its progress instead of the state from before it started.
+	s.networkSnapshot.Store(nil)
+	s.sortedIndices.Store(nil)
 
-	slog.Info("Processed epoch", "epoch", epoch, "validators", len(rewards), "duration", time.Since(startTime))
-	return nil
+	advanced := epoch > s.latestSyncEpoch
+	if advanced {
+		s.latestSyncEpoch = epoch
+	}
+	return advanced
 }
 
 // ---------------------------------------------------------------------
@@ -274,10 +630,12 @@ func (s *Service) accumulateRewards(validatorIndex uint64, income *types.Validat
 	if income == nil {
 		return
 	}
+	// New entries start from zero rather than aliasing income, which may be retained as the
+	// epoch's contribution (see epoch_contributions.go).
 	existing, exists := s.cache[validatorIndex]
 	if !exists {
-		s.cache[validatorIndex] = income
-		return
+		existing = &types.ValidatorEpochIncome{}
+		s.cache[validatorIndex] = existing
 	}
 	// In-place accumulation to avoid copying struct
 	existing.AttestationSourceReward += income.AttestationSourceReward
@@ -297,15 +655,37 @@ func (s *Service) accumulateRewards(validatorIndex uint64, income *types.Validat
 	existing.TxFeeRewardWei = addWei(existing.TxFeeRewardWei, income.TxFeeRewardWei)
 }
 
+func (s *Service) accumulateSyncSlots(validatorIndex uint64, p *SyncParticipation) {
+	existing, exists := s.syncSlots[validatorIndex]
+	if !exists {
+		existing = &SyncParticipation{}
+		s.syncSlots[validatorIndex] = existing
+	}
+	existing.Participated += p.Participated
+	existing.Missed += p.Missed
+}
+
+// nextCacheReset returns when the window open at current closes: the next 00:00 UTC+8.
+func (s *Service) nextCacheReset(current time.Time) time.Time {
+	current = current.In(windowLocation)
+	return time.Date(current.Year(), current.Month(), current.Day()+1, 0, 0, 0, 0, windowLocation)
+}
+
+// nextWindowPersist returns when the window open at current is persisted: its close at midnight plus
+// this process's reset jitter. The jitter staggers only this step, never the window boundary.
+func (s *Service) nextWindowPersist(current time.Time) time.Time {
+	return s.nextCacheReset(current).Add(s.resetJitter)
+}
+
 func (s *Service) cacheResetTimerWithClock(now func() time.Time) {
-	loc := time.FixedZone("UTC+8", 8*60*60)
+	loc := windowLocation
 	for {
 		current := now().In(loc)
-		// Calculate next 00:00 UTC+8
-		nextRun := time.Date(current.Year(), current.Month(), current.Day()+1, 0, 0, 0, 0, loc)
+		nextRun := s.nextCacheReset(current)
 		duration := nextRun.Sub(current)
 
-		slog.Info("Scheduled next cache reset", "next_run", nextRun, "wait_duration", duration)
+		slog.Info("Scheduled next cache reset", "next_run", nextRun, "wait_duration", duration,
+			"persist_at", s.nextWindowPersist(current))
 
 		timer := time.NewTimer(duration)
 		select {
@@ -313,24 +693,105 @@ func (s *Service) cacheResetTimerWithClock(now func() time.Time) {
 			timer.Stop()
 			return
 		case <-timer.C:
-			s.resetCacheAt(now())
+			// Every instance turns the window over exactly at midnight; only the Dora lookups and
+			// file writes of the closed window wait for the jitter.
+			closed := s.closeWindowAt(nextRun)
+			s.writeSharedState()
+			if closed == nil {
+				continue
+			}
+			stopped := !s.waitResetJitter()
+			s.persistClosedWindow(closed)
+			if stopped {
+				return
+			}
 		}
 	}
 }
 
+// waitResetJitter sleeps for this process's reset jitter. It returns false if the service stops
+// first, in which case the caller still persists the closed window before exiting.
+func (s *Service) waitResetJitter() bool {
+	if s.resetJitter <= 0 {
+		return true
+	}
+	timer := time.NewTimer(s.resetJitter)
+	defer timer.Stop()
+	select {
+	case <-s.ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// closedWindow is the cache state detached from the service when a window closes; it is persisted to
+// the history files after cacheMux is released.
+type closedWindow struct {
+	snapshot *NetworkRewardSnapshot
+	cache    map[uint64]*types.ValidatorEpochIncome
+}
+
 func (s *Service) resetCacheAt(currentTime time.Time) {
+	if closed := s.closeWindowAt(currentTime); closed != nil {
+		s.persistClosedWindow(closed)
+	}
+}
+
+// closeWindowAt starts a new window at currentTime and returns the closed window's snapshot and
+// cache, or nil when it had no rewards. Only the swap holds cacheMux; dropped failed epochs are
+// written to FAILED_EPOCHS_FILE after it is released.
+func (s *Service) closeWindowAt(currentTime time.Time) *closedWindow {
 	s.cacheMux.Lock()
-	defer s.cacheMux.Unlock()
 
+	var closed *closedWindow
 	if len(s.cache) > 0 {
-		snapshot := s.computeNetworkSnapshotLocked(currentTime)
-		s.persistSnapshot(snapshot)
+		closed = &closedWindow{snapshot: s.computeNetworkSnapshotLocked(currentTime), cache: s.cache}
 	}
 
 	s.cache = make(map[uint64]*types.ValidatorEpochIncome)
+	s.syncSlots = make(map[uint64]*SyncParticipation)
+	s.idealAttestation = make(map[uint64]idealReward)
+	s.idealPerIncrementTotal = 0
+	s.blocksProposed = make(map[uint64]uint64)
+	s.recipientEL = make(map[feeRecipientKey][]byte)
+	s.elBlocks = make(map[uint64]ELBlockReward)
+	clear(s.epochContributions)
+	clear(s.incompleteEpochs)
+	droppedFailed := s.resetFailedEpochsLocked()
+	// The next read rebuilds the snapshot for the new, empty window.
+	s.networkSnapshot.Store(nil)
+	s.sortedIndices.Store(nil)
 	// NOTE: We do NOT reset latestSyncEpoch here. It serves as the high-water mark for synchronization.
 	s.setCacheWindowStart(currentTime)
+	s.cacheMux.Unlock()
+
 	slog.Info("Cache reset")
+	if droppedFailed {
+		s.persistFailedEpochs()
+	}
+	return closed
+}
+
+// persistClosedWindow appends a closed window to the history files. It runs without cacheMux, so
+// the Dora lookups and file rewrites do not block readers or the sync loop.
+func (s *Service) persistClosedWindow(w *closedWindow) {
+	s.persistSnapshot(w.snapshot)
+
+	var addresses map[string]recordedAddress
+	if s.AddressHistoryEnabled() {
+		addresses = s.resolveRecordedAddresses(w.snapshot.WindowEnd)
+	}
+	if !s.ValidatorHistoryEnabled() && len(addresses) == 0 {
+		return
+	}
+	balances := s.effectiveBalancesOf(w.cache)
+	if s.ValidatorHistoryEnabled() {
+		s.persistValidatorHistory(validatorHistoryEntryOf(w, balances))
+	}
+	if len(addresses) > 0 {
+		s.persistAddressHistory(s.addressHistoryEntryOf(w, addresses, balances))
+	}
 }
 
 // ---------------------------------------------------------------------
@@ -349,111 +810,396 @@ func (s *Service) GetRewards(validatorIndices []uint64) map[uint64]*types.Valida
 	return result
 }
 
+// TotalNetworkRewards returns the network snapshot of the current window, computing it only when no
+// epoch was merged since it was cached. The hot read path takes no lock; callers must not modify the
+// result.
 func (s *Service) TotalNetworkRewards() *NetworkRewardSnapshot {
+	snap := s.networkSnapshot.Load()
+	if snap == nil {
+		snap = s.RecomputeNetworkRewards()
+	}
+	if warm := s.warmupSnapshot.Load(); warm != nil {
+		// Until the backfill is done the live totals only cover part of the window, so the last
+		// stored window is the better answer; keep it until live sync has passed its last epoch.
+		s.syncMu.RLock()
+		phase := s.syncPhase
+		s.syncMu.RUnlock()
+		if phase != SyncPhaseLive || snap.WindowEndEpoch < warm.WindowEndEpoch {
+			return warm
+		}
+		s.warmupSnapshot.CompareAndSwap(warm, nil)
+	}
+	return snap
+}
+
+// loadWarmupSnapshot reads the newest snapshot from the history file for TotalNetworkRewards to serve
+// while the cache warms up.
+func (s *Service) loadWarmupSnapshot() {
+	if s.historyPath == "" {
+		return
+	}
+	s.historyMu.Lock()
+	entries, err := s.readHistoryLocked()
+	s.historyMu.Unlock()
+	if err != nil {
+		slog.Warn("Failed to load warm-up snapshot", "path", s.historyPath, "error", err)
+		return
+	}
+	if len(entries) == 0 {
+		return
+	}
+	warm := entries[len(entries)-1]
+	warm.FromHistory = true
+	s.warmupSnapshot.Store(&warm)
+	slog.Info("Serving stored network snapshot until the cache warms up", "window_start", warm.WindowStart, "window_end_epoch", warm.WindowEndEpoch)
+}
+
+// RecomputeNetworkRewards rebuilds the cached network snapshot from the current cache and returns it.
+func (s *Service) RecomputeNetworkRewards() *NetworkRewardSnapshot {
 	s.cacheMux.RLock()
 	defer s.cacheMux.RUnlock()
-	return s.computeNetworkSnapshotLocked(time.Now())
+	return s.recomputeNetworkRewardsLocked()
+}
+
+// recomputeNetworkRewardsLocked is RecomputeNetworkRewards for callers that hold cacheMux. Storing
+// under the lock means a reset, which clears the snapshot under the write lock, cannot be undone by
+// a snapshot of the closed window.
+func (s *Service) recomputeNetworkRewardsLocked() *NetworkRewardSnapshot {
+	snap := s.computeNetworkSnapshotLocked(time.Now())
+	s.networkSnapshot.Store(snap)
+	// Every cache change ends in a recompute, so the validator set may have changed too.
+	s.sortedIndices.Store(nil)
+	return snap
 }
 
+// NetworkRewardHistory returns the stored snapshots, oldest first; never nil unless err is set.
 func (s *Service) NetworkRewardHistory() ([]NetworkRewardSnapshot, error) {
 	if s.historyPath == "" {
-		return nil, nil
+		return []NetworkRewardSnapshot{}, nil
 	}
 	s.historyMu.Lock()
 	defer s.historyMu.Unlock()
 
+	entries, err := s.readHistoryLocked()
+	if err != nil {
+		return nil, err
+	}
+	if entries == nil {
+		entries = []NetworkRewardSnapshot{}
+	}
+	return entries, nil
+}
+
+// readHistoryLocked reads every snapshot in the history file; caller must hold historyMu.
+func (s *Service) readHistoryLocked() ([]NetworkRewardSnapshot, error) {
 	f, err := os.Open(s.historyPath)
 	if os.IsNotExist(err) {
-		return []NetworkRewardSnapshot{}, nil
+		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
 
+	var r io.Reader = f
+	if historyGzipped(s.historyPath) {
+		gz, err := gzip.NewReader(f)
+		if errors.Is(err, io.EOF) {
+			return nil, nil // Empty file.
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read rewards history: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
 	var entries []NetworkRewardSnapshot
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		if b := bytes.TrimSpace(scanner.Bytes()); len(b) > 0 {
-			var e NetworkRewardSnapshot
-			_ = json.Unmarshal(b, &e)
-			entries = append(entries, e)
+	skipped, err := readJSONLines(r, func(line []byte) error {
+		var e NetworkRewardSnapshot
+		if err := json.Unmarshal(line, &e); err != nil {
+			return err
 		}
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read rewards history: %w", err)
 	}
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("scan rewards history: %w", err)
+	if skipped > 0 {
+		slog.Warn("Skipped malformed rewards history lines", "path", s.historyPath, "skipped", skipped)
 	}
 	return entries, nil
 }
 
+// readJSONLines calls decode for every non-empty line of r. Lines that decode rejects are skipped and
+// counted, so one corrupt line neither aborts the read nor turns into a zero-valued entry.
+func readJSONLines(r io.Reader, decode func(line []byte) error) (skipped int, err error) {
+	// bufio.Reader rather than Scanner: validator history lines can exceed Scanner's token limit.
+	br := bufio.NewReader(r)
+	for lineNo := 1; ; lineNo++ {
+		line, readErr := br.ReadBytes('\n')
+		if b := bytes.TrimSpace(line); len(b) > 0 {
+			if err := decode(b); err != nil {
+				skipped++
+				slog.Debug("Skipping malformed history line", "line", lineNo, "error", err)
+			}
+		}
+		if errors.Is(readErr, io.EOF) {
+			return skipped, nil
+		}
+		if readErr != nil {
+			return skipped, readErr
+		}
+	}
+}
+
+// HistoryVersion returns a token that changes whenever the rewards history file is rewritten or appended.
+// It is empty when history is disabled or the file does not exist yet.
+func (s *Service) HistoryVersion() string {
+	if s.historyPath == "" {
+		return ""
+	}
+	s.historyMu.Lock()
+	defer s.historyMu.Unlock()
+
+	info, err := os.Stat(s.historyPath)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size())
+}
+
+// LatestSyncEpoch returns the highest epoch processed so far.
+func (s *Service) LatestSyncEpoch() uint64 {
+	s.cacheMux.RLock()
+	defer s.cacheMux.RUnlock()
+	return s.latestSyncEpoch
+}
+
+// ValidatorBalance returns the current balance (not effective balance) of a validator in gwei,
+// read from the beacon state at the latest synced epoch.
+func (s *Service) ValidatorBalance(index uint64) (uint64, error) {
+	slot := s.LatestSyncEpoch() * s.chain.SlotsPerEpoch
+	return s.beaconCL.Balance(slot, index)
+}
+
+// ClRewardRate returns a validator's average CL reward per epoch over the current cache window.
+// Only CL rewards are credited to the validator balance; EL rewards go to the fee recipient.
+// ok is false when the validator has no cached rewards or the window is empty.
+func (s *Service) ClRewardRate(index uint64) (gweiPerEpoch float64, ok bool) {
+	start, end := s.GetRewardWindow()
+	epochs := end.Sub(start).Seconds() / float64(s.chain.SecondsPerEpoch())
+	if epochs <= 0 {
+		return 0, false
+	}
+
+	s.cacheMux.RLock()
+	income, exists := s.cache[index]
+	var cl int64
+	if exists {
+		cl = income.TotalClRewards()
+	}
+	s.cacheMux.RUnlock()
+	if !exists {
+		return 0, false
+	}
+	return float64(cl) / epochs, true
+}
+
+// RewardsSnapshot is a copy of validator rewards taken under a single read lock, labelled with the
+// latest epoch merged into the cache at that moment so clients can tell whether two reads agree.
+type RewardsSnapshot struct {
+	Rewards          map[uint64]*ValidatorReward
+	AsOfEpoch        uint64
+	WindowStart      time.Time
+	WindowEnd        time.Time
+	WindowStartEpoch uint64
+	WindowEndEpoch   uint64
+	AprAvailable     bool
+}
+
 func (s *Service) GetTotalRewards(validatorIndices []uint64, effectiveBalances map[uint64]int64) map[uint64]*ValidatorReward {
+	return s.SnapshotRewards(validatorIndices, effectiveBalances).Rewards
+}
+
+// SnapshotRewards copies the rewards of the given validators together with the epoch and window they
+// cover. Everything is read under one lock, so no epoch can be merged between the rewards and labels.
+func (s *Service) SnapshotRewards(validatorIndices []uint64, effectiveBalances map[uint64]int64) RewardsSnapshot {
 	s.cacheMux.RLock()
 	defer s.cacheMux.RUnlock()
 
-	// use network snapshot for project APR calculation
-	snapshot := s.computeNetworkSnapshotLocked(time.Now())
+	// The project APR comes from the cached snapshot; it is only built here when an epoch was merged
+	// since.
+	snapshot := s.networkSnapshot.Load()
+	if snapshot == nil {
+		snapshot = s.recomputeNetworkRewardsLocked()
+	}
 
 	result := make(map[uint64]*ValidatorReward, len(validatorIndices))
 	for _, index := range validatorIndices {
-
-		income, exists := s.cache[index]
-		if !exists {
-			continue
+		if r := s.validatorRewardLocked(index, effectiveBalances[index], snapshot.ProjectAprPercent); r != nil {
+			result[index] = r
 		}
+	}
+	start, end := s.rewardWindowLocked()
+	startEpoch, endEpoch := s.windowEpochsLocked(start)
+	return RewardsSnapshot{
+		Rewards:          result,
+		AsOfEpoch:        s.latestSyncEpoch,
+		WindowStart:      start,
+		WindowEnd:        end,
+		WindowStartEpoch: startEpoch,
+		WindowEndEpoch:   endEpoch,
+		AprAvailable:     s.aprAvailable(start, end),
+	}
+}
 
-		cl := income.TotalClRewards()
-		elWei := weiBytesToBigInt(income.TxFeeRewardWei)
-		elGwei := new(big.Int).Div(elWei, gweiScalar).Int64()
+// CachedValidatorIndices returns the validators with rewards in the current window, in ascending order.
+// The set is sorted once per merged epoch and shared, so paged exports do not copy the cache keys on
+// every page; callers must not modify the result.
+func (s *Service) CachedValidatorIndices() []uint64 {
+	if indices := s.sortedIndices.Load(); indices != nil {
+		return *indices
+	}
+	s.cacheMux.RLock()
+	defer s.cacheMux.RUnlock()
+	indices := slices.Sorted(maps.Keys(s.cache))
+	// Stored under the read lock so a reset, which clears it under the write lock, cannot be undone
+	// by a set read from the closed window.
+	s.sortedIndices.Store(&indices)
+	return indices
+}
 
-		// Calc Total
-		totalGwei := cl + elGwei
-		bal := effectiveBalances[index]
+// ValidatorRewards is GetTotalRewards for bulk reads: the caller supplies the APR (e.g. from one
+// TotalNetworkRewards call) so no network snapshot is computed per batch. Results keep the order of
+// validatorIndices and skip validators without cached rewards.
+func (s *Service) ValidatorRewards(validatorIndices []uint64, effectiveBalances map[uint64]int64, aprPercent float64) []*ValidatorReward {
+	s.cacheMux.RLock()
+	defer s.cacheMux.RUnlock()
 
-		r := &ValidatorReward{
-			ValidatorIndex:       index,
-			ClRewardsGwei:        cl,
-			ElRewardsGwei:        elGwei,
-			TotalRewardsGwei:     totalGwei,
-			EffectiveBalanceGwei: bal,
+	result := make([]*ValidatorReward, 0, len(validatorIndices))
+	for _, index := range validatorIndices {
+		if r := s.validatorRewardLocked(index, effectiveBalances[index], aprPercent); r != nil {
+			result = append(result, r)
 		}
+	}
+	return result
+}
 
-		r.ProjectAPRPercent = snapshot.ProjectAprPercent
+// validatorRewardLocked builds the reward summary for one validator; caller must hold cacheMux.
+func (s *Service) validatorRewardLocked(index uint64, effectiveBalance int64, aprPercent float64) *ValidatorReward {
+	income, exists := s.cache[index]
+	if !exists {
+		return nil
+	}
 
-		result[index] = r
+	cl := income.TotalClRewards()
+	elWei := weiBytesToBigInt(income.TxFeeRewardWei)
+	elGwei := new(big.Int).Div(elWei, gweiScalar).Int64()
+
+	r := &ValidatorReward{
+		ValidatorIndex:       index,
+		ClRewardsGwei:        utils.Gwei(cl),
+		ElRewardsGwei:        utils.Gwei(elGwei),
+		TotalRewardsGwei:     utils.Gwei(cl + elGwei),
+		GrossClRewardsGwei:   utils.Gwei(grossClRewardsGwei(income)),
+		ClPenaltiesGwei:      utils.Gwei(clPenaltiesGwei(income)),
+		InactivityLeakGwei:   utils.Gwei(inactivityLeakGwei(income)),
+		EffectiveBalanceGwei: utils.Gwei(effectiveBalance),
+		ProjectAPRPercent:    aprPercent,
 	}
-	return result
+	balance := effectiveBalance
+	if balance <= 0 {
+		balance = s.config.DefaultEffectiveBalanceGwei
+	}
+	ideal := s.idealAttestation[index].total() * float64(balance/utils.GweiPerEth)
+	r.EfficiencyPercent = efficiencyPercent(attestationNetGwei(income), ideal)
+	if p := s.syncSlots[index]; p != nil {
+		r.SyncSlotsParticipated = p.Participated
+		r.SyncSlotsMissed = p.Missed
+	}
+	s.applyActivityLocked(r)
+	return r
 }
 
 func (s *Service) GetRewardWindow() (time.Time, time.Time) {
-
 	s.cacheMux.RLock()
-	latestEpoch := s.latestSyncEpoch
-	s.cacheMux.RUnlock()
+	defer s.cacheMux.RUnlock()
+	return s.rewardWindowLocked()
+}
+
+// rewardWindowLocked returns the current window; caller must hold cacheMux.
+func (s *Service) rewardWindowLocked() (time.Time, time.Time) {
 	start := s.cacheWindowStartTime().UTC()
-	end := utils.EpochToTime(latestEpoch)
+	return start, s.windowEndLocked(start)
+}
+
+// windowEndLocked returns the end of the newest synced epoch, clamped to start. Before the first
+// epoch is merged latestSyncEpoch is still zero, which must not read as "synced through the end of
+// epoch 0"; the window is empty then. Caller must hold cacheMux.
+func (s *Service) windowEndLocked(start time.Time) time.Time {
+	if s.latestSyncEpoch == 0 {
+		return start
+	}
+	end := s.chain.EpochToTime(s.latestSyncEpoch)
 	if end.Before(start) {
-		return start, start
+		return start
 	}
-	return start, end
+	return end
+}
+
+// windowEpochsLocked returns the epoch containing start, which is where the window's sync begins,
+// and the newest synced epoch clamped to it like windowEndLocked. Caller must hold cacheMux.
+func (s *Service) windowEpochsLocked(start time.Time) (uint64, uint64) {
+	first := s.chain.TimeToEpoch(start)
+	return first, max(s.latestSyncEpoch, first)
+}
+
+// AprAvailable reports whether the current window is long enough (MinAPRWindowSeconds) for a meaningful APR.
+func (s *Service) AprAvailable() bool {
+	return s.aprAvailable(s.GetRewardWindow())
+}
+
+func (s *Service) aprAvailable(start, end time.Time) bool {
+	observed := end.Sub(start)
+	return observed > 0 && observed >= time.Duration(s.config.MinAPRWindowSeconds)*time.Second
 }
 
 // computeNetworkSnapshotLocked aggregates rewards; caller must hold cacheMux.
 func (s *Service) computeNetworkSnapshotLocked(now time.Time) *NetworkRewardSnapshot {
+	return s.completeNetworkSnapshot(s.networkSnapshotTotalsLocked(), now)
+}
+
+// networkSnapshotTotals is the part of a network snapshot read from the cache. It is detached from
+// cacheMux so the Dora lookups that complete the snapshot can run without holding the lock.
+type networkSnapshotTotals struct {
+	snap              *NetworkRewardSnapshot
+	observed          time.Duration
+	attestationTotal  int64
+	idealPerIncrement float64
+	cacheSize         int
+	latestSyncEpoch   uint64
+}
+
+// networkSnapshotTotalsLocked sums the cached rewards of the current window; caller must hold cacheMux.
+func (s *Service) networkSnapshotTotalsLocked() networkSnapshotTotals {
 	start := s.cacheWindowStartTime().UTC()
-	end := utils.EpochToTime(s.latestSyncEpoch)
-	if end.Before(start) {
-		end = start
-	}
+	end := s.windowEndLocked(start)
+	startEpoch, endEpoch := s.windowEpochsLocked(start)
 	duration := end.Sub(start)
+	observed := duration
 	if duration <= 0 {
 		duration = s.config.CacheResetInterval
 		start = end.Add(-duration)
 	}
 
-	var clTotal int64
+	var clTotal, leakTotal, attestationTotal int64
 	elWei := big.NewInt(0)
 	for _, inc := range s.cache {
 		clTotal += inc.TotalClRewards()
+		leakTotal += inactivityLeakGwei(inc)
+		attestationTotal += attestationNetGwei(inc)
 		elWei.Add(elWei, weiBytesToBigInt(inc.TxFeeRewardWei))
 	}
 	elTotal := new(big.Int).Div(elWei, gweiScalar).Int64()
@@ -463,80 +1209,176 @@ func (s *Service) computeNetworkSnapshotLocked(now time.Time) *NetworkRewardSnap
 		WindowEnd:             end,
 		WindowDurationSeconds: duration.Seconds(),
 		ActiveValidatorCount:  len(s.cache),
-		ClRewardsGwei:         clTotal,
-		ElRewardsGwei:         elTotal,
-		TotalRewardsGwei:      clTotal + elTotal,
-	}
-
-	// Effective balance
-	if s.doraDB != nil {
-		// This db call can take time, potentially blocking the lock?
-		// Ideally we shouldn't hold lock over DB calls.
-		// But for simplicity in this refactor we keep it, as this only happens on cache reset/stats.
-		ctx, cancel := context.WithTimeout(s.ctx, s.config.RequestTimeout)
-		if count, err := s.doraDB.ActiveValidatorCount(ctx, utils.TimeToEpoch(now)); err == nil && count > 0 {
+		ClRewardsGwei:         utils.Gwei(clTotal),
+		ElRewardsGwei:         utils.Gwei(elTotal),
+		TotalRewardsGwei:      utils.Gwei(clTotal + elTotal),
+		InactivityLeakGwei:    utils.Gwei(leakTotal),
+		IncompleteEpochs:      len(s.incompleteEpochs),
+		WindowStartEpoch:      startEpoch,
+		WindowEndEpoch:        endEpoch,
+	}
+	return networkSnapshotTotals{
+		snap:              snap,
+		observed:          observed,
+		attestationTotal:  attestationTotal,
+		idealPerIncrement: s.idealPerIncrementTotal,
+		cacheSize:         len(s.cache),
+		latestSyncEpoch:   s.latestSyncEpoch,
+	}
+}
+
+// completeNetworkSnapshot adds effective balances from Dora, efficiency and APR to totals. It does
+// not read the cache, so callers need not hold cacheMux.
+func (s *Service) completeNetworkSnapshot(totals networkSnapshotTotals, now time.Time) *NetworkRewardSnapshot {
+	snap, observed := totals.snap, totals.observed
+	start := snap.WindowStart
+
+	if s.tracked != nil {
+		// Totals only cover TRACKED_VALIDATORS; network-wide Dora figures would skew APR.
+		snap.Scoped = true
+		snap.TrackedValidatorCount = len(s.tracked)
+		if s.doraDB != nil {
+			ctx, cancel := context.WithTimeout(s.ctx, s.config.DBQueryTimeout)
+			if balances, err := s.doraDB.EffectiveBalances(ctx, s.config.TrackedValidators); err == nil {
+				var eff int64
+				for _, b := range balances {
+					eff += b
+				}
+				snap.TotalEffectiveBalanceGwei = utils.Gwei(eff)
+			}
+			cancel()
+		}
+	} else if s.doraDB != nil {
+		ctx, cancel := context.WithTimeout(s.ctx, s.config.DBQueryTimeout)
+		if count, err := s.doraDB.ActiveValidatorCount(ctx, s.chain.TimeToEpoch(now)); err == nil && count > 0 {
 			snap.ActiveValidatorCount = int(count)
 		}
-		if eff, err := s.doraDB.TotalEffectiveBalance(ctx, utils.TimeToEpoch(now)); err == nil {
-			snap.TotalEffectiveBalanceGwei = eff
+		if eff, err := s.doraDB.TotalEffectiveBalance(ctx, s.chain.TimeToEpoch(now)); err == nil {
+			snap.TotalEffectiveBalanceGwei = utils.Gwei(eff)
+		}
+		if observed > 0 {
+			// Validators that joined or left mid-window only count for the epochs they were active.
+			weighted, err := s.doraDB.TimeWeightedEffectiveBalance(ctx, s.chain.TimeToEpoch(start), totals.latestSyncEpoch+1)
+			if err == nil {
+				snap.TimeWeightedEffectiveBalanceGwei = utils.Gwei(weighted)
+			}
 		}
 		cancel()
 	}
 
 	if snap.TotalEffectiveBalanceGwei == 0 {
-		snap.TotalEffectiveBalanceGwei = int64(len(s.cache)) * defaultEffectiveBalanceGwei
+		snap.TotalEffectiveBalanceGwei = utils.Gwei(int64(totals.cacheSize) * s.config.DefaultEffectiveBalanceGwei)
 	}
 
-	if snap.TotalEffectiveBalanceGwei > 0 && snap.WindowDurationSeconds > 0 {
-		apr := float64(snap.TotalRewardsGwei) / float64(snap.TotalEffectiveBalanceGwei)
-		apr *= s.config.CacheResetInterval.Seconds() / snap.WindowDurationSeconds
-		apr *= 100.0 * 365.0
-		snap.ProjectAprPercent = apr
+	effectiveBalance := snap.TotalEffectiveBalanceGwei
+	if snap.TimeWeightedEffectiveBalanceGwei > 0 {
+		effectiveBalance = snap.TimeWeightedEffectiveBalanceGwei
+	}
+	idealTotal := totals.idealPerIncrement * float64(effectiveBalance/utils.GweiPerEth)
+	snap.EfficiencyPercent = efficiencyPercent(totals.attestationTotal, idealTotal)
+
+	// A window that only spans a few minutes extrapolates to a meaningless APR; withhold it until
+	// enough time has been observed.
+	minWindow := time.Duration(s.config.MinAPRWindowSeconds) * time.Second
+	snap.AprAvailable = observed > 0 && observed >= minWindow
+
+	if snap.AprAvailable {
+		snap.SimpleAprPercent = s.projectAPR(snap.TotalRewardsGwei, snap.TotalEffectiveBalanceGwei, snap.WindowDurationSeconds)
+		snap.ProjectAprPercent = snap.SimpleAprPercent
+		if snap.TimeWeightedEffectiveBalanceGwei > 0 {
+			snap.ProjectAprPercent = s.projectAPR(snap.TotalRewardsGwei, snap.TimeWeightedEffectiveBalanceGwei, snap.WindowDurationSeconds)
+		}
 	}
 
 	return snap
 }
 
+// projectAPR annualises rewards earned over windowSeconds against balance.
+func (s *Service) projectAPR(rewards, balance utils.Gwei, windowSeconds float64) float64 {
+	if balance <= 0 || windowSeconds <= 0 {
+		return 0
+	}
+	apr := float64(rewards) / float64(balance)
+	apr *= s.config.CacheResetInterval.Seconds() / windowSeconds
+	apr *= 100.0 * 365.0
+	return apr
+}
+
+// epochRewards collects one epoch's results from the concurrent slot and attestation fetches.
+type epochRewards struct {
+	mu        sync.Mutex
+	income    map[uint64]*types.ValidatorEpochIncome
+	syncSlots map[uint64]*SyncParticipation
+	proposed  map[uint64]uint64 // Blocks proposed per validator.
+	// Validators with attestation rewards this epoch and the epoch's ideal reward per 1 ETH increment.
+	attesters         []uint64
+	idealPerIncrement idealReward
+	expectedAttesters int // Validators active this epoch; 0 when unknown.
+	// EL rewards by fee recipient and proposer; only filled when fee recipients are tracked.
+	feeRecipients map[feeRecipientKey][]byte
+	elBlocks      []ELBlockReward // EL tip of each block proposed this epoch.
+}
+
+func newEpochRewards() *epochRewards {
+	return &epochRewards{
+		income:    make(map[uint64]*types.ValidatorEpochIncome),
+		syncSlots: make(map[uint64]*SyncParticipation),
+		proposed:  make(map[uint64]uint64),
+
+		feeRecipients: make(map[feeRecipientKey][]byte),
+	}
+}
+
 // getRewardsForEpoch fetches rewards (Beacon + EL)
-func (s *Service) getRewardsForEpoch(epoch uint64) (map[uint64]*types.ValidatorEpochIncome, error) {
-	assigns, err := s.beaconCL.ProposerAssignments(epoch)
+func (s *Service) getRewardsForEpoch(epoch uint64) (*epochRewards, error) {
+	proposers, err := s.beaconCL.ProposerAssignmentsForEpoch(s.chain, epoch)
 	if err != nil {
 		return nil, err
 	}
 
-	proposers := make(map[uint64]uint64, len(assigns.Data))
-	for _, pa := range assigns.Data {
-		proposers[uint64(pa.Slot)] = uint64(pa.ValidatorIndex)
-	}
+	rewards := newEpochRewards()
 
-	rewards := make(map[uint64]*types.ValidatorEpochIncome)
-	var mu sync.Mutex
+	// The attestation and sync committee rewards APIs only exist from Altair on; before it only
+	// EL and block rewards are counted so backfill does not retry those epochs forever.
+	preAltair := epoch < s.config.AltairEpoch
+	if preAltair {
+		slog.Info("Epoch predates Altair; skipping attestation and sync committee rewards", "epoch", epoch, "altair_epoch", s.config.AltairEpoch)
+	}
 
 	g, _ := errgroup.WithContext(s.ctx)
 
 	// Fetch Slot Rewards (EL, Sync, Block)
-	slots := uint64(len(assigns.Data))
+	slots := s.chain.SlotsPerEpoch
 	startSlot := epoch * slots
+	fetchSync := !preAltair && (s.tracked == nil || s.syncCommitteeHasTracked(startSlot, slots))
 	for i := uint64(0); i < slots; i++ {
 		slot := startSlot + i
 		g.Go(func() error {
-			return s.processSlot(slot, proposers, rewards, &mu)
+			return s.processSlot(slot, proposers, rewards, fetchSync)
 		})
 	}
 
 	// Fetch Attestations
 	g.Go(func() error {
-		ar, err := s.beaconCL.AttestationRewards(epoch)
+		if preAltair {
+			return nil
+		}
+		// TrackedValidators is empty unless the deployment is scoped; empty requests every validator.
+		ar, err := s.beaconCL.AttestationRewardsFor(epoch, s.config.TrackedValidators)
 		if err != nil {
 			return err
 		}
-		mu.Lock()
-		defer mu.Unlock()
+		expected := s.expectedAttesters(epoch)
+		rewards.mu.Lock()
+		defer rewards.mu.Unlock()
+		rewards.expectedAttesters = expected
+		rewards.idealPerIncrement = idealRewardPerIncrement(ar.Data.IdealRewards)
 		for _, r := range ar.Data.TotalRewards {
-			e := s.getEntry(rewards, r.ValidatorIndex)
-			e.AttestationHeadReward = uint64(r.Head)
-			e.AttestationSourceReward = uint64(r.Source)
-			e.AttestationTargetReward = uint64(r.Target)
+			if !s.isTracked(r.ValidatorIndex) {
+				continue
+			}
+			applyAttestationRewards(s.getEntry(rewards.income, r.ValidatorIndex), r)
+			rewards.attesters = append(rewards.attesters, r.ValidatorIndex)
 		}
 		return nil
 	})
@@ -547,53 +1389,199 @@ func (s *Service) getRewardsForEpoch(epoch uint64) (map[uint64]*types.ValidatorE
 	return rewards, nil
 }
 
-func (s *Service) processSlot(slot uint64, proposers map[uint64]uint64, rewards map[uint64]*types.ValidatorEpochIncome, mu *sync.Mutex) error {
+// isTracked reports whether rewards for idx should be recorded.
+func (s *Service) isTracked(idx uint64) bool {
+	if s.tracked == nil {
+		return true
+	}
+	_, ok := s.tracked[idx]
+	return ok
+}
+
+// syncCommitteeHasTracked probes the epoch's sync committee rewards and reports whether any tracked
+// validator is a member. Membership only changes every sync committee period, so one successful
+// probe covers the whole epoch. If no slot can be probed it returns true so nothing is missed.
+func (s *Service) syncCommitteeHasTracked(startSlot, slots uint64) bool {
+	for slot := startSlot; slot < startSlot+slots; slot++ {
+		syncRew, err := s.beaconCL.SyncCommitteeRewards(slot)
+		if err != nil || syncRew == nil || len(syncRew.Data) == 0 {
+			continue
+		}
+		for _, r := range syncRew.Data {
+			if s.isTracked(r.ValidatorIndex) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func (s *Service) processSlot(slot uint64, proposers map[uint64]uint64, rewards *epochRewards, fetchSync bool) error {
 	proposer, ok := proposers[slot]
 	if !ok {
-		return fmt.Errorf("no proposer for slot %d", slot)
+		// Skipped/orphaned slots are normal; a gap here must not fail the whole epoch.
+		slog.Debug("No proposer for slot, skipping", "slot", slot)
+		return nil
+	}
+
+	if fetchSync {
+		s.processSyncCommitteeRewards(slot, rewards)
+	}
+
+	// EL and block rewards only ever credit the proposer.
+	if !s.isTracked(proposer) {
+		return nil
+	}
+
+	// A 404 from one endpoint while the other answers for the same slot means the node does not serve
+	// that endpoint, rather than that the slot was missed.
+	payload, blkErr := s.executionPayload(slot)
+	blkNum := payload.BlockNumber
+	blkRew, rewErr := s.beaconCL.BlockRewards(slot)
+	if blkErr == nil || rewErr == nil {
+		rewards.mu.Lock()
+		rewards.proposed[proposer]++
+		rewards.mu.Unlock()
 	}
 
 	// EL Rewards
-	blkNum, err := s.beaconCL.ExecutionBlockNumber(slot)
-	if err == nil {
-		if el, err := elrewards.GetELRewardForBlock(blkNum, *s.elClient); err == nil {
-			mu.Lock()
-			s.getEntry(rewards, proposer).TxFeeRewardWei = el.Bytes()
-			mu.Unlock()
-		}
-	} else if err == types.ErrBlockNotFound {
-		mu.Lock()
-		s.getEntry(rewards, proposer).ProposalsMissed++
-		mu.Unlock()
-	}
-
-	// Sync Committee
-	if syncRew, err := s.beaconCL.SyncCommitteeRewards(slot); err == nil && syncRew != nil {
-		mu.Lock()
-		for _, r := range syncRew.Data {
-			e := s.getEntry(rewards, r.ValidatorIndex)
-			if r.Reward > 0 {
-				e.SyncCommitteeReward += uint64(r.Reward)
-			} else {
-				e.SyncCommitteePenalty += uint64(-r.Reward)
+	switch {
+	case blkErr == nil:
+		if el, recipient, err := s.executionReward(blkNum); err == nil {
+			rewards.mu.Lock()
+			s.getEntry(rewards.income, proposer).TxFeeRewardWei = el.Bytes()
+			rewards.elBlocks = append(rewards.elBlocks, newELBlockReward(blkNum, slot, proposer, el))
+			rewards.mu.Unlock()
+			if s.trackFeeRecipients() {
+				if payload.FeeRecipient != "" {
+					recipient = payload.FeeRecipient
+				}
+				s.recordFeeRecipient(blkNum, recipient, proposer, el, rewards)
 			}
 		}
-		mu.Unlock()
+	case blkErr == types.ErrBlockNotFound && rewErr == nil:
+		s.markBlockEndpointsUnsupported(slot)
+	case blkErr == types.ErrBlockNotFound:
+		rewards.mu.Lock()
+		s.getEntry(rewards.income, proposer).ProposalsMissed++
+		rewards.mu.Unlock()
 	}
 
 	// Block Inclusion
-	if blkRew, err := s.beaconCL.BlockRewards(slot); err == nil {
-		mu.Lock()
-		e := s.getEntry(rewards, blkRew.Data.ProposerIndex)
+	if rewErr == nil {
+		rewards.mu.Lock()
+		e := s.getEntry(rewards.income, blkRew.Data.ProposerIndex)
 		e.ProposerAttestationInclusionReward += blkRew.Data.Attestations
 		e.ProposerSlashingInclusionReward += blkRew.Data.AttesterSlashings + blkRew.Data.ProposerSlashings
 		e.ProposerSyncInclusionReward += blkRew.Data.SyncAggregate
-		mu.Unlock()
+		rewards.mu.Unlock()
+	} else if rewErr == types.ErrBlockNotFound && blkErr == nil {
+		s.markEndpointUnsupported(endpointBlockRewards, slot)
 	}
 
 	return nil
 }
 
+// processSyncCommitteeRewards records sync committee rewards for a proposed slot. A positive reward
+// means the member's signature was included (participated); a negative one means it was missing.
+func (s *Service) processSyncCommitteeRewards(slot uint64, rewards *epochRewards) {
+	syncRew, err := s.beaconCL.SyncCommitteeRewards(slot)
+	if err == types.ErrBlockNotFound {
+		// Only probe the block on 404s, which are rare (missed slots) on a supporting node.
+		if _, blockErr := s.executionBlockNumber(slot); blockErr == nil {
+			s.markEndpointUnsupported(endpointSyncCommitteeRewards, slot)
+		}
+		return
+	}
+	if err != nil || syncRew == nil {
+		return
+	}
+	rewards.mu.Lock()
+	defer rewards.mu.Unlock()
+	for _, r := range syncRew.Data {
+		if !s.isTracked(r.ValidatorIndex) {
+			continue
+		}
+		e := s.getEntry(rewards.income, r.ValidatorIndex)
+		p := rewards.syncSlots[r.ValidatorIndex]
+		if p == nil {
+			p = &SyncParticipation{}
+			rewards.syncSlots[r.ValidatorIndex] = p
+		}
+		if r.Reward > 0 {
+			e.SyncCommitteeReward += uint64(r.Reward)
+			p.Participated++
+		} else {
+			e.SyncCommitteePenalty += uint64(-r.Reward)
+			if r.Reward < 0 {
+				p.Missed++
+			}
+		}
+	}
+}
+
+// validateProposerAssignments rejects duty responses that are structurally unusable.
+// The beacon node is expected to return one assignment per slot of the epoch (including slots
+// that end up skipped); a partial list would leave slots without a proposer, so it is an error and
+// the epoch is retried rather than processed with missing block rewards.
+func validateProposerAssignments(chain utils.ChainConfig, epoch uint64, assigns *types.EpochProposerAssignmentsApiResponse) error {
+	if assigns == nil || len(assigns.Data) == 0 {
+		return fmt.Errorf("no proposer assignments for epoch %d", epoch)
+	}
+	if n := uint64(len(assigns.Data)); n != chain.SlotsPerEpoch {
+		return fmt.Errorf("got %d proposer assignments for epoch %d, want %d", n, epoch, chain.SlotsPerEpoch)
+	}
+	firstSlot := epoch * chain.SlotsPerEpoch
+	lastSlot := firstSlot + chain.SlotsPerEpoch - 1
+	for _, pa := range assigns.Data {
+		if pa == nil || pa.Slot < 0 || uint64(pa.Slot) < firstSlot || uint64(pa.Slot) > lastSlot {
+			return fmt.Errorf("malformed proposer assignments for epoch %d", epoch)
+		}
+	}
+	return nil
+}
+
+// applyAttestationRewards records attestation rewards, routing negative source/target values
+// (missed or late votes, amplified during an inactivity leak) into the penalty fields.
+func applyAttestationRewards(e *types.ValidatorEpochIncome, r *types.TotalAttestationRewardsContainer) {
+	if r.Head > 0 {
+		e.AttestationHeadReward = uint64(r.Head)
+	}
+	if r.Source >= 0 {
+		e.AttestationSourceReward = uint64(r.Source)
+	} else {
+		e.AttestationSourcePenalty = uint64(-r.Source)
+	}
+	if r.Target >= 0 {
+		e.AttestationTargetReward = uint64(r.Target)
+	} else {
+		e.AttestationTargetPenalty = uint64(-r.Target)
+	}
+}
+
+// inactivityLeakGwei returns the finality-delay and attestation penalties as a negative amount.
+// TotalClRewards already nets these in; this surfaces them separately for non-finality periods.
+func inactivityLeakGwei(income *types.ValidatorEpochIncome) int64 {
+	if income == nil {
+		return 0
+	}
+	return -int64(income.FinalityDelayPenalty + income.AttestationSourcePenalty + income.AttestationTargetPenalty)
+}
+
+// grossClRewardsGwei sums the CL reward components of income, mirroring TotalClRewards without penalties.
+func grossClRewardsGwei(income *types.ValidatorEpochIncome) int64 {
+	return int64(income.AttestationSourceReward + income.AttestationTargetReward + income.AttestationHeadReward +
+		income.ProposerSlashingInclusionReward + income.ProposerAttestationInclusionReward +
+		income.ProposerSyncInclusionReward + income.SyncCommitteeReward + income.SlashingReward)
+}
+
+// clPenaltiesGwei sums the CL penalty components of income that TotalClRewards subtracts.
+func clPenaltiesGwei(income *types.ValidatorEpochIncome) int64 {
+	return int64(income.AttestationSourcePenalty + income.AttestationTargetPenalty + income.FinalityDelayPenalty +
+		income.SyncCommitteePenalty + income.SlashingPenalty)
+}
+
 func (s *Service) getEntry(m map[uint64]*types.ValidatorEpochIncome, idx uint64) *types.ValidatorEpochIncome {
 	if m[idx] == nil {
 		m[idx] = &types.ValidatorEpochIncome{}
@@ -601,22 +1589,89 @@ func (s *Service) getEntry(m map[uint64]*types.ValidatorEpochIncome, idx uint64)
 	return m[idx]
 }
 
+// persistSnapshot appends snap to the history file. Restarts and forced resets can close several
+// windows on the same day; the 31-day average assumes one snapshot per day, so a snapshot whose window
+// starts on the same day as the last entry replaces it instead.
 func (s *Service) persistSnapshot(snap *NetworkRewardSnapshot) {
 	if s.historyPath == "" || snap == nil {
 		return
 	}
 	s.historyMu.Lock()
 	defer s.historyMu.Unlock()
+
+	entries, err := s.readHistoryLocked()
+	if err != nil {
+		slog.Warn("Failed to read rewards history; appending snapshot", "path", s.historyPath, "error", err)
+	}
+	if n := len(entries); n > 0 && sameWindowDay(entries[n-1].WindowStart, snap.WindowStart) {
+		entries[n-1] = *snap
+		if err := s.writeHistoryLocked(entries); err != nil {
+			slog.Error("Failed to rewrite rewards history file", "path", s.historyPath, "error", err)
+		}
+		return
+	}
+	// A gzip stream cannot be extended in place, so a compressed history is rewritten whole. At one
+	// line per day that stays cheap, and the file is compressed as a single stream. After a failed
+	// read the entries are incomplete; appending keeps whatever the file still holds instead.
+	if historyGzipped(s.historyPath) && err == nil {
+		if err := s.writeHistoryLocked(append(entries, *snap)); err != nil {
+			slog.Error("Failed to rewrite rewards history file", "path", s.historyPath, "error", err)
+		}
+		return
+	}
+
 	_ = os.MkdirAll(filepath.Dir(s.historyPath), 0o755)
 	f, err := os.OpenFile(s.historyPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
 	if err != nil {
 		slog.Error("Failed to open rewards history file", "path", s.historyPath, "error", err)
 		return
 	}
-	_ = json.NewEncoder(f).Encode(snap)
+	if historyGzipped(s.historyPath) {
+		// Appended as a separate gzip member, which readers decode as part of the same stream.
+		gz := gzip.NewWriter(f)
+		_ = json.NewEncoder(gz).Encode(snap)
+		_ = gz.Close()
+	} else {
+		_ = json.NewEncoder(f).Encode(snap)
+	}
 	_ = f.Close()
 }
 
+// historyGzipped reports whether a history file is gzip-compressed, which is chosen by a .gz suffix.
+func historyGzipped(path string) bool {
+	return strings.HasSuffix(path, ".gz")
+}
+
+// writeHistoryLocked replaces the history file atomically; caller must hold historyMu.
+func (s *Service) writeHistoryLocked(entries []NetworkRewardSnapshot) error {
+	var buf bytes.Buffer
+	var w io.Writer = &buf
+	var gz *gzip.Writer
+	if historyGzipped(s.historyPath) {
+		gz = gzip.NewWriter(&buf)
+		w = gz
+	}
+	enc := json.NewEncoder(w)
+	for i := range entries {
+		if err := enc.Encode(&entries[i]); err != nil {
+			return err
+		}
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return err
+		}
+	}
+	return writeFileAtomic(s.historyPath, buf.Bytes())
+}
+
+// sameWindowDay reports whether a and b fall on the same calendar day in windowLocation.
+func sameWindowDay(a, b time.Time) bool {
+	ay, am, ad := a.In(windowLocation).Date()
+	by, bm, bd := b.In(windowLocation).Date()
+	return ay == by && am == bm && ad == bd
+}
+
 func (s *Service) setCacheWindowStart(t time.Time) {
 	s.cacheWindowMu.Lock()
 	s.cacheWindowStart = t
@@ -3,18 +3,33 @@ package rewards
 import (
 	"bufio"
 	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
 	"math"
 	"math/big"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"beacon-rewards/internal/config"
+	"beacon-rewards/internal/dora"
 	"beacon-rewards/internal/utils"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gobitfly/eth-rewards/types"
 )
 
@@ -32,7 +47,7 @@ func TestTotalNetworkRewards(t *testing.T) {
 		AttestationSourceReward: 64,
 	}
 	svc.cache[1].TxFeeRewardWei = new(big.Int).Mul(big.NewInt(5), gweiScalar).Bytes()
-	currentEpoch := utils.TimeToEpoch(time.Now())
+	currentEpoch := utils.DefaultChain().TimeToEpoch(time.Now())
 	svc.latestSyncEpoch = currentEpoch
 	svc.cacheMux.Unlock()
 
@@ -49,11 +64,18 @@ func TestTotalNetworkRewards(t *testing.T) {
 	if snapshot.TotalRewardsGwei != 69 {
 		t.Fatalf("unexpected total rewards: %d", snapshot.TotalRewardsGwei)
 	}
-	if snapshot.TotalEffectiveBalanceGwei != defaultEffectiveBalanceGwei {
+	if int64(snapshot.TotalEffectiveBalanceGwei) != cfg.DefaultEffectiveBalanceGwei {
 		t.Fatalf("unexpected effective balance: %d", snapshot.TotalEffectiveBalanceGwei)
 	}
 
-	expectedEnd := utils.EpochToTime(currentEpoch)
+	if snapshot.WindowStartEpoch != utils.DefaultChain().TimeToEpoch(windowStart) || snapshot.WindowEndEpoch != currentEpoch {
+		t.Fatalf("window epochs = [%d, %d], want [%d, %d]", snapshot.WindowStartEpoch, snapshot.WindowEndEpoch, utils.DefaultChain().TimeToEpoch(windowStart), currentEpoch)
+	}
+	if rs := svc.SnapshotRewards(nil, nil); rs.WindowStartEpoch != snapshot.WindowStartEpoch || rs.WindowEndEpoch != currentEpoch {
+		t.Fatalf("rewards snapshot window epochs = [%d, %d], want the network snapshot's", rs.WindowStartEpoch, rs.WindowEndEpoch)
+	}
+
+	expectedEnd := utils.DefaultChain().EpochToTime(currentEpoch)
 	expectedDuration := expectedEnd.Sub(windowStart).Seconds()
 	if math.Abs(snapshot.WindowDurationSeconds-expectedDuration) > 2 {
 		t.Fatalf("duration mismatch: got %f want %f", snapshot.WindowDurationSeconds, expectedDuration)
@@ -66,9 +88,86 @@ func TestTotalNetworkRewards(t *testing.T) {
 	}
 }
 
-func TestCacheResetTimer(t *testing.T) {
+func TestNextWindowPersistAppliesJitter(t *testing.T) {
 	cfg := config.DefaultConfig()
 	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.CacheResetJitter = 5 * time.Minute
+
+	current := time.Date(2024, 1, 1, 15, 0, 0, 0, windowLocation)
+	midnight := time.Date(2024, 1, 2, 0, 0, 0, 0, windowLocation)
+	svc := NewService(cfg)
+	next := svc.nextWindowPersist(current)
+	if next.Before(midnight) || next.After(midnight.Add(cfg.CacheResetJitter)) {
+		t.Fatalf("next persist = %s, want within [%s, %s]", next, midnight, midnight.Add(cfg.CacheResetJitter))
+	}
+	// The offset is drawn once per process, so every day's persistence uses the same one.
+	if again := svc.nextWindowPersist(current.Add(24 * time.Hour)); again.Sub(next) != 24*time.Hour {
+		t.Fatalf("next persist a day later = %s, want %s", again, next.Add(24*time.Hour))
+	}
+}
+
+func TestCacheResetJitterOnlyDelaysPersistence(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(dir, "history.jsonl")
+	cfg.ValidatorHistoryFile = filepath.Join(dir, "validator_history.jsonl")
+	cfg.AddressHistoryFile = filepath.Join(dir, "address_history.jsonl")
+	cfg.FineHistoryFile = filepath.Join(dir, "fine.jsonl")
+	cfg.FailedEpochsFile = filepath.Join(dir, "failed_epochs.json")
+	cfg.CacheCheckpointFile = filepath.Join(dir, "checkpoint.json")
+	cfg.CacheResetJitter = 5 * time.Minute
+
+	current := time.Date(2024, 1, 1, 15, 0, 0, 0, windowLocation)
+	midnight := time.Date(2024, 1, 2, 0, 0, 0, 0, windowLocation)
+	svc := NewService(cfg)
+	if next := svc.nextCacheReset(current); !next.Equal(midnight) {
+		t.Fatalf("next reset = %s, want %s regardless of jitter", next, midnight)
+	}
+
+	// Fire the timer just before midnight with a short jitter: the window turns over at midnight
+	// right away, while the closed window reaches the history file only after the jitter.
+	svc.resetJitter = 200 * time.Millisecond
+	svc.cacheMux.Lock()
+	svc.cache[1] = &types.ValidatorEpochIncome{AttestationHeadReward: 10}
+	svc.cacheMux.Unlock()
+	var calls atomic.Int32
+	go svc.cacheResetTimerWithClock(func() time.Time {
+		if calls.Add(1) == 1 {
+			return midnight.Add(-10 * time.Millisecond)
+		}
+		return midnight.Add(time.Hour)
+	})
+	defer svc.cancel()
+
+	deadline := time.Now().Add(time.Second)
+	for svc.GetRewards([]uint64{1})[1] != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("window was not closed at midnight")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if start, _ := svc.GetRewardWindow(); !start.Equal(midnight) {
+		t.Fatalf("new window starts at %s, want %s", start, midnight)
+	}
+	if history, _ := svc.NetworkRewardHistory(); len(history) != 0 {
+		t.Fatalf("history written before the jitter elapsed: %+v", history)
+	}
+	for {
+		if history, _ := svc.NetworkRewardHistory(); len(history) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("closed window was not persisted after the jitter")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestCacheResetTimer(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(dir, "history.jsonl")
+	cfg.ValidatorHistoryFile = filepath.Join(dir, "validator_history.jsonl")
 	svc := NewService(cfg)
 
 	// Freeze time near midnight UTC+8 so the reset triggers quickly, then advance on subsequent calls
@@ -87,7 +186,7 @@ func TestCacheResetTimer(t *testing.T) {
 	svc.cache[42] = &types.ValidatorEpochIncome{
 		AttestationSourceReward: 10,
 	}
-	svc.latestSyncEpoch = utils.TimeToEpoch(base)
+	svc.latestSyncEpoch = utils.DefaultChain().TimeToEpoch(base)
 	svc.cacheMux.Unlock()
 
 	done := make(chan struct{})
@@ -151,7 +250,7 @@ func TestCacheResetTimer(t *testing.T) {
 	latestEpoch := svc.latestSyncEpoch
 	svc.cacheMux.RUnlock()
 
-	expectedEpoch := utils.TimeToEpoch(base)
+	expectedEpoch := utils.DefaultChain().TimeToEpoch(base)
 	if latestEpoch != expectedEpoch {
 		t.Fatalf("latestSyncEpoch should be preserved, got %d want %d", latestEpoch, expectedEpoch)
 	}
@@ -169,8 +268,9 @@ func TestStartEpochUsesLookback(t *testing.T) {
 	cfg.BackfillLookback = 6 * time.Hour
 	svc := NewService(cfg)
 
-	now := time.Unix(utils.GenesisTimestamp(), 0).Add(time.Duration(100*utils.SECONDS_PER_EPOCH) * time.Second)
-	expected := utils.TimeToEpoch(now.Add(-cfg.BackfillLookback))
+	chain := svc.Chain()
+	now := chain.EpochStartTime(100)
+	expected := chain.TimeToEpoch(now.Add(-cfg.BackfillLookback))
 
 	if got := svc.startEpoch(now); got != expected {
 		t.Fatalf("start epoch from lookback mismatch: got %d want %d", got, expected)
@@ -183,28 +283,2098 @@ func TestStartEpochDefaultsToCacheWindow(t *testing.T) {
 	cfg.BackfillLookback = 0
 	svc := NewService(cfg)
 
-	windowStart := time.Unix(utils.GenesisTimestamp(), 0).Add(time.Duration(20*utils.SECONDS_PER_EPOCH) * time.Second)
+	windowStart := svc.Chain().EpochStartTime(20)
 	svc.setCacheWindowStart(windowStart)
 
 	now := windowStart.Add(time.Hour)
-	expected := utils.TimeToEpoch(windowStart)
+	expected := svc.Chain().TimeToEpoch(windowStart)
 
 	if got := svc.startEpoch(now); got != expected {
 		t.Fatalf("default start epoch mismatch: got %d want %d", got, expected)
 	}
 }
 
-func TestNetworkRewardHistoryScannerError(t *testing.T) {
+func TestNetworkRewardHistorySkipsMalformedLines(t *testing.T) {
 	cfg := config.DefaultConfig()
 	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
 	svc := NewService(cfg)
 
-	oversizedLine := strings.Repeat("x", bufio.MaxScanTokenSize+16)
-	if err := os.WriteFile(cfg.RewardsHistoryFile, []byte(oversizedLine+"\n"), 0o644); err != nil {
+	lines := []string{
+		`{"window_start":"2025-01-01T00:00:00Z","total_rewards_gwei":100}`,
+		`not json`,
+		`{"window_start":"2025-01-02T00:00:00Z","total_rewards_gwei":`,
+		strings.Repeat("x", bufio.MaxScanTokenSize+16),
+		``,
+		`{"window_start":"2025-01-03T00:00:00Z","total_rewards_gwei":300}`,
+	}
+	if err := os.WriteFile(cfg.RewardsHistoryFile, []byte(strings.Join(lines, "\n")), 0o644); err != nil {
 		t.Fatalf("failed to write history file: %v", err)
 	}
 
-	if _, err := svc.NetworkRewardHistory(); err == nil {
-		t.Fatalf("expected scanner error for oversized history line")
+	history, err := svc.NetworkRewardHistory()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(history) != 2 || history[0].TotalRewardsGwei != 100 || history[1].TotalRewardsGwei != 300 {
+		t.Fatalf("expected only the two valid snapshots, got %+v", history)
+	}
+
+	var decoded int
+	skipped, err := readJSONLines(strings.NewReader(strings.Join(lines, "\n")), func(line []byte) error {
+		var e NetworkRewardSnapshot
+		if err := json.Unmarshal(line, &e); err != nil {
+			return err
+		}
+		decoded++
+		return nil
+	})
+	if err != nil || skipped != 3 || decoded != 2 {
+		t.Fatalf("readJSONLines = (skipped %d, decoded %d, %v), want 3 skipped and 2 decoded", skipped, decoded, err)
+	}
+}
+
+func TestNetworkRewardHistoryRoundTrip(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	svc := NewService(cfg)
+
+	want := NetworkRewardSnapshot{
+		WindowStart:                      time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		WindowEnd:                        time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC),
+		WindowDurationSeconds:            86400,
+		ActiveValidatorCount:             12,
+		ClRewardsGwei:                    100,
+		ElRewardsGwei:                    50,
+		TotalRewardsGwei:                 150,
+		TotalEffectiveBalanceGwei:        384_000_000_000,
+		TimeWeightedEffectiveBalanceGwei: 380_000_000_000,
+		InactivityLeakGwei:               3,
+		EfficiencyPercent:                99.5,
+		ProjectAprPercent:                3.25,
+		SimpleAprPercent:                 3.2,
+		AprAvailable:                     true,
+		Scoped:                           true,
+		TrackedValidatorCount:            12,
+		IncompleteEpochs:                 2,
+		WindowStartEpoch:                 100,
+		WindowEndEpoch:                   325,
+		FromHistory:                      true,
+	}
+	// Every field is set, so a field added without a JSON round trip shows up here.
+	v := reflect.ValueOf(want)
+	for i := 0; i < v.NumField(); i++ {
+		if v.Field(i).IsZero() {
+			t.Fatalf("fixture leaves %s zero; set it so the round trip covers it", v.Type().Field(i).Name)
+		}
+	}
+
+	svc.historyMu.Lock()
+	err := svc.writeHistoryLocked([]NetworkRewardSnapshot{want})
+	svc.historyMu.Unlock()
+	if err != nil {
+		t.Fatalf("writeHistoryLocked: %v", err)
+	}
+	history, err := svc.NetworkRewardHistory()
+	if err != nil {
+		t.Fatalf("NetworkRewardHistory: %v", err)
+	}
+	if len(history) != 1 || !reflect.DeepEqual(history[0], want) {
+		t.Fatalf("round trip = %+v, want %+v", history, want)
+	}
+}
+
+func TestTotalNetworkRewardsServesHistoryWhileWarming(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	now := time.Now().In(windowLocation)
+	first := utils.DefaultChain().TimeToEpoch(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, windowLocation))
+	stored := NetworkRewardSnapshot{TotalRewardsGwei: 5000, ProjectAprPercent: 3.1, WindowStartEpoch: first, WindowEndEpoch: first + 50}
+	line, _ := json.Marshal(stored)
+	if err := os.WriteFile(cfg.RewardsHistoryFile, append(line, '\n'), 0o644); err != nil {
+		t.Fatalf("write history: %v", err)
+	}
+
+	off := *cfg
+	off.WarmupFromHistory = false
+	if snap := NewService(&off).TotalNetworkRewards(); snap.FromHistory {
+		t.Fatal("served the stored snapshot with WARMUP_FROM_HISTORY off")
+	}
+
+	svc := NewService(cfg)
+	if snap := svc.TotalNetworkRewards(); !snap.FromHistory || snap.TotalRewardsGwei != 5000 {
+		t.Fatalf("warming snapshot = %+v, want the stored one flagged from_history", snap)
+	}
+
+	// Live sync behind the stored window still serves it.
+	svc.setSyncPhase(SyncPhaseLive, 0, 0)
+	svc.cacheMux.Lock()
+	svc.latestSyncEpoch = first + 10
+	svc.cacheMux.Unlock()
+	if snap := svc.RecomputeNetworkRewards(); snap.FromHistory {
+		t.Fatal("RecomputeNetworkRewards returned the stored snapshot")
+	}
+	if snap := svc.TotalNetworkRewards(); !snap.FromHistory {
+		t.Fatal("stopped serving the stored snapshot before live sync passed it")
+	}
+
+	svc.cacheMux.Lock()
+	svc.latestSyncEpoch = first + 50
+	svc.cacheMux.Unlock()
+	svc.RecomputeNetworkRewards()
+	if snap := svc.TotalNetworkRewards(); snap.FromHistory {
+		t.Fatal("still serving the stored snapshot after live sync caught up")
+	}
+	// Once caught up it is dropped for good, even if the phase changes again.
+	svc.setSyncPhase(SyncPhaseBackfill, 0, 0)
+	if snap := svc.TotalNetworkRewards(); snap.FromHistory {
+		t.Fatal("stored snapshot came back after warm-up ended")
+	}
+}
+
+func TestGzipNetworkRewardHistory(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl.gz")
+	svc := NewService(cfg)
+
+	day := time.Date(2025, 3, 1, 0, 0, 0, 0, windowLocation)
+	svc.persistSnapshot(&NetworkRewardSnapshot{WindowStart: day, TotalRewardsGwei: 1})
+	svc.persistSnapshot(&NetworkRewardSnapshot{WindowStart: day.Add(24 * time.Hour), TotalRewardsGwei: 2})
+	svc.persistSnapshot(&NetworkRewardSnapshot{WindowStart: day.Add(30 * time.Hour), TotalRewardsGwei: 3}) // Same day: replaces 2.
+
+	raw, err := os.ReadFile(cfg.RewardsHistoryFile)
+	if err != nil {
+		t.Fatalf("read history file: %v", err)
+	}
+	if len(raw) < 2 || raw[0] != 0x1f || raw[1] != 0x8b {
+		t.Fatalf("history file does not start with the gzip magic: % x", raw[:min(len(raw), 4)])
+	}
+
+	// A member appended after a failed read is decoded as part of the same stream.
+	f, err := os.OpenFile(cfg.RewardsHistoryFile, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatalf("open history file: %v", err)
+	}
+	gz := gzip.NewWriter(f)
+	_, _ = gz.Write([]byte(`{"total_rewards_gwei":4}` + "\n"))
+	_ = gz.Close()
+	_ = f.Close()
+
+	history, err := svc.NetworkRewardHistory()
+	if err != nil {
+		t.Fatalf("NetworkRewardHistory: %v", err)
+	}
+	var totals []utils.Gwei
+	for _, e := range history {
+		totals = append(totals, e.TotalRewardsGwei)
+	}
+	if !slices.Equal(totals, []utils.Gwei{1, 3, 4}) {
+		t.Fatalf("history totals = %v, want [1 3 4]", totals)
+	}
+}
+
+func TestApplyAttestationRewardsRoutesPenalties(t *testing.T) {
+	income := &types.ValidatorEpochIncome{}
+	applyAttestationRewards(income, &types.TotalAttestationRewardsContainer{
+		Head:   5,
+		Source: -7,
+		Target: 11,
+	})
+
+	if income.AttestationHeadReward != 5 || income.AttestationTargetReward != 11 {
+		t.Fatalf("unexpected rewards: head=%d target=%d", income.AttestationHeadReward, income.AttestationTargetReward)
+	}
+	if income.AttestationSourceReward != 0 || income.AttestationSourcePenalty != 7 {
+		t.Fatalf("negative source should be a penalty: reward=%d penalty=%d", income.AttestationSourceReward, income.AttestationSourcePenalty)
+	}
+}
+
+func TestInactivityLeakReported(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	svc := NewService(cfg)
+	t.Cleanup(svc.Stop)
+
+	svc.cacheMux.Lock()
+	svc.cache[1] = &types.ValidatorEpochIncome{
+		AttestationHeadReward:    100,
+		AttestationSourcePenalty: 10,
+		AttestationTargetPenalty: 20,
+		FinalityDelayPenalty:     30,
+	}
+	svc.cache[2] = &types.ValidatorEpochIncome{
+		AttestationHeadReward: 50,
+	}
+	svc.latestSyncEpoch = utils.DefaultChain().TimeToEpoch(time.Now())
+	svc.cacheMux.Unlock()
+
+	rewards := svc.GetTotalRewards([]uint64{1, 2}, nil)
+	if got := rewards[1].InactivityLeakGwei; got != -60 {
+		t.Fatalf("validator 1 inactivity leak = %d, want -60", got)
+	}
+	if got := rewards[1].ClRewardsGwei; got != 40 {
+		t.Fatalf("validator 1 net CL rewards = %d, want 40", got)
+	}
+	if got := rewards[2].InactivityLeakGwei; got != 0 {
+		t.Fatalf("validator 2 inactivity leak = %d, want 0", got)
+	}
+
+	if got := svc.TotalNetworkRewards().InactivityLeakGwei; got != -60 {
+		t.Fatalf("snapshot inactivity leak = %d, want -60", got)
+	}
+}
+
+func TestGrossRewardsMinusPenaltiesEqualsNet(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	svc := NewService(cfg)
+	t.Cleanup(svc.Stop)
+
+	svc.cacheMux.Lock()
+	svc.cache[1] = &types.ValidatorEpochIncome{
+		AttestationSourceReward:            10,
+		AttestationTargetReward:            20,
+		AttestationHeadReward:              30,
+		ProposerSlashingInclusionReward:    1,
+		ProposerAttestationInclusionReward: 2,
+		ProposerSyncInclusionReward:        3,
+		SyncCommitteeReward:                40,
+		SlashingReward:                     4,
+		AttestationSourcePenalty:           5,
+		AttestationTargetPenalty:           6,
+		FinalityDelayPenalty:               7,
+		SyncCommitteePenalty:               8,
+		SlashingPenalty:                    200,
+	}
+	svc.cacheMux.Unlock()
+
+	r := svc.GetTotalRewards([]uint64{1}, nil)[1]
+	if r.GrossClRewardsGwei != 110 {
+		t.Fatalf("gross CL rewards = %d, want 110", r.GrossClRewardsGwei)
+	}
+	if r.ClPenaltiesGwei != 226 {
+		t.Fatalf("CL penalties = %d, want 226", r.ClPenaltiesGwei)
+	}
+	if r.GrossClRewardsGwei-r.ClPenaltiesGwei != r.ClRewardsGwei {
+		t.Fatalf("gross %d - penalties %d != net %d", r.GrossClRewardsGwei, r.ClPenaltiesGwei, r.ClRewardsGwei)
+	}
+}
+
+func TestProcessSlotSkipsMissingProposer(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	svc := NewService(cfg)
+	t.Cleanup(svc.Stop)
+
+	// Sparse proposer map: slot 65 has no entry (e.g. an orphaned slot).
+	proposers := map[uint64]uint64{64: 1, 66: 2}
+	rewards := newEpochRewards()
+
+	if err := svc.processSlot(65, proposers, rewards, true); err != nil {
+		t.Fatalf("missing proposer should not be an error, got %v", err)
+	}
+	if len(rewards.income) != 0 {
+		t.Fatalf("expected no rewards recorded for skipped slot, got %d", len(rewards.income))
+	}
+}
+
+// fullProposerAssignments returns one assignment per slot of epoch.
+func fullProposerAssignments(epoch uint64) *types.EpochProposerAssignmentsApiResponse {
+	slots := utils.DefaultChain().SlotsPerEpoch
+	assigns := &types.EpochProposerAssignmentsApiResponse{}
+	for slot := epoch * slots; slot < (epoch+1)*slots; slot++ {
+		assigns.Data = append(assigns.Data, &types.EpochProposerAssignmentsContainer{Slot: int64(slot), ValidatorIndex: int64(slot)})
+	}
+	return assigns
+}
+
+func TestValidateProposerAssignments(t *testing.T) {
+	valid := fullProposerAssignments(2)
+	if err := validateProposerAssignments(utils.DefaultChain(), 2, valid); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := validateProposerAssignments(utils.DefaultChain(), 2, &types.EpochProposerAssignmentsApiResponse{}); err == nil {
+		t.Fatalf("expected error for empty assignments")
+	}
+
+	outOfRange := &types.EpochProposerAssignmentsApiResponse{
+		Data: []*types.EpochProposerAssignmentsContainer{{Slot: 96, ValidatorIndex: 1}},
+	}
+	if err := validateProposerAssignments(utils.DefaultChain(), 2, outOfRange); err == nil {
+		t.Fatalf("expected error for slot outside epoch")
+	}
+
+	truncated := fullProposerAssignments(2)
+	truncated.Data = truncated.Data[:2]
+	if err := validateProposerAssignments(utils.DefaultChain(), 2, truncated); err == nil {
+		t.Fatalf("expected error for a partial assignment list")
+	}
+}
+
+func TestGetRewardsForEpochRejectsTruncatedAssignments(t *testing.T) {
+	var other atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/eth/v1/validator/duties/proposer/2" {
+			_, _ = w.Write([]byte(`{"data":[{"slot":"64","validator_index":"1"},{"slot":"65","validator_index":"2"}]}`))
+			return
+		}
+		other.Add(1)
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.BeaconNodeURL = srv.URL
+	svc := NewService(cfg)
+
+	if _, err := svc.getRewardsForEpoch(2); err == nil || !strings.Contains(err.Error(), "got 2 proposer assignments") {
+		t.Fatalf("getRewardsForEpoch error = %v, want the partial assignment list rejected", err)
+	}
+	if n := other.Load(); n != 0 {
+		t.Fatalf("made %d reward requests for an epoch with partial assignments, want none", n)
+	}
+}
+
+func TestUpcomingProposals(t *testing.T) {
+	chain := utils.DefaultChain()
+	const epoch, validator = 1000, 7
+	first := uint64(epoch) * chain.SlotsPerEpoch
+	assigned := map[uint64]bool{first + 1: true, first + 20: true, first + chain.SlotsPerEpoch + 3: true}
+	var revealNext atomic.Bool
+	var currentFetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, "/eth/v1/validator/duties/proposer/"), 10, 64)
+		if err == nil && e == epoch {
+			currentFetches.Add(1)
+		}
+		if err != nil || e > epoch+1 || (e == epoch+1 && !revealNext.Load()) {
+			http.Error(w, "duties not available", http.StatusBadRequest)
+			return
+		}
+		var duties []string
+		for slot := e * chain.SlotsPerEpoch; slot < (e+1)*chain.SlotsPerEpoch; slot++ {
+			proposer := slot + 100
+			if assigned[slot] {
+				proposer = validator
+			}
+			duties = append(duties, fmt.Sprintf(`{"pubkey":"0x","slot":"%d","validator_index":"%d"}`, slot, proposer))
+		}
+		_, _ = fmt.Fprintf(w, `{"data":[%s]}`, strings.Join(duties, ","))
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.BeaconNodeURL = srv.URL
+	svc := NewService(cfg)
+
+	// Ten slots into the epoch: the proposal in slot 1 has passed.
+	now := chain.EpochStartTime(epoch).Add(time.Duration(10*chain.SecondsPerSlot) * time.Second)
+	proposals, nextKnown, err := svc.UpcomingProposals(context.Background(), validator, now)
+	if err != nil || nextKnown {
+		t.Fatalf("UpcomingProposals = %v, %v; want only the current epoch checked", nextKnown, err)
+	}
+	wantTime := chain.EpochStartTime(epoch).Add(time.Duration(20*chain.SecondsPerSlot) * time.Second)
+	if len(proposals) != 1 || proposals[0].Slot != first+20 || proposals[0].Epoch != epoch || !proposals[0].Time.Equal(wantTime) {
+		t.Fatalf("proposals = %+v, want slot %d at %v", proposals, first+20, wantTime)
+	}
+
+	revealNext.Store(true)
+	proposals, nextKnown, err = svc.UpcomingProposals(context.Background(), validator, now)
+	if err != nil || !nextKnown || len(proposals) != 2 || proposals[1].Slot != first+chain.SlotsPerEpoch+3 || proposals[1].Epoch != epoch+1 {
+		t.Fatalf("UpcomingProposals = %+v, %v, %v; want the next epoch's proposal too", proposals, nextKnown, err)
+	}
+	if n := currentFetches.Load(); n != 1 {
+		t.Fatalf("current epoch duties fetched %d times, want 1 (cached after the first query)", n)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := svc.UpcomingProposals(ctx, validator, now); err != nil {
+		t.Fatalf("cached duties should not need the beacon node: %v", err)
+	}
+
+	if _, _, err := svc.UpcomingProposals(context.Background(), validator, chain.EpochStartTime(epoch+5)); err == nil {
+		t.Fatal("expected an error when the current epoch's duties are unavailable")
+	}
+	svc.proposerDutiesMu.Lock()
+	defer svc.proposerDutiesMu.Unlock()
+	if len(svc.proposerDuties) != 0 {
+		t.Fatalf("proposer duty cache = %v epochs, want past epochs dropped", len(svc.proposerDuties))
+	}
+}
+
+func TestSyncStatusBackfillProgress(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	svc := NewService(cfg)
+	t.Cleanup(svc.Stop)
+
+	svc.setSyncPhase(SyncPhaseBackfill, 100, 199)
+	svc.backfillProcessed.Store(25)
+	svc.cacheMux.Lock()
+	svc.latestSyncEpoch = 130
+	svc.cacheMux.Unlock()
+
+	status := svc.SyncStatus()
+	if status.Phase != SyncPhaseBackfill || status.StartEpoch != 100 || status.TargetEpoch != 199 || status.CurrentEpoch != 130 {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+	if status.EpochsRemaining != 75 {
+		t.Fatalf("epochs remaining = %d, want 75", status.EpochsRemaining)
+	}
+	if math.Abs(status.PercentComplete-25) > 1e-9 {
+		t.Fatalf("percent complete = %f, want 25", status.PercentComplete)
+	}
+}
+
+func TestSyncStatusLiveCaughtUp(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	svc := NewService(cfg)
+	t.Cleanup(svc.Stop)
+
+	head := safeHeadEpoch(svc.Chain(), time.Now())
+	svc.setSyncPhase(SyncPhaseLive, head-10, head-1)
+	svc.cacheMux.Lock()
+	svc.latestSyncEpoch = head
+	svc.cacheMux.Unlock()
+
+	status := svc.SyncStatus()
+	if status.Phase != SyncPhaseLive || status.EpochsRemaining != 0 || status.PercentComplete != 100 {
+		t.Fatalf("unexpected live status: %+v", status)
+	}
+}
+
+func TestAprUnavailableForShortWindow(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.MinAPRWindowSeconds = 3600
+	svc := NewService(cfg)
+	t.Cleanup(svc.Stop)
+
+	currentEpoch := utils.DefaultChain().TimeToEpoch(time.Now())
+	svc.setCacheWindowStart(utils.DefaultChain().EpochToTime(currentEpoch).Add(-10 * time.Minute))
+
+	svc.cacheMux.Lock()
+	svc.cache[1] = &types.ValidatorEpochIncome{AttestationSourceReward: 1_000_000}
+	svc.latestSyncEpoch = currentEpoch
+	svc.cacheMux.Unlock()
+
+	snapshot := svc.TotalNetworkRewards()
+	if snapshot.AprAvailable {
+		t.Fatalf("expected APR to be unavailable for a 10 minute window")
+	}
+	if snapshot.ProjectAprPercent != 0 {
+		t.Fatalf("expected APR to be zeroed, got %f", snapshot.ProjectAprPercent)
+	}
+	if svc.AprAvailable() {
+		t.Fatalf("AprAvailable should agree with the snapshot")
+	}
+
+	cfg.MinAPRWindowSeconds = 0
+	if snapshot := svc.RecomputeNetworkRewards(); !snapshot.AprAvailable || snapshot.ProjectAprPercent <= 0 {
+		t.Fatalf("expected APR once the minimum is lifted, got %+v", snapshot)
+	}
+}
+
+func TestAPRDistribution(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	svc := NewService(cfg)
+	t.Cleanup(svc.Stop)
+
+	// A one-day window at 36.5 ETH: every 1,000,000 gwei earned is 1% APR.
+	currentEpoch := utils.DefaultChain().TimeToEpoch(time.Now())
+	svc.setCacheWindowStart(utils.DefaultChain().EpochToTime(currentEpoch).Add(-24 * time.Hour))
+	balances := make(map[uint64]int64)
+	svc.cacheMux.Lock()
+	for idx := uint64(1); idx <= 6; idx++ {
+		svc.cache[idx] = &types.ValidatorEpochIncome{AttestationSourceReward: idx * 1_000_000}
+		svc.idealAttestation[idx] = idealReward{Source: 10}
+		balances[idx] = 36_500_000_000
+	}
+	svc.idealAttestation[6] = idealReward{Source: 1} // Activated late in the window.
+	svc.idealPerIncrementTotal = 10
+	svc.latestSyncEpoch = currentEpoch
+	svc.cacheMux.Unlock()
+
+	dist := svc.APRDistribution(balances, 0.5)
+	if !dist.AprAvailable || dist.ValidatorCount != 5 || dist.ExcludedCount != 1 {
+		t.Fatalf("unexpected counts: %+v", dist)
+	}
+	got := []float64{dist.MinPercent, dist.P10Percent, dist.P25Percent, dist.P50Percent, dist.P75Percent, dist.P90Percent, dist.MaxPercent}
+	want := []float64{1, 1.4, 2, 3, 4, 4.6, 5}
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-9 {
+			t.Fatalf("distribution = %v, want %v", got, want)
+		}
+	}
+
+	if dist := svc.APRDistribution(balances, 0); dist.ValidatorCount != 6 || dist.ExcludedCount != 0 {
+		t.Fatalf("a zero fraction should include every validator: %+v", dist)
+	}
+}
+
+func TestRewardWindowIsEmptyBeforeFirstSync(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	svc := NewService(cfg)
+	t.Cleanup(svc.Stop)
+	// A devnet that started minutes ago: the window start (midnight) precedes the end of epoch 0.
+	chain := utils.NewChainConfig(time.Now().Add(-10*time.Minute).Unix(), 0, 0)
+	svc.SetChain(chain)
+	windowStart := chain.EpochStartTime(0).Add(-time.Hour)
+	svc.setCacheWindowStart(windowStart)
+
+	if start, end := svc.GetRewardWindow(); !start.Equal(windowStart) || !end.Equal(windowStart) {
+		t.Fatalf("window before any sync = [%s, %s], want empty at %s", start, end, windowStart)
+	}
+
+	svc.cacheMux.Lock()
+	svc.latestSyncEpoch = 1
+	svc.cacheMux.Unlock()
+	if _, end := svc.GetRewardWindow(); !end.Equal(chain.EpochStartTime(2)) {
+		t.Fatalf("window end = %s, want the end of epoch 1 (%s)", end, chain.EpochStartTime(2))
+	}
+}
+
+func TestTotalNetworkRewardsServesCachedSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(dir, "history.jsonl")
+	cfg.ValidatorHistoryFile = filepath.Join(dir, "validator_history.jsonl")
+	svc := NewService(cfg)
+	t.Cleanup(svc.Stop)
+
+	svc.cacheMux.Lock()
+	svc.cache[1] = &types.ValidatorEpochIncome{AttestationSourceReward: 100}
+	svc.cacheMux.Unlock()
+
+	first := svc.TotalNetworkRewards()
+	svc.cacheMux.Lock()
+	svc.cache[2] = &types.ValidatorEpochIncome{AttestationSourceReward: 50}
+	svc.cacheMux.Unlock()
+
+	if got := svc.TotalNetworkRewards(); got != first || got.ClRewardsGwei != 100 {
+		t.Fatalf("expected the cached snapshot until a refresh, got %+v", got)
+	}
+	if got := svc.RecomputeNetworkRewards(); got.ClRewardsGwei != 150 {
+		t.Fatalf("recomputed ClRewardsGwei = %d, want 150", got.ClRewardsGwei)
+	}
+	if got := svc.TotalNetworkRewards(); got.ClRewardsGwei != 150 {
+		t.Fatalf("ClRewardsGwei after recompute = %d, want 150", got.ClRewardsGwei)
+	}
+
+	svc.resetCacheAt(time.Now())
+	if got := svc.TotalNetworkRewards(); got.ClRewardsGwei != 0 {
+		t.Fatalf("ClRewardsGwei after cache reset = %d, want 0", got.ClRewardsGwei)
+	}
+}
+
+func TestMergedEpochRefreshesNetworkSnapshot(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	svc := NewService(cfg)
+	t.Cleanup(svc.Stop)
+	svc.setSyncPhase(SyncPhaseBackfill, 1, 2)
+
+	// Reads between backfilled epochs see each one, not the snapshot cached before it.
+	for epoch := uint64(1); epoch <= 2; epoch++ {
+		data := newEpochRewards()
+		data.income[epoch] = &types.ValidatorEpochIncome{AttestationSourceReward: 100}
+		svc.mergeEpoch(epoch, data)
+		if got, want := svc.TotalNetworkRewards().ClRewardsGwei, utils.Gwei(epoch*100); got != want {
+			t.Fatalf("ClRewardsGwei after epoch %d = %d, want %d", epoch, got, want)
+		}
+	}
+}
+
+// BenchmarkTotalNetworkRewards compares the cached read path with recomputing the snapshot per call.
+func BenchmarkTotalNetworkRewards(b *testing.B) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = ""
+	svc := NewService(cfg)
+	b.Cleanup(svc.Stop)
+	for i := uint64(0); i < 100_000; i++ {
+		svc.cache[i] = &types.ValidatorEpochIncome{AttestationSourceReward: 1000, AttestationTargetReward: 2000}
+	}
+
+	b.Run("recompute", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			svc.RecomputeNetworkRewards()
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		svc.RecomputeNetworkRewards()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			svc.TotalNetworkRewards()
+		}
+	})
+}
+
+func TestClampBackfillRange(t *testing.T) {
+	tests := []struct {
+		name        string
+		from, to    uint64
+		max         uint64
+		wantFrom    uint64
+		wantClamped bool
+	}{
+		{name: "within cap", from: 100, to: 199, max: 100, wantFrom: 100},
+		{name: "exceeds cap", from: 0, to: 1_000_000, max: 7200, wantFrom: 1_000_000 - 7200 + 1, wantClamped: true},
+		{name: "cap disabled", from: 0, to: 1_000_000, max: 0, wantFrom: 0},
+		{name: "empty range", from: 10, to: 5, max: 1, wantFrom: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, clamped := clampBackfillRange(tt.from, tt.to, tt.max)
+			if got != tt.wantFrom || clamped != tt.wantClamped {
+				t.Fatalf("clampBackfillRange(%d, %d, %d) = (%d, %v), want (%d, %v)",
+					tt.from, tt.to, tt.max, got, clamped, tt.wantFrom, tt.wantClamped)
+			}
+		})
+	}
+}
+
+func TestBackfillEpochsOrder(t *testing.T) {
+	collect := func(from, to uint64, order string) []uint64 {
+		var got []uint64
+		for e := range backfillEpochs(from, to, order) {
+			got = append(got, e)
+		}
+		return got
+	}
+
+	if got, want := collect(3, 6, config.BackfillOrderAsc), []uint64{3, 4, 5, 6}; !slices.Equal(got, want) {
+		t.Fatalf("asc = %v, want %v", got, want)
+	}
+	if got, want := collect(3, 6, config.BackfillOrderDesc), []uint64{6, 5, 4, 3}; !slices.Equal(got, want) {
+		t.Fatalf("desc = %v, want %v", got, want)
+	}
+	if got, want := collect(0, 0, config.BackfillOrderDesc), []uint64{0}; !slices.Equal(got, want) {
+		t.Fatalf("single epoch = %v, want %v", got, want)
+	}
+	if got := collect(6, 3, config.BackfillOrderDesc); len(got) != 0 {
+		t.Fatalf("empty range yielded %v", got)
+	}
+}
+
+func TestDescendingBackfillKeepsHighWaterMark(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		requested []uint64
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/eth/v1/validator/duties/proposer/"):
+			epoch, _ := strconv.ParseUint(path.Base(r.URL.Path), 10, 64)
+			mu.Lock()
+			requested = append(requested, epoch)
+			mu.Unlock()
+			slots := utils.DefaultChain().SlotsPerEpoch
+			duties := make([]string, 0, slots)
+			for slot := epoch * slots; slot < (epoch+1)*slots; slot++ {
+				duties = append(duties, fmt.Sprintf(`{"pubkey":"0x01","validator_index":"5","slot":"%d"}`, slot))
+			}
+			_, _ = w.Write([]byte(`{"data":[` + strings.Join(duties, ",") + `]}`))
+		case strings.HasPrefix(r.URL.Path, "/eth/v1/beacon/rewards/attestations/"):
+			_, _ = w.Write([]byte(`{"data":{"ideal_rewards":[],"total_rewards":[]}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.BeaconNodeURL = srv.URL
+	cfg.BackfillConcurrency = 1
+	cfg.BackfillOrder = config.BackfillOrderDesc
+	svc := NewService(cfg)
+	t.Cleanup(svc.Stop)
+
+	svc.runBackfill(10, 13)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []uint64{13, 12, 11, 10}; !slices.Equal(requested, want) {
+		t.Fatalf("processed epochs %v, want %v", requested, want)
+	}
+	if got := svc.LatestSyncEpoch(); got != 13 {
+		t.Fatalf("latestSyncEpoch = %d, want the newest epoch 13", got)
+	}
+}
+
+func TestReconcileSampleRecordsCacheDelta(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.EnableReconcile = true
+	svc := NewService(cfg)
+
+	svc.cacheMux.Lock()
+	svc.cache[1] = &types.ValidatorEpochIncome{AttestationSourceReward: 100}
+	epochRewards := map[uint64]*types.ValidatorEpochIncome{
+		1: {AttestationSourceReward: 10, AttestationHeadReward: 5},
+		2: {AttestationTargetReward: 7},
+	}
+	before := svc.cachedClLocked(epochRewards, 10)
+	for idx, income := range epochRewards {
+		svc.accumulateRewards(idx, income)
+	}
+	svc.recordReconcileSampleLocked(42, before)
+	svc.cacheMux.Unlock()
+
+	sample := svc.reconcileSample
+	if sample == nil || sample.epoch != 42 {
+		t.Fatalf("unexpected sample: %+v", sample)
+	}
+	if sample.deltas[1] != 15 || sample.deltas[2] != 7 {
+		t.Fatalf("unexpected deltas: %v", sample.deltas)
+	}
+
+	fresh := map[uint64]*types.ValidatorEpochIncome{
+		1: {AttestationSourceReward: 10, AttestationHeadReward: 5},
+		2: {AttestationTargetReward: 9},
+	}
+	result := compareReconcileSample(sample, fresh, 0)
+	if result.Sampled != 2 || result.Mismatched != 1 || result.MaxDiffGwei != 2 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if result := compareReconcileSample(sample, fresh, 2); result.Mismatched != 0 {
+		t.Fatalf("difference within threshold should not be reported: %+v", result)
+	}
+}
+
+func TestValidatorRewardGweiJSONEncoding(t *testing.T) {
+	t.Cleanup(func() { utils.SetGweiAsString(false) })
+
+	reward := ValidatorReward{ValidatorIndex: 7, ClRewardsGwei: 9_007_199_254_740_993}
+
+	tests := []struct {
+		asString bool
+		want     string
+	}{
+		{asString: false, want: `"cl_rewards_gwei":9007199254740993`},
+		{asString: true, want: `"cl_rewards_gwei":"9007199254740993"`},
+	}
+	for _, tt := range tests {
+		utils.SetGweiAsString(tt.asString)
+		b, err := json.Marshal(reward)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		if !strings.Contains(string(b), tt.want) {
+			t.Fatalf("asString=%v: %s does not contain %s", tt.asString, b, tt.want)
+		}
+		if !strings.Contains(string(b), `"validator_index":7`) {
+			t.Fatalf("non-gwei fields must stay numeric: %s", b)
+		}
+
+		var decoded ValidatorReward
+		if err := json.Unmarshal(b, &decoded); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		if decoded.ClRewardsGwei != reward.ClRewardsGwei {
+			t.Fatalf("round trip = %d, want %d", decoded.ClRewardsGwei, reward.ClRewardsGwei)
+		}
+	}
+}
+
+func TestProjectAPRTimeWeightedDenominator(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	svc := NewService(cfg)
+
+	day := cfg.CacheResetInterval.Seconds()
+	// A validator set that doubled halfway through the window averages 1.5x the starting balance,
+	// so the point-in-time (2x) denominator understates APR.
+	simple := svc.projectAPR(1_000, 2_000_000, day)
+	weighted := svc.projectAPR(1_000, 1_500_000, day)
+	if math.Abs(simple-18.25) > 1e-9 {
+		t.Fatalf("simple APR = %f, want 18.25", simple)
+	}
+	if weighted <= simple {
+		t.Fatalf("time-weighted APR %f should exceed simple APR %f", weighted, simple)
+	}
+	if got := svc.projectAPR(1_000, 0, day); got != 0 {
+		t.Fatalf("APR with zero balance = %f, want 0", got)
+	}
+}
+
+func TestTrackedValidatorsSkipUntrackedProposers(t *testing.T) {
+	var requests atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if strings.HasPrefix(r.URL.Path, "/eth/v1/beacon/rewards/sync_committee/") {
+			_, _ = w.Write([]byte(`{"data":[{"validator_index":"5","reward":"10"},{"validator_index":"9","reward":"-3"}]}`))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.BeaconNodeURL = srv.URL
+	cfg.TrackedValidators = []uint64{9}
+	svc := NewService(cfg)
+
+	proposers := map[uint64]uint64{64: 1}
+	rewards := newEpochRewards()
+
+	if err := svc.processSlot(64, proposers, rewards, false); err != nil {
+		t.Fatalf("processSlot returned error: %v", err)
+	}
+	if n := requests.Load(); n != 0 {
+		t.Fatalf("expected no beacon requests for an untracked proposer, got %d", n)
+	}
+
+	if err := svc.processSlot(64, proposers, rewards, true); err != nil {
+		t.Fatalf("processSlot returned error: %v", err)
+	}
+	if len(rewards.income) != 1 || rewards.income[9] == nil || rewards.income[9].SyncCommitteePenalty != 3 {
+		t.Fatalf("expected only tracked validator 9 to be recorded, got %+v", rewards.income)
+	}
+
+	if !svc.syncCommitteeHasTracked(64, 32) {
+		t.Fatalf("validator 9 is in the sync committee; probe should report true")
+	}
+	cfg.TrackedValidators = []uint64{42}
+	if NewService(cfg).syncCommitteeHasTracked(64, 32) {
+		t.Fatalf("validator 42 is not in the sync committee; probe should report false")
+	}
+}
+
+func TestScopedSnapshot(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.TrackedValidators = []uint64{1, 2}
+	svc := NewService(cfg)
+
+	snap := svc.TotalNetworkRewards()
+	if !snap.Scoped || snap.TrackedValidatorCount != 2 {
+		t.Fatalf("expected scoped snapshot for 2 tracked validators, got %+v", snap)
+	}
+}
+
+func TestValidatorHistoryRetainsBoundedWindows(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(dir, "history.jsonl")
+	cfg.ValidatorHistoryFile = filepath.Join(dir, "validator_history.jsonl")
+	cfg.ValidatorHistoryDays = 2
+	svc := NewService(cfg)
+	t.Cleanup(svc.Stop)
+
+	day := 24 * time.Hour
+	start := time.Now().Add(-4 * day).Truncate(time.Second)
+	for i := 0; i < 3; i++ {
+		windowStart := start.Add(time.Duration(i) * day)
+		svc.setCacheWindowStart(windowStart)
+		svc.cacheMux.Lock()
+		svc.latestSyncEpoch = utils.DefaultChain().TimeToEpoch(windowStart.Add(day))
+		svc.cache[1] = &types.ValidatorEpochIncome{AttestationHeadReward: uint64(100 * (i + 1))}
+		svc.cache[1].TxFeeRewardWei = new(big.Int).Mul(big.NewInt(int64(i+1)), gweiScalar).Bytes()
+		svc.cache[2] = &types.ValidatorEpochIncome{AttestationHeadReward: 7}
+		svc.cacheMux.Unlock()
+		svc.resetCacheAt(windowStart.Add(day))
+	}
+
+	// A fresh service reads the rewritten file; only the last two windows survive.
+	reloaded := NewService(cfg)
+	t.Cleanup(reloaded.Stop)
+	history, err := reloaded.ValidatorRewardHistory([]uint64{1, 3}, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("expected 2 retained windows, got %d", len(history))
+	}
+	if !history[0].WindowStart.Equal(start.Add(day)) {
+		t.Fatalf("oldest window starts %v, want %v", history[0].WindowStart, start.Add(day))
+	}
+	last := history[1]
+	if last.ValidatorCount != 1 || last.ClRewardsGwei != 300 || last.ElRewardsGwei != 3 || last.TotalRewardsGwei != 303 {
+		t.Fatalf("unexpected last window: %+v", last)
+	}
+
+	recent, err := reloaded.ValidatorRewardHistory([]uint64{1, 2}, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(recent) != 1 || recent[0].TotalRewardsGwei != 310 || recent[0].ValidatorCount != 2 {
+		t.Fatalf("unexpected days=1 history: %+v", recent)
+	}
+}
+
+func TestAddressHistoryRecordsAndLooksUpWindows(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(dir, "history.jsonl")
+	cfg.AddressHistoryFile = filepath.Join(dir, "address_history.jsonl")
+	cfg.AddressHistoryDays = 30
+	cfg.AddressHistoryMaxAddresses = 2
+	svc := NewService(cfg)
+	t.Cleanup(svc.Stop)
+
+	day := 24 * time.Hour
+	windowStart := time.Date(2024, 3, 10, 0, 0, 0, 0, windowLocation)
+	queried := windowStart.Add(time.Hour)
+	svc.trackAddressAt("0xaa", queried)
+	svc.trackAddressAt("0xbb", queried.Add(time.Minute))
+	svc.trackAddressAt("0xcc", queried.Add(2*time.Minute)) // Over the cap: 0xaa, the oldest, is dropped.
+	svc.SetLabeledAddresses([]string{"0xdd"})
+
+	recorded := svc.recordedAddresses(windowStart.Add(day))
+	if len(recorded) != 3 || recorded["0xdd"] != nil || recorded["0xbb"] == nil || recorded["0xcc"] == nil {
+		t.Fatalf("recorded addresses = %v, want 0xbb, 0xcc and labelled 0xdd", recorded)
+	}
+
+	svc.setCacheWindowStart(windowStart)
+	svc.cacheMux.Lock()
+	svc.latestSyncEpoch = utils.DefaultChain().TimeToEpoch(windowStart.Add(day))
+	svc.cache[1] = &types.ValidatorEpochIncome{AttestationHeadReward: 1000}
+	svc.cache[1].TxFeeRewardWei = new(big.Int).Mul(big.NewInt(24), gweiScalar).Bytes()
+	svc.cache[2] = &types.ValidatorEpochIncome{AttestationHeadReward: 500}
+	svc.cacheMux.Unlock()
+	closed := svc.closeWindowAt(windowStart.Add(day))
+	entry := svc.addressHistoryEntryOf(closed, map[string]recordedAddress{
+		"0xbb": {validators: []uint64{1, 2, 3}, lastQueried: recorded["0xbb"]},
+		"0xdd": {validators: []uint64{2}},
+	}, map[uint64]int64{1: 64_000_000_000})
+	svc.persistAddressHistory(entry)
+
+	// A fresh service reads the file back and carries the queried address forward.
+	reloaded := NewService(cfg)
+	t.Cleanup(reloaded.Stop)
+	got, ok, err := reloaded.AddressRewardsAt("0xbb", time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC))
+	if err != nil || !ok {
+		t.Fatalf("AddressRewardsAt = %v, %v; want a recorded window", ok, err)
+	}
+	if got.ValidatorCount != 2 || got.ClRewardsGwei != 1500 || got.ElRewardsGwei != 24 || got.TotalRewardsGwei != 1524 {
+		t.Fatalf("unexpected totals: %+v", got)
+	}
+	// Validator 2 has no balance and counts with the default.
+	if got.EffectiveBalanceGwei != 64_000_000_000+cfg.DefaultEffectiveBalanceGwei || got.AprPercent <= 0 {
+		t.Fatalf("unexpected balance or APR: %+v", got)
+	}
+	if _, ok, _ := reloaded.AddressRewardsAt("0xbb", windowStart.Add(day)); ok {
+		t.Fatal("found a window for a day without one")
+	}
+	if _, ok, _ := reloaded.AddressRewardsAt("0xcc", windowStart); ok {
+		t.Fatal("found an address that was not recorded in the window")
+	}
+
+	carried := reloaded.recordedAddresses(windowStart.Add(2 * day))
+	if len(carried) != 1 || carried["0xbb"] == nil || !carried["0xbb"].Equal(*recorded["0xbb"]) {
+		t.Fatalf("carried addresses = %v, want 0xbb with its query time", carried)
+	}
+	if expired := reloaded.recordedAddresses(windowStart.Add(40 * day)); len(expired) != 0 {
+		t.Fatalf("addresses not queried within ADDRESS_HISTORY_DAYS are still recorded: %v", expired)
+	}
+}
+
+func TestCachedValidatorIndicesRefreshAfterMergeAndReset(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	svc := NewService(cfg)
+
+	svc.cache[7] = &types.ValidatorEpochIncome{AttestationHeadReward: 1}
+	svc.cache[3] = &types.ValidatorEpochIncome{AttestationHeadReward: 1}
+	if got := svc.CachedValidatorIndices(); !slices.Equal(got, []uint64{3, 7}) {
+		t.Fatalf("indices = %v, want [3 7]", got)
+	}
+
+	// Until the next epoch is merged the shared set is served as is.
+	svc.cache[5] = &types.ValidatorEpochIncome{AttestationHeadReward: 1}
+	if got := svc.CachedValidatorIndices(); !slices.Equal(got, []uint64{3, 7}) {
+		t.Fatalf("indices before merge = %v, want [3 7]", got)
+	}
+	data := newEpochRewards()
+	data.income[9] = &types.ValidatorEpochIncome{AttestationHeadReward: 1}
+	svc.mergeEpoch(1, data)
+	if got := svc.CachedValidatorIndices(); !slices.Equal(got, []uint64{3, 5, 7, 9}) {
+		t.Fatalf("indices after merge = %v, want [3 5 7 9]", got)
+	}
+
+	svc.closeWindowAt(time.Now())
+	if got := svc.CachedValidatorIndices(); len(got) != 0 {
+		t.Fatalf("indices after reset = %v, want none", got)
+	}
+}
+
+func TestTrackAddressEvictsLeastRecentlyQueried(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.AddressHistoryFile = filepath.Join(t.TempDir(), "address_history.jsonl")
+	cfg.AddressHistoryDays = 365
+	cfg.AddressHistoryMaxAddresses = 2
+	svc := NewService(cfg)
+
+	now := time.Now()
+	svc.trackAddressAt("0xaa", now)
+	svc.trackAddressAt("0xbb", now.Add(time.Second))
+	svc.trackAddressAt("0xaa", now.Add(2*time.Second)) // Refreshes 0xaa, so 0xbb is now the oldest.
+	svc.trackAddressAt("0xcc", now.Add(3*time.Second))
+
+	recorded := svc.recordedAddresses(now.Add(time.Minute))
+	if len(recorded) != 2 || recorded["0xaa"] == nil || recorded["0xcc"] == nil {
+		t.Fatalf("recorded addresses = %v, want 0xaa and 0xcc", recorded)
+	}
+	if !recorded["0xaa"].Equal(now.Add(2 * time.Second)) {
+		t.Fatalf("0xaa last queried %v, want the refreshed time", recorded["0xaa"])
+	}
+}
+
+func TestResetWritesValidatorHistoryOutsideCacheLock(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(dir, "history.jsonl")
+	cfg.ValidatorHistoryFile = filepath.Join(dir, "validator_history.jsonl")
+	cfg.ValidatorHistoryDays = 30
+	svc := NewService(cfg)
+	t.Cleanup(svc.Stop)
+
+	svc.cacheMux.Lock()
+	svc.cache[1] = &types.ValidatorEpochIncome{AttestationHeadReward: 100}
+	svc.cacheMux.Unlock()
+
+	// Hold the history files so the reset stalls while persisting the closed window.
+	svc.historyMu.Lock()
+	done := make(chan struct{})
+	go func() {
+		svc.resetCacheAt(time.Now())
+		close(done)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if svc.cacheMux.TryRLock() {
+			emptied := len(svc.cache) == 0
+			svc.cacheMux.RUnlock()
+			if emptied {
+				break
+			}
+		}
+		if time.Now().After(deadline) {
+			svc.historyMu.Unlock()
+			t.Fatal("cache stayed locked or unreset while the history was being written")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	svc.historyMu.Unlock()
+	<-done
+
+	history, err := svc.ValidatorRewardHistory([]uint64{1}, 0)
+	if err != nil || len(history) != 1 || history[0].ClRewardsGwei != 100 {
+		t.Fatalf("validator history = %+v, %v; want the closed window", history, err)
+	}
+}
+
+func TestSyncParticipationCounts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/eth/v1/beacon/rewards/sync_committee/64":
+			_, _ = w.Write([]byte(`{"data":[{"validator_index":"5","reward":"10"},{"validator_index":"9","reward":"-3"}]}`))
+		case "/eth/v1/beacon/rewards/sync_committee/65":
+			_, _ = w.Write([]byte(`{"data":[{"validator_index":"5","reward":"10"},{"validator_index":"9","reward":"10"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.BeaconNodeURL = srv.URL
+	svc := NewService(cfg)
+
+	// Slot 66 was missed: no proposer and no sync committee outcome is recorded for it.
+	proposers := map[uint64]uint64{64: 1, 65: 2}
+	epoch := newEpochRewards()
+	for _, slot := range []uint64{64, 65, 66} {
+		if err := svc.processSlot(slot, proposers, epoch, true); err != nil {
+			t.Fatalf("processSlot(%d) returned error: %v", slot, err)
+		}
+	}
+
+	svc.cacheMux.Lock()
+	for idx, income := range epoch.income {
+		svc.accumulateRewards(idx, income)
+	}
+	for idx, p := range epoch.syncSlots {
+		svc.accumulateSyncSlots(idx, p)
+	}
+	svc.cacheMux.Unlock()
+
+	got := svc.GetTotalRewards([]uint64{5, 9}, nil)
+	if got[5].SyncSlotsParticipated != 2 || got[5].SyncSlotsMissed != 0 {
+		t.Fatalf("validator 5 sync slots = %d/%d, want 2/0", got[5].SyncSlotsParticipated, got[5].SyncSlotsMissed)
+	}
+	if got[9].SyncSlotsParticipated != 1 || got[9].SyncSlotsMissed != 1 {
+		t.Fatalf("validator 9 sync slots = %d/%d, want 1/1", got[9].SyncSlotsParticipated, got[9].SyncSlotsMissed)
+	}
+}
+
+func TestPreAltairEpochSkipsAttestationAndSyncRewards(t *testing.T) {
+	duties := make([]string, 0, utils.DefaultChain().SlotsPerEpoch)
+	for slot := uint64(64); slot < 64+utils.DefaultChain().SlotsPerEpoch; slot++ {
+		duties = append(duties, fmt.Sprintf(`{"pubkey":"0x01","validator_index":"1","slot":"%d"}`, slot))
+	}
+	var altairRequests atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/eth/v1/validator/duties/proposer/2":
+			_, _ = w.Write([]byte(`{"data":[` + strings.Join(duties, ",") + `]}`))
+		case strings.HasPrefix(r.URL.Path, "/eth/v1/beacon/rewards/attestations/"),
+			strings.HasPrefix(r.URL.Path, "/eth/v1/beacon/rewards/sync_committee/"):
+			altairRequests.Add(1)
+			http.NotFound(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.BeaconNodeURL = srv.URL
+	cfg.AltairEpoch = 10
+	svc := NewService(cfg)
+
+	rewards, err := svc.getRewardsForEpoch(2)
+	if err != nil {
+		t.Fatalf("pre-Altair epoch should not fail, got %v", err)
+	}
+	if n := altairRequests.Load(); n != 0 {
+		t.Fatalf("expected no attestation/sync reward requests before Altair, got %d", n)
+	}
+	if rewards.income[1] == nil || rewards.income[1].ProposalsMissed != utils.DefaultChain().SlotsPerEpoch {
+		t.Fatalf("expected proposer outcome to be recorded, got %+v", rewards.income)
+	}
+
+	cfg.AltairEpoch = 0
+	if _, err := NewService(cfg).getRewardsForEpoch(2); err == nil {
+		t.Fatalf("expected attestation rewards 404 to fail the epoch once Altair is active")
+	}
+}
+
+func TestValidatorRewardsForExport(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	svc := NewService(cfg)
+
+	svc.cacheMux.Lock()
+	for _, idx := range []uint64{9, 2, 5} {
+		svc.cache[idx] = &types.ValidatorEpochIncome{AttestationHeadReward: idx * 10}
+	}
+	svc.syncSlots[5] = &SyncParticipation{Participated: 3, Missed: 1}
+	svc.cacheMux.Unlock()
+
+	indices := svc.CachedValidatorIndices()
+	if len(indices) != 3 || indices[0] != 2 || indices[1] != 5 || indices[2] != 9 {
+		t.Fatalf("CachedValidatorIndices = %v, want [2 5 9]", indices)
+	}
+
+	got := svc.ValidatorRewards([]uint64{5, 7, 2}, map[uint64]int64{5: 32_000_000_000}, 4.2)
+	if len(got) != 2 || got[0].ValidatorIndex != 5 || got[1].ValidatorIndex != 2 {
+		t.Fatalf("expected rewards for 5 then 2, got %+v", got)
+	}
+	r := got[0]
+	if r.TotalRewardsGwei != 50 || r.EffectiveBalanceGwei != 32_000_000_000 || r.ProjectAPRPercent != 4.2 || r.SyncSlotsParticipated != 3 || r.SyncSlotsMissed != 1 {
+		t.Fatalf("unexpected reward for validator 5: %+v", r)
+	}
+}
+
+func TestValidatorSetAPRHistory(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(dir, "history.jsonl")
+	cfg.ValidatorHistoryFile = filepath.Join(dir, "validator_history.jsonl")
+	cfg.ValidatorHistoryDays = 30
+	svc := NewService(cfg)
+
+	day := 24 * time.Hour
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	svc.persistValidatorHistory(validatorHistoryEntry{
+		WindowStart: start,
+		WindowEnd:   start.Add(day),
+		Validators: map[uint64]validatorWindowTotals{
+			1: {Cl: 8_000_000, El: 1_000_000},
+			2: {Cl: 1_000_000},
+		},
+	})
+	svc.persistValidatorHistory(validatorHistoryEntry{
+		WindowStart: start.Add(day),
+		WindowEnd:   start.Add(2 * day),
+		Validators:  map[uint64]validatorWindowTotals{2: {Cl: 5_000_000}},
+	})
+
+	// Validator 1 uses its 64 ETH balance; validator 3 never earned and is ignored.
+	aprs, err := svc.ValidatorSetAPRHistory([]uint64{1, 3}, map[uint64]int64{1: 64_000_000_000}, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(aprs) != 1 {
+		t.Fatalf("expected only the window validator 1 earned in, got %v", aprs)
+	}
+	want := 9_000_000.0 / 64_000_000_000 * 365 * 100
+	if math.Abs(aprs[0]-want) > 1e-9 {
+		t.Fatalf("apr = %f, want %f", aprs[0], want)
+	}
+}
+
+func TestPreviousWindowEffectiveBalances(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(dir, "history.jsonl")
+	cfg.ValidatorHistoryFile = filepath.Join(dir, "validator_history.jsonl")
+	cfg.ValidatorHistoryDays = 30
+	svc := NewService(cfg)
+
+	if got, err := svc.PreviousWindowEffectiveBalances([]uint64{1}); err != nil || len(got) != 0 {
+		t.Fatalf("without history = %v, %v; want empty", got, err)
+	}
+
+	day := 24 * time.Hour
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	svc.persistValidatorHistory(validatorHistoryEntry{
+		WindowStart: start,
+		WindowEnd:   start.Add(day),
+		Validators:  map[uint64]validatorWindowTotals{1: {Cl: 10, EffectiveBalance: 30_000_000_000}},
+	})
+	svc.persistValidatorHistory(validatorHistoryEntry{
+		WindowStart: start.Add(day),
+		WindowEnd:   start.Add(2 * day),
+		Validators: map[uint64]validatorWindowTotals{
+			1: {Cl: 10, EffectiveBalance: 32_000_000_000},
+			2: {Cl: 10}, // Closed without Dora.
+			3: {Cl: 10, EffectiveBalance: 31_000_000_000},
+		},
+	})
+
+	// A fresh service reads the file back: only the latest window counts.
+	got, err := NewService(cfg).PreviousWindowEffectiveBalances([]uint64{1, 2, 4})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := map[uint64]int64{1: 32_000_000_000}; !maps.Equal(got, want) {
+		t.Fatalf("balances = %v, want %v", got, want)
+	}
+}
+
+func TestUnsupportedEndpointsAreNotCountedAsMissedSlots(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/eth/v1/beacon/blocks/64":
+			_, _ = w.Write([]byte(`{"data":{"message":{"body":{"execution_payload":{"block_number":"0","transactions":[]}}}}}`))
+		case "/eth/v1/beacon/rewards/blocks/65":
+			_, _ = w.Write([]byte(`{"data":{"proposer_index":"2","total":"7","attestations":"7","sync_aggregate":"0","proposer_slashings":"0","attester_slashings":"0"}}`))
+		default:
+			// Slot 64: no block rewards or sync endpoints. Slot 65: no v1 block endpoint. Slot 66: missed.
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.BeaconNodeURL = srv.URL
+	svc := NewService(cfg)
+
+	proposers := map[uint64]uint64{64: 1, 65: 2, 66: 3}
+	epoch := newEpochRewards()
+	for _, slot := range []uint64{64, 65, 66} {
+		if err := svc.processSlot(slot, proposers, epoch, true); err != nil {
+			t.Fatalf("processSlot(%d) returned error: %v", slot, err)
+		}
+	}
+
+	for idx, want := range map[uint64]uint64{1: 0, 2: 0, 3: 1} {
+		var got uint64
+		if e := epoch.income[idx]; e != nil {
+			got = e.ProposalsMissed
+		}
+		if got != want {
+			t.Fatalf("validator %d ProposalsMissed = %d, want %d", idx, got, want)
+		}
+	}
+	if got := epoch.income[2].ProposerAttestationInclusionReward; got != 7 {
+		t.Fatalf("block inclusion reward = %d, want 7", got)
+	}
+	if epoch.proposed[1] != 1 || epoch.proposed[2] != 1 || epoch.proposed[3] != 0 {
+		t.Fatalf("unexpected proposed block counts: %v", epoch.proposed)
+	}
+
+	want := []string{endpointBlocks, endpointBlockRewards, endpointSyncCommitteeRewards, endpointBlocksV2}
+	if got := svc.UnsupportedEndpoints(); !slices.Equal(got, want) {
+		t.Fatalf("UnsupportedEndpoints() = %v, want %v", got, want)
+	}
+}
+
+func TestBlockEndpointFallsBackToV2(t *testing.T) {
+	var v1Requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/eth/v2/beacon/blocks/64":
+			_, _ = w.Write([]byte(`{"version":"electra","data":{"message":{"body":{"execution_payload":{"block_number":"1234"}}}}}`))
+		case "/eth/v1/beacon/blocks/64":
+			v1Requests.Add(1)
+			http.NotFound(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	for _, version := range []string{config.BeaconBlockAPIV1, config.BeaconBlockAPIV2} {
+		v1Requests.Store(0)
+		cfg := config.DefaultConfig()
+		cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+		cfg.BeaconNodeURL = srv.URL
+		cfg.BeaconBlockAPIVersion = version
+		svc := NewService(cfg)
+
+		if n, err := svc.executionBlockNumber(64); err != nil || n != 1234 {
+			t.Fatalf("%s: executionBlockNumber = %d, %v; want 1234", version, n, err)
+		}
+		if _, err := svc.executionBlockNumber(65); err != types.ErrBlockNotFound {
+			t.Fatalf("%s: missed slot error = %v, want ErrBlockNotFound", version, err)
+		}
+
+		wantV1, wantUnsupported := int32(1), []string{endpointBlocks}
+		if version == config.BeaconBlockAPIV2 {
+			wantV1, wantUnsupported = 0, nil
+		}
+		if got := v1Requests.Load(); got != wantV1 {
+			t.Fatalf("%s: v1 block requests = %d, want %d", version, got, wantV1)
+		}
+		if got := svc.UnsupportedEndpoints(); !slices.Equal(got, wantUnsupported) {
+			t.Fatalf("%s: UnsupportedEndpoints() = %v, want %v", version, got, wantUnsupported)
+		}
+	}
+}
+
+func TestPersistSnapshotReplacesSameDayWindow(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	svc := NewService(cfg)
+
+	loc := time.FixedZone("UTC+8", 8*60*60)
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, loc)
+	svc.persistSnapshot(&NetworkRewardSnapshot{WindowStart: day.Add(-24 * time.Hour), TotalRewardsGwei: 1})
+	svc.persistSnapshot(&NetworkRewardSnapshot{WindowStart: day, TotalRewardsGwei: 2})
+	// A restart later the same day closes a second window starting on that date.
+	svc.persistSnapshot(&NetworkRewardSnapshot{WindowStart: day.Add(5 * time.Hour), TotalRewardsGwei: 3})
+
+	history, err := svc.NetworkRewardHistory()
+	if err != nil {
+		t.Fatalf("NetworkRewardHistory returned error: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("expected 2 snapshots, got %d: %+v", len(history), history)
+	}
+	if history[0].TotalRewardsGwei != 1 {
+		t.Fatalf("previous day snapshot changed: %+v", history[0])
+	}
+	if history[1].TotalRewardsGwei != 3 || !history[1].WindowStart.Equal(day.Add(5*time.Hour)) {
+		t.Fatalf("expected latest same-day snapshot to be kept, got %+v", history[1])
+	}
+}
+
+func TestAttestationEfficiency(t *testing.T) {
+	duties := make([]string, 0, utils.DefaultChain().SlotsPerEpoch)
+	for slot := uint64(64); slot < 64+utils.DefaultChain().SlotsPerEpoch; slot++ {
+		duties = append(duties, fmt.Sprintf(`{"pubkey":"0x01","validator_index":"5","slot":"%d"}`, slot))
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/eth/v1/validator/duties/proposer/2":
+			_, _ = w.Write([]byte(`{"data":[` + strings.Join(duties, ",") + `]}`))
+		case "/eth/v1/beacon/rewards/attestations/2":
+			_, _ = w.Write([]byte(`{"data":{
+				"ideal_rewards":[
+					{"effective_balance":"1000000000","head":"18","source":"37","target":"74"},
+					{"effective_balance":"32000000000","head":"100","source":"200","target":"300"}
+				],
+				"total_rewards":[
+					{"validator_index":"5","head":"100","source":"200","target":"300","inclusion_delay":"0"},
+					{"validator_index":"6","head":"0","source":"-200","target":"-300","inclusion_delay":"0"}
+				]}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.BeaconNodeURL = srv.URL
+	svc := NewService(cfg)
+
+	if err := svc.processEpoch(2); err != nil {
+		t.Fatalf("processEpoch returned error: %v", err)
+	}
+
+	got := svc.GetTotalRewards([]uint64{5, 6}, map[uint64]int64{6: 64_000_000_000})
+	if got[5].EfficiencyPercent != 100 {
+		t.Fatalf("validator 5 efficiency = %f, want 100", got[5].EfficiencyPercent)
+	}
+	// Validator 6 has twice the balance, so its ideal is 1200 gwei.
+	if want := -500.0 / 1200 * 100; math.Abs(got[6].EfficiencyPercent-want) > 1e-9 {
+		t.Fatalf("validator 6 efficiency = %f, want %f", got[6].EfficiencyPercent, want)
+	}
+
+	// Without Dora both validators fall back to 32 ETH: 100 gwei net against a 1200 gwei ideal.
+	if want, got := 100.0/1200*100, svc.TotalNetworkRewards().EfficiencyPercent; math.Abs(got-want) > 1e-9 {
+		t.Fatalf("network efficiency = %f, want %f", got, want)
+	}
+
+	// Validator 6 missed source and target at twice the balance and got no head reward.
+	detail, ok := svc.AttestationDetail(6, 64_000_000_000)
+	if !ok {
+		t.Fatal("AttestationDetail(6) found no data")
+	}
+	if detail.Source.ActualGwei != -200 || detail.Source.IdealGwei != 400 ||
+		detail.Target.ActualGwei != -300 || detail.Target.IdealGwei != 600 ||
+		detail.Head.ActualGwei != 0 || detail.Head.IdealGwei != 200 {
+		t.Fatalf("validator 6 detail = %+v", detail)
+	}
+	if detail.Total.ActualGwei != -500 || detail.Total.IdealGwei != 1200 || detail.Total.EfficiencyPercent != got[6].EfficiencyPercent {
+		t.Fatalf("validator 6 total = %+v, want -500 of 1200 matching efficiency_percent", detail.Total)
+	}
+	if detail, _ := svc.AttestationDetail(5, 0); detail.Head.EfficiencyPercent != 100 || detail.EffectiveBalanceGwei != 32_000_000_000 {
+		t.Fatalf("validator 5 detail = %+v, want 100%% head at the default balance", detail)
+	}
+	if _, ok := svc.AttestationDetail(7, 0); ok {
+		t.Fatal("AttestationDetail(7) found data for a validator without attestations")
+	}
+}
+
+func TestTopProposersRanksByProposerRewards(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	svc := NewService(cfg)
+
+	svc.cacheMux.Lock()
+	svc.cache[1] = &types.ValidatorEpochIncome{
+		AttestationHeadReward:              1_000_000, // Attestation rewards do not count towards the ranking.
+		ProposerAttestationInclusionReward: 100,
+	}
+	svc.cache[2] = &types.ValidatorEpochIncome{
+		ProposerAttestationInclusionReward: 100,
+		ProposerSyncInclusionReward:        20,
+		TxFeeRewardWei:                     big.NewInt(50_000_000_000).Bytes(), // 50 gwei
+	}
+	svc.cache[3] = &types.ValidatorEpochIncome{AttestationHeadReward: 10}
+	svc.blocksProposed[1] = 1
+	svc.blocksProposed[2] = 2
+	svc.cacheMux.Unlock()
+
+	top := svc.TopProposers(10)
+	if len(top) != 2 {
+		t.Fatalf("expected 2 proposers, got %+v", top)
+	}
+	if top[0].ValidatorIndex != 2 || top[0].BlocksProposed != 2 || top[0].TotalProposerRewardsGwei != 170 {
+		t.Fatalf("unexpected top proposer: %+v", top[0])
+	}
+	if top[0].ClProposerRewardsGwei != 120 || top[0].ElRewardsGwei != 50 {
+		t.Fatalf("unexpected reward split: %+v", top[0])
+	}
+	if top[1].ValidatorIndex != 1 || top[1].TotalProposerRewardsGwei != 100 {
+		t.Fatalf("unexpected second proposer: %+v", top[1])
+	}
+
+	if got := svc.TopProposers(1); len(got) != 1 || got[0].ValidatorIndex != 2 {
+		t.Fatalf("limit not applied: %+v", got)
+	}
+}
+
+func TestSnapshotRewardsIsLabelledWithSyncedEpoch(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	svc := NewService(cfg)
+
+	svc.cacheMux.Lock()
+	svc.cache[1] = &types.ValidatorEpochIncome{AttestationHeadReward: 10}
+	svc.latestSyncEpoch = 1234
+	svc.cacheMux.Unlock()
+
+	snap := svc.SnapshotRewards([]uint64{1}, nil)
+	if snap.AsOfEpoch != 1234 {
+		t.Fatalf("AsOfEpoch = %d, want 1234", snap.AsOfEpoch)
+	}
+	if snap.Rewards[1].ClRewardsGwei != 10 {
+		t.Fatalf("unexpected rewards: %+v", snap.Rewards[1])
+	}
+
+	// The snapshot is a copy: later merges do not change it.
+	svc.cacheMux.Lock()
+	svc.accumulateRewards(1, &types.ValidatorEpochIncome{AttestationHeadReward: 5})
+	svc.latestSyncEpoch = 1235
+	svc.cacheMux.Unlock()
+	if snap.Rewards[1].ClRewardsGwei != 10 || snap.AsOfEpoch != 1234 {
+		t.Fatalf("snapshot changed after merge: %+v epoch %d", snap.Rewards[1], snap.AsOfEpoch)
+	}
+}
+
+func TestSnapshotRewardsUsesCachedNetworkSnapshot(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	svc := NewService(cfg)
+
+	svc.cacheMux.Lock()
+	svc.cache[1] = &types.ValidatorEpochIncome{AttestationHeadReward: 10}
+	svc.cacheMux.Unlock()
+	svc.networkSnapshot.Store(&NetworkRewardSnapshot{ProjectAprPercent: 4.2})
+
+	snap := svc.SnapshotRewards([]uint64{1}, nil)
+	if got := snap.Rewards[1].ProjectAPRPercent; got != 4.2 {
+		t.Fatalf("ProjectAPRPercent = %v, want the cached snapshot's 4.2", got)
+	}
+
+	// A reset clears the snapshot; the next read rebuilds it for the new window and keeps it.
+	svc.closeWindowAt(time.Now())
+	svc.SnapshotRewards(nil, nil)
+	if cached := svc.networkSnapshot.Load(); cached == nil || cached.ProjectAprPercent == 4.2 {
+		t.Fatalf("cached snapshot after reset = %+v, want one rebuilt for the new window", cached)
+	}
+}
+
+func TestFailedEpochIsListedPersistedAndRetried(t *testing.T) {
+	var healthy atomic.Bool
+	duties := make([]string, 0, utils.DefaultChain().SlotsPerEpoch)
+	for slot := uint64(64); slot < 64+utils.DefaultChain().SlotsPerEpoch; slot++ {
+		duties = append(duties, fmt.Sprintf(`{"pubkey":"0x01","validator_index":"5","slot":"%d"}`, slot))
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		switch r.URL.Path {
+		case "/eth/v1/validator/duties/proposer/2":
+			_, _ = w.Write([]byte(`{"data":[` + strings.Join(duties, ",") + `]}`))
+		case "/eth/v1/beacon/rewards/attestations/2":
+			_, _ = w.Write([]byte(`{"data":{
+				"ideal_rewards":[{"effective_balance":"32000000000","head":"100","source":"200","target":"300"}],
+				"total_rewards":[{"validator_index":"5","head":"100","source":"200","target":"300","inclusion_delay":"0"}]}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.FailedEpochsFile = filepath.Join(t.TempDir(), "failed_epochs.json")
+	cfg.BeaconNodeURL = srv.URL
+	cfg.EpochProcessMaxRetries = 1
+	cfg.RetainedEpochContributions = 0 // Only failed epochs can be re-run.
+	svc := NewService(cfg)
+
+	if err := svc.processEpochWithRetry(2); err == nil {
+		t.Fatalf("expected processing to fail while the beacon node is down")
+	}
+	failed := svc.FailedEpochs()
+	if len(failed) != 1 || failed[0].Epoch != 2 || failed[0].Failures != 1 || failed[0].Error == "" {
+		t.Fatalf("failed epochs = %+v, want epoch 2 with one failure", failed)
+	}
+	if err := svc.RetryEpoch(3); !errors.Is(err, ErrEpochUnknown) {
+		t.Fatalf("RetryEpoch(3) error = %v, want ErrEpochUnknown", err)
+	}
+
+	// A restarted service picks the failed epoch up from FAILED_EPOCHS_FILE.
+	restarted := NewService(cfg)
+	restarted.loadFailedEpochs(2)
+	if got := restarted.FailedEpochs(); len(got) != 1 || got[0].Epoch != 2 {
+		t.Fatalf("reloaded failed epochs = %+v, want epoch 2", got)
+	}
+	// One whose window has since closed is dropped on load.
+	stale := NewService(cfg)
+	stale.loadFailedEpochs(3)
+	if got := stale.FailedEpochs(); len(got) != 0 {
+		t.Fatalf("failed epochs loaded below the window's start epoch: %+v", got)
+	}
+
+	healthy.Store(true)
+	if err := restarted.RetryEpoch(2); err != nil {
+		t.Fatalf("RetryEpoch(2) returned error: %v", err)
+	}
+	if got := restarted.FailedEpochs(); len(got) != 0 {
+		t.Fatalf("failed epochs after retry = %+v, want none", got)
+	}
+	if got := restarted.GetRewards([]uint64{5})[5]; got == nil || got.AttestationHeadReward != 100 {
+		t.Fatalf("validator 5 income after retry = %+v, want the epoch's rewards", got)
+	}
+	if err := restarted.RetryEpoch(2); !errors.Is(err, ErrEpochUnknown) {
+		t.Fatalf("second RetryEpoch(2) error = %v, want ErrEpochUnknown", err)
+	}
+	data, err := os.ReadFile(cfg.FailedEpochsFile)
+	if err != nil || strings.TrimSpace(string(data)) != "[]" {
+		t.Fatalf("failed epochs file = %q (%v), want an empty list", data, err)
+	}
+}
+
+func TestReprocessingEpochReplacesItsContribution(t *testing.T) {
+	var (
+		healthy atomic.Bool
+		head    atomic.Int64
+	)
+	healthy.Store(true)
+	head.Store(100)
+	duties := make([]string, 0, utils.DefaultChain().SlotsPerEpoch)
+	for slot := uint64(64); slot < 64+utils.DefaultChain().SlotsPerEpoch; slot++ {
+		duties = append(duties, fmt.Sprintf(`{"pubkey":"0x01","validator_index":"5","slot":"%d"}`, slot))
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		switch r.URL.Path {
+		case "/eth/v1/validator/duties/proposer/2":
+			_, _ = w.Write([]byte(`{"data":[` + strings.Join(duties, ",") + `]}`))
+		case "/eth/v1/beacon/rewards/attestations/2":
+			_, _ = fmt.Fprintf(w, `{"data":{
+				"ideal_rewards":[{"effective_balance":"32000000000","head":"100","source":"200","target":"300"}],
+				"total_rewards":[
+					{"validator_index":"5","head":"%d","source":"200","target":"300","inclusion_delay":"0"},
+					{"validator_index":"6","head":"0","source":"-200","target":"-300","inclusion_delay":"0"}
+				]}}`, head.Load())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.BeaconNodeURL = srv.URL
+	cfg.EpochProcessMaxRetries = 1
+	cfg.RetainedEpochContributions = 32
+	svc := NewService(cfg)
+
+	check := func(stage string, wantHead uint64) {
+		t.Helper()
+		got := svc.GetRewards([]uint64{5, 6})
+		if got[5] == nil || got[5].AttestationHeadReward != wantHead || got[5].AttestationSourceReward != 200 {
+			t.Fatalf("%s: validator 5 income = %+v, want head %d and source 200 counted once", stage, got[5], wantHead)
+		}
+		if got[6] == nil || got[6].AttestationSourcePenalty != 200 || got[6].AttestationTargetPenalty != 300 {
+			t.Fatalf("%s: validator 6 income = %+v, want penalties counted once", stage, got[6])
+		}
+		// 600 gwei ideal for 32 increments.
+		if svc.idealPerIncrementTotal != 18.75 {
+			t.Fatalf("%s: idealPerIncrementTotal = %f, want 18.75", stage, svc.idealPerIncrementTotal)
+		}
+	}
+
+	if err := svc.processEpochWithRetry(2); err != nil {
+		t.Fatalf("processEpochWithRetry returned error: %v", err)
+	}
+	check("first run", 100)
+
+	// Re-run after success: the beacon node now reports a corrected head reward.
+	head.Store(120)
+	if err := svc.RetryEpoch(2); err != nil {
+		t.Fatalf("RetryEpoch returned error: %v", err)
+	}
+	check("re-run", 120)
+
+	// Re-run that fails part-way: the previous contribution stays and the epoch is not reported as failed.
+	healthy.Store(false)
+	if err := svc.RetryEpoch(2); err == nil {
+		t.Fatalf("expected RetryEpoch to fail while the beacon node is down")
+	}
+	check("failed re-run", 120)
+	if failed := svc.FailedEpochs(); len(failed) != 0 {
+		t.Fatalf("failed epochs = %+v, want none while the epoch's data is retained", failed)
+	}
+
+	if err := svc.RemoveEpoch(2); err != nil {
+		t.Fatalf("RemoveEpoch returned error: %v", err)
+	}
+	if got := svc.GetRewards([]uint64{5, 6}); len(got) != 0 {
+		t.Fatalf("rewards after removal = %+v, want none", got)
+	}
+	if svc.idealPerIncrementTotal != 0 || len(svc.idealAttestation) != 0 {
+		t.Fatalf("ideal totals after removal = %f, %v, want empty", svc.idealPerIncrementTotal, svc.idealAttestation)
+	}
+	if err := svc.RemoveEpoch(2); !errors.Is(err, ErrEpochUnknown) {
+		t.Fatalf("second RemoveEpoch error = %v, want ErrEpochUnknown", err)
+	}
+}
+
+func TestRetainedEpochContributionsAreBounded(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.RetainedEpochContributions = 2
+	svc := NewService(cfg)
+
+	svc.cacheMux.Lock()
+	for _, epoch := range []uint64{7, 5, 6} {
+		data := newEpochRewards()
+		data.income[1] = &types.ValidatorEpochIncome{AttestationHeadReward: 10}
+		svc.applyEpochLocked(epoch, data)
+	}
+	svc.cacheMux.Unlock()
+
+	if svc.epochRetained(5) || !svc.epochRetained(6) || !svc.epochRetained(7) {
+		t.Fatalf("retained epochs = %v, want the newest two (6, 7)", slices.Sorted(maps.Keys(svc.epochContributions)))
+	}
+	if got := svc.GetRewards([]uint64{1})[1].AttestationHeadReward; got != 30 {
+		t.Fatalf("head reward = %d, want 30 from all three epochs", got)
+	}
+}
+
+func TestIncompleteEpochsAreFlagged(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.RetainedEpochContributions = 4
+	svc := NewService(cfg)
+
+	apply := func(epoch uint64, expected int, attesters ...uint64) {
+		data := newEpochRewards()
+		for _, idx := range attesters {
+			data.income[idx] = &types.ValidatorEpochIncome{AttestationHeadReward: 10}
+		}
+		data.attesters = attesters
+		data.expectedAttesters = expected
+		svc.cacheMux.Lock()
+		svc.applyEpochLocked(epoch, data)
+		svc.cacheMux.Unlock()
+	}
+
+	apply(10, 3, 1, 2, 3) // complete
+	apply(11, 3, 1, 2)    // one validator missing
+	apply(12, 0, 1)       // expected count unknown: not flagged
+	if got := svc.RecomputeNetworkRewards().IncompleteEpochs; got != 1 {
+		t.Fatalf("incomplete epochs = %d, want 1", got)
+	}
+	if c := svc.incompleteEpochs[11]; c.Expected != 3 || c.Received != 2 {
+		t.Fatalf("epoch 11 completeness = %+v, want 2 of 3", c)
+	}
+
+	apply(11, 3, 1, 2, 3) // re-processed with full data
+	if got := svc.RecomputeNetworkRewards().IncompleteEpochs; got != 0 {
+		t.Fatalf("incomplete epochs after re-processing = %d, want 0", got)
+	}
+}
+
+func TestValidatorActivityFlagsOffline(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(dir, "history.jsonl")
+	cfg.ValidatorHistoryFile = filepath.Join(dir, "validator_history.jsonl")
+	cfg.AddressHistoryFile = filepath.Join(dir, "address_history.jsonl")
+	cfg.OfflineThresholdEpochs = 2
+	svc := NewService(cfg)
+
+	// Validator 1 attests every epoch; 2 stops after epoch 101; 3 only ever gets penalties.
+	svc.cacheMux.Lock()
+	for epoch := uint64(100); epoch <= 105; epoch++ {
+		data := newEpochRewards()
+		data.income[1] = &types.ValidatorEpochIncome{AttestationHeadReward: 10}
+		data.income[2] = &types.ValidatorEpochIncome{AttestationSourcePenalty: 5}
+		if epoch <= 101 {
+			data.income[2] = &types.ValidatorEpochIncome{AttestationTargetReward: 10}
+		}
+		data.income[3] = &types.ValidatorEpochIncome{AttestationSourcePenalty: 5}
+		data.attesters = []uint64{1, 2, 3}
+		svc.applyEpochLocked(epoch, data)
+		svc.latestSyncEpoch = epoch
+	}
+	svc.cacheMux.Unlock()
+
+	got := make(map[uint64]*ValidatorReward)
+	for _, r := range svc.ValidatorRewards([]uint64{1, 2, 3}, nil, 0) {
+		got[r.ValidatorIndex] = r
+	}
+	if r := got[1]; r.LastActiveEpoch == nil || *r.LastActiveEpoch != 105 || *r.EpochsSinceActive != 0 || r.Offline {
+		t.Fatalf("validator 1 = %+v, want active at 105 and online", r)
+	}
+	if r := got[2]; r.LastActiveEpoch == nil || *r.LastActiveEpoch != 101 || *r.EpochsSinceActive != 4 || !r.Offline {
+		t.Fatalf("validator 2 = %+v, want last active at 101, 4 epochs ago, offline", r)
+	}
+	if r := got[3]; r.LastActiveEpoch != nil || !r.Offline {
+		t.Fatalf("validator 3 = %+v, want no active epoch and offline", r)
+	}
+
+	// The last active epoch survives the window reset.
+	svc.resetCacheAt(time.Now())
+	svc.cacheMux.RLock()
+	last := svc.lastActive[2]
+	svc.cacheMux.RUnlock()
+	if last != 101 {
+		t.Fatalf("last active epoch after reset = %d, want 101", last)
+	}
+}
+
+func TestReplicaLoadsPrimarySharedState(t *testing.T) {
+	dir := t.TempDir()
+	primaryCfg := config.DefaultConfig()
+	primaryCfg.RewardsHistoryFile = filepath.Join(dir, "history.jsonl")
+	primaryCfg.SharedStateFile = filepath.Join(dir, "state.json")
+	primary := NewService(primaryCfg)
+
+	data := newEpochRewards()
+	data.income[1] = &types.ValidatorEpochIncome{AttestationHeadReward: 10, TxFeeRewardWei: big.NewInt(5e9).Bytes()}
+	data.income[2] = &types.ValidatorEpochIncome{AttestationSourceReward: 7}
+	data.syncSlots[2] = &SyncParticipation{Participated: 30, Missed: 2}
+	data.proposed[1] = 1
+	data.attesters = []uint64{1, 2}
+	data.idealPerIncrement = idealReward{Source: 0.5, Target: 0.75, Head: 0.25}
+	data.elBlocks = []ELBlockReward{{BlockNumber: 100, Slot: 64, ProposerIndex: 1, TipGwei: 5, TipWei: "5000000000"}}
+	data.feeRecipients[feeRecipientKey{recipient: "0xabc", proposer: 1}] = big.NewInt(5e9).Bytes()
+	primary.cacheMux.Lock()
+	primary.applyEpochLocked(9, data)
+	primary.latestSyncEpoch = 9
+	primary.incompleteEpochs[8] = epochCompleteness{Expected: 3, Received: 2}
+	primary.cacheMux.Unlock()
+	primary.writeSharedState()
+
+	replicaCfg := config.DefaultConfig()
+	replicaCfg.RewardsHistoryFile = filepath.Join(dir, "history.jsonl")
+	replicaCfg.SharedStateFile = primaryCfg.SharedStateFile
+	replicaCfg.ReadOnlyReplica = true
+	replica := NewService(replicaCfg)
+	if err := replica.loadSharedState(); err != nil {
+		t.Fatalf("loadSharedState: %v", err)
+	}
+
+	got := replica.GetRewards([]uint64{1, 2})
+	if got[1].AttestationHeadReward != 10 || got[2].AttestationSourceReward != 7 {
+		t.Fatalf("replica rewards = %+v, want the primary's", got)
+	}
+	if el := weiBytesToBigInt(got[1].TxFeeRewardWei); el.Cmp(big.NewInt(5e9)) != 0 {
+		t.Fatalf("replica EL reward = %s wei, want 5000000000", el)
+	}
+	if status := replica.SyncStatus(); status.CurrentEpoch != 9 {
+		t.Fatalf("replica latest epoch = %d, want 9", status.CurrentEpoch)
+	}
+	if p := replica.syncSlots[2]; p == nil || p.Participated != 30 || p.Missed != 2 {
+		t.Fatalf("replica sync participation = %+v, want 30/2", p)
+	}
+	if replica.blocksProposed[1] != 1 || replica.idealAttestation[2] != data.idealPerIncrement || replica.idealPerIncrementTotal != 1.5 {
+		t.Fatalf("replica proposals/ideal = %v %v %v", replica.blocksProposed, replica.idealAttestation, replica.idealPerIncrementTotal)
+	}
+	if blocks := replica.ELRewardsByBlock(100, 100); len(blocks) != 1 || blocks[0].Slot != 64 {
+		t.Fatalf("replica EL blocks = %+v, want block 100 at slot 64", blocks)
+	}
+	if fee := replica.FeeRecipientRewards([]string{"0xabc"}, nil); fee["0xabc"] != 5 {
+		t.Fatalf("replica fee recipient rewards = %v, want 5 gwei", fee)
+	}
+	if replica.SharedStateWrittenAt().IsZero() {
+		t.Fatal("SharedStateWrittenAt is zero after a load")
+	}
+	if got := replica.TotalNetworkRewards().IncompleteEpochs; got != 1 {
+		t.Fatalf("replica incomplete epochs = %d, want the primary's 1", got)
+	}
+
+	// A replica never writes the shared file back.
+	replica.cacheMux.Lock()
+	replica.latestSyncEpoch = 99
+	replica.cacheMux.Unlock()
+	replica.writeSharedState()
+	if err := replica.loadSharedState(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if status := replica.SyncStatus(); status.CurrentEpoch != 9 {
+		t.Fatalf("replica overwrote the shared state: latest epoch = %d", status.CurrentEpoch)
+	}
+}
+
+func TestServiceUsesItsOwnChain(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.BackfillLookback = 0
+	svc := NewService(cfg)
+
+	windowStart := time.Unix(1_800_000_000+1000, 0)
+	svc.setCacheWindowStart(windowStart)
+	svc.SetChain(utils.ChainConfig{GenesisTimestamp: 1_800_000_000, SecondsPerSlot: 5, SlotsPerEpoch: 4})
+
+	// 1000s after this network's genesis at 20s epochs, whatever the default chain says.
+	if got := svc.startEpoch(time.Now()); got != 50 {
+		t.Fatalf("startEpoch = %d, want 50", got)
+	}
+	if got := utils.DefaultChain().TimeToEpoch(windowStart); got == 50 {
+		t.Fatal("test needs a chain that differs from the default")
+	}
+}
+
+func TestFineHistoryAppendsAndTrims(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.FineHistoryFile = filepath.Join(t.TempDir(), "fine.jsonl")
+	cfg.FineHistoryInterval = 4
+	cfg.FineHistoryRetention = time.Hour
+	svc := NewService(cfg)
+
+	if !svc.fineHistoryDue(100) || svc.fineHistoryDue(101) {
+		t.Fatalf("fineHistoryDue should only hold every %d epochs", cfg.FineHistoryInterval)
+	}
+
+	// An hour is 9.375 epochs, so recording epoch 112 drops 100 but keeps 104 and 108.
+	for _, epoch := range []uint64{100, 104, 108, 112} {
+		svc.appendFineHistory(epoch, svc.Chain().EpochToTime(epoch))
+	}
+	entries, err := svc.FineNetworkHistory(0, math.MaxUint64)
+	if err != nil {
+		t.Fatalf("FineNetworkHistory returned error: %v", err)
+	}
+	var epochs []uint64
+	for _, e := range entries {
+		epochs = append(epochs, e.Epoch)
+	}
+	if !slices.Equal(epochs, []uint64{104, 108, 112}) {
+		t.Fatalf("retained epochs = %v, want [104 108 112]", epochs)
+	}
+
+	entries, err = svc.FineNetworkHistory(105, 110)
+	if err != nil || len(entries) != 1 || entries[0].Epoch != 108 {
+		t.Fatalf("FineNetworkHistory(105, 110) = %+v, %v; want only epoch 108", entries, err)
+	}
+}
+
+func TestFineHistoryQueriesDoraOutsideCacheLock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+	mock.MatchExpectationsInOrder(false)
+	mock.ExpectQuery("SELECT").WillDelayFor(300 * time.Millisecond).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(int64(1)))
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(int64(32_000_000_000)))
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(int64(32_000_000_000)))
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.FineHistoryFile = filepath.Join(t.TempDir(), "fine.jsonl")
+	cfg.FineHistoryInterval = 1
+	svc := NewService(cfg)
+	svc.SetDoraDB(dora.NewFromConn(db))
+	svc.cacheMux.Lock()
+	svc.cache[1] = &types.ValidatorEpochIncome{AttestationHeadReward: 10}
+	svc.cacheMux.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		svc.appendFineHistory(100, svc.Chain().EpochToTime(100))
+	}()
+
+	// The first Dora query is still in flight, yet a merge could take the cache lock.
+	time.Sleep(100 * time.Millisecond)
+	if !svc.cacheMux.TryLock() {
+		t.Fatal("cacheMux held while the fine history snapshot queries Dora")
+	}
+	svc.cacheMux.Unlock()
+	select {
+	case <-done:
+		t.Fatal("fine history appended before the delayed Dora query returned")
+	default:
+	}
+
+	<-done
+	entries, err := svc.FineNetworkHistory(0, math.MaxUint64)
+	if err != nil || len(entries) != 1 || entries[0].TotalEffectiveBalanceGwei != 32_000_000_000 {
+		t.Fatalf("FineNetworkHistory = %+v, %v; want one entry with Dora's balance", entries, err)
+	}
+}
+
+func TestFineHistoryCompactsLazily(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.FineHistoryFile = filepath.Join(t.TempDir(), "fine.jsonl")
+	cfg.FineHistoryInterval = 1
+	cfg.FineHistoryRetention = time.Hour
+	svc := NewService(cfg)
+
+	fileEpochs := func() []uint64 {
+		t.Helper()
+		svc.fineHistoryMu.Lock()
+		defer svc.fineHistoryMu.Unlock()
+		entries, err := svc.readFineHistoryLocked()
+		if err != nil {
+			t.Fatalf("read fine history: %v", err)
+		}
+		var epochs []uint64
+		for _, e := range entries {
+			epochs = append(epochs, e.Epoch)
+		}
+		return epochs
+	}
+
+	// Epoch 100 is past the one-hour retention at epoch 110 (9.375 epochs) but not a quarter past it,
+	// so the append leaves it in the file and only readers hide it.
+	for _, epoch := range []uint64{100, 110} {
+		svc.appendFineHistory(epoch, svc.Chain().EpochToTime(epoch))
+	}
+	if got := fileEpochs(); !slices.Equal(got, []uint64{100, 110}) {
+		t.Fatalf("file epochs = %v, want [100 110] before compaction", got)
+	}
+	entries, err := svc.FineNetworkHistory(0, math.MaxUint64)
+	if err != nil || len(entries) != 1 || entries[0].Epoch != 110 {
+		t.Fatalf("FineNetworkHistory = %+v, %v; want only epoch 110", entries, err)
+	}
+
+	svc.appendFineHistory(113, svc.Chain().EpochToTime(113))
+	if got := fileEpochs(); !slices.Equal(got, []uint64{110, 113}) {
+		t.Fatalf("file epochs = %v, want [110 113] after compaction", got)
+	}
+}
+
+func TestCacheCheckpointRestore(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(dir, "history.jsonl")
+	cfg.CacheCheckpointFile = filepath.Join(dir, "checkpoint.json")
+	cfg.CacheFlushInterval = time.Minute
+	now := time.Now()
+
+	svc := NewService(cfg)
+	svc.setCacheWindowStart(now)
+	data := newEpochRewards()
+	data.income[1] = &types.ValidatorEpochIncome{AttestationHeadReward: 10}
+	data.attesters = []uint64{1}
+	svc.cacheMux.Lock()
+	svc.applyEpochLocked(9, data)
+	svc.latestSyncEpoch = 9
+	svc.cacheMux.Unlock()
+
+	// Backfill finishes epochs out of order, so it is never checkpointed.
+	svc.setSyncPhase(SyncPhaseBackfill, 0, 9)
+	svc.writeCheckpoint()
+	if _, err := os.Stat(cfg.CacheCheckpointFile); !os.IsNotExist(err) {
+		t.Fatalf("checkpoint written during backfill: %v", err)
+	}
+	svc.setSyncPhase(SyncPhaseLive, 0, 9)
+	svc.writeCheckpoint()
+
+	restarted := NewService(cfg)
+	if !restarted.restoreCheckpoint(now) {
+		t.Fatal("restoreCheckpoint = false, want the same-window checkpoint restored")
+	}
+	if got := restarted.GetRewards([]uint64{1}); got[1] == nil || got[1].AttestationHeadReward != 10 {
+		t.Fatalf("restored rewards = %+v, want the checkpointed ones", got)
+	}
+	if restarted.latestSyncEpoch != 9 {
+		t.Fatalf("restored latestSyncEpoch = %d, want 9", restarted.latestSyncEpoch)
+	}
+
+	// Once the window has rolled over, the checkpoint belongs to a closed window.
+	rolled := NewService(cfg)
+	if rolled.restoreCheckpoint(now.Add(24 * time.Hour)) {
+		t.Fatal("restoreCheckpoint = true after the window rolled over, want it ignored")
+	}
+	if got := rolled.GetRewards([]uint64{1}); got[1] != nil && got[1].AttestationHeadReward != 0 {
+		t.Fatalf("rolled-over rewards = %+v, want an empty cache", got)
 	}
 }
@@ -0,0 +1,81 @@
+package rewards
+
+import (
+	"log/slog"
+	"slices"
+
+	"beacon-rewards/internal/config"
+
+	"github.com/gobitfly/eth-rewards/types"
+)
+
+// Beacon endpoints that some clients or versions do not serve: the v1 block endpoint is deprecated in
+// favour of v2 (see BEACON_BLOCK_API_VERSION), and older releases lack the rewards endpoints. eth-rewards maps every 404 to
+// ErrBlockNotFound, so an unsupported endpoint looks like a missed slot; it is told apart by another
+// endpoint finding a block for the same slot.
+const (
+	endpointBlocks               = "/eth/v1/beacon/blocks"
+	endpointBlocksV2             = "/eth/v2/beacon/blocks"
+	endpointBlockRewards         = "/eth/v1/beacon/rewards/blocks"
+	endpointSyncCommitteeRewards = "/eth/v1/beacon/rewards/sync_committee"
+)
+
+// markEndpointUnsupported records that endpoint returned 404 for a slot with a block and logs a
+// warning the first time, since the affected reward component is otherwise silently zero.
+func (s *Service) markEndpointUnsupported(endpoint string, slot uint64) {
+	if _, loaded := s.unsupportedEndpoints.LoadOrStore(endpoint, slot); loaded {
+		return
+	}
+	slog.Warn("Beacon node does not serve a rewards endpoint; the matching reward component will be missing",
+		"endpoint", endpoint, "slot", slot, "beacon_node", s.BeaconNodeURL())
+}
+
+// UnsupportedEndpoints lists the rewards endpoints detected as unsupported by a beacon node.
+func (s *Service) UnsupportedEndpoints() []string {
+	var endpoints []string
+	s.unsupportedEndpoints.Range(func(key, _ any) bool {
+		endpoints = append(endpoints, key.(string))
+		return true
+	})
+	slices.Sort(endpoints)
+	return endpoints
+}
+
+// executionBlockNumber returns the slot's execution block number; see executionPayload.
+func (s *Service) executionBlockNumber(slot uint64) (uint64, error) {
+	payload, err := s.executionPayload(slot)
+	return payload.BlockNumber, err
+}
+
+// executionPayload returns the slot's execution payload from the block endpoint selected by
+// BEACON_BLOCK_API_VERSION. With v1, a 404 is retried on v2; v2 finding the block means the node
+// dropped v1, which is reported once like any unsupported endpoint.
+func (s *Service) executionPayload(slot uint64) (executionPayload, error) {
+	if s.blockAPIV2Only() {
+		return s.beaconCL.ExecutionPayloadV2(slot)
+	}
+	blkNum, err := s.beaconCL.ExecutionBlockNumber(slot)
+	if err != types.ErrBlockNotFound {
+		return executionPayload{BlockNumber: blkNum}, err
+	}
+	payload, err := s.beaconCL.ExecutionPayloadV2(slot)
+	if err == nil {
+		s.markEndpointUnsupported(endpointBlocks, slot)
+	}
+	return payload, err
+}
+
+// blockAPIV2Only reports whether blocks are read from the v2 endpoint alone: when configured, and
+// when FEE_RECIPIENTS_FILE needs the fee recipient, which only the v2 block carries.
+func (s *Service) blockAPIV2Only() bool {
+	return s.config.BeaconBlockAPIVersion == config.BeaconBlockAPIV2 || s.trackFeeRecipients()
+}
+
+// markBlockEndpointsUnsupported records that every block endpoint executionBlockNumber tried returned
+// 404 for a slot with a block.
+func (s *Service) markBlockEndpointsUnsupported(slot uint64) {
+	if !s.blockAPIV2Only() {
+		s.markEndpointUnsupported(endpointBlocks, slot)
+	}
+	s.markEndpointUnsupported(endpointBlocksV2, slot)
+}
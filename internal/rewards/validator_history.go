@@ -0,0 +1,272 @@
+package rewards
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"math/big"
+	"os"
+	"slices"
+	"time"
+
+	"beacon-rewards/internal/utils"
+
+	"github.com/gobitfly/eth-rewards/types"
+)
+
+// The network history file keeps one aggregate per cache window, which cannot be split by address.
+// The validator history file complements it with per-validator totals for each completed window, so an
+// address's daily series can be rebuilt by summing the validators it currently resolves to. Only the
+// last ValidatorHistoryDays windows are retained; the file is rewritten on each cache reset.
+
+// validatorWindowTotals is one validator's rewards over a completed cache window.
+type validatorWindowTotals struct {
+	Cl int64 `json:"cl"`
+	El int64 `json:"el"`
+	// EffectiveBalance is the validator's effective balance (gwei) when the window closed; zero when
+	// Dora was unavailable.
+	EffectiveBalance int64 `json:"effective_balance,omitempty"`
+}
+
+// validatorHistoryEntry is one line of the validator history file.
+type validatorHistoryEntry struct {
+	WindowStart time.Time                        `json:"window_start"`
+	WindowEnd   time.Time                        `json:"window_end"`
+	Validators  map[uint64]validatorWindowTotals `json:"validators"`
+}
+
+// DailyRewards sums rewards for a set of validators over one completed cache window.
+type DailyRewards struct {
+	WindowStart      time.Time  `json:"window_start"`
+	WindowEnd        time.Time  `json:"window_end"`
+	ValidatorCount   int        `json:"validator_count"` // Validators with rewards in the window.
+	ClRewardsGwei    utils.Gwei `json:"cl_rewards_gwei"`
+	ElRewardsGwei    utils.Gwei `json:"el_rewards_gwei"`
+	TotalRewardsGwei utils.Gwei `json:"total_rewards_gwei"`
+}
+
+// ValidatorHistoryEnabled reports whether per-validator daily totals are being retained.
+func (s *Service) ValidatorHistoryEnabled() bool {
+	return s.validatorHistoryPath != "" && s.config.ValidatorHistoryDays > 0
+}
+
+// ValidatorRewardHistory returns per-window reward sums for the given validators over the last `days`
+// completed cache windows, oldest first. The current (open) window is not included.
+func (s *Service) ValidatorRewardHistory(validatorIndices []uint64, days int) ([]DailyRewards, error) {
+	if !s.ValidatorHistoryEnabled() {
+		return []DailyRewards{}, nil
+	}
+	s.historyMu.Lock()
+	defer s.historyMu.Unlock()
+	if err := s.loadValidatorHistoryLocked(); err != nil {
+		return nil, err
+	}
+
+	entries := s.validatorHistory
+	if days > 0 && len(entries) > days {
+		entries = entries[len(entries)-days:]
+	}
+
+	result := make([]DailyRewards, 0, len(entries))
+	for _, e := range entries {
+		day := DailyRewards{WindowStart: e.WindowStart, WindowEnd: e.WindowEnd}
+		for _, idx := range validatorIndices {
+			totals, ok := e.Validators[idx]
+			if !ok {
+				continue
+			}
+			day.ValidatorCount++
+			day.ClRewardsGwei += utils.Gwei(totals.Cl)
+			day.ElRewardsGwei += utils.Gwei(totals.El)
+		}
+		day.TotalRewardsGwei = day.ClRewardsGwei + day.ElRewardsGwei
+		result = append(result, day)
+	}
+	return result, nil
+}
+
+// ValidatorSetAPRHistory returns the APR the given validators earned in each of the last `days`
+// retained windows, oldest first. Per-window balances are not stored, so the current effective
+// balances are used (DefaultEffectiveBalanceGwei when missing); only validators with rewards in a
+// window count towards its balance. Windows where none of them had rewards are skipped.
+func (s *Service) ValidatorSetAPRHistory(validatorIndices []uint64, effectiveBalances map[uint64]int64, days int) ([]float64, error) {
+	if !s.ValidatorHistoryEnabled() {
+		return nil, nil
+	}
+	s.historyMu.Lock()
+	defer s.historyMu.Unlock()
+	if err := s.loadValidatorHistoryLocked(); err != nil {
+		return nil, err
+	}
+
+	entries := s.validatorHistory
+	if days > 0 && len(entries) > days {
+		entries = entries[len(entries)-days:]
+	}
+
+	aprs := make([]float64, 0, len(entries))
+	for _, e := range entries {
+		windowSeconds := e.WindowEnd.Sub(e.WindowStart).Seconds()
+		if windowSeconds <= 0 {
+			continue
+		}
+		var rewards, balance int64
+		for _, idx := range validatorIndices {
+			totals, ok := e.Validators[idx]
+			if !ok {
+				continue
+			}
+			rewards += totals.Cl + totals.El
+			if b := effectiveBalances[idx]; b > 0 {
+				balance += b
+			} else {
+				balance += s.config.DefaultEffectiveBalanceGwei
+			}
+		}
+		if balance == 0 {
+			continue
+		}
+		aprs = append(aprs, float64(rewards)/float64(balance)*(365*24*time.Hour).Seconds()/windowSeconds*100)
+	}
+	return aprs, nil
+}
+
+// PreviousWindowEffectiveBalances returns the effective balances recorded for the given validators
+// when the most recent completed window closed. Validators without a recorded balance are omitted;
+// the map is empty when no window has been retained yet.
+func (s *Service) PreviousWindowEffectiveBalances(validatorIndices []uint64) (map[uint64]int64, error) {
+	balances := make(map[uint64]int64)
+	if !s.ValidatorHistoryEnabled() {
+		return balances, nil
+	}
+	s.historyMu.Lock()
+	defer s.historyMu.Unlock()
+	if err := s.loadValidatorHistoryLocked(); err != nil {
+		return nil, err
+	}
+	if len(s.validatorHistory) == 0 {
+		return balances, nil
+	}
+
+	last := s.validatorHistory[len(s.validatorHistory)-1]
+	for _, idx := range validatorIndices {
+		if totals, ok := last.Validators[idx]; ok && totals.EffectiveBalance > 0 {
+			balances[idx] = totals.EffectiveBalance
+		}
+	}
+	return balances, nil
+}
+
+// validatorHistoryEntryOf captures a closed window as a history entry with the balances from
+// effectiveBalancesOf.
+func validatorHistoryEntryOf(w *closedWindow, balances map[uint64]int64) validatorHistoryEntry {
+	entry := validatorHistoryEntry{
+		WindowStart: w.snapshot.WindowStart,
+		WindowEnd:   w.snapshot.WindowEnd,
+		Validators:  make(map[uint64]validatorWindowTotals, len(w.cache)),
+	}
+	for idx, income := range w.cache {
+		entry.Validators[idx] = validatorWindowTotals{
+			Cl:               income.TotalClRewards(),
+			El:               new(big.Int).Div(weiBytesToBigInt(income.TxFeeRewardWei), gweiScalar).Int64(),
+			EffectiveBalance: balances[idx],
+		}
+	}
+	return entry
+}
+
+// effectiveBalancesOf looks up the effective balance of every validator of a closed window in Dora,
+// so the next window can report how it changed; nil without Dora or when the lookup fails.
+func (s *Service) effectiveBalancesOf(cache map[uint64]*types.ValidatorEpochIncome) map[uint64]int64 {
+	if s.doraDB == nil || len(cache) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, s.config.DBQueryTimeout)
+	defer cancel()
+	balances, err := s.doraDB.EffectiveBalances(ctx, slices.Collect(maps.Keys(cache)))
+	if err != nil {
+		slog.Warn("Failed to record effective balances for the closing window", "error", err)
+		return nil
+	}
+	return balances
+}
+
+// persistValidatorHistory appends entry, drops windows beyond ValidatorHistoryDays, and rewrites the file.
+func (s *Service) persistValidatorHistory(entry validatorHistoryEntry) {
+	if !s.ValidatorHistoryEnabled() {
+		return
+	}
+	s.historyMu.Lock()
+	defer s.historyMu.Unlock()
+	if err := s.loadValidatorHistoryLocked(); err != nil {
+		slog.Warn("Discarding unreadable validator history", "path", s.validatorHistoryPath, "error", err)
+		s.validatorHistory = nil
+	}
+
+	// Same-day windows replace each other, matching persistSnapshot.
+	if n := len(s.validatorHistory); n > 0 && sameWindowDay(s.validatorHistory[n-1].WindowStart, entry.WindowStart) {
+		s.validatorHistory[n-1] = entry
+	} else {
+		s.validatorHistory = append(s.validatorHistory, entry)
+	}
+	if keep := s.config.ValidatorHistoryDays; len(s.validatorHistory) > keep {
+		s.validatorHistory = append([]validatorHistoryEntry(nil), s.validatorHistory[len(s.validatorHistory)-keep:]...)
+	}
+
+	if err := s.writeValidatorHistoryLocked(); err != nil {
+		slog.Error("Failed to write validator history file", "path", s.validatorHistoryPath, "error", err)
+	}
+}
+
+// loadValidatorHistoryLocked reads the history file once; caller must hold historyMu.
+func (s *Service) loadValidatorHistoryLocked() error {
+	if s.validatorHistoryLoaded {
+		return nil
+	}
+	f, err := os.Open(s.validatorHistoryPath)
+	if os.IsNotExist(err) {
+		s.validatorHistoryLoaded = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var entries []validatorHistoryEntry
+	skipped, err := readJSONLines(f, func(line []byte) error {
+		var e validatorHistoryEntry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return err
+		}
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("read validator history: %w", err)
+	}
+	if skipped > 0 {
+		slog.Warn("Skipped malformed validator history lines", "path", s.validatorHistoryPath, "skipped", skipped)
+	}
+	if keep := s.config.ValidatorHistoryDays; len(entries) > keep {
+		entries = entries[len(entries)-keep:]
+	}
+	s.validatorHistory = entries
+	s.validatorHistoryLoaded = true
+	return nil
+}
+
+// writeValidatorHistoryLocked replaces the history file atomically; caller must hold historyMu.
+func (s *Service) writeValidatorHistoryLocked() error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for i := range s.validatorHistory {
+		if err := enc.Encode(&s.validatorHistory[i]); err != nil {
+			return err
+		}
+	}
+	return writeFileAtomic(s.validatorHistoryPath, buf.Bytes())
+}
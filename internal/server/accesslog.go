@@ -0,0 +1,73 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	// maxRequestIDLength bounds client-supplied request IDs before they reach the logs.
+	maxRequestIDLength = 128
+)
+
+// loggingMiddleware writes one access log line per request with the given fields (see
+// config.AccessLogFieldNames). It also tags the request with an ID: the client's X-Request-ID when
+// present, otherwise a random one, echoed back in the response.
+func loggingMiddleware(fields []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+		query := c.Request.URL.RawQuery
+
+		requestID := c.GetHeader(requestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = newRequestID()
+		}
+		c.Header(requestIDHeader, requestID)
+
+		c.Next()
+
+		args := make([]any, 0, 2*len(fields))
+		for _, field := range fields {
+			var value any
+			switch field {
+			case "method":
+				value = c.Request.Method
+			case "path":
+				value = path
+			case "query":
+				value = query
+			case "status":
+				value = c.Writer.Status()
+			case "latency":
+				value = time.Since(start)
+			case "ip":
+				value = c.ClientIP()
+			case "bytes":
+				// gin's writer counts body bytes; Size is -1 until something is written.
+				value = max(c.Writer.Size(), 0)
+			case "referer":
+				value = c.Request.Referer()
+			case "user_agent":
+				value = c.Request.UserAgent()
+			case "request_id":
+				value = requestID
+			default:
+				continue
+			}
+			args = append(args, field, value)
+		}
+		slog.Info("HTTP request", args...)
+	}
+}
+
+func newRequestID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLoggingMiddlewareRecordsResponseSize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var buf bytes.Buffer
+	original := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(original) })
+
+	body := strings.Repeat("x", 1234)
+	router := gin.New()
+	router.Use(loggingMiddleware([]string{"status", "bytes", "referer", "user_agent", "request_id"}))
+	router.GET("/ping", func(c *gin.Context) {
+		c.String(http.StatusOK, body)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set("Referer", "https://example.com/dashboard")
+	req.Header.Set("User-Agent", "probe/1.0")
+	req.Header.Set(requestIDHeader, "abc-123")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if got := w.Header().Get(requestIDHeader); got != "abc-123" {
+		t.Fatalf("response %s = %q, want the client's ID", requestIDHeader, got)
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("decode access log %q: %v", buf.String(), err)
+	}
+	if entry["bytes"] != float64(len(body)) {
+		t.Fatalf("bytes = %v, want %d", entry["bytes"], len(body))
+	}
+	if entry["referer"] != "https://example.com/dashboard" || entry["user_agent"] != "probe/1.0" || entry["request_id"] != "abc-123" {
+		t.Fatalf("unexpected access log fields: %v", entry)
+	}
+	if _, ok := entry["path"]; ok {
+		t.Fatalf("path was not selected but was logged: %v", entry)
+	}
+}
+
+func TestLoggingMiddlewareGeneratesRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(loggingMiddleware(nil))
+	router.GET("/empty", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/empty", nil))
+	if got := w.Header().Get(requestIDHeader); len(got) != 16 {
+		t.Fatalf("generated request ID = %q, want 16 hex characters", got)
+	}
+}
@@ -0,0 +1,192 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"beacon-rewards/internal/dora"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Electra constants behind the derived activation churn and the activation delay. The churn is a
+// balance, like the correlation penalty in estimation.go, so both follow the same fork.
+const (
+	minPerEpochChurnLimitGwei = 128_000_000_000 // MIN_PER_EPOCH_CHURN_LIMIT_ELECTRA
+	churnLimitQuotient        = 65536
+	maxSeedLookahead          = 4 // A dequeued validator activates 1 + MAX_SEED_LOOKAHEAD epochs later.
+)
+
+// ActivationQueueEstimate is the estimated activation of one pending validator.
+type ActivationQueueEstimate struct {
+	ValidatorIndex uint64 `json:"validator_index"`
+	QueuePosition  int64  `json:"queue_position"` // 1-based among all eligible validators pending activation.
+	// Scheduled is set once the chain has assigned the activation epoch; the estimate is then exact.
+	Scheduled bool `json:"scheduled"`
+	// The estimate is omitted while the validator is not yet eligible for activation, and so not queued.
+	EstimatedActivationEpoch *uint64    `json:"estimated_activation_epoch,omitempty"`
+	EstimatedActivationTime  *time.Time `json:"estimated_activation_time,omitempty"`
+}
+
+// ActivationQueueResponse reports the activation queue and estimates for the requested validators.
+type ActivationQueueResponse struct {
+	Epoch          uint64                    `json:"epoch"`
+	QueueLength    int64                     `json:"queue_length"`
+	ChurnLimitGwei uint64                    `json:"churn_limit_gwei"` // Balance activated per epoch assumed by the estimates.
+	Address        string                    `json:"address,omitempty"`
+	Validators     []ActivationQueueEstimate `json:"validators"`
+}
+
+// activationQueueHandler estimates when pending validators will be activated.
+// @Summary      Estimate activation-queue position and time
+// @Description  For a validator index, or every pending validator of an address, returns its position among validators eligible for activation and the estimated activation epoch and time. The queue is ordered by activation eligibility epoch, then index. Validators with an assigned activation epoch report it as is; queued ones wait until ACTIVATION_CHURN_LIMIT_GWEI per epoch (derived from the total active balance like the Electra churn when 0) has drained the effective balance ahead of and including them, plus the seed lookahead. Validators that are not yet eligible have no queue position or estimate.
+// @Tags         Validators
+// @Produce      json
+// @Param        index    query     int     false  "Validator index"
+// @Param        address  query     string  false  "Withdrawal/deposit address or withdrawal credentials"
+// @Success      200      {object}  ActivationQueueResponse
+// @Failure      400      {object}  map[string]string
+// @Failure      404      {object}  map[string]string
+// @Failure      500      {object}  map[string]string
+// @Failure      503      {object}  map[string]string
+// @Router       /validators/activation-queue [get]
+func (s *Server) activationQueueHandler(c *gin.Context) {
+	rawIndex := strings.TrimSpace(c.Query("index"))
+	address := strings.TrimSpace(c.Query("address"))
+	if (rawIndex == "") == (address == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "exactly one of index or address is required"})
+		return
+	}
+	var index uint64
+	if rawIndex != "" {
+		n, err := strconv.ParseUint(rawIndex, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "index must be a validator index"})
+			return
+		}
+		index = n
+	} else {
+		normalized, err := normalizeAddressInput(address)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		address = normalized
+	}
+	if !s.ensureDoraDB(c) {
+		return
+	}
+
+	ctx, cancel := s.requestContext(c)
+	defer cancel()
+
+	chain := s.chain()
+	currentEpoch := chain.TimeToEpoch(time.Now())
+	activation := make(map[uint64]uint64) // Pending validators by index with their activation epoch.
+	var indices []uint64
+	if rawIndex != "" {
+		lifecycles, err := s.doraDB.ValidatorLifecycles(ctx, []uint64{index})
+		if err != nil {
+			slog.Error("Failed to load validator lifecycle", "index", index, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load validator"})
+			return
+		}
+		lc, ok := lifecycles[index]
+		if !ok || lc.ActivationEpoch <= currentEpoch {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("validator %d is not pending activation", index)})
+			return
+		}
+		activation[index] = lc.ActivationEpoch
+		indices = append(indices, index)
+	} else {
+		details, err := s.doraDB.ValidatorDetailsByAddress(ctx, address)
+		if err != nil {
+			if errors.Is(err, dora.ErrInvalidAddress) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			slog.Error("Failed to load validators by address", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load validator details for addresses"})
+			return
+		}
+		for _, p := range pendingValidators(chain, details, currentEpoch) {
+			activation[p.ValidatorIndex] = p.ActivationEpoch
+			indices = append(indices, p.ValidatorIndex)
+		}
+	}
+
+	resp := ActivationQueueResponse{Epoch: currentEpoch, Address: address, Validators: []ActivationQueueEstimate{}}
+	queueLength, err := s.doraDB.ActivationQueueLength(ctx, currentEpoch)
+	if err != nil {
+		slog.Error("Failed to load activation queue length", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load activation queue"})
+		return
+	}
+	totalBalance, err := s.doraDB.TotalEffectiveBalance(ctx, currentEpoch)
+	if err != nil {
+		slog.Error("Failed to load total active balance", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load activation queue"})
+		return
+	}
+	positions, err := s.doraDB.ActivationQueuePositions(ctx, currentEpoch, indices)
+	if err != nil {
+		slog.Error("Failed to load activation queue positions", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load activation queue"})
+		return
+	}
+	resp.QueueLength = queueLength
+	resp.ChurnLimitGwei = s.activationChurnLimitGwei(totalBalance)
+
+	for _, idx := range indices {
+		pos, queued := positions[idx]
+		est := ActivationQueueEstimate{ValidatorIndex: idx, QueuePosition: pos.Position}
+		epoch := activation[idx]
+		switch {
+		case epoch != farFutureEpoch:
+			est.Scheduled = true
+		case queued:
+			epoch = estimateActivationEpoch(currentEpoch, pos.BalanceThroughGwei, resp.ChurnLimitGwei)
+		default:
+			resp.Validators = append(resp.Validators, est)
+			continue
+		}
+		at := chain.EpochStartTime(epoch)
+		est.EstimatedActivationEpoch = &epoch
+		est.EstimatedActivationTime = &at
+		resp.Validators = append(resp.Validators, est)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// activationChurnLimitGwei returns ACTIVATION_CHURN_LIMIT_GWEI, or derives the per-epoch activation
+// churn from the total active balance like Electra's get_activation_exit_churn_limit:
+// max(128 ETH, total / 65536) rounded down to a whole increment, capped at
+// MAX_ACTIVATION_CHURN_LIMIT_GWEI.
+func (s *Server) activationChurnLimitGwei(totalActiveBalanceGwei int64) uint64 {
+	if s.config.ActivationChurnLimitGwei > 0 {
+		return s.config.ActivationChurnLimitGwei
+	}
+	churn := uint64(max(totalActiveBalanceGwei, 0)) / churnLimitQuotient
+	churn = max(churn, minPerEpochChurnLimitGwei)
+	churn -= churn % effectiveBalanceIncrementGwei
+	if limit := s.config.MaxActivationChurnLimitGwei; limit > 0 {
+		churn = min(churn, limit)
+	}
+	return churn
+}
+
+// estimateActivationEpoch estimates when a queued validator activates: the queue drains churnGwei of
+// effective balance per epoch until balanceThroughGwei, the balance ahead of it plus its own, has
+// passed, and a dequeued validator activates after the seed lookahead.
+func estimateActivationEpoch(currentEpoch uint64, balanceThroughGwei int64, churnGwei uint64) uint64 {
+	if churnGwei == 0 {
+		churnGwei = minPerEpochChurnLimitGwei
+	}
+	epochsInQueue := (uint64(max(balanceThroughGwei, 1)) + churnGwei - 1) / churnGwei
+	return currentEpoch + epochsInQueue + maxSeedLookahead
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"beacon-rewards/internal/config"
+	"beacon-rewards/internal/dora"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestActivationChurnLimitGwei(t *testing.T) {
+	const eth = 1_000_000_000
+	cfg := config.DefaultConfig()
+	s := &Server{config: cfg}
+	for total, want := range map[int64]uint64{
+		0:                 128 * eth,
+		8_000_000 * eth:   128 * eth, // 122 ETH is below the minimum.
+		12_000_000 * eth:  183 * eth, // 183.1 ETH, rounded down to a whole increment.
+		100_000_000 * eth: 256 * eth, // Capped at MAX_ACTIVATION_CHURN_LIMIT_GWEI.
+	} {
+		if got := s.activationChurnLimitGwei(total); got != want {
+			t.Fatalf("activationChurnLimitGwei(%d) = %d, want %d", total, got, want)
+		}
+	}
+
+	cfg.MaxActivationChurnLimitGwei = 0
+	if got := s.activationChurnLimitGwei(100_000_000 * eth); got != 1525*eth {
+		t.Fatalf("uncapped activationChurnLimitGwei = %d, want %d", got, 1525*eth)
+	}
+	cfg.ActivationChurnLimitGwei = 64 * eth
+	if got := s.activationChurnLimitGwei(100_000_000 * eth); got != 64*eth {
+		t.Fatalf("configured activationChurnLimitGwei = %d, want %d", got, 64*eth)
+	}
+}
+
+func TestEstimateActivationEpoch(t *testing.T) {
+	const eth = 1_000_000_000
+	for _, tc := range []struct {
+		balanceThrough int64
+		want           uint64
+	}{
+		{32 * eth, 100 + 1 + maxSeedLookahead},
+		{256 * eth, 100 + 1 + maxSeedLookahead},
+		{288 * eth, 100 + 2 + maxSeedLookahead},
+		{2048 * eth, 100 + 8 + maxSeedLookahead}, // One compounding validator fills 8 epochs of churn.
+	} {
+		if got := estimateActivationEpoch(100, tc.balanceThrough, 256*eth); got != tc.want {
+			t.Fatalf("estimateActivationEpoch(100, %d, 256 ETH) = %d, want %d", tc.balanceThrough, got, tc.want)
+		}
+	}
+}
+
+func TestActivationQueueHandlerValidatesInput(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	s := &Server{config: config.DefaultConfig(), doraDB: &dora.DB{}}
+	for query, want := range map[string]int{
+		"":                      http.StatusBadRequest,
+		"?index=1&address=0x01": http.StatusBadRequest,
+		"?index=abc":            http.StatusBadRequest,
+		"?address=0x123":        http.StatusBadRequest,
+		"?index=5":              http.StatusNotFound, // Unknown to the (empty) database.
+		"?address=0x0988dc1554cf6877508208fff8aab4e5afa11ee3": http.StatusOK,
+	} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/validators/activation-queue"+query, nil)
+		s.activationQueueHandler(c)
+		if w.Code != want {
+			t.Fatalf("%q: status = %d, want %d: %s", query, w.Code, want, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// addressCacheKey identifies one POST /rewards/by-address result.
+type addressCacheKey struct {
+	address            string    // Normalized (lower-case) address or bls: key.
+	windowStart        time.Time // Start of the reward window the result covers.
+	epoch              uint64    // latestSyncEpoch the result was computed at.
+	includeIndices     bool
+	includeZeroRewards bool
+}
+
+type addressCacheEntry struct {
+	key     addressCacheKey
+	result  AddressRewardsResult
+	expires time.Time
+}
+
+// addressResultCache is a size-bounded LRU of address results. Entries live for at most ttl and are
+// all dropped once a request sees a newer sync epoch or a new reward window, so results never outlive
+// the epoch they cover or survive the midnight reset.
+type addressResultCache struct {
+	size int
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	window  time.Time
+	epoch   uint64
+	order   *list.List // Front is most recently used; elements hold *addressCacheEntry.
+	entries map[addressCacheKey]*list.Element
+}
+
+func newAddressResultCache(size int, ttl time.Duration) *addressResultCache {
+	return &addressResultCache{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[addressCacheKey]*list.Element, size),
+	}
+}
+
+// get returns the cached result for key if present and not expired.
+func (c *addressResultCache) get(key addressCacheKey, now time.Time) (AddressRewardsResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.advanceLocked(key)
+	el, ok := c.entries[key]
+	if !ok {
+		return AddressRewardsResult{}, false
+	}
+	entry := el.Value.(*addressCacheEntry)
+	if now.After(entry.expires) {
+		c.removeLocked(el)
+		return AddressRewardsResult{}, false
+	}
+	c.order.MoveToFront(el)
+	return entry.result, true
+}
+
+// put stores result under key, evicting the least recently used entry when full.
+func (c *addressResultCache) put(key addressCacheKey, result AddressRewardsResult, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.advanceLocked(key)
+	if key.epoch < c.epoch || key.windowStart.Before(c.window) {
+		return // Computed before the epoch advanced or the window reset; already outdated.
+	}
+	if el, ok := c.entries[key]; ok {
+		entry := el.Value.(*addressCacheEntry)
+		entry.result = result
+		entry.expires = now.Add(c.ttl)
+		c.order.MoveToFront(el)
+		return
+	}
+	for c.order.Len() >= c.size {
+		c.removeLocked(c.order.Back())
+	}
+	c.entries[key] = c.order.PushFront(&addressCacheEntry{key: key, result: result, expires: now.Add(c.ttl)})
+}
+
+// advanceLocked drops every entry once a newer sync epoch or reward window is seen.
+func (c *addressResultCache) advanceLocked(key addressCacheKey) {
+	if key.epoch <= c.epoch && !key.windowStart.After(c.window) {
+		return
+	}
+	c.epoch = max(c.epoch, key.epoch)
+	if key.windowStart.After(c.window) {
+		c.window = key.windowStart
+	}
+	c.order.Init()
+	clear(c.entries)
+}
+
+func (c *addressResultCache) removeLocked(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.entries, el.Value.(*addressCacheEntry).key)
+}
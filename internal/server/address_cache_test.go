@@ -0,0 +1,92 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAddressResultCache(t *testing.T) {
+	now := time.Now()
+	c := newAddressResultCache(2, time.Minute)
+	key := func(addr string, epoch uint64) addressCacheKey {
+		return addressCacheKey{address: addr, epoch: epoch}
+	}
+
+	c.put(key("a", 10), AddressRewardsResult{Address: "a"}, now)
+	c.put(key("b", 10), AddressRewardsResult{Address: "b"}, now)
+	if got, ok := c.get(key("a", 10), now); !ok || got.Address != "a" {
+		t.Fatalf("get(a) = (%+v, %v), want cached result", got, ok)
+	}
+	if _, ok := c.get(addressCacheKey{address: "a", epoch: 10, includeIndices: true}, now); ok {
+		t.Fatalf("include_validator_indices must be part of the key")
+	}
+
+	// "b" is least recently used and is evicted by the third entry.
+	c.put(key("c", 10), AddressRewardsResult{Address: "c"}, now)
+	if _, ok := c.get(key("b", 10), now); ok {
+		t.Fatalf("expected b to be evicted")
+	}
+	if _, ok := c.get(key("a", 10), now); !ok {
+		t.Fatalf("expected a to survive eviction")
+	}
+
+	if _, ok := c.get(key("a", 10), now.Add(2*time.Minute)); ok {
+		t.Fatalf("expected the entry to expire after the TTL")
+	}
+
+	c.put(key("c", 10), AddressRewardsResult{Address: "c"}, now)
+	if _, ok := c.get(key("d", 11), now); ok {
+		t.Fatalf("unexpected hit for a new epoch")
+	}
+	if len(c.entries) != 0 || c.order.Len() != 0 {
+		t.Fatalf("advancing the epoch should drop all entries, have %d", len(c.entries))
+	}
+	c.put(key("c", 10), AddressRewardsResult{Address: "c"}, now)
+	if len(c.entries) != 0 {
+		t.Fatalf("results computed for an older epoch should not be stored")
+	}
+}
+
+func TestAddressResultCacheClearsOnWindowReset(t *testing.T) {
+	now := time.Now()
+	midnight := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	c := newAddressResultCache(4, time.Hour)
+	before := addressCacheKey{address: "a", windowStart: midnight.Add(-24 * time.Hour), epoch: 10}
+	c.put(before, AddressRewardsResult{Address: "a"}, now)
+
+	// The reset keeps latestSyncEpoch, so only the new window start tells the results apart.
+	after := before
+	after.windowStart = midnight
+	if _, ok := c.get(after, now); ok {
+		t.Fatal("result of the closed window served after the reset")
+	}
+	if len(c.entries) != 0 {
+		t.Fatalf("the reset should drop all entries, have %d", len(c.entries))
+	}
+	c.put(before, AddressRewardsResult{Address: "a"}, now)
+	if len(c.entries) != 0 {
+		t.Fatal("results computed for the closed window should not be stored")
+	}
+}
+
+func TestAddressResultCacheConcurrentUse(t *testing.T) {
+	c := newAddressResultCache(8, time.Minute)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				k := addressCacheKey{address: fmt.Sprintf("0x%d", i%16), epoch: uint64(i / 50)}
+				c.put(k, AddressRewardsResult{Address: k.address}, time.Now())
+				c.get(k, time.Now())
+			}
+		}(g)
+	}
+	wg.Wait()
+	if c.order.Len() > 8 || len(c.entries) != c.order.Len() {
+		t.Fatalf("cache size %d/%d exceeds bound or is inconsistent", c.order.Len(), len(c.entries))
+	}
+}
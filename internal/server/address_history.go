@@ -0,0 +1,59 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// addressRewardsAtHandler returns what an address earned in one past cache window.
+// @Summary      Get an address's rewards for a past day
+// @Description  Returns the CL/EL totals, effective balance and APR an address's validators earned in the completed cache window that started on the given day (UTC+8), as recorded at that window's reset. Only labelled addresses and addresses with validators queried through POST /rewards/by-address within ADDRESS_HISTORY_DAYS are recorded, so an address is available from the window after its first query. Validators are those the address funded when the window closed.
+// @Tags         Rewards
+// @Produce      json
+// @Param        address  query     string  true  "Withdrawal/deposit address or withdrawal credentials"
+// @Param        date     query     string  true  "Window start day in UTC+8 (YYYY-MM-DD)"
+// @Success      200      {object}  rewards.AddressWindowRewards
+// @Failure      400      {object}  map[string]string
+// @Failure      404      {object}  map[string]string
+// @Failure      500      {object}  map[string]string
+// @Failure      503      {object}  map[string]string
+// @Router       /rewards/by-address/at [get]
+func (s *Server) addressRewardsAtHandler(c *gin.Context) {
+	if !s.rewardsService.AddressHistoryEnabled() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Per-address daily history is disabled"})
+		return
+	}
+
+	address := strings.TrimSpace(c.Query("address"))
+	if address == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Address cannot be empty"})
+		return
+	}
+	address, err := normalizeAddressInput(address)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// Midnight UTC falls on the same UTC+8 calendar day, which is all the lookup compares.
+	date, err := time.Parse(time.DateOnly, strings.TrimSpace(c.Query("date")))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date must be formatted as YYYY-MM-DD"})
+		return
+	}
+
+	result, ok, err := s.rewardsService.AddressRewardsAt(address, date)
+	if err != nil {
+		slog.Error("Failed to load address history", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load address history"})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no rewards recorded for " + address + " on " + date.Format(time.DateOnly)})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
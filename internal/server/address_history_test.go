@@ -0,0 +1,65 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"beacon-rewards/internal/config"
+	"beacon-rewards/internal/rewards"
+)
+
+func TestAddressRewardsAtHandler(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(dir, "history.jsonl")
+	cfg.AddressHistoryFile = filepath.Join(dir, "address_history.jsonl")
+	cfg.AddressHistoryDays = 365
+	cfg.EnableFrontend = false
+
+	address := "0x00000000219ab540356cbb839cbe05303d7705fa"
+	line := `{"window_start":"2024-03-10T00:00:00+08:00","window_end":"2024-03-11T00:00:00+08:00",` +
+		`"addresses":{"` + address + `":{"validator_count":1,"cl":2000,"el":200,"effective_balance":32000000000}}}` + "\n"
+	if err := os.WriteFile(cfg.AddressHistoryFile, []byte(line), 0o644); err != nil {
+		t.Fatalf("write address history: %v", err)
+	}
+
+	get := func(s *Server, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	disabled := *cfg
+	disabled.AddressHistoryDays = 0
+	if w := get(NewServer(&disabled, rewards.NewService(&disabled), nil), "/rewards/by-address/at?address="+address+"&date=2024-03-10"); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("disabled: status = %d, want 503", w.Code)
+	}
+
+	s := NewServer(cfg, rewards.NewService(cfg), nil)
+	for target, want := range map[string]int{
+		"/rewards/by-address/at?date=2024-03-10":                                 http.StatusBadRequest,
+		"/rewards/by-address/at?address=" + address + "&date=10/03/2024":         http.StatusBadRequest,
+		"/rewards/by-address/at?address=" + address + "&date=2024-03-11":         http.StatusNotFound,
+		"/rewards/by-address/at?address=0x" + address[4:] + "00&date=2024-03-10": http.StatusNotFound,
+	} {
+		if w := get(s, target); w.Code != want {
+			t.Fatalf("GET %s: status = %d, want %d: %s", target, w.Code, want, w.Body.String())
+		}
+	}
+
+	w := get(s, "/rewards/by-address/at?address="+address+"&date=2024-03-10")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	var resp rewards.AddressWindowRewards
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Address != address || resp.TotalRewardsGwei != 2200 || resp.ValidatorCount != 1 || resp.AprPercent <= 0 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+}
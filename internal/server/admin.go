@@ -0,0 +1,179 @@
+package server
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"beacon-rewards/internal/rewards"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaintenanceRequest toggles maintenance mode.
+type MaintenanceRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// NodesRequest replaces the nodes the service syncs from; an omitted field keeps the current nodes.
+type NodesRequest struct {
+	// BeaconNodeURL is a comma-separated list in BEACON_NODE_URL format, weights included.
+	BeaconNodeURL    string `json:"beacon_node_url"`
+	ExecutionNodeURL string `json:"execution_node_url"`
+}
+
+// requireAdmin only lets requests carrying the configured ADMIN_TOKEN as a bearer token through.
+func (s *Server) requireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !s.isAdmin(c) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "admin token required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// isAdmin reports whether the request carries the configured ADMIN_TOKEN as a bearer token.
+// It is always false when no token is configured.
+func (s *Server) isAdmin(c *gin.Context) bool {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	return ok && s.config.AdminToken != "" &&
+		subtle.ConstantTimeCompare([]byte(token), []byte(s.config.AdminToken)) == 1
+}
+
+// maintenanceGate answers every request except health checks and admin calls with 503 while
+// maintenance mode is on, so clients see a deliberate pause instead of Dora or node errors.
+func (s *Server) maintenanceGate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !s.maintenance.Load() {
+			c.Next()
+			return
+		}
+		path := unversionedPath(c.Request.URL.Path)
+		if path == "/health" || strings.HasPrefix(path, "/admin/") {
+			c.Next()
+			return
+		}
+		setRetryAfter(c, s.config.RetryAfter)
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"status": "maintenance"})
+	}
+}
+
+// maintenanceHandler switches maintenance mode on or off.
+// @Summary      Toggle maintenance mode
+// @Description  While enabled, every endpoint except /health and /admin/* returns 503 with {"status":"maintenance"}. Requires Authorization: Bearer <ADMIN_TOKEN>.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        Authorization  header  string              true  "Bearer <ADMIN_TOKEN>"
+// @Param        request        body    MaintenanceRequest  true  "Desired state"
+// @Success      200  {object}  map[string]bool
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Router       /admin/maintenance [post]
+func (s *Server) maintenanceHandler(c *gin.Context) {
+	var req MaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		bindFailed(c, err, "Invalid request body: enabled is required")
+		return
+	}
+	s.maintenance.Store(*req.Enabled)
+	c.JSON(http.StatusOK, gin.H{"maintenance": *req.Enabled})
+}
+
+// retryEpochHandler re-processes a failed or retained epoch.
+// @Summary      Re-process an epoch
+// @Description  Re-processes an epoch listed by /sync/failed-epochs, or one of the last RETAINED_EPOCH_CONTRIBUTIONS processed epochs. A retained epoch's previous contribution is subtracted before the new one is added, so no epoch is counted twice; if processing fails it is left unchanged. Requires Authorization: Bearer <ADMIN_TOKEN>.
+// @Tags         Admin
+// @Produce      json
+// @Param        Authorization  header  string  true  "Bearer <ADMIN_TOKEN>"
+// @Param        epoch          path    int     true  "Epoch number"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/sync/retry-epoch/{epoch} [post]
+func (s *Server) retryEpochHandler(c *gin.Context) {
+	epoch, err := strconv.ParseUint(c.Param("epoch"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "epoch must be an epoch number"})
+		return
+	}
+
+	err = s.rewardsService.RetryEpoch(epoch)
+	switch {
+	case errors.Is(err, rewards.ErrEpochUnknown):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, rewards.ErrEpochRetryInProgress):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err != nil:
+		slog.Error("Failed to retry epoch", "epoch", epoch, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process epoch; its previous data is unchanged"})
+	default:
+		c.JSON(http.StatusOK, gin.H{"epoch": epoch, "processed": true})
+	}
+}
+
+// removeEpochHandler takes an epoch's data out of the current window.
+// @Summary      Remove an epoch
+// @Description  Subtracts a retained epoch's contribution from every total of the current window, or drops a failed epoch from /sync/failed-epochs. Requires Authorization: Bearer <ADMIN_TOKEN>.
+// @Tags         Admin
+// @Produce      json
+// @Param        Authorization  header  string  true  "Bearer <ADMIN_TOKEN>"
+// @Param        epoch          path    int     true  "Epoch number"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /admin/sync/epochs/{epoch} [delete]
+func (s *Server) removeEpochHandler(c *gin.Context) {
+	epoch, err := strconv.ParseUint(c.Param("epoch"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "epoch must be an epoch number"})
+		return
+	}
+
+	if err := s.rewardsService.RemoveEpoch(epoch); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"epoch": epoch, "removed": true})
+}
+
+// nodesHandler swaps the beacon and/or execution nodes without a restart.
+// @Summary      Replace the beacon/EL nodes
+// @Description  Replaces the beacon node list and/or the execution node used for syncing. Each new node must answer a quick connectivity probe (/eth/v1/node/version, eth_chainId) or nothing is changed. Requests already in flight finish on the old nodes. Returns the active set. Requires Authorization: Bearer <ADMIN_TOKEN>.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        Authorization  header  string        true  "Bearer <ADMIN_TOKEN>"
+// @Param        request        body    NodesRequest  true  "New nodes"
+// @Success      200  {object}  rewards.NodeSet
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      502  {object}  map[string]string
+// @Router       /admin/nodes [post]
+func (s *Server) nodesHandler(c *gin.Context) {
+	var req NodesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		bindFailed(c, err, "Invalid request body")
+		return
+	}
+	if strings.TrimSpace(req.BeaconNodeURL) == "" && strings.TrimSpace(req.ExecutionNodeURL) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "beacon_node_url or execution_node_url is required"})
+		return
+	}
+
+	nodes, err := s.rewardsService.SetNodes(c.Request.Context(), req.BeaconNodeURL, req.ExecutionNodeURL)
+	if err != nil {
+		slog.Warn("Rejected node replacement", "error", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, nodes)
+}
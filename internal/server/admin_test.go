@@ -0,0 +1,172 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"beacon-rewards/internal/config"
+	"beacon-rewards/internal/rewards"
+)
+
+func TestMaintenanceModeToggle(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.EnableFrontend = false
+	cfg.AdminToken = "secret"
+	s := NewServer(cfg, rewards.NewService(cfg), nil)
+
+	do := func(method, path, body, token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		s.router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do(http.MethodGet, "/sync/status", "", ""); w.Code != http.StatusOK {
+		t.Fatalf("status before maintenance = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	if w := do(http.MethodPost, "/admin/maintenance", `{"enabled":true}`, "wrong"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("toggle with wrong token = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+	if w := do(http.MethodPost, "/admin/maintenance", `{"enabled":true}`, "secret"); w.Code != http.StatusOK {
+		t.Fatalf("enable maintenance = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	w := do(http.MethodGet, "/sync/status", "", "")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status during maintenance = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	if got := w.Header().Get("Retry-After"); got != "30" {
+		t.Fatalf("Retry-After = %q, want %q", got, "30")
+	}
+	var body map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body["status"] != "maintenance" {
+		t.Fatalf("unexpected maintenance body %q (err %v)", w.Body.String(), err)
+	}
+
+	w = do(http.MethodGet, "/health", "", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("health during maintenance = %d, want %d", w.Code, http.StatusOK)
+	}
+	var health map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &health); err != nil {
+		t.Fatalf("decode health: %v", err)
+	}
+	if health["status"] != "healthy" || health["maintenance"] != true {
+		t.Fatalf("unexpected health response: %v", health)
+	}
+
+	if w := do(http.MethodPost, "/admin/maintenance", `{"enabled":false}`, "secret"); w.Code != http.StatusOK {
+		t.Fatalf("disable maintenance = %d, want %d", w.Code, http.StatusOK)
+	}
+	if w := do(http.MethodGet, "/sync/status", "", ""); w.Code != http.StatusOK {
+		t.Fatalf("status after maintenance = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestAdminRoutesRequireConfiguredToken(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.EnableFrontend = false
+	cfg.MaintenanceMode = true
+	s := NewServer(cfg, nil, nil)
+
+	// Without ADMIN_TOKEN there is no way to leave maintenance mode via the API.
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/admin/maintenance", strings.NewReader(`{"enabled":false}`))
+	s.router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("admin route without token = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/rewards/range?from=1&to=2", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("MAINTENANCE_MODE should start the API in maintenance, got %d", w.Code)
+	}
+}
+
+func TestForceRecomputeRequiresAdminToken(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.EnableFrontend = false
+	cfg.AdminToken = "secret"
+	s := NewServer(cfg, rewards.NewService(cfg), nil)
+
+	for token, want := range map[string]int{"": http.StatusUnauthorized, "wrong": http.StatusUnauthorized, "secret": http.StatusOK} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/rewards/network?force_recompute=true", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		s.router.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Fatalf("force_recompute with token %q = %d, want %d", token, w.Code, want)
+		}
+	}
+}
+
+func TestRetryEpochRejectsEpochsThatDidNotFail(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.EnableFrontend = false
+	cfg.AdminToken = "secret"
+	s := NewServer(cfg, rewards.NewService(cfg), nil)
+
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/sync/failed-epochs", nil))
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != "[]" {
+		t.Fatalf("failed epochs = %d %q, want 200 []", w.Code, w.Body.String())
+	}
+
+	for path, want := range map[string]int{
+		"/admin/sync/retry-epoch/12":   http.StatusNotFound,
+		"/admin/sync/retry-epoch/next": http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		s.router.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Fatalf("POST %s = %d, want %d", path, w.Code, want)
+		}
+	}
+}
+
+func TestNodesHandler(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.EnableFrontend = false
+	cfg.AdminToken = "secret"
+	s := NewServer(cfg, rewards.NewService(cfg), nil)
+
+	post := func(body, token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/admin/nodes", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		s.router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := post(`{"execution_node_url":"http://x"}`, "wrong"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong token: status = %d, want 401", w.Code)
+	}
+	if w := post(`{}`, "secret"); w.Code != http.StatusBadRequest {
+		t.Fatalf("no nodes: status = %d, want 400", w.Code)
+	}
+	// Nothing listens on port 1, so the probe fails and the nodes are kept.
+	if w := post(`{"beacon_node_url":"http://127.0.0.1:1"}`, "secret"); w.Code != http.StatusBadGateway {
+		t.Fatalf("unreachable node: status = %d, want 502: %s", w.Code, w.Body.String())
+	}
+	if got := s.rewardsService.BeaconNodeURL(); got != cfg.BeaconNodeURL {
+		t.Fatalf("beacon nodes = %q after a failed probe, want %q", got, cfg.BeaconNodeURL)
+	}
+}
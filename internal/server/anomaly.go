@@ -0,0 +1,156 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"beacon-rewards/internal/rewards"
+)
+
+// Alert kinds sent to ANOMALY_WEBHOOK_URL; each kind is debounced separately.
+const (
+	anomalyAPRDeviation   = "apr_deviation"
+	anomalyMissedProposal = "missed_proposals"
+)
+
+// AnomalyAlert is the JSON payload posted to the anomaly webhook.
+type AnomalyAlert struct {
+	Kind              string    `json:"kind"`
+	Message           string    `json:"message"`
+	DetectedAt        time.Time `json:"detected_at"`
+	WindowStart       time.Time `json:"window_start"`
+	WindowEnd         time.Time `json:"window_end"`
+	CurrentAprPercent float64   `json:"current_apr_percent"`
+	AverageAprPercent float64   `json:"average_apr_percent"`
+	BlocksProposed    uint64    `json:"blocks_proposed"`
+	ProposalsMissed   uint64    `json:"proposals_missed"`
+}
+
+// anomalyNotifier posts alerts to the webhook, dropping repeats of a kind within the debounce period.
+type anomalyNotifier struct {
+	url      string
+	client   *http.Client
+	debounce time.Duration
+
+	mu       sync.Mutex
+	lastSent map[string]time.Time
+}
+
+func newAnomalyNotifier(url string, timeout, debounce time.Duration) *anomalyNotifier {
+	return &anomalyNotifier{
+		url:      url,
+		client:   &http.Client{Timeout: timeout},
+		debounce: debounce,
+		lastSent: make(map[string]time.Time),
+	}
+}
+
+// detectAnomalies compares the snapshot with the 31-day average APR and the window's proposal counts.
+// The APR check needs an available APR and a positive average; the missed-proposal check waits for at
+// least an epoch's worth (slotsPerEpoch) of proposals so a single early miss does not read as a spike.
+func detectAnomalies(snap *rewards.NetworkRewardSnapshot, avgAPR float64, proposed, missed, slotsPerEpoch uint64, aprFactor, missedRatio float64, now time.Time) []AnomalyAlert {
+	base := AnomalyAlert{
+		DetectedAt:        now.UTC(),
+		WindowStart:       snap.WindowStart,
+		WindowEnd:         snap.WindowEnd,
+		CurrentAprPercent: snap.ProjectAprPercent,
+		AverageAprPercent: avgAPR,
+		BlocksProposed:    proposed,
+		ProposalsMissed:   missed,
+	}
+
+	var alerts []AnomalyAlert
+	if snap.AprAvailable && avgAPR > 0 {
+		apr := snap.ProjectAprPercent
+		if apr > avgAPR*aprFactor || apr < avgAPR/aprFactor {
+			alert := base
+			alert.Kind = anomalyAPRDeviation
+			alert.Message = fmt.Sprintf("network APR %.3f%% deviates from the 31-day average %.3f%% by more than a factor of %g", apr, avgAPR, aprFactor)
+			alerts = append(alerts, alert)
+		}
+	}
+	if total := proposed + missed; total >= slotsPerEpoch && float64(missed) > float64(total)*missedRatio {
+		alert := base
+		alert.Kind = anomalyMissedProposal
+		alert.Message = fmt.Sprintf("%d of %d proposals in the window were missed (threshold %.1f%%)", missed, total, missedRatio*100)
+		alerts = append(alerts, alert)
+	}
+	return alerts
+}
+
+// notify posts the alert unless one of the same kind was sent within the debounce period. Only an
+// alert the webhook accepted starts the period, so a failed delivery is retried on the next check.
+func (n *anomalyNotifier) notify(ctx context.Context, alert AnomalyAlert) {
+	n.mu.Lock()
+	last, ok := n.lastSent[alert.Kind]
+	n.mu.Unlock()
+	if ok && alert.DetectedAt.Sub(last) < n.debounce {
+		return
+	}
+
+	body, err := json.Marshal(alert)
+	if err != nil {
+		slog.Error("Failed to encode anomaly alert", "kind", alert.Kind, "error", err)
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		slog.Error("Failed to create anomaly webhook request", "error", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		slog.Warn("Anomaly webhook request failed", "kind", alert.Kind, "error", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		slog.Warn("Anomaly webhook rejected alert", "kind", alert.Kind, "status", resp.Status)
+		return
+	}
+	n.mu.Lock()
+	n.lastSent[alert.Kind] = alert.DetectedAt
+	n.mu.Unlock()
+	slog.Info("Sent anomaly alert", "kind", alert.Kind, "message", alert.Message)
+}
+
+// anomalyRoutine checks for anomalies once per epoch until ctx is cancelled.
+func (s *Server) anomalyRoutine(ctx context.Context, n *anomalyNotifier) {
+	ticker := time.NewTicker(time.Duration(s.chain().SecondsPerEpoch()) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.checkAnomalies(ctx, n, time.Now())
+		}
+	}
+}
+
+func (s *Server) checkAnomalies(ctx context.Context, n *anomalyNotifier, now time.Time) {
+	snap := s.rewardsService.TotalNetworkRewards()
+	history, err := s.rewardsService.NetworkRewardHistory()
+	if err != nil {
+		slog.Warn("Failed to load reward history for anomaly check", "error", err)
+	}
+	// The average excludes the current window so a sharp move is not diluted by itself.
+	var avgAPR float64
+	if len(history) > 0 {
+		avgAPR = calculate31DayAverageAPR(history, nil)
+	}
+	proposed, missed := s.rewardsService.ProposalCounts()
+
+	for _, alert := range detectAnomalies(snap, avgAPR, proposed, missed, s.chain().SlotsPerEpoch, s.config.AnomalyAPRFactor, s.config.AnomalyMissedRatio, now) {
+		n.notify(ctx, alert)
+	}
+}
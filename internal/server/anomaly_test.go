@@ -0,0 +1,88 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"beacon-rewards/internal/rewards"
+)
+
+func TestDetectAnomalies(t *testing.T) {
+	now := time.Now()
+	snap := &rewards.NetworkRewardSnapshot{AprAvailable: true, ProjectAprPercent: 3}
+
+	if alerts := detectAnomalies(snap, 3.2, 100, 2, 32, 2, 0.1, now); len(alerts) != 0 {
+		t.Fatalf("expected no alerts for normal values, got %+v", alerts)
+	}
+
+	snap.ProjectAprPercent = 1.2
+	alerts := detectAnomalies(snap, 3.2, 100, 2, 32, 2, 0.1, now)
+	if len(alerts) != 1 || alerts[0].Kind != anomalyAPRDeviation {
+		t.Fatalf("expected an APR deviation alert, got %+v", alerts)
+	}
+
+	snap.AprAvailable = false
+	if alerts := detectAnomalies(snap, 3.2, 100, 2, 32, 2, 0.1, now); len(alerts) != 0 {
+		t.Fatalf("APR should not be judged while unavailable, got %+v", alerts)
+	}
+
+	alerts = detectAnomalies(snap, 3.2, 80, 20, 32, 2, 0.1, now)
+	if len(alerts) != 1 || alerts[0].Kind != anomalyMissedProposal || alerts[0].ProposalsMissed != 20 {
+		t.Fatalf("expected a missed proposal alert, got %+v", alerts)
+	}
+	if alerts := detectAnomalies(snap, 3.2, 2, 3, 32, 2, 0.1, now); len(alerts) != 0 {
+		t.Fatalf("too few proposals to judge a spike, got %+v", alerts)
+	}
+}
+
+func TestAnomalyNotifierDebounces(t *testing.T) {
+	var received []AnomalyAlert
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert AnomalyAlert
+		if err := json.NewDecoder(r.Body).Decode(&alert); err != nil {
+			t.Errorf("decode alert: %v", err)
+		}
+		received = append(received, alert)
+	}))
+	t.Cleanup(hook.Close)
+
+	n := newAnomalyNotifier(hook.URL, time.Second, time.Hour)
+	now := time.Now()
+	n.notify(context.Background(), AnomalyAlert{Kind: anomalyAPRDeviation, DetectedAt: now})
+	n.notify(context.Background(), AnomalyAlert{Kind: anomalyAPRDeviation, DetectedAt: now.Add(30 * time.Minute)})
+	n.notify(context.Background(), AnomalyAlert{Kind: anomalyMissedProposal, DetectedAt: now.Add(30 * time.Minute)})
+	n.notify(context.Background(), AnomalyAlert{Kind: anomalyAPRDeviation, DetectedAt: now.Add(2 * time.Hour)})
+
+	if len(received) != 3 {
+		t.Fatalf("webhook received %d alerts, want 3", len(received))
+	}
+	if received[1].Kind != anomalyMissedProposal {
+		t.Fatalf("kinds should be debounced separately, got %+v", received)
+	}
+}
+
+func TestAnomalyNotifierRetriesFailedDelivery(t *testing.T) {
+	var attempts int
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(hook.Close)
+
+	n := newAnomalyNotifier(hook.URL, time.Second, time.Hour)
+	now := time.Now()
+	n.notify(context.Background(), AnomalyAlert{Kind: anomalyAPRDeviation, DetectedAt: now})
+	n.notify(context.Background(), AnomalyAlert{Kind: anomalyAPRDeviation, DetectedAt: now.Add(time.Minute)})
+	n.notify(context.Background(), AnomalyAlert{Kind: anomalyAPRDeviation, DetectedAt: now.Add(2 * time.Minute)})
+
+	// The rejected alert must not debounce the retry; the accepted retry debounces the third.
+	if attempts != 2 {
+		t.Fatalf("webhook received %d attempts, want 2", attempts)
+	}
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"time"
+)
+
+// aprDistributionBuildTimeout bounds one rebuild of the APR distribution. The rebuild looks up the
+// effective balance of every cached validator in exportBatchSize batches, which on a large network
+// takes far longer than REQUEST_TIMEOUT, so it runs in the background and requests only read the
+// result.
+const aprDistributionBuildTimeout = 10 * time.Minute
+
+// aprDistributionRoutine rebuilds the APR distribution every EPOCH_CHECK_INTERVAL in which the synced
+// epoch or the reward window moved, until ctx is cancelled.
+func (s *Server) aprDistributionRoutine(ctx context.Context) {
+	interval := s.config.EpochCheckInterval
+	if interval <= 0 {
+		interval = 12 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.refreshAPRDistribution(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refreshAPRDistribution rebuilds the cached distribution unless it already covers the synced epoch
+// and window. When Dora fails the previous distribution is kept and the next call retries, since one
+// built on default balances would misstate most validators' APR.
+func (s *Server) refreshAPRDistribution(ctx context.Context) {
+	epoch := s.rewardsService.LatestSyncEpoch()
+	windowStart, _ := s.rewardsService.GetRewardWindow()
+	if e := s.aprDist.Load(); e != nil && e.epoch == epoch && e.windowStart.Equal(windowStart) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, aprDistributionBuildTimeout)
+	defer cancel()
+
+	var balances map[uint64]int64
+	if s.doraDB != nil {
+		indices := s.rewardsService.CachedValidatorIndices()
+		balances = make(map[uint64]int64, len(indices))
+		for start := 0; start < len(indices); start += exportBatchSize {
+			batch := indices[start:min(start+exportBatchSize, len(indices))]
+			b, err := s.doraDB.EffectiveBalances(ctx, batch)
+			if err != nil {
+				slog.Warn("Failed to load effective balances for APR distribution", "epoch", epoch, "error", err)
+				return
+			}
+			maps.Copy(balances, b)
+		}
+	}
+
+	dist := s.rewardsService.APRDistribution(balances, s.config.MinAPRActiveFraction)
+	s.aprDist.Store(&aprDistributionEntry{epoch: epoch, windowStart: windowStart, dist: dist})
+}
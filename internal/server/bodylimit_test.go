@@ -0,0 +1,53 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"beacon-rewards/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRewardsHandlerRejectsOversizedBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.DefaultConfig()
+	cfg.MaxRequestBodyBytes = 64
+	s := &Server{config: cfg}
+	router := gin.New()
+	router.POST("/rewards", maxBodySize(cfg.MaxRequestBodyBytes), s.rewardsHandler)
+
+	body := `{"validators":[` + strings.Repeat("1,", 100) + `1]}`
+
+	// Declared Content-Length is rejected before the handler runs.
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/rewards", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+
+	// Chunked bodies (unknown length) are cut off while binding.
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/rewards", io.NopCloser(strings.NewReader(body)))
+	req.ContentLength = -1
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("chunked status = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+
+	// Small malformed bodies still get 400.
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/rewards", strings.NewReader(`{"validators":`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("malformed status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
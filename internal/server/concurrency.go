@@ -0,0 +1,31 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// concurrencyLimit allows at most limit requests through at once, shared by every route it is
+// attached to. Requests beyond that are shed with 503 instead of queueing, so a burst of expensive
+// lookups cannot pile up DB and compute work. A limit of zero or less disables it.
+func concurrencyLimit(limit int) gin.HandlerFunc {
+	if limit <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	slots := make(chan struct{}, limit)
+	return func(c *gin.Context) {
+		select {
+		case slots <- struct{}{}:
+		default:
+			setRetryAfter(c, time.Second)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"error": "too many concurrent address queries, retry later",
+			})
+			return
+		}
+		defer func() { <-slots }()
+		c.Next()
+	}
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"beacon-rewards/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestConcurrencyLimitShedsExcessRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	router := gin.New()
+	router.POST("/rewards/by-address", concurrencyLimit(2), func(c *gin.Context) {
+		entered <- struct{}{}
+		<-release
+		c.Status(http.StatusOK)
+	})
+
+	var wg sync.WaitGroup
+	codes := make([]int, 2)
+	for i := range codes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rewards/by-address", nil))
+			codes[i] = w.Code
+		}()
+		<-entered
+	}
+
+	// Both slots are held, so a third request is rejected without reaching the handler.
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rewards/by-address", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	if got := w.Header().Get("Retry-After"); got != "1" {
+		t.Fatalf("Retry-After = %q, want %q", got, "1")
+	}
+
+	close(release)
+	wg.Wait()
+	for i, code := range codes {
+		if code != http.StatusOK {
+			t.Fatalf("request %d status = %d, want %d", i, code, http.StatusOK)
+		}
+	}
+
+	// Slots are returned once handlers finish.
+	go func() { <-entered }()
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rewards/by-address", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status after release = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestAddressQueryLimitIsSharedAcrossRoutes(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.EnableFrontend = false
+	cfg.AddressQueryConcurrency = 1
+	s := NewServer(cfg, nil, nil)
+
+	// Hold the only slot through a separate route that uses the server's limiter.
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	holder := gin.New()
+	holder.GET("/hold", s.addressLimit, func(c *gin.Context) {
+		close(entered)
+		<-release
+	})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		holder.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/hold", nil))
+	}()
+	<-entered
+
+	for _, req := range []struct{ method, path string }{
+		{http.MethodPost, "/rewards/by-address"},
+		{http.MethodPost, "/v1/rewards/by-address"},
+		{http.MethodGet, "/rewards/by-label/x"},
+		{http.MethodGet, "/v1/rewards/by-label/x"},
+	} {
+		path := req.path
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest(req.method, path, strings.NewReader(`{"address":"0x01"}`)))
+		if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "too many concurrent") {
+			t.Fatalf("%s: status = %d body = %s, want the shared limit to shed it", path, w.Code, w.Body.String())
+		}
+	}
+	close(release)
+	<-done
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"os"
+	"slices"
 	"strings"
 
 	"beacon-rewards/internal/dora"
@@ -30,7 +31,7 @@ func loadDepositorLabels(path string) (map[string]string, error) {
 		if addr == "" || label == "" {
 			continue
 		}
-		labels[strings.ToLower(addr)] = label
+		labels[depositorLabelKey(addr)] = label
 	}
 
 	return labels, nil
@@ -64,6 +65,31 @@ func (s *Server) lookupDepositorLabel(address string) (string, bool) {
 	if len(s.depositorLabels) == 0 || strings.TrimSpace(address) == "" {
 		return "", false
 	}
-	label, ok := s.depositorLabels[strings.ToLower(address)]
+	label, ok := s.depositorLabels[depositorLabelKey(address)]
 	return label, ok
 }
+
+// depositorLabelKey normalizes an address so labels match regardless of checksum casing.
+func depositorLabelKey(address string) string {
+	if key, err := normalizeAddressInput(address); err == nil {
+		return key
+	}
+	return strings.ToLower(strings.TrimSpace(address))
+}
+
+// addressesForLabel returns the label as spelled in the labels file and the addresses mapped to it,
+// sorted. Labels match case-insensitively.
+func (s *Server) addressesForLabel(label string) (string, []string) {
+	var (
+		canonical string
+		addresses []string
+	)
+	for addr, l := range s.depositorLabels {
+		if strings.EqualFold(l, label) {
+			canonical = l
+			addresses = append(addresses, addr)
+		}
+	}
+	slices.Sort(addresses)
+	return canonical, addresses
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"beacon-rewards/internal/dora"
+	"beacon-rewards/internal/rewards"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DepositorValidator is one validator funded by an address, with its reward in the current window.
+type DepositorValidator struct {
+	ValidatorIndex uint64 `json:"validator_index"`
+	// Status is "active", or "exiting" once an exit epoch has been scheduled.
+	Status               string `json:"status"`
+	DepositGwei          int64  `json:"deposit_gwei"`
+	EffectiveBalanceGwei int64  `json:"effective_balance_gwei"`
+	// Reward is omitted for validators without rewards in the current window.
+	Reward *rewards.ValidatorReward `json:"reward,omitempty"`
+}
+
+// DepositorValidatorsResponse is the JSON body of GET /depositors/{address}/validators.
+type DepositorValidatorsResponse struct {
+	Address    string               `json:"address"`
+	Total      int                  `json:"total"` // Active validators of the address across all pages.
+	Limit      int                  `json:"limit"`
+	NextCursor *uint64              `json:"next_cursor,omitempty"`
+	Validators []DepositorValidator `json:"validators"`
+	// AsOfEpoch and WindowStart identify the cache state the page's rewards were read from.
+	AsOfEpoch   uint64    `json:"as_of_epoch"`
+	WindowStart time.Time `json:"window_start"`
+}
+
+// depositorValidatorsHandler lists the validators funded by an address with per-validator detail.
+// @Summary      List the validators funded by an address with their rewards
+// @Description  Returns the active validators funded by a deposit or withdrawal address, ordered by index, each with its status, total deposit, effective balance and reward in the current window. This is the per-validator breakdown behind POST /rewards/by-address. Pages hold validators with index >= cursor; pass next_cursor back until it is omitted.
+// @Tags         Validators
+// @Produce      json
+// @Param        address  path      string  true   "Deposit/withdrawal address or withdrawal credentials"
+// @Param        cursor   query     int     false  "Lowest validator index of the page"
+// @Param        limit    query     int     false  "Page size, capped at MAX_API_LIMIT"  default(100)
+// @Success      200      {object}  DepositorValidatorsResponse
+// @Failure      400      {object}  map[string]string
+// @Failure      500      {object}  map[string]string
+// @Failure      503      {object}  map[string]string
+// @Router       /depositors/{address}/validators [get]
+func (s *Server) depositorValidatorsHandler(c *gin.Context) {
+	address, err := normalizeAddressInput(c.Param("address"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var cursor uint64
+	if raw := c.Query("cursor"); raw != "" {
+		n, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cursor must be a validator index"})
+			return
+		}
+		cursor = n
+	}
+	limit := s.limitParam(c)
+	if !s.ensureDoraDB(c) {
+		return
+	}
+
+	ctx, cancel := s.requestContext(c)
+	defer cancel()
+
+	indices, err := s.doraDB.ActiveValidatorsIndexByAddress(ctx, address, s.chain().TimeToEpoch(time.Now()))
+	if err != nil {
+		if errors.Is(err, dora.ErrInvalidAddress) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		slog.Error("Failed to load validators by address", "address", address, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load validators for address"})
+		return
+	}
+	page, next := pageIndices(indices, cursor, limit)
+
+	balances, err := s.doraDB.EffectiveBalances(ctx, page)
+	if err != nil {
+		slog.Error("Failed to load effective balances", "address", address, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load validators for address"})
+		return
+	}
+	deposits, err := s.doraDB.DepositAmounts(ctx, page)
+	if err != nil {
+		slog.Error("Failed to load deposit amounts", "address", address, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load validators for address"})
+		return
+	}
+	lifecycles, err := s.doraDB.ValidatorLifecycles(ctx, page)
+	if err != nil {
+		slog.Error("Failed to load validator lifecycles", "address", address, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load validators for address"})
+		return
+	}
+
+	asOfEpoch := s.rewardsService.LatestSyncEpoch()
+	windowStart, _ := s.rewardsService.GetRewardWindow()
+	apr := s.rewardsService.TotalNetworkRewards().ProjectAprPercent
+	rewardsByIndex := make(map[uint64]*rewards.ValidatorReward, len(page))
+	for _, r := range s.rewardsService.ValidatorRewards(page, balances, apr) {
+		rewardsByIndex[r.ValidatorIndex] = r
+	}
+	resp := DepositorValidatorsResponse{
+		Address:     address,
+		Total:       len(indices),
+		Limit:       limit,
+		NextCursor:  next,
+		Validators:  make([]DepositorValidator, 0, len(page)),
+		AsOfEpoch:   asOfEpoch,
+		WindowStart: windowStart,
+	}
+	for _, idx := range page {
+		resp.Validators = append(resp.Validators, DepositorValidator{
+			ValidatorIndex:       idx,
+			Status:               activeValidatorStatus(lifecycles[idx]),
+			DepositGwei:          deposits[idx],
+			EffectiveBalanceGwei: balances[idx],
+			Reward:               rewardsByIndex[idx],
+		})
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// activeValidatorStatus names the state of an active validator from its lifecycle.
+func activeValidatorStatus(lc dora.ValidatorLifecycle) string {
+	if lc.ExitEpoch != 0 && lc.ExitEpoch != farFutureEpoch {
+		return "exiting"
+	}
+	return "active"
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"beacon-rewards/internal/config"
+	"beacon-rewards/internal/dora"
+	"beacon-rewards/internal/rewards"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestDepositorValidatorsHandler(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.EnableFrontend = false
+
+	get := func(s *Server, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	address := "0x00000000219ab540356cbb839cbe05303d7705fa"
+	if w := get(NewServer(cfg, rewards.NewService(cfg), nil), "/depositors/"+address+"/validators"); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("without Dora: status = %d, want 503", w.Code)
+	}
+
+	s := NewServer(cfg, rewards.NewService(cfg), &dora.DB{})
+	for target, want := range map[string]int{
+		"/depositors/nope/validators":                     http.StatusBadRequest,
+		"/depositors/" + address + "/validators?cursor=x": http.StatusBadRequest,
+	} {
+		if w := get(s, target); w.Code != want {
+			t.Fatalf("GET %s: status = %d, want %d", target, w.Code, want)
+		}
+	}
+
+	w := get(s, "/depositors/"+address+"/validators?limit=10")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	var resp DepositorValidatorsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Address != address || resp.Limit != 10 || resp.Total != 0 || resp.Validators == nil || resp.NextCursor != nil {
+		t.Fatalf("response = %+v, want an empty first and last page for %s", resp, address)
+	}
+}
+
+func TestDepositorValidatorsHandlerPages(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	address := "0x00000000219ab540356cbb839cbe05303d7705fa"
+	// Dora stores epochs shifted by 2^63: MaxInt64 is the far future epoch, MinInt64+n epoch n.
+	farFuture, activation, exit := int64(math.MaxInt64), int64(math.MinInt64), int64(math.MinInt64+500)
+	expectPage := func(balances, deposits, lifecycles *sqlmock.Rows) {
+		mock.ExpectQuery("FROM deposit_txs").WithArgs(address, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"validator_index"}).AddRow(3).AddRow(7).AddRow(9))
+		mock.ExpectQuery("SELECT validator_index, effective_balance").WillReturnRows(balances)
+		mock.ExpectQuery("SELECT v.validator_index, COALESCE").WillReturnRows(deposits)
+		mock.ExpectQuery("SELECT validator_index, activation_epoch, exit_epoch").WillReturnRows(lifecycles)
+	}
+	expectPage(
+		sqlmock.NewRows([]string{"validator_index", "effective_balance"}).AddRow(3, int64(32_000_000_000)).AddRow(7, int64(31_000_000_000)),
+		sqlmock.NewRows([]string{"validator_index", "total_deposit"}).AddRow(3, int64(32_000_000_000)).AddRow(7, int64(33_000_000_000)),
+		sqlmock.NewRows([]string{"validator_index", "activation_epoch", "exit_epoch"}).AddRow(3, activation, farFuture).AddRow(7, activation, exit),
+	)
+	expectPage(
+		sqlmock.NewRows([]string{"validator_index", "effective_balance"}).AddRow(9, int64(32_000_000_000)),
+		sqlmock.NewRows([]string{"validator_index", "total_deposit"}).AddRow(9, int64(32_000_000_000)),
+		sqlmock.NewRows([]string{"validator_index", "activation_epoch", "exit_epoch"}).AddRow(9, activation, farFuture),
+	)
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.EnableFrontend = false
+	s := NewServer(cfg, rewards.NewService(cfg), dora.NewFromConn(db))
+	get := func(target string) DepositorValidatorsResponse {
+		t.Helper()
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: status = %d, want 200: %s", target, w.Code, w.Body.String())
+		}
+		var resp DepositorValidatorsResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		return resp
+	}
+
+	first := get("/depositors/" + address + "/validators?limit=2")
+	if first.Total != 3 || first.Limit != 2 || first.NextCursor == nil || *first.NextCursor != 9 {
+		t.Fatalf("first page = %+v, want 2 of 3 validators and next_cursor 9", first)
+	}
+	want := []DepositorValidator{
+		{ValidatorIndex: 3, Status: "active", DepositGwei: 32_000_000_000, EffectiveBalanceGwei: 32_000_000_000},
+		{ValidatorIndex: 7, Status: "exiting", DepositGwei: 33_000_000_000, EffectiveBalanceGwei: 31_000_000_000},
+	}
+	if len(first.Validators) != len(want) {
+		t.Fatalf("first page validators = %+v, want %+v", first.Validators, want)
+	}
+	for i, v := range first.Validators {
+		if v != want[i] {
+			t.Fatalf("first page validator %d = %+v, want %+v", i, v, want[i])
+		}
+	}
+
+	last := get("/depositors/" + address + "/validators?limit=2&cursor=9")
+	if last.Total != 3 || last.NextCursor != nil || len(last.Validators) != 1 || last.Validators[0].ValidatorIndex != 9 || last.Validators[0].Status != "active" {
+		t.Fatalf("last page = %+v, want only validator 9 and no next_cursor", last)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sqlmock expectations: %v", err)
+	}
+}
+
+func TestActiveValidatorStatus(t *testing.T) {
+	if got := activeValidatorStatus(dora.ValidatorLifecycle{ActivationEpoch: 1, ExitEpoch: farFutureEpoch}); got != "active" {
+		t.Fatalf("status without exit = %q, want active", got)
+	}
+	if got := activeValidatorStatus(dora.ValidatorLifecycle{ActivationEpoch: 1, ExitEpoch: 500}); got != "exiting" {
+		t.Fatalf("status with exit = %q, want exiting", got)
+	}
+}
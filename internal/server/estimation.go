@@ -5,25 +5,28 @@ import (
 	"beacon-rewards/internal/rewards"
 	"beacon-rewards/internal/utils"
 	"log/slog"
+	"math"
 	"sort"
+	"time"
 )
 
 const (
 	estimateWindowDays = 31
 	secondsPerDay      = 24 * 60 * 60
 	secondsPerYear     = 365 * secondsPerDay
-	// Default fallback for validators missing effective balance data.
-	defaultEffectiveBalanceGwei int64 = 32_000_000_000
 	// maxHistoryDays is the maximum number of days to consider for average APR calculation.
 	maxHistoryDays = 31
+	// minSetHistoryDays is how many days of a validator set's own APR are needed before it replaces
+	// the network APR; fewer days are dominated by proposal luck.
+	minSetHistoryDays = 7
 )
 
 // 6975 epochs = 31 days
-func estimateWindowEpochs() uint64 {
-	return uint64(estimateWindowDays*secondsPerDay) / uint64(utils.SECONDS_PER_EPOCH)
+func estimateWindowEpochs(chain utils.ChainConfig) uint64 {
+	return uint64(estimateWindowDays*secondsPerDay) / chain.SecondsPerEpoch()
 }
 
-func activeSecondsInWindow(lifecycle dora.ValidatorLifecycle, currentEpoch, epochsInWindow uint64) float64 {
+func activeSecondsInWindow(chain utils.ChainConfig, lifecycle dora.ValidatorLifecycle, currentEpoch, epochsInWindow uint64) float64 {
 	windowStart := uint64(0)
 	if currentEpoch > epochsInWindow {
 		windowStart = currentEpoch - epochsInWindow
@@ -38,10 +41,11 @@ func activeSecondsInWindow(lifecycle dora.ValidatorLifecycle, currentEpoch, epoc
 	}
 
 	activeEpochs := end - start
-	return float64(activeEpochs) * float64(utils.SECONDS_PER_EPOCH)
+	return float64(activeEpochs) * float64(chain.SecondsPerEpoch())
 }
 
 func estimateRecentRewardsForValidators(
+	chain utils.ChainConfig,
 	validatorIndices []uint64,
 	aprPercent float64,
 	currentEpoch uint64,
@@ -49,6 +53,7 @@ func estimateRecentRewardsForValidators(
 	effectiveBalances map[uint64]int64,
 	depositBalances map[uint64]int64,
 	lifecycles map[uint64]dora.ValidatorLifecycle,
+	defaultBalanceGwei int64, // fallback for validators with neither effective balance nor deposits
 ) float64 {
 	if aprPercent <= 0 || len(validatorIndices) == 0 || epochsInWindow == 0 {
 		return 0
@@ -68,11 +73,11 @@ func estimateRecentRewardsForValidators(
 				slog.Debug("using deposit balance for validator", "validator_index", idx, "deposit_balance", dep)
 				balance = dep
 			} else {
-				balance = defaultEffectiveBalanceGwei
+				balance = defaultBalanceGwei
 			}
 		}
 
-		activeSeconds := activeSecondsInWindow(lifecycle, currentEpoch, epochsInWindow)
+		activeSeconds := activeSecondsInWindow(chain, lifecycle, currentEpoch, epochsInWindow)
 		if activeSeconds == 0 {
 			slog.Debug("validator is not active in the window", "validator_index", idx)
 			continue
@@ -86,7 +91,8 @@ func estimateRecentRewardsForValidators(
 
 // calculate31DayAverageAPR computes the average APR from historical snapshots
 // with outlier removal using the IQR (Interquartile Range) method.
-// It considers up to the last 31 days of history plus the current snapshot.
+// It considers up to the last 31 days of history plus the current snapshot. A snapshot served from
+// history while the cache warms up is already the newest history entry and is not counted again.
 func calculate31DayAverageAPR(history []rewards.NetworkRewardSnapshot, currentSnapshot *rewards.NetworkRewardSnapshot) float64 {
 	// Collect APR values from history (up to maxHistoryDays)
 	aprValues := make([]float64, 0, maxHistoryDays+1)
@@ -103,7 +109,7 @@ func calculate31DayAverageAPR(history []rewards.NetworkRewardSnapshot, currentSn
 	}
 
 	// Add current snapshot if valid
-	if currentSnapshot != nil && currentSnapshot.ProjectAprPercent > 0 {
+	if currentSnapshot != nil && !currentSnapshot.FromHistory && currentSnapshot.ProjectAprPercent > 0 {
 		aprValues = append(aprValues, currentSnapshot.ProjectAprPercent)
 	}
 
@@ -112,6 +118,24 @@ func calculate31DayAverageAPR(history []rewards.NetworkRewardSnapshot, currentSn
 		return 0
 	}
 
+	return averageAPR(aprValues)
+}
+
+// validatorSetAverageAPR averages a validator set's own daily APRs (see
+// rewards.Service.ValidatorSetAPRHistory) with the same outlier removal as the network average.
+// ok is false when fewer than minSetHistoryDays values exist and the network APR should be used.
+func validatorSetAverageAPR(dailyAPRs []float64) (apr float64, ok bool) {
+	if len(dailyAPRs) > maxHistoryDays {
+		dailyAPRs = dailyAPRs[len(dailyAPRs)-maxHistoryDays:]
+	}
+	if len(dailyAPRs) < minSetHistoryDays {
+		return 0, false
+	}
+	return averageAPR(dailyAPRs), true
+}
+
+// averageAPR returns the mean of aprValues after IQR outlier removal; aprValues must be non-empty.
+func averageAPR(aprValues []float64) float64 {
 	// If we only have one value, return it directly
 	if len(aprValues) == 1 {
 		return aprValues[0]
@@ -130,7 +154,7 @@ func calculate31DayAverageAPR(history []rewards.NetworkRewardSnapshot, currentSn
 	}
 	avg := sum / float64(len(filtered))
 
-	slog.Debug("Calculated 31-day average APR",
+	slog.Debug("Calculated average APR",
 		"total_values", len(aprValues),
 		"after_outlier_removal", len(filtered),
 		"average_apr", avg)
@@ -173,3 +197,106 @@ func removeOutliersIQR(values []float64) []float64 {
 
 	return filtered
 }
+
+// skimThresholdGwei is the balance above which the withdrawal sweep skims 0x01 validators.
+const skimThresholdGwei uint64 = 32_000_000_000
+
+// SkimEstimate describes when a 0x01 validator's balance is expected to exceed 32 ETH and become
+// eligible for the next partial withdrawal (skim). The sweep itself may take additional time to
+// reach the validator, which is not included.
+type SkimEstimate struct {
+	ValidatorIndex         uint64     `json:"validator_index"`
+	CredentialType         string     `json:"credential_type"`
+	Applicable             bool       `json:"applicable"`
+	Reason                 string     `json:"reason,omitempty"`
+	BalanceGwei            utils.Gwei `json:"balance_gwei"`
+	RewardRateGweiPerEpoch float64    `json:"reward_rate_gwei_per_epoch"`
+	EpochsUntilSkim        *uint64    `json:"epochs_until_skim,omitempty"`
+	EstimatedSkimTime      *time.Time `json:"estimated_skim_time,omitempty"`
+}
+
+// estimateSkim fills the epoch/time fields of est from its balance and reward rate.
+func estimateSkim(chain utils.ChainConfig, est *SkimEstimate, currentEpoch uint64) {
+	balance := uint64(est.BalanceGwei)
+	var epochs uint64
+	if balance <= skimThresholdGwei {
+		if est.RewardRateGweiPerEpoch <= 0 {
+			est.Reason = "no positive reward rate observed in the current window"
+			return
+		}
+		// Strictly above the threshold: the sweep only withdraws an excess.
+		missing := float64(skimThresholdGwei-balance) + 1
+		epochs = uint64(math.Ceil(missing / est.RewardRateGweiPerEpoch))
+	}
+	at := chain.EpochToTime(currentEpoch + epochs)
+	est.EpochsUntilSkim = &epochs
+	est.EstimatedSkimTime = &at
+}
+
+// Slashing parameters from the consensus spec (Electra).
+const (
+	epochsPerSlashingsVector       = 8192
+	proportionalSlashingMultiplier = 3
+	effectiveBalanceIncrementGwei  = utils.GweiPerEth
+)
+
+// SlashingEstimate models the correlation penalty a slashed validator still has to pay. The penalty is
+// applied once, EPOCHS_PER_SLASHINGS_VECTOR/2 epochs before the validator's withdrawable epoch (halfway
+// through the slashings vector, unless a long exit queue had already pushed the withdrawable epoch
+// later), and scales with the balance slashed network-wide around the same time.
+type SlashingEstimate struct {
+	ValidatorIndex uint64 `json:"validator_index"`
+	Slashed        bool   `json:"slashed"`
+	Reason         string `json:"reason,omitempty"`
+	// SlashedEpoch comes from the block that included the slashing. Without it, it is derived from the
+	// withdrawable epoch (slashing epoch + 8192), which is too late for validators already exiting.
+	SlashedEpoch            uint64     `json:"slashed_epoch,omitempty"`
+	CorrelationPenaltyEpoch uint64     `json:"correlation_penalty_epoch,omitempty"`
+	CorrelationPenaltyTime  *time.Time `json:"correlation_penalty_time,omitempty"`
+	PenaltyApplied          bool       `json:"penalty_applied"`
+	EffectiveBalanceGwei    utils.Gwei `json:"effective_balance_gwei"`
+	// Balance of all validators slashed within the vector and the total active balance it is compared with.
+	NetworkSlashedBalanceGwei     utils.Gwei `json:"network_slashed_balance_gwei"`
+	TotalActiveBalanceGwei        utils.Gwei `json:"total_active_balance_gwei"`
+	SlashedBalanceFraction        float64    `json:"slashed_balance_fraction"`
+	EstimatedRemainingPenaltyGwei utils.Gwei `json:"estimated_remaining_penalty_gwei"`
+}
+
+// correlationPenaltyGwei applies the Electra process_slashings formula: the slashed balance times the
+// proportional multiplier (capped at the total balance), spread per effective balance increment.
+func correlationPenaltyGwei(effectiveBalance, slashedBalance, totalBalance int64) int64 {
+	if totalBalance < effectiveBalanceIncrementGwei || effectiveBalance <= 0 {
+		return 0
+	}
+	adjusted := min(slashedBalance*proportionalSlashingMultiplier, totalBalance)
+	perIncrement := adjusted / (totalBalance / effectiveBalanceIncrementGwei)
+	return perIncrement * (effectiveBalance / effectiveBalanceIncrementGwei)
+}
+
+// estimateSlashing fills the schedule and penalty fields of est for a validator with withdrawableEpoch,
+// slashed in slashedEpoch (nil when unknown). Once the penalty epoch has passed the remaining penalty
+// is zero.
+func estimateSlashing(chain utils.ChainConfig, est *SlashingEstimate, slashedEpoch *uint64, withdrawableEpoch, currentEpoch uint64) {
+	if withdrawableEpoch < epochsPerSlashingsVector {
+		est.Reason = "withdrawable epoch is not consistent with a slashing"
+		return
+	}
+	est.SlashedEpoch = withdrawableEpoch - epochsPerSlashingsVector
+	if slashedEpoch != nil {
+		est.SlashedEpoch = *slashedEpoch
+	}
+	// process_slashings keys the penalty on the withdrawable epoch, not on the slashing epoch.
+	est.CorrelationPenaltyEpoch = withdrawableEpoch - epochsPerSlashingsVector/2
+	at := chain.EpochToTime(est.CorrelationPenaltyEpoch)
+	est.CorrelationPenaltyTime = &at
+
+	if total := int64(est.TotalActiveBalanceGwei); total > 0 {
+		est.SlashedBalanceFraction = float64(est.NetworkSlashedBalanceGwei) / float64(total)
+	}
+	if currentEpoch >= est.CorrelationPenaltyEpoch {
+		est.PenaltyApplied = true
+		return
+	}
+	est.EstimatedRemainingPenaltyGwei = utils.Gwei(correlationPenaltyGwei(
+		int64(est.EffectiveBalanceGwei), int64(est.NetworkSlashedBalanceGwei), int64(est.TotalActiveBalanceGwei)))
+}
@@ -10,25 +10,28 @@ import (
 	"beacon-rewards/internal/utils"
 )
 
+const testDefaultBalanceGwei int64 = 32_000_000_000
+
 func TestEstimateWindowEpochs(t *testing.T) {
-	expected := uint64(estimateWindowDays*secondsPerDay) / uint64(utils.SECONDS_PER_EPOCH)
-	if got := estimateWindowEpochs(); got != expected {
+	expected := uint64(estimateWindowDays*secondsPerDay) / utils.DefaultChain().SecondsPerEpoch()
+	if got := estimateWindowEpochs(utils.DefaultChain()); got != expected {
 		t.Fatalf("estimateWindowEpochs = %d, want %d", got, expected)
 	}
 }
 
 func TestActiveSecondsInWindow(t *testing.T) {
-	seconds := activeSecondsInWindow(dora.ValidatorLifecycle{
+	chain := utils.DefaultChain()
+	seconds := activeSecondsInWindow(chain, dora.ValidatorLifecycle{
 		ActivationEpoch: 10,
 		ExitEpoch:       200,
 	}, 100, 50)
 
-	expected := float64(50 * utils.SECONDS_PER_EPOCH)
+	expected := float64(50 * chain.SecondsPerEpoch())
 	if seconds != expected {
 		t.Fatalf("unexpected active seconds: got %f want %f", seconds, expected)
 	}
 
-	seconds = activeSecondsInWindow(dora.ValidatorLifecycle{
+	seconds = activeSecondsInWindow(chain, dora.ValidatorLifecycle{
 		ActivationEpoch: 10,
 		ExitEpoch:       40,
 	}, 100, 50)
@@ -54,6 +57,7 @@ func TestEstimateRecentRewardsForValidators(t *testing.T) {
 	epochsInWindow := uint64(50)
 
 	estimated := estimateRecentRewardsForValidators(
+		utils.DefaultChain(),
 		validatorIndices,
 		aprPercent,
 		currentEpoch,
@@ -61,9 +65,10 @@ func TestEstimateRecentRewardsForValidators(t *testing.T) {
 		effectiveBalances,
 		map[uint64]int64{},
 		lifecycles,
+		testDefaultBalanceGwei,
 	)
 
-	activeSeconds := float64(50 * utils.SECONDS_PER_EPOCH) // validator 1 is active for the full 50-epoch window
+	activeSeconds := float64(50 * utils.DefaultChain().SecondsPerEpoch()) // validator 1 is active for the full 50-epoch window
 	expected := float64(effectiveBalances[1]) * (aprPercent / 100.0) * (activeSeconds / float64(secondsPerYear))
 
 	if math.Abs(estimated-expected) > expected*1e-9 {
@@ -85,6 +90,7 @@ func TestEstimateRecentRewardsForValidatorsUsesDefaultBalance(t *testing.T) {
 	epochsInWindow := uint64(50)
 
 	estimated := estimateRecentRewardsForValidators(
+		utils.DefaultChain(),
 		validatorIndices,
 		aprPercent,
 		currentEpoch,
@@ -92,10 +98,11 @@ func TestEstimateRecentRewardsForValidatorsUsesDefaultBalance(t *testing.T) {
 		effectiveBalances,
 		map[uint64]int64{},
 		lifecycles,
+		testDefaultBalanceGwei,
 	)
 
-	activeSeconds := float64(50 * utils.SECONDS_PER_EPOCH)
-	expected := float64(defaultEffectiveBalanceGwei) * (aprPercent / 100.0) * (activeSeconds / float64(secondsPerYear))
+	activeSeconds := float64(50 * utils.DefaultChain().SecondsPerEpoch())
+	expected := float64(testDefaultBalanceGwei) * (aprPercent / 100.0) * (activeSeconds / float64(secondsPerYear))
 
 	if math.Abs(estimated-expected) > expected*1e-9 {
 		t.Fatalf("unexpected estimated rewards with default balance: got %f want %f", estimated, expected)
@@ -119,6 +126,7 @@ func TestEstimateRecentRewardsForValidatorsUsesDepositFallback(t *testing.T) {
 	epochsInWindow := uint64(50)
 
 	estimated := estimateRecentRewardsForValidators(
+		utils.DefaultChain(),
 		validatorIndices,
 		aprPercent,
 		currentEpoch,
@@ -126,9 +134,10 @@ func TestEstimateRecentRewardsForValidatorsUsesDepositFallback(t *testing.T) {
 		effectiveBalances,
 		depositBalances,
 		lifecycles,
+		testDefaultBalanceGwei,
 	)
 
-	activeSeconds := float64(50 * utils.SECONDS_PER_EPOCH)
+	activeSeconds := float64(50 * utils.DefaultChain().SecondsPerEpoch())
 	expected := float64(depositBalances[4]) * (aprPercent / 100.0) * (activeSeconds / float64(secondsPerYear))
 
 	if math.Abs(estimated-expected) > expected*1e-9 {
@@ -240,6 +249,13 @@ func TestCalculate31DayAverageAPR(t *testing.T) {
 			expectedMin:     10.5,                                                     // average of 10.0, 10.5, 11.0, 11.5 = 10.75
 			expectedMax:     11.0,
 		},
+		{
+			name:            "warm-up snapshot from history is not counted twice",
+			history:         []rewards.NetworkRewardSnapshot{{ProjectAprPercent: 10.0}, {ProjectAprPercent: 12.0}},
+			currentSnapshot: &rewards.NetworkRewardSnapshot{ProjectAprPercent: 12.0, FromHistory: true},
+			expectedMin:     11.0,
+			expectedMax:     11.0,
+		},
 		{
 			name:            "nil current snapshot uses history only",
 			history:         []rewards.NetworkRewardSnapshot{{ProjectAprPercent: 10.0}},
@@ -292,3 +308,99 @@ func TestCalculate31DayAverageAPRLimitsTo31Days(t *testing.T) {
 		t.Fatalf("expected average close to %f, got %f", expectedAvg, result)
 	}
 }
+
+func TestEstimateSkim(t *testing.T) {
+	t.Run("below threshold", func(t *testing.T) {
+		est := SkimEstimate{Applicable: true, BalanceGwei: 31_999_990_000, RewardRateGweiPerEpoch: 1_000}
+		estimateSkim(utils.DefaultChain(), &est, 100)
+		if est.EpochsUntilSkim == nil || *est.EpochsUntilSkim != 11 {
+			t.Fatalf("EpochsUntilSkim = %v, want 11", est.EpochsUntilSkim)
+		}
+		if want := utils.DefaultChain().EpochToTime(111); !est.EstimatedSkimTime.Equal(want) {
+			t.Fatalf("EstimatedSkimTime = %v, want %v", est.EstimatedSkimTime, want)
+		}
+	})
+
+	t.Run("already above threshold", func(t *testing.T) {
+		est := SkimEstimate{Applicable: true, BalanceGwei: 32_000_500_000, RewardRateGweiPerEpoch: 1_000}
+		estimateSkim(utils.DefaultChain(), &est, 100)
+		if est.EpochsUntilSkim == nil || *est.EpochsUntilSkim != 0 {
+			t.Fatalf("EpochsUntilSkim = %v, want 0", est.EpochsUntilSkim)
+		}
+	})
+
+	t.Run("no reward rate", func(t *testing.T) {
+		est := SkimEstimate{Applicable: true, BalanceGwei: 31_000_000_000}
+		estimateSkim(utils.DefaultChain(), &est, 100)
+		if est.EpochsUntilSkim != nil || est.Reason == "" {
+			t.Fatalf("expected no estimate with a reason, got %+v", est)
+		}
+	})
+}
+
+func TestEstimateSlashing(t *testing.T) {
+	const gweiPerEth = utils.GweiPerEth
+	newEstimate := func() SlashingEstimate {
+		return SlashingEstimate{
+			Slashed:                   true,
+			EffectiveBalanceGwei:      32 * gweiPerEth,
+			NetworkSlashedBalanceGwei: 1000 * 32 * gweiPerEth,
+			TotalActiveBalanceGwei:    32_000_000 * gweiPerEth,
+		}
+	}
+
+	t.Run("penalty pending", func(t *testing.T) {
+		est := newEstimate()
+		estimateSlashing(utils.DefaultChain(), &est, nil, 20_000, 12_000)
+		if est.SlashedEpoch != 11_808 || est.CorrelationPenaltyEpoch != 15_904 {
+			t.Fatalf("slashed/penalty epoch = %d/%d, want 11808/15904", est.SlashedEpoch, est.CorrelationPenaltyEpoch)
+		}
+		// 3 * 32,000 ETH slashed out of 32M ETH costs 0.003 ETH per increment.
+		if est.EstimatedRemainingPenaltyGwei != 96_000_000 || est.PenaltyApplied {
+			t.Fatalf("remaining penalty = %d (applied %v), want 96000000", est.EstimatedRemainingPenaltyGwei, est.PenaltyApplied)
+		}
+		if math.Abs(est.SlashedBalanceFraction-0.001) > 1e-12 {
+			t.Fatalf("slashed fraction = %f, want 0.001", est.SlashedBalanceFraction)
+		}
+	})
+
+	t.Run("penalty applied", func(t *testing.T) {
+		est := newEstimate()
+		estimateSlashing(utils.DefaultChain(), &est, nil, 20_000, 16_000)
+		if !est.PenaltyApplied || est.EstimatedRemainingPenaltyGwei != 0 {
+			t.Fatalf("expected the penalty to be applied already, got %+v", est)
+		}
+	})
+
+	t.Run("slashed while exiting", func(t *testing.T) {
+		// The exit queue had set the withdrawable epoch beyond slashing epoch + 8192, so the slashing
+		// epoch comes from the slashing itself while the penalty still follows the withdrawable epoch.
+		est := newEstimate()
+		slashedEpoch := uint64(10_000)
+		estimateSlashing(utils.DefaultChain(), &est, &slashedEpoch, 20_000, 12_000)
+		if est.SlashedEpoch != 10_000 || est.CorrelationPenaltyEpoch != 15_904 {
+			t.Fatalf("slashed/penalty epoch = %d/%d, want 10000/15904", est.SlashedEpoch, est.CorrelationPenaltyEpoch)
+		}
+	})
+
+	t.Run("mass slashing caps at the full balance", func(t *testing.T) {
+		if got := correlationPenaltyGwei(32*gweiPerEth, 20_000_000*gweiPerEth, 32_000_000*gweiPerEth); got != 32*gweiPerEth {
+			t.Fatalf("penalty = %d, want the full effective balance", got)
+		}
+	})
+}
+
+func TestValidatorSetAverageAPR(t *testing.T) {
+	if _, ok := validatorSetAverageAPR([]float64{4, 4, 4}); ok {
+		t.Fatalf("expected fallback to network APR with fewer than %d days", minSetHistoryDays)
+	}
+
+	daily := []float64{99, 4, 4, 4, 4, 4, 4, 4}
+	apr, ok := validatorSetAverageAPR(daily)
+	if !ok {
+		t.Fatalf("expected set APR with %d days of history", len(daily))
+	}
+	if math.Abs(apr-4) > 1e-9 {
+		t.Fatalf("apr = %f, want 4 after outlier removal", apr)
+	}
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etagFor derives a strong ETag from the request URL and the given data version parts, so different
+// query parameters over the same data version never share a tag.
+func etagFor(c *gin.Context, parts ...any) string {
+	h := sha256.New()
+	fmt.Fprint(h, c.Request.URL.Path, "?", c.Request.URL.RawQuery)
+	for _, p := range parts {
+		fmt.Fprintf(h, "|%v", p)
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// notModified sets the ETag header and responds with 304 when If-None-Match matches it.
+// Callers must return without writing a body when it reports true.
+func notModified(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+	if !etagMatches(c.GetHeader("If-None-Match"), etag) {
+		return false
+	}
+	c.Status(http.StatusNotModified)
+	return true
+}
+
+// etagMatches implements the weak comparison used for If-None-Match (RFC 9110 section 13.1.2).
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
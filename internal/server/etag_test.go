@@ -0,0 +1,88 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"beacon-rewards/internal/config"
+	"beacon-rewards/internal/rewards"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNetworkRewardsConditionalGet(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	s := &Server{config: cfg, rewardsService: rewards.NewService(cfg)}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/rewards/network", nil)
+	s.networkRewardsHandler(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatalf("expected ETag header on first response")
+	}
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/rewards/network", nil)
+	c.Request.Header.Set("If-None-Match", etag)
+	s.networkRewardsHandler(c)
+	c.Writer.WriteHeaderNow()
+
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNotModified)
+	}
+	if w.Body.Len() != 0 {
+		t.Fatalf("304 response should have no body, got %q", w.Body.String())
+	}
+}
+
+func TestEtagMatches(t *testing.T) {
+	const etag = `"abc"`
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{header: "", want: false},
+		{header: `"abc"`, want: true},
+		{header: `W/"abc"`, want: true},
+		{header: `"xyz", "abc"`, want: true},
+		{header: "*", want: true},
+		{header: `"xyz"`, want: false},
+	}
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, etag); got != tt.want {
+			t.Fatalf("etagMatches(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestLeaderboardETagChangesWithQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	s := &Server{config: config.DefaultConfig()}
+	newCtx := func(target string) *gin.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+		return c
+	}
+
+	a := s.leaderboardETag(newCtx("/deposits/top-deposits?limit=10"))
+	b := s.leaderboardETag(newCtx("/deposits/top-deposits?limit=20"))
+	if a == b {
+		t.Fatalf("expected different ETags for different queries")
+	}
+	if again := s.leaderboardETag(newCtx("/deposits/top-deposits?limit=10")); again != a {
+		t.Fatalf("ETag not stable: %s vs %s", again, a)
+	}
+}
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"beacon-rewards/internal/config"
+	"beacon-rewards/internal/rewards"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRewardsExportFormats(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	s := &Server{config: cfg, rewardsService: rewards.NewService(cfg)}
+
+	tests := []struct {
+		query      string
+		wantStatus int
+		wantType   string
+		wantBody   string
+	}{
+		// The cache is empty: ndjson streams nothing, json still produces a valid array.
+		{query: "", wantStatus: http.StatusOK, wantType: "application/x-ndjson", wantBody: ""},
+		{query: "?format=json", wantStatus: http.StatusOK, wantType: "application/json", wantBody: "[]"},
+		{query: "?format=csv", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/rewards/export"+tt.query, nil)
+		s.rewardsExportHandler(c)
+
+		if w.Code != tt.wantStatus {
+			t.Fatalf("%q: status = %d, want %d", tt.query, w.Code, tt.wantStatus)
+		}
+		if tt.wantStatus != http.StatusOK {
+			continue
+		}
+		if w.Header().Get("Content-Type") != tt.wantType {
+			t.Fatalf("%q: Content-Type = %q, want %q", tt.query, w.Header().Get("Content-Type"), tt.wantType)
+		}
+		if w.Body.String() != tt.wantBody {
+			t.Fatalf("%q: body = %q, want %q", tt.query, w.Body.String(), tt.wantBody)
+		}
+	}
+}
+
+func TestPageIndices(t *testing.T) {
+	indices := []uint64{2, 5, 7, 9, 12}
+
+	page, next := pageIndices(indices, 0, 2)
+	if !slices.Equal(page, []uint64{2, 5}) || next == nil || *next != 7 {
+		t.Fatalf("first page = %v next %v, want [2 5] and 7", page, next)
+	}
+	// A cursor between cached indices starts at the next one.
+	page, next = pageIndices(indices, 8, 2)
+	if !slices.Equal(page, []uint64{9, 12}) || next != nil {
+		t.Fatalf("last page = %v next %v, want [9 12] and no cursor", page, next)
+	}
+	if page, next = pageIndices(indices, 13, 2); len(page) != 0 || next != nil {
+		t.Fatalf("past the end = %v next %v, want an empty last page", page, next)
+	}
+}
+
+func TestRewardsExportCursorMode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	s := &Server{config: cfg, rewardsService: rewards.NewService(cfg)}
+
+	for query, want := range map[string]int{
+		"?cursor=0&limit=10": http.StatusOK,
+		"?limit=10":          http.StatusOK,
+		"?cursor=abc":        http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/rewards/export"+query, nil)
+		s.rewardsExportHandler(c)
+
+		if w.Code != want {
+			t.Fatalf("%q: status = %d, want %d", query, w.Code, want)
+		}
+		if want != http.StatusOK {
+			continue
+		}
+		var page RewardsExportPage
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+			t.Fatalf("%q: decode page: %v", query, err)
+		}
+		if page.Limit != 10 || len(page.Rewards) != 0 || page.NextCursor != nil {
+			t.Fatalf("%q: page = %+v, want an empty last page with limit 10", query, page)
+		}
+	}
+}
+
+func TestAPRDistributionIsBuiltInTheBackground(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	svc := rewards.NewService(cfg)
+	s := &Server{config: cfg, rewardsService: svc}
+
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/rewards/apr/distribution", nil)
+		s.aprDistributionHandler(c)
+		return w
+	}
+	decode := func(w *httptest.ResponseRecorder) rewards.APRDistribution {
+		var dist rewards.APRDistribution
+		if err := json.Unmarshal(w.Body.Bytes(), &dist); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return dist
+	}
+
+	// The handler never builds the distribution itself.
+	if w := get(); w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("before the first build: status = %d, Retry-After %q; want 503 with Retry-After", w.Code, w.Header().Get("Retry-After"))
+	}
+	s.refreshAPRDistribution(context.Background())
+	if s.aprDist.Load() == nil {
+		t.Fatal("distribution was not cached")
+	}
+	if w := get(); w.Code != http.StatusOK {
+		t.Fatalf("after the build: status = %d, want 200", w.Code)
+	}
+
+	// An entry for the current epoch and window is kept by the refresh.
+	windowStart, _ := svc.GetRewardWindow()
+	s.aprDist.Store(&aprDistributionEntry{epoch: svc.LatestSyncEpoch(), windowStart: windowStart, dist: rewards.APRDistribution{ValidatorCount: 42}})
+	s.refreshAPRDistribution(context.Background())
+	if got := decode(get()); got.ValidatorCount != 42 {
+		t.Fatalf("ValidatorCount = %d, want the cached 42", got.ValidatorCount)
+	}
+
+	// One from another epoch is rebuilt.
+	s.aprDist.Store(&aprDistributionEntry{epoch: svc.LatestSyncEpoch() + 1, windowStart: windowStart, dist: rewards.APRDistribution{ValidatorCount: 42}})
+	s.refreshAPRDistribution(context.Background())
+	if got := decode(get()); got.ValidatorCount != 0 {
+		t.Fatalf("ValidatorCount = %d, want a rebuilt distribution", got.ValidatorCount)
+	}
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"beacon-rewards/internal/dora"
+
+	"gopkg.in/yaml.v3"
+)
+
+// loadFeeRecipientOwners reads FEE_RECIPIENTS_FILE, a YAML mapping of fee recipient address to
+// the owner (depositor or withdrawal) address whose by-address view is credited with its EL rewards.
+// Both sides are returned normalized (lower-case 0x form).
+func loadFeeRecipientOwners(path string) (map[string]string, error) {
+	if strings.TrimSpace(path) == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make(map[string]string)
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	owners := make(map[string]string, len(raw))
+	for recipient, owner := range raw {
+		r, err := dora.NormalizeAddress(recipient)
+		if err != nil {
+			return nil, fmt.Errorf("fee recipient %q: %w", recipient, err)
+		}
+		o, err := dora.NormalizeAddress(owner)
+		if err != nil {
+			return nil, fmt.Errorf("owner of fee recipient %q: %w", recipient, err)
+		}
+		owners[r] = o
+	}
+
+	return owners, nil
+}
+
+// feeRecipientsOf returns the fee recipients mapped to any of the owner addresses, sorted.
+func (s *Server) feeRecipientsOf(owners []string) []string {
+	if len(s.feeRecipientOwners) == 0 {
+		return nil
+	}
+	var recipients []string
+	for recipient, owner := range s.feeRecipientOwners {
+		if slices.ContainsFunc(owners, func(o string) bool { return strings.EqualFold(o, owner) }) {
+			recipients = append(recipients, recipient)
+		}
+	}
+	slices.Sort(recipients)
+	return recipients
+}
+
+// applyFeeRecipientRewards credits result with the EL rewards sent to fee recipients owned by the
+// given addresses. Blocks proposed by the result's own validators are skipped, since their EL
+// rewards are already counted.
+func (s *Server) applyFeeRecipientRewards(result *AddressRewardsResult, owners []string, details []dora.ValidatorDetail) {
+	recipients := s.feeRecipientsOf(owners)
+	if len(recipients) == 0 {
+		return
+	}
+
+	own := make(map[uint64]struct{}, len(details))
+	for _, d := range details {
+		own[d.ValidatorIndex] = struct{}{}
+	}
+	for _, gwei := range s.rewardsService.FeeRecipientRewards(recipients, own) {
+		result.FeeRecipientElRewardsGwei += gwei
+	}
+	result.ElRewardsGwei += result.FeeRecipientElRewardsGwei
+	result.TotalRewardsGwei += result.FeeRecipientElRewardsGwei
+	result.FeeRecipients = recipients
+}
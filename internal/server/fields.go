@@ -0,0 +1,93 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fieldSet holds the JSON field names a client asked for with ?fields=.
+type fieldSet map[string]struct{}
+
+// parseFields reads the comma-separated fields query parameter and checks every name against the
+// JSON fields of model. A nil set means the parameter was absent and the full object is returned.
+func parseFields(c *gin.Context, model any) (fieldSet, error) {
+	raw := strings.TrimSpace(c.Query("fields"))
+	if raw == "" {
+		return nil, nil
+	}
+
+	known := jsonFieldNames(reflect.TypeOf(model))
+	fields := make(fieldSet)
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := known[name]; !ok {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+		fields[name] = struct{}{}
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return fields, nil
+}
+
+// jsonFieldNames returns the names encoding/json uses for the exported fields of struct type t.
+func jsonFieldNames(t reflect.Type) map[string]struct{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	names := make(map[string]struct{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			for n := range jsonFieldNames(f.Type) {
+				names[n] = struct{}{}
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		names[name] = struct{}{}
+	}
+	return names
+}
+
+// fieldsView marshals value with only the requested fields; a nil set marshals it unchanged.
+type fieldsView struct {
+	value  any
+	fields fieldSet
+}
+
+// MarshalJSON implements json.Marshaler.
+func (v fieldsView) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(v.value)
+	if err != nil || v.fields == nil {
+		return b, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(b, &all); err != nil {
+		return nil, err
+	}
+	for name := range all {
+		if _, ok := v.fields[name]; !ok {
+			delete(all, name)
+		}
+	}
+	return json.Marshal(all)
+}
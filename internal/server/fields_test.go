@@ -0,0 +1,63 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"beacon-rewards/internal/config"
+	"beacon-rewards/internal/rewards"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestFieldsViewRestrictsOutput(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/?fields=validator_index,+total_rewards_gwei", nil)
+	fields, err := parseFields(c, rewards.ValidatorReward{})
+	if err != nil {
+		t.Fatalf("parseFields returned error: %v", err)
+	}
+
+	b, err := json.Marshal(fieldsView{value: &rewards.ValidatorReward{ValidatorIndex: 7, ClRewardsGwei: 5, TotalRewardsGwei: 9}, fields: fields})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if got, want := string(b), `{"total_rewards_gwei":9,"validator_index":7}`; got != want {
+		t.Fatalf("filtered JSON = %s, want %s", got, want)
+	}
+
+	full, _ := json.Marshal(fieldsView{value: AddressRewardsResult{Address: "0xabc"}})
+	if !strings.Contains(string(full), `"window_start"`) {
+		t.Fatalf("nil field set should marshal the full object, got %s", full)
+	}
+
+	c, _ = gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/?fields=validator_index,nope", nil)
+	if _, err := parseFields(c, rewards.ValidatorReward{}); err == nil {
+		t.Fatalf("expected an error for an unknown field")
+	}
+}
+
+func TestRewardsHandlerRejectsUnknownFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	s := &Server{config: cfg, rewardsService: rewards.NewService(cfg)}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/rewards?fields=total_rewards_gwei,bogus", strings.NewReader(`{"validators":[1]}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	s.rewardsHandler(c)
+
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "bogus") {
+		t.Fatalf("status = %d body = %s, want 400 naming the field", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,32 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"os"
+)
+
+// listenUnix listens on a Unix domain socket at path. A socket file left behind by a previous run
+// that did not shut down cleanly is removed first; any other file at path is left alone.
+func listenUnix(path string) (net.Listener, error) {
+	if err := removeSocket(path); err != nil {
+		return nil, err
+	}
+	return net.Listen("unix", path)
+}
+
+// removeSocket deletes the socket file at path if there is one. It refuses to delete anything that
+// is not a socket so a mistyped SERVER_ADDRESS cannot remove an unrelated file.
+func removeSocket(path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+	return os.Remove(path)
+}
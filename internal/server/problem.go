@@ -0,0 +1,113 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"mime"
+	"net/http"
+
+	"beacon-rewards/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+const problemContentType = "application/problem+json"
+
+// APIError is the structured form of an error response: the HTTP status, the message legacy
+// clients read from "error", and any other members of the body (e.g. bad_lines).
+type APIError struct {
+	Status     int
+	Detail     string
+	Extensions map[string]any
+}
+
+// parseAPIError reads a legacy {"error": "..."} body. ok is false for any other JSON shape.
+func parseAPIError(status int, body []byte) (APIError, bool) {
+	var members map[string]any
+	if err := json.Unmarshal(body, &members); err != nil {
+		return APIError{}, false
+	}
+	detail, ok := members["error"].(string)
+	if !ok {
+		return APIError{}, false
+	}
+	delete(members, "error")
+	return APIError{Status: status, Detail: detail, Extensions: members}, true
+}
+
+// problem renders e as an RFC 7807 problem object for the request path instance. Extension members
+// are kept, but never override the standard ones.
+func (e APIError) problem(instance string) map[string]any {
+	p := make(map[string]any, len(e.Extensions)+5)
+	maps.Copy(p, e.Extensions)
+	p["type"] = "about:blank" // The status alone identifies the problem; see title.
+	p["title"] = http.StatusText(e.Status)
+	p["status"] = e.Status
+	p["detail"] = e.Detail
+	p["instance"] = instance
+	return p
+}
+
+// errorFormat rewrites JSON error responses as application/problem+json when ERROR_FORMAT=problem.
+// Handlers keep writing the legacy body; it is buffered and converted once the handler returns, so
+// every route and middleware error is covered. Bodies that are not legacy errors pass through.
+func errorFormat(format string) gin.HandlerFunc {
+	if format != config.ErrorFormatProblem {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return func(c *gin.Context) {
+		w := &problemWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		if w.buffering {
+			w.flush(c.Request.URL.Path)
+		}
+	}
+}
+
+// problemWriter holds back the body of JSON responses with an error status until flush.
+type problemWriter struct {
+	gin.ResponseWriter
+	buf       bytes.Buffer
+	buffering bool
+}
+
+// capture reports whether the body being written is a JSON error to convert.
+func (w *problemWriter) capture() bool {
+	if w.buffering {
+		return true
+	}
+	if w.ResponseWriter.Written() || w.Status() < http.StatusBadRequest {
+		return false
+	}
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	w.buffering = mediaType == "application/json"
+	return w.buffering
+}
+
+func (w *problemWriter) Write(b []byte) (int, error) {
+	if w.capture() {
+		return w.buf.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *problemWriter) WriteString(s string) (int, error) {
+	if w.capture() {
+		return w.buf.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+// flush writes the buffered body, converted to a problem object when it is a legacy error.
+func (w *problemWriter) flush(instance string) {
+	body := w.buf.Bytes()
+	if apiErr, ok := parseAPIError(w.Status(), body); ok {
+		if converted, err := json.Marshal(apiErr.problem(instance)); err == nil {
+			w.Header().Set("Content-Type", problemContentType)
+			body = converted
+		}
+	}
+	_, _ = w.ResponseWriter.Write(body)
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"beacon-rewards/internal/config"
+	"beacon-rewards/internal/rewards"
+)
+
+func TestErrorFormatProblem(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.EnableFrontend = false
+	cfg.ErrorFormat = config.ErrorFormatProblem
+	s := NewServer(cfg, rewards.NewService(cfg), nil)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/rewards/upload", bytes.NewBufferString("1\nabc\n"))
+	req.Header.Set("Content-Type", "text/plain")
+	s.router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != problemContentType {
+		t.Fatalf("Content-Type = %q, want %q", ct, problemContentType)
+	}
+	var problem map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("decode problem: %v: %s", err, w.Body.String())
+	}
+	want := map[string]any{
+		"type":     "about:blank",
+		"title":    "Bad Request",
+		"status":   float64(http.StatusBadRequest),
+		"detail":   "validator list contains unparseable lines",
+		"instance": "/v1/rewards/upload",
+	}
+	for k, v := range want {
+		if problem[k] != v {
+			t.Fatalf("%s = %v, want %v (body %s)", k, problem[k], v, w.Body.String())
+		}
+	}
+	if _, ok := problem["bad_lines"]; !ok {
+		t.Fatalf("extension member bad_lines was dropped: %s", w.Body.String())
+	}
+	if _, ok := problem["error"]; ok {
+		t.Fatalf("legacy error member should be replaced by detail: %s", w.Body.String())
+	}
+
+	// Successful responses are untouched.
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if ct := w.Header().Get("Content-Type"); ct == problemContentType {
+		t.Fatalf("health: Content-Type = %q, want plain JSON", ct)
+	}
+}
+
+func TestErrorFormatLegacyIsDefault(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.EnableFrontend = false
+	s := NewServer(cfg, rewards.NewService(cfg), nil)
+
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/rewards/network/fine", nil))
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body["error"] == nil {
+		t.Fatalf("body = %s, want a legacy {\"error\": ...} object", w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct == problemContentType {
+		t.Fatalf("Content-Type = %q, want legacy JSON", ct)
+	}
+}
@@ -1,23 +1,35 @@
 package server
 
 import (
+	"container/list"
 	"math"
 	"net/http"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/time/rate"
 )
 
-// ipRateLimiter enforces a token bucket limiter per client IP.
+// ipRateLimiter enforces a token bucket limiter per client IP. At most maxIPs limiters are kept; the
+// least recently seen IP is evicted first, so a flood of distinct (or spoofed) addresses cannot grow
+// memory without bound. An evicted IP simply starts again with a full bucket.
 type ipRateLimiter struct {
 	rate     rate.Limit
 	burst    int
+	maxIPs   int
 	mu       sync.Mutex
-	limiters map[string]*rate.Limiter
+	order    *list.List // Front is most recently seen; elements hold *ipLimiterEntry.
+	limiters map[string]*list.Element
 }
 
-func newIPRateLimiter(rps float64, burst int) *ipRateLimiter {
+type ipLimiterEntry struct {
+	ip      string
+	limiter *rate.Limiter
+}
+
+func newIPRateLimiter(rps float64, burst, maxIPs int) *ipRateLimiter {
 	if burst <= 0 {
 		burst = max(int(math.Ceil(rps)), 1)
 	}
@@ -25,7 +37,9 @@ func newIPRateLimiter(rps float64, burst int) *ipRateLimiter {
 	return &ipRateLimiter{
 		rate:     rate.Limit(rps),
 		burst:    burst,
-		limiters: make(map[string]*rate.Limiter),
+		maxIPs:   max(maxIPs, 1),
+		order:    list.New(),
+		limiters: make(map[string]*list.Element),
 	}
 }
 
@@ -37,24 +51,53 @@ func (l *ipRateLimiter) getLimiter(ip string) *rate.Limiter {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	limiter, ok := l.limiters[ip]
-	if !ok {
-		limiter = rate.NewLimiter(l.rate, l.burst)
-		l.limiters[ip] = limiter
+	if el, ok := l.limiters[ip]; ok {
+		l.order.MoveToFront(el)
+		return el.Value.(*ipLimiterEntry).limiter
 	}
+	for l.order.Len() >= l.maxIPs {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.limiters, oldest.Value.(*ipLimiterEntry).ip)
+	}
+	limiter := rate.NewLimiter(l.rate, l.burst)
+	l.limiters[ip] = l.order.PushFront(&ipLimiterEntry{ip: ip, limiter: limiter})
 	return limiter
 }
 
+// size reports how many IPs currently hold a limiter.
+func (l *ipRateLimiter) size() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.limiters)
+}
+
 func (l *ipRateLimiter) allow(ip string) bool {
+	ok, _ := l.reserve(ip)
+	return ok
+}
+
+// reserve takes a token for ip if one is available. Otherwise it returns false and how long until
+// the bucket refills enough for the next request, without consuming anything.
+func (l *ipRateLimiter) reserve(ip string) (bool, time.Duration) {
 	if ip == "" {
 		ip = "unknown"
 	}
-	return l.getLimiter(ip).Allow()
+	r := l.getLimiter(ip).Reserve()
+	if !r.OK() {
+		return false, time.Second
+	}
+	if delay := r.Delay(); delay > 0 {
+		r.Cancel()
+		return false, delay
+	}
+	return true, 0
 }
 
 func (l *ipRateLimiter) middleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if !l.allow(c.ClientIP()) {
+		if ok, wait := l.reserve(c.ClientIP()); !ok {
+			setRetryAfter(c, wait)
 			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
 				"error": "rate limit exceeded",
 			})
@@ -63,3 +106,9 @@ func (l *ipRateLimiter) middleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// setRetryAfter sets the Retry-After header in whole seconds, rounding up so clients never retry early.
+func setRetryAfter(c *gin.Context, d time.Duration) {
+	secs := max(int64(math.Ceil(d.Seconds())), 1)
+	c.Header("Retry-After", strconv.FormatInt(secs, 10))
+}
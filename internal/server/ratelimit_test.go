@@ -1,6 +1,7 @@
 package server
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -12,7 +13,7 @@ import (
 func TestIPRateLimiterBlocksBurstPerIP(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
-	limiter := newIPRateLimiter(1, 1)
+	limiter := newIPRateLimiter(1, 1, 100)
 	router := gin.New()
 	router.Use(limiter.middleware())
 	router.GET("/ping", func(c *gin.Context) {
@@ -33,6 +34,8 @@ func TestIPRateLimiterBlocksBurstPerIP(t *testing.T) {
 
 	if w := makeRequest("1.1.1.1:1234"); w.Code != http.StatusTooManyRequests {
 		t.Fatalf("second request status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	} else if got := w.Header().Get("Retry-After"); got != "1" {
+		t.Fatalf("Retry-After = %q, want %q", got, "1")
 	}
 
 	if w := makeRequest("2.2.2.2:1234"); w.Code != http.StatusOK {
@@ -46,8 +49,37 @@ func TestIPRateLimiterBlocksBurstPerIP(t *testing.T) {
 }
 
 func TestIPRateLimiterDefaultBurst(t *testing.T) {
-	limiter := newIPRateLimiter(5.1, 0)
+	limiter := newIPRateLimiter(5.1, 0, 100)
 	if got, want := limiter.Burst(), 6; got != want {
 		t.Fatalf("default burst = %d, want %d", got, want)
 	}
 }
+
+func TestIPRateLimiterEvictsLeastRecentlySeenIP(t *testing.T) {
+	const maxIPs = 50
+	limiter := newIPRateLimiter(1, 1, maxIPs)
+
+	if !limiter.allow("10.0.0.1") {
+		t.Fatalf("first request from 10.0.0.1 should be allowed")
+	}
+	for i := 0; i < 10*maxIPs; i++ {
+		limiter.allow(fmt.Sprintf("198.51.%d.%d", i/256, i%256))
+		if got := limiter.size(); got > maxIPs {
+			t.Fatalf("limiter tracks %d IPs after %d inserts, want at most %d", got, i+1, maxIPs)
+		}
+	}
+	if got := limiter.size(); got != maxIPs {
+		t.Fatalf("limiter tracks %d IPs, want %d", got, maxIPs)
+	}
+
+	// 10.0.0.1 was evicted long ago, so it starts again with a full bucket.
+	if !limiter.allow("10.0.0.1") {
+		t.Fatalf("evicted IP should get a fresh limiter")
+	}
+	// The most recently seen IP keeps its (empty) bucket.
+	n := 10*maxIPs - 1
+	last := fmt.Sprintf("198.51.%d.%d", n/256, n%256)
+	if limiter.allow(last) {
+		t.Fatalf("recently seen IP should still be rate limited")
+	}
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// NameResolver maps a human-readable name (e.g. an ENS name) to a 0x address.
+type NameResolver interface {
+	Resolve(ctx context.Context, name string) (string, error)
+}
+
+// SetNameResolver replaces the resolver consulted for address inputs that are not hex (nil disables it).
+func (s *Server) SetNameResolver(r NameResolver) {
+	s.nameResolver = r
+}
+
+// httpNameResolver resolves names with GET <url>?name=<name>, expecting {"address":"0x..."}.
+type httpNameResolver struct {
+	url    string
+	client *http.Client
+}
+
+func newHTTPNameResolver(rawURL string, timeout time.Duration) *httpNameResolver {
+	return &httpNameResolver{url: rawURL, client: &http.Client{Timeout: timeout}}
+}
+
+// Resolve implements NameResolver.
+func (r *httpNameResolver) Resolve(ctx context.Context, name string) (string, error) {
+	u, err := url.Parse(r.url)
+	if err != nil {
+		return "", fmt.Errorf("parse resolver URL: %w", err)
+	}
+	q := u.Query()
+	q.Set("name", name)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		if s := strings.TrimSpace(string(msg)); s != "" {
+			return "", fmt.Errorf("resolver returned %s: %s", resp.Status, s)
+		}
+		return "", fmt.Errorf("resolver returned %s", resp.Status)
+	}
+
+	var payload struct {
+		Address string `json:"address"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return "", fmt.Errorf("decode resolver response: %w", err)
+	}
+	if payload.Address == "" {
+		return "", errors.New("resolver returned no address")
+	}
+	return payload.Address, nil
+}
+
+// looksLikeName reports whether an address input is a name to resolve rather than a hex address,
+// withdrawal credentials or a bls: key.
+func looksLikeName(input string) bool {
+	lower := strings.ToLower(input)
+	return !strings.HasPrefix(lower, "0x") && !strings.HasPrefix(lower, "bls:")
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"beacon-rewards/internal/config"
+	"beacon-rewards/internal/dora"
+	"beacon-rewards/internal/rewards"
+
+	"github.com/gin-gonic/gin"
+)
+
+type stubResolver map[string]string
+
+func (r stubResolver) Resolve(_ context.Context, name string) (string, error) {
+	if addr, ok := r[name]; ok {
+		return addr, nil
+	}
+	return "", errors.New("not found")
+}
+
+func TestAddressRewardsHandlerResolvesNames(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	s := &Server{config: cfg, rewardsService: rewards.NewService(cfg), doraDB: &dora.DB{}}
+	s.SetNameResolver(stubResolver{"pool.eth": "0x0988DC1554CF6877508208FFF8AAB4E5AFA11EE3"})
+
+	post := func(address string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/rewards/by-address", strings.NewReader(`{"address":"`+address+`"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+		s.addressRewardsHandler(c)
+		return w
+	}
+
+	w := post("pool.eth")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	var result AddressRewardsResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if result.Address != "0x0988dc1554cf6877508208fff8aab4e5afa11ee3" {
+		t.Fatalf("address = %q, want the normalized resolved address", result.Address)
+	}
+
+	if w := post("unknown.eth"); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "unknown.eth") {
+		t.Fatalf("unresolvable name: status = %d body = %s, want 400 naming the input", w.Code, w.Body.String())
+	}
+}
+
+func TestHTTPNameResolver(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("name") != "pool.eth" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"address":"0x0988dc1554cf6877508208fff8aab4e5afa11ee3"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	r := newHTTPNameResolver(srv.URL+"/resolve?chain=1", time.Second)
+	if got, err := r.Resolve(context.Background(), "pool.eth"); err != nil || got != "0x0988dc1554cf6877508208fff8aab4e5afa11ee3" {
+		t.Fatalf("Resolve = (%q, %v)", got, err)
+	}
+	if _, err := r.Resolve(context.Background(), "missing.eth"); err == nil {
+		t.Fatalf("expected an error for a 404 from the resolver")
+	}
+}
@@ -1,19 +1,28 @@
 package server
 
 import (
+	"beacon-rewards/internal/beacon"
 	"beacon-rewards/internal/config"
 	"beacon-rewards/internal/dora"
 	"beacon-rewards/internal/rewards"
 	"beacon-rewards/internal/utils"
 	"context"
+	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"html/template"
 	"log/slog"
+	"maps"
+	"math"
 	"net/http"
+	"path"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -24,6 +33,14 @@ import (
 const (
 	rateLimitRPS   = 100.0
 	rateLimitBurst = 0 // 0 uses ceil(rps) as burst
+
+	farFutureEpoch = math.MaxUint64 // activation epoch of validators not yet scheduled
+
+	exportBatchSize = 1000 // validators per effective-balance lookup and flush in /rewards/export
+
+	maxBalanceHistoryEpochs = 10_000 // widest epoch range served by /validators/:index/balance-history
+
+	topProposersDefaultLimit = 50 // /proposers/top results without a limit parameter
 )
 
 // @title           Beacon Rewards API
@@ -41,9 +58,30 @@ type Server struct {
 	doraDB          *dora.DB
 	router          *gin.Engine
 	httpServer      *http.Server
+	socketPath      string // Set while serving on a Unix socket; removed again on Stop.
+	maintenance     atomic.Bool
+	nameResolver    NameResolver        // Consulted for address inputs that are not hex; nil when NAME_RESOLVER_URL is unset.
+	addressCache    *addressResultCache // Recent POST /rewards/by-address results; nil when ADDRESS_CACHE_SIZE is 0.
+	addressLimit    gin.HandlerFunc     // ADDRESS_QUERY_CONCURRENCY slots, shared by the address and label routes under both prefixes.
+	stopBackground  context.CancelFunc  // Stops background checks started by Start.
 	depositorLabels map[string]string
 	templates       map[string]*template.Template
 	frontendEnabled bool
+	// feeRecipientOwners maps fee recipient to owner address, both normalized; see fee_recipients.go.
+	feeRecipientOwners map[string]string
+	// aprDist is the GET /rewards/apr/distribution result, rebuilt in the background by
+	// aprDistributionRoutine; nil until the first build finishes.
+	aprDist atomic.Pointer[aprDistributionEntry]
+	// routeKeys collects the endpoint keys (see config.EndpointKey) of every route handle saw, enabled
+	// or not, so ENABLED_ENDPOINTS/DISABLED_ENDPOINTS entries that match nothing can be reported.
+	routeKeys map[string]struct{}
+}
+
+// aprDistributionEntry is a cached APR distribution with the synced epoch and window it covers.
+type aprDistributionEntry struct {
+	epoch       uint64
+	windowStart time.Time
+	dist        rewards.APRDistribution
 }
 
 // NewServer creates a new HTTP server
@@ -51,20 +89,25 @@ func NewServer(cfg *config.Config, rewardsService *rewards.Service, doraDB *dora
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
 	router.Use(gin.Recovery())
-	router.Use(loggingMiddleware())
-	limiter := newIPRateLimiter(rateLimitRPS, rateLimitBurst)
+	router.Use(loggingMiddleware(cfg.AccessLogFields))
+	router.Use(errorFormat(cfg.ErrorFormat))
+	limiter := newIPRateLimiter(rateLimitRPS, rateLimitBurst, cfg.RateLimitMaxIPs)
 	router.Use(limiter.middleware())
-	slog.Info("Rate limiting enabled", "rps", rateLimitRPS, "burst", limiter.Burst())
+	slog.Info("Rate limiting enabled", "rps", rateLimitRPS, "burst", limiter.Burst(), "max_ips", cfg.RateLimitMaxIPs)
 
 	depositorLabels, err := loadDepositorLabels(cfg.DepositorLabelsFile)
 	if err != nil {
 		slog.Warn("Failed to load depositor labels", "path", cfg.DepositorLabelsFile, "error", err)
 	}
+	feeRecipientOwners, err := loadFeeRecipientOwners(cfg.FeeRecipientsFile)
+	if err != nil {
+		slog.Warn("Failed to load fee recipient owners", "path", cfg.FeeRecipientsFile, "error", err)
+	}
 
 	var templates map[string]*template.Template
 	frontendEnabled := cfg.EnableFrontend
 	if cfg.EnableFrontend {
-		templates, err = loadTemplates()
+		templates, err = loadTemplates(templateFS(cfg.TemplatesDir))
 		if err != nil {
 			slog.Warn("Failed to load templates", "error", err)
 		}
@@ -81,9 +124,24 @@ func NewServer(cfg *config.Config, rewardsService *rewards.Service, doraDB *dora
 		rewardsService:  rewardsService,
 		doraDB:          doraDB,
 		router:          router,
+		addressLimit:    concurrencyLimit(cfg.AddressQueryConcurrency),
 		depositorLabels: depositorLabels,
 		templates:       templates,
 		frontendEnabled: frontendEnabled,
+
+		feeRecipientOwners: feeRecipientOwners,
+	}
+
+	s.maintenance.Store(cfg.MaintenanceMode)
+	if cfg.NameResolverURL != "" {
+		s.nameResolver = newHTTPNameResolver(cfg.NameResolverURL, cfg.RequestTimeout)
+	}
+	if cfg.AddressCacheSize > 0 {
+		// A result covers one synced epoch, so it never needs to live longer than an epoch.
+		s.addressCache = newAddressResultCache(cfg.AddressCacheSize, time.Duration(s.chain().SecondsPerEpoch())*time.Second)
+	}
+	if rewardsService != nil && len(depositorLabels) > 0 {
+		rewardsService.SetLabeledAddresses(slices.Collect(maps.Keys(depositorLabels)))
 	}
 
 	// Set HTML renderer
@@ -92,15 +150,18 @@ func NewServer(cfg *config.Config, rewardsService *rewards.Service, doraDB *dora
 	}
 
 	s.setupRoutes()
+	s.warnUnmatchedEndpoints()
 
 	return s
 }
 
 // setupRoutes configures the API routes
 func (s *Server) setupRoutes() {
+	s.router.Use(apiVersionMiddleware(), s.maintenanceGate(), handlerTimeout(s.config.RequestTimeout))
+
 	if s.frontendEnabled {
 		// Static files
-		s.router.Static("/static", "./internal/server/static")
+		s.router.StaticFS("/static", http.FS(staticFS(s.config.TemplatesDir)))
 
 		// Page routes (HTML pages) - order matters, more specific routes first
 		s.router.GET("/", func(c *gin.Context) {
@@ -116,19 +177,14 @@ func (s *Server) setupRoutes() {
 		slog.Info("Registering API-only routes; frontend disabled")
 	}
 
-	s.router.GET("/deposits/top-withdrawals", s.topWithdrawalsPageOrAPIHandler)
-	s.router.GET("/rewards/network", s.networkRewardsPageOrAPIHandler)
 	if s.frontendEnabled {
-		s.router.GET("/rewards/by-address", s.addressRewardsPageHandler)
+		s.handle(&s.router.RouterGroup, http.MethodGet, "/rewards/by-address", s.addressRewardsPageHandler)
 	}
 
-	// Health check endpoint
-	s.router.GET("/health", s.healthHandler)
-
-	// API endpoints
-	s.router.POST("/rewards", s.rewardsHandler)
-	s.router.POST("/rewards/by-address", s.addressRewardsHandler)
-	s.router.GET("/deposits/top-deposits", s.topDepositsHandler)
+	// The API is served under /v1 and, as an alias, unprefixed. Only the unprefixed leaderboard and
+	// network routes can answer with HTML pages.
+	s.registerAPIRoutes(&s.router.RouterGroup, true)
+	s.registerAPIRoutes(s.router.Group(apiVersionPrefix), false)
 
 	// Swagger UI (requires generated docs; run `swag init` and import docs package in main)
 	//http://localhost:8080/swagger/index.html
@@ -136,15 +192,130 @@ func (s *Server) setupRoutes() {
 	s.router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 }
 
+// registerAPIRoutes registers the JSON API on r. With pages, the leaderboard and network routes use
+// the handlers that render HTML for browsers when the frontend is enabled. Routes turned off by
+// ENABLED_ENDPOINTS/DISABLED_ENDPOINTS are skipped.
+func (s *Server) registerAPIRoutes(r *gin.RouterGroup, pages bool) {
+	if pages {
+		s.handle(r, http.MethodGet, "/deposits/top-withdrawals", s.topWithdrawalsPageOrAPIHandler)
+		s.handle(r, http.MethodGet, "/rewards/network", s.networkRewardsPageOrAPIHandler)
+	} else {
+		s.handle(r, http.MethodGet, "/deposits/top-withdrawals", s.topWithdrawalsAPIHandler)
+		s.handle(r, http.MethodGet, "/rewards/network", s.networkRewardsHandler)
+	}
+
+	// Health check endpoint
+	s.handle(r, http.MethodGet, "/health", s.healthHandler)
+	s.handle(r, http.MethodGet, "/sync/status", s.syncStatusHandler)
+	s.handle(r, http.MethodGet, "/sync/failed-epochs", s.failedEpochsHandler)
+
+	// API endpoints
+	bodyLimit := maxBodySize(s.config.MaxRequestBodyBytes)
+	s.handle(r, http.MethodPost, "/rewards", bodyLimit, s.rewardsHandler)
+	s.handle(r, http.MethodPost, "/rewards/upload", bodyLimit, s.rewardsUploadHandler)
+	s.handle(r, http.MethodPost, "/rewards/by-address", bodyLimit, s.addressLimit, s.addressRewardsHandler)
+	s.handle(r, http.MethodGet, "/rewards/range", s.rewardsRangeHandler)
+	s.handle(r, http.MethodGet, "/rewards/network/summary", s.networkRewardsSummaryHandler)
+	s.handle(r, http.MethodGet, "/rewards/network/fine", s.fineNetworkRewardsHandler)
+	s.handle(r, http.MethodGet, "/rewards/el", s.elRewardsHandler)
+	s.handle(r, http.MethodGet, "/rewards/export", s.rewardsExportHandler)
+	s.handle(r, http.MethodGet, "/rewards/apr/distribution", s.aprDistributionHandler)
+	s.handle(r, http.MethodGet, "/rewards/by-address/history", s.addressRewardHistoryHandler)
+	s.handle(r, http.MethodGet, "/rewards/by-address/at", s.addressRewardsAtHandler)
+	s.handle(r, http.MethodGet, "/rewards/by-label/:label", s.addressLimit, s.labelRewardsHandler)
+	s.handle(r, http.MethodGet, "/proposers/top", s.topProposersHandler)
+	s.handle(r, http.MethodGet, "/validators/pending/by-address", s.pendingValidatorsHandler)
+	s.handle(r, http.MethodGet, "/validators/activation-queue", s.activationQueueHandler)
+	s.handle(r, http.MethodGet, "/validators/credential-types/by-address", s.credentialTypesHandler)
+	s.handle(r, http.MethodGet, "/validators/skim-estimate", s.skimEstimateHandler)
+	s.handle(r, http.MethodGet, "/validators/:index/balance-history", s.balanceHistoryHandler)
+	s.handle(r, http.MethodGet, "/validators/:index/slashing-estimate", s.slashingEstimateHandler)
+	s.handle(r, http.MethodGet, "/validators/:index/attestation-detail", s.attestationDetailHandler)
+	s.handle(r, http.MethodGet, "/validators/:index/upcoming-proposals", s.upcomingProposalsHandler)
+	// Top deposits has no HTML page, so it serves the documented JSON in every frontend mode.
+	s.handle(r, http.MethodGet, "/deposits/top-deposits", s.topDepositsHandler)
+	s.handle(r, http.MethodGet, "/deposits/links", s.depositLinksHandler)
+	s.handle(r, http.MethodGet, "/depositors/:address/validators", s.depositorValidatorsHandler)
+
+	// Admin endpoints are only registered when ADMIN_TOKEN is set.
+	if s.config.AdminToken != "" {
+		admin := r.Group("/admin", s.requireAdmin())
+		s.handle(admin, http.MethodPost, "/maintenance", maxBodySize(s.config.MaxRequestBodyBytes), s.maintenanceHandler)
+		// A read-only replica does not sync, so it has no epochs to re-run or remove and no nodes to swap.
+		if !s.config.ReadOnlyReplica {
+			s.handle(admin, http.MethodPost, "/sync/retry-epoch/:epoch", s.retryEpochHandler)
+			s.handle(admin, http.MethodDelete, "/sync/epochs/:epoch", s.removeEpochHandler)
+			s.handle(admin, http.MethodPost, "/nodes", maxBodySize(s.config.MaxRequestBodyBytes), s.nodesHandler)
+		}
+	}
+}
+
+// handle registers a route on g unless ENABLED_ENDPOINTS/DISABLED_ENDPOINTS turn it off; requests
+// for a route that was not registered get gin's 404.
+func (s *Server) handle(g *gin.RouterGroup, method, relativePath string, handlers ...gin.HandlerFunc) {
+	route := unversionedPath(path.Join(g.BasePath(), relativePath))
+	if s.routeKeys == nil {
+		s.routeKeys = make(map[string]struct{})
+	}
+	s.routeKeys[config.EndpointKey("", route)] = struct{}{}
+	s.routeKeys[config.EndpointKey(method, route)] = struct{}{}
+	if !s.config.EndpointEnabled(method, route) {
+		return
+	}
+	g.Handle(method, relativePath, handlers...)
+}
+
+// warnUnmatchedEndpoints logs ENABLED_ENDPOINTS/DISABLED_ENDPOINTS entries that name no route of this
+// instance, which usually means a typo: the entry then silently has no effect. Admin routes only
+// exist when ADMIN_TOKEN is set.
+func (s *Server) warnUnmatchedEndpoints() {
+	for env, set := range map[string]map[string]struct{}{
+		"ENABLED_ENDPOINTS":  s.config.EnabledEndpoints,
+		"DISABLED_ENDPOINTS": s.config.DisabledEndpoints,
+	} {
+		for _, key := range slices.Sorted(maps.Keys(set)) {
+			if _, ok := s.routeKeys[key]; !ok {
+				slog.Warn("Endpoint setting matches no route", "setting", env, "entry", key)
+			}
+		}
+	}
+}
+
 // Start starts the HTTP server
 func (s *Server) Start() error {
 	s.httpServer = &http.Server{
-		Addr:    s.config.ListenAddress(),
-		Handler: s.router,
+		Addr:         s.config.ListenAddress(),
+		Handler:      s.router,
+		ReadTimeout:  s.config.ReadTimeout,
+		WriteTimeout: s.config.WriteTimeout,
+		IdleTimeout:  s.config.IdleTimeout,
 	}
 
 	slog.Info("Starting HTTP server", "address", s.httpServer.Addr)
 
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopBackground = cancel
+	if s.rewardsService != nil && s.config.EndpointEnabled(http.MethodGet, "/rewards/apr/distribution") {
+		go s.aprDistributionRoutine(ctx)
+	}
+	if s.config.AnomalyWebhookURL != "" {
+		go s.anomalyRoutine(ctx, newAnomalyNotifier(s.config.AnomalyWebhookURL, s.config.RequestTimeout, s.config.AnomalyDebounce))
+	}
+
+	if path := s.config.UnixSocketPath(); path != "" {
+		ln, err := listenUnix(path)
+		if err != nil {
+			return fmt.Errorf("listen on unix socket %s: %w", path, err)
+		}
+		s.socketPath = path
+		go func() {
+			if err := s.httpServer.Serve(ln); err != nil && err != http.ErrServerClosed {
+				slog.Error("HTTP server stopped", "error", err)
+			}
+		}()
+		return nil
+	}
+
 	go func() {
 		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			slog.Error("Failed to start HTTP server", "error", err)
@@ -156,6 +327,9 @@ func (s *Server) Start() error {
 
 // Stop gracefully stops the HTTP server
 func (s *Server) Stop() error {
+	if s.stopBackground != nil {
+		s.stopBackground()
+	}
 	if s.httpServer == nil {
 		return nil
 	}
@@ -164,7 +338,13 @@ func (s *Server) Stop() error {
 	defer cancel()
 
 	slog.Info("Stopping HTTP server")
-	return s.httpServer.Shutdown(ctx)
+	err := s.httpServer.Shutdown(ctx)
+	if s.socketPath != "" {
+		if rmErr := removeSocket(s.socketPath); rmErr != nil {
+			slog.Warn("Failed to remove unix socket", "path", s.socketPath, "error", rmErr)
+		}
+	}
+	return err
 }
 
 // healthHandler handles health check requests
@@ -174,30 +354,106 @@ func (s *Server) Stop() error {
 // @Success      200  {object}  map[string]interface{}
 // @Router       /health [get]
 func (s *Server) healthHandler(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"status": "healthy",
 		"time":   time.Now().Unix(),
-	})
+	}
+	// Maintenance is reported alongside, not instead of, the real state.
+	if s.maintenance.Load() {
+		response["maintenance"] = true
+	}
+	if s.config.EnableReconcile && s.rewardsService != nil {
+		if result := s.rewardsService.LastReconcile(); result != nil {
+			response["reconcile"] = result
+		}
+	}
+	if s.config.ReadOnlyReplica && s.rewardsService != nil {
+		response["read_only_replica"] = true
+		if writtenAt := s.rewardsService.SharedStateWrittenAt(); !writtenAt.IsZero() {
+			response["shared_state_written_at"] = writtenAt
+		}
+	}
+	if s.rewardsService != nil {
+		if unsupported := s.rewardsService.UnsupportedEndpoints(); len(unsupported) > 0 {
+			response["unsupported_beacon_endpoints"] = unsupported
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// syncStatusHandler reports backfill/live sync progress.
+// @Summary      Sync progress
+// @Description  Reports the sync phase (backfill|live|replica), the epoch range being processed and how much is left.
+// @Tags         Health
+// @Produce      json
+// @Success      200  {object}  rewards.SyncStatus
+// @Router       /sync/status [get]
+func (s *Server) syncStatusHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, s.rewardsService.SyncStatus())
+}
+
+// failedEpochsHandler lists epochs whose rewards are missing because processing gave up.
+// @Summary      Failed epochs
+// @Description  Lists epochs of the current window that exhausted EPOCH_PROCESS_MAX_RETRIES, oldest first. Their rewards are missing from all totals until re-run with POST /admin/sync/retry-epoch/{epoch}.
+// @Tags         Health
+// @Produce      json
+// @Success      200  {array}  rewards.FailedEpoch
+// @Router       /sync/failed-epochs [get]
+func (s *Server) failedEpochsHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, s.rewardsService.FailedEpochs())
+}
+
+// topProposersHandler ranks validators by the rewards earned from their own blocks in the current window.
+// @Summary      Get the top block proposers in the current window
+// @Description  Ranks validators that proposed at least one block by proposer rewards: attestation, sync aggregate and slashing inclusion plus EL transaction fees.
+// @Tags         Rewards
+// @Produce      json
+// @Param        limit  query     int  false  "Number of results to return, capped at MAX_API_LIMIT"  default(50)
+// @Success      200    {array}   rewards.ProposerReward
+// @Router       /proposers/top [get]
+func (s *Server) topProposersHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, s.rewardsService.TopProposers(s.limitParamOr(c, topProposersDefaultLimit)))
 }
 
 // topDepositsHandler aggregates deposit amounts && validator counts by depositor (tx sender) and returns top N by validator counts.
 // @Summary      aggregates deposit amounts && validator counts by depositor (tx sender) and returns top N by validator counts.
 // @Tags         Deposits
 // @Produce      json
-// @Param        limit    query     int     false  "Number of results to return"  default(100)
+// @Param        limit    query     int     false  "Number of results to return, capped at MAX_API_LIMIT"  default(100)
 // @Param        sort_by  query     string  false  "Sort field (total_deposit,depositor_address,withdrawal_address,validators_total, slashed, voluntary_exited, active, total_active_effective_balance)"  default(total_deposit)
 // @Param        order    query     string  false  "Sort order (asc|desc)"  default(desc)
-// @Success      200     {object}  map[string]interface{}
+// @Param        min_deposit  query  string  false  "Minimum total deposit, as integer gwei or decimal ETH with an eth suffix (e.g. 100eth)"
+// @Param        min_validators  query  int  false  "Minimum number of validators"  default(0)
+// @Param        If-None-Match  header  string  false  "ETag from a previous response"
+// @Success      200     {object}  TopDepositsResponse
+// @Success      304     "Not Modified"
+// @Failure      400     {object}  map[string]string
 // @Failure      503     {object}  map[string]string
 // @Failure      500     {object}  map[string]string
 // @Router       /deposits/top-deposits [get]
 func (s *Server) topDepositsHandler(c *gin.Context) {
+	var minDeposit utils.Gwei
+	if raw := c.Query("min_deposit"); raw != "" {
+		var err error
+		if minDeposit, err = utils.ParseGwei(raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "min_deposit: " + err.Error()})
+			return
+		}
+	}
+	minValidators, ok := minValidatorsParam(c)
+	if !ok {
+		return
+	}
 	if !s.ensureDoraDB(c) {
 		return
 	}
+	if notModified(c, s.leaderboardETag(c)) {
+		return
+	}
 
-	s.respondWithTop(c, "total_deposit", func(ctx context.Context, limit int, sortBy string, order string) (any, error) {
-		stats, err := s.doraDB.TopDepositorAddresses(ctx, limit, sortBy, order)
+	filters := gin.H{"min_validators": minValidators}
+	s.respondWithTop(c, "total_deposit", filters, func(ctx context.Context, limit int, sortBy string, order string) (any, error) {
+		stats, err := s.doraDB.TopDepositorAddresses(ctx, limit, sortBy, order, int64(minDeposit), minValidators)
 		if err != nil {
 			return nil, err
 		}
@@ -210,20 +466,32 @@ func (s *Server) topDepositsHandler(c *gin.Context) {
 // @Summary      Aggregates deposit totals and validator counts by withdrawal address and returns the top set.
 // @Tags         Deposits
 // @Produce      json
-// @Param        limit    query     int     false  "Number of results to return"  default(100)
+// @Param        limit    query     int     false  "Number of results to return, capped at MAX_API_LIMIT"  default(100)
 // @Param        sort_by  query     string  false  "Sort field (total_deposit,withdrawal_address,validators_total, slashed, voluntary_exited, active, total_active_effective_balance)"  default(total_active_effective_balance)
 // @Param        order    query     string  false  "Sort order (asc|desc)"  default(desc)
-// @Success      200     {object}  map[string]interface{}
+// @Param        min_validators  query  int  false  "Minimum number of validators"  default(0)
+// @Param        If-None-Match  header  string  false  "ETag from a previous response"
+// @Success      200     {object}  TopWithdrawalsResponse
+// @Success      304     "Not Modified"
+// @Failure      400     {object}  map[string]string
 // @Failure      503     {object}  map[string]string
 // @Failure      500     {object}  map[string]string
 // @Router       /deposits/top-withdrawals [get]
 func (s *Server) topWithdrawalsAPIHandler(c *gin.Context) {
+	minValidators, ok := minValidatorsParam(c)
+	if !ok {
+		return
+	}
 	if !s.ensureDoraDB(c) {
 		return
 	}
+	if notModified(c, s.leaderboardETag(c)) {
+		return
+	}
 
-	s.respondWithTop(c, "total_active_effective_balance", func(ctx context.Context, limit int, sortBy string, order string) (any, error) {
-		stats, err := s.doraDB.TopWithdrawalAddresses(ctx, limit, sortBy, order)
+	filters := gin.H{"min_validators": minValidators}
+	s.respondWithTop(c, "total_active_effective_balance", filters, func(ctx context.Context, limit int, sortBy string, order string) (any, error) {
+		stats, err := s.doraDB.TopWithdrawalAddresses(ctx, limit, sortBy, order, minValidators)
 		if err != nil {
 			return nil, err
 		}
@@ -232,15 +500,97 @@ func (s *Server) topWithdrawalsAPIHandler(c *gin.Context) {
 	})
 }
 
+// depositLinksHandler returns depositor to withdrawal address pairs with their validator counts.
+// @Summary      Link depositor addresses to the withdrawal addresses they fund
+// @Description  Pairs each deposit transaction sender with the withdrawal addresses of the validators it funded and counts the validators per pair, for clustering operator identities. Results are bounded by limit (capped at MAX_API_LIMIT).
+// @Tags         Deposits
+// @Produce      json
+// @Param        limit    query     int     false  "Number of pairs to return, capped at MAX_API_LIMIT"  default(100)
+// @Param        sort_by  query     string  false  "Sort field (validator_count, depositor_address, withdrawal_address)"  default(validator_count)
+// @Param        order    query     string  false  "Sort order (asc|desc)"  default(desc)
+// @Param        If-None-Match  header  string  false  "ETag from a previous response"
+// @Success      200     {object}  DepositLinksResponse
+// @Success      304     "Not Modified"
+// @Failure      400     {object}  map[string]string
+// @Failure      503     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /deposits/links [get]
+func (s *Server) depositLinksHandler(c *gin.Context) {
+	switch strings.TrimSpace(c.Query("sort_by")) {
+	case "", "validator_count", "depositor_address", "withdrawal_address":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort_by must be validator_count, depositor_address or withdrawal_address"})
+		return
+	}
+	switch strings.ToLower(strings.TrimSpace(c.Query("order"))) {
+	case "", "asc", "desc":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "order must be asc or desc"})
+		return
+	}
+	if !s.ensureDoraDB(c) {
+		return
+	}
+	if notModified(c, s.leaderboardETag(c)) {
+		return
+	}
+
+	s.respondWithTop(c, "validator_count", nil, func(ctx context.Context, limit int, sortBy string, order string) (any, error) {
+		return s.doraDB.DepositorWithdrawalLinks(ctx, limit, sortBy, order)
+	})
+}
+
+// minValidatorsParam parses the leaderboards' min_validators query parameter, answering 400 when it
+// is not a non-negative integer. It returns 0 when the parameter is absent.
+func minValidatorsParam(c *gin.Context) (int64, bool) {
+	n, err := parseMinValidators(c.Query("min_validators"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return 0, false
+	}
+	return n, true
+}
+
+// parseMinValidators parses a min_validators value; empty means no filter.
+func parseMinValidators(raw string) (int64, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || n < 0 {
+		return 0, errors.New("min_validators must be a non-negative integer")
+	}
+	return n, nil
+}
+
 // networkRewardsHandler returns aggregated cache rewards for all validators over the config window.
 // @Summary      Get total validator rewards for the config window
 // @Description  Uses cached consensus/execution rewards to calculate global CL/EL totals and a daily APR estimate.
 // @Tags         Rewards
 // @Produce      json
+// @Param        If-None-Match  header  string  false  "ETag from a previous response"
+// @Param        force_recompute  query  bool    false  "Rebuild the snapshot instead of serving the one cached since the last merged epoch (requires Authorization: Bearer <ADMIN_TOKEN>)"
 // @Success      200  {object}  map[string]interface{}
+// @Success      304  "Not Modified"
+// @Failure      401  {object}  map[string]string
 // @Router       /rewards/network [get]
 func (s *Server) networkRewardsHandler(c *gin.Context) {
-	snapshot := s.rewardsService.TotalNetworkRewards()
+	var snapshot *rewards.NetworkRewardSnapshot
+	if force, _ := strconv.ParseBool(c.Query("force_recompute")); force {
+		if !s.isAdmin(c) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "admin token required"})
+			return
+		}
+		snapshot = s.rewardsService.RecomputeNetworkRewards()
+	} else {
+		windowStart, windowEnd := s.rewardsService.GetRewardWindow()
+		if notModified(c, etagFor(c, s.rewardsService.HistoryVersion(), windowStart.Unix(), windowEnd.Unix())) {
+			return
+		}
+		snapshot = s.rewardsService.TotalNetworkRewards()
+	}
+
 	historyEntries, err := s.rewardsService.NetworkRewardHistory()
 	if err != nil {
 		slog.Error("Failed to load rewards history", "error", err)
@@ -248,19 +598,116 @@ func (s *Server) networkRewardsHandler(c *gin.Context) {
 
 	response := gin.H{
 		"current": snapshot,
+		"history": historyEntries,
 	}
 	if err != nil {
+		response["history"] = []rewards.NetworkRewardSnapshot{}
 		response["history_error"] = "failed to load stored history"
-	} else if len(historyEntries) > 0 {
-		response["history"] = historyEntries
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
+// NetworkRewardsSummary is the small subset of the network snapshot served by /rewards/network/summary.
+type NetworkRewardsSummary struct {
+	WindowStart          time.Time  `json:"window_start"`
+	WindowEnd            time.Time  `json:"window_end"`
+	ProjectAprPercent    float64    `json:"project_apr_percent"`
+	Apr31dAvg            float64    `json:"apr_31d_avg"` // Same outlier-trimmed average the 31-day estimates use; 0 without history.
+	TotalRewardsGwei     utils.Gwei `json:"total_rewards_gwei"`
+	ActiveValidatorCount int        `json:"active_validator_count"`
+	// FromHistory is set while the cache warms up and the figures are the last stored window's.
+	FromHistory bool `json:"from_history,omitempty"`
+}
+
+// networkRewardsSummaryHandler returns the current APR figures without the history array.
+// @Summary      Get a compact network rewards summary
+// @Description  Returns the current APR, the 31-day average APR, total rewards and active validator count for the current window. Intended for badges and widgets that do not need /rewards/network's history.
+// @Tags         Rewards
+// @Produce      json
+// @Param        If-None-Match  header  string  false  "ETag from a previous response"
+// @Success      200  {object}  NetworkRewardsSummary
+// @Success      304  "Not Modified"
+// @Router       /rewards/network/summary [get]
+func (s *Server) networkRewardsSummaryHandler(c *gin.Context) {
+	windowStart, windowEnd := s.rewardsService.GetRewardWindow()
+	if notModified(c, etagFor(c, s.rewardsService.HistoryVersion(), windowStart.Unix(), windowEnd.Unix())) {
+		return
+	}
+	snapshot := s.rewardsService.TotalNetworkRewards()
+
+	history, err := s.rewardsService.NetworkRewardHistory()
+	if err != nil {
+		// The average then falls back to the current snapshot alone.
+		slog.Error("Failed to load rewards history", "error", err)
+	}
+
+	c.JSON(http.StatusOK, NetworkRewardsSummary{
+		WindowStart:          snapshot.WindowStart,
+		WindowEnd:            snapshot.WindowEnd,
+		ProjectAprPercent:    snapshot.ProjectAprPercent,
+		Apr31dAvg:            calculate31DayAverageAPR(history, snapshot),
+		TotalRewardsGwei:     snapshot.TotalRewardsGwei,
+		ActiveValidatorCount: snapshot.ActiveValidatorCount,
+		FromHistory:          snapshot.FromHistory,
+	})
+}
+
+// fineNetworkRewardsHandler returns the intra-day network snapshots.
+// @Summary      Get intra-day network reward totals
+// @Description  Returns the running network snapshot recorded every FINE_HISTORY_INTERVAL synced epochs, oldest first, for epochs in [from, to]. Totals are cumulative since the window start, so they drop back when the cache resets. Entries older than FINE_HISTORY_RETENTION are dropped.
+// @Tags         Rewards
+// @Produce      json
+// @Param        from  query     int  false  "First epoch (inclusive)"
+// @Param        to    query     int  false  "Last epoch (inclusive)"
+// @Success      200   {array}   rewards.FineNetworkSnapshot
+// @Failure      400   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Failure      503   {object}  map[string]string
+// @Router       /rewards/network/fine [get]
+func (s *Server) fineNetworkRewardsHandler(c *gin.Context) {
+	if !s.rewardsService.FineHistoryEnabled() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Fine network history is disabled"})
+		return
+	}
+
+	from, to := uint64(0), uint64(math.MaxUint64)
+	if raw := c.Query("from"); raw != "" {
+		n, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be an epoch number"})
+			return
+		}
+		from = n
+	}
+	if raw := c.Query("to"); raw != "" {
+		n, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be an epoch number"})
+			return
+		}
+		to = n
+	}
+	if from > to {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must not be greater than to"})
+		return
+	}
+
+	entries, err := s.rewardsService.FineNetworkHistory(from, to)
+	if err != nil {
+		slog.Error("Failed to load fine network history", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load fine network history"})
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
 // RewardsRequest represents the request body for rewards query
 type RewardsRequest struct {
 	Validators []uint64 `json:"validators" binding:"required"`
+	// AsOfEpoch, when set, must match the epoch the cache has reached; otherwise 409 is returned with
+	// the current epoch so a client can re-read a consistent set.
+	AsOfEpoch *uint64 `json:"as_of_epoch,omitempty"`
 }
 
 // AddressRewardsRequest represents the request body for reward aggregation per depositor address.
@@ -270,26 +717,130 @@ type AddressRewardsRequest struct {
 
 // AddressRewardsResult captures the aggregated rewards per depositor or withdrawal address.
 type AddressRewardsResult struct {
-	Address                        string    `json:"address"`
-	DepositorLabel                 string    `json:"depositor_label,omitempty"`
-	ActiveValidatorCount           int       `json:"active_validator_count"`
-	ValidatorIndices               []uint64  `json:"validator_indices,omitempty"`
-	ClRewardsGwei                  int64     `json:"cl_rewards_gwei"`
-	ElRewardsGwei                  int64     `json:"el_rewards_gwei"`
-	TotalRewardsGwei               int64     `json:"total_rewards_gwei"`
-	TotalEffectiveBalanceGwei      int64     `json:"total_effective_balance_gwei"`
-	EstimatedHistoryRewards31dGwei float64   `json:"estimated_history_rewards_31d_gwei"`
-	WeightedAverageStakeTime       int64     `json:"weighted_average_stake_time(seconds)"`
-	WindowStart                    time.Time `json:"window_start"`
-	WindowEnd                      time.Time `json:"window_end"`
+	Address                        string     `json:"address"`
+	DepositorLabel                 string     `json:"depositor_label,omitempty"`
+	ActiveValidatorCount           int        `json:"active_validator_count"`
+	PendingValidatorCount          int        `json:"pending_validator_count"`
+	ValidatorIndices               *[]uint64  `json:"validator_indices,omitempty"` // Set, possibly to [], only with include_validator_indices.
+	ClRewardsGwei                  utils.Gwei `json:"cl_rewards_gwei"`
+	ElRewardsGwei                  utils.Gwei `json:"el_rewards_gwei"`
+	TotalRewardsGwei               utils.Gwei `json:"total_rewards_gwei"`
+	GrossClRewardsGwei             utils.Gwei `json:"gross_cl_rewards_gwei"`
+	ClPenaltiesGwei                utils.Gwei `json:"cl_penalties_gwei"`
+	InactivityLeakGwei             utils.Gwei `json:"inactivity_leak_gwei"`
+	TotalEffectiveBalanceGwei      utils.Gwei `json:"total_effective_balance_gwei"`
+	EstimatedHistoryRewards31dGwei float64    `json:"estimated_history_rewards_31d_gwei"`
+	EstimateAprSource              string     `json:"estimate_apr_source"` // "validator_set" or "network"
+	WeightedAverageStakeTime       int64      `json:"weighted_average_stake_time(seconds)"`
+	WindowStart                    time.Time  `json:"window_start"`
+	WindowEnd                      time.Time  `json:"window_end"`
+	// ValidatorCredentialTypes maps each validator index to its withdrawal credential prefix
+	// ("0x00", "0x01" or "0x02"); only set with include_validator_indices.
+	ValidatorCredentialTypes map[uint64]string `json:"validator_credential_types,omitempty"`
+	// Addresses lists the addresses combined by GET /rewards/by-label; Address then holds the label.
+	Addresses []string `json:"addresses,omitempty"`
+	// Effective-balance-weighted stake time of the active validators from each basis;
+	// WeightedAverageStakeTime repeats the one selected by STAKE_TIME_BASIS.
+	WeightedAverageTimeSinceActivation int64 `json:"weighted_average_time_since_activation_seconds"`
+	WeightedAverageTimeSinceDeposit    int64 `json:"weighted_average_time_since_deposit_seconds"`
+	// FeeRecipientElRewardsGwei is EL income that other validators sent to the fee recipients in
+	// FeeRecipients, which FEE_RECIPIENTS_FILE maps to this address; ElRewardsGwei and
+	// TotalRewardsGwei already include it.
+	FeeRecipientElRewardsGwei utils.Gwei `json:"fee_recipient_el_rewards_gwei,omitempty"`
+	FeeRecipients             []string   `json:"fee_recipients,omitempty"`
+	// ZeroRewardValidatorCount is the number of active validators without rewards in the current
+	// window. It is only reported with include_zero_rewards, which also adds their effective balance
+	// to TotalEffectiveBalanceGwei so it covers every active validator.
+	ZeroRewardValidatorCount int `json:"zero_reward_validator_count,omitempty"`
+	// BalanceChangeGwei is the change in effective balance since the previous cache window closed,
+	// summed over the validators whose balance was recorded then; a drop points at penalties or an
+	// inactivity leak. Omitted until a window with recorded balances is retained.
+	BalanceChangeGwei *utils.Gwei `json:"balance_change_gwei,omitempty"`
+}
+
+// TopDepositsResponse is the JSON body of GET /deposits/top-deposits, as built by respondWithTop.
+// Each result carries total_active_effective_balance next to the deposit totals.
+type TopDepositsResponse struct {
+	Limit         int                  `json:"limit"`
+	SortBy        string               `json:"sort_by"`
+	Order         string               `json:"order"`
+	LimitCapped   bool                 `json:"limit_capped,omitempty"`
+	MinValidators int64                `json:"min_validators"` // Applied min_validators filter; 0 means none.
+	Results       []dora.DepositorStat `json:"results"`
+}
+
+// DepositLinksResponse is the JSON body of GET /deposits/links.
+type DepositLinksResponse struct {
+	Limit       int                            `json:"limit"`
+	SortBy      string                         `json:"sort_by"`
+	Order       string                         `json:"order"`
+	LimitCapped bool                           `json:"limit_capped,omitempty"`
+	Results     []dora.DepositorWithdrawalLink `json:"results"`
+}
+
+// TopWithdrawalsResponse is the JSON body of GET /deposits/top-withdrawals.
+type TopWithdrawalsResponse struct {
+	Limit         int                   `json:"limit"`
+	SortBy        string                `json:"sort_by"`
+	Order         string                `json:"order"`
+	LimitCapped   bool                  `json:"limit_capped,omitempty"`
+	MinValidators int64                 `json:"min_validators"` // Applied min_validators filter; 0 means none.
+	Results       []dora.WithdrawalStat `json:"results"`
+}
+
+// CredentialTypesResponse counts an address's validators per withdrawal credential prefix.
+type CredentialTypesResponse struct {
+	Address        string         `json:"address"`
+	ValidatorCount int            `json:"validator_count"`
+	Counts         map[string]int `json:"counts"` // Keyed by prefix: "0x00", "0x01", "0x02".
+}
+
+// PendingValidator describes a validator that is deposited but not yet active.
+type PendingValidator struct {
+	ValidatorIndex  uint64 `json:"validator_index"`
+	ActivationEpoch uint64 `json:"activation_epoch"`
+	// EstimatedActivationTime is omitted while the validator is still queued without an assigned activation epoch.
+	EstimatedActivationTime *time.Time `json:"estimated_activation_time,omitempty"`
+}
+
+// PendingValidatorsResponse lists pending validators for an address.
+type PendingValidatorsResponse struct {
+	Address    string             `json:"address"`
+	Count      int                `json:"count"`
+	Validators []PendingValidator `json:"validators"`
+}
+
+// BalanceHistoryResponse is a validator's per-epoch balance series.
+type BalanceHistoryResponse struct {
+	ValidatorIndex uint64              `json:"validator_index"`
+	FromEpoch      uint64              `json:"from_epoch"`
+	ToEpoch        uint64              `json:"to_epoch"`
+	Balances       []dora.BalancePoint `json:"balances"`
+}
+
+// AddressRewardHistoryResponse is the per-window reward series for an address.
+type AddressRewardHistoryResponse struct {
+	Address        string                 `json:"address"`
+	Days           int                    `json:"days"`
+	ValidatorCount int                    `json:"validator_count"`
+	History        []rewards.DailyRewards `json:"history"`
 }
 
 // RewardsResponse
 type RewardsResponse struct {
 	ValidatorCount int                                 `json:"validator_count"`
 	Rewards        map[uint64]*rewards.ValidatorReward `json:"rewards"`
+	AprAvailable   bool                                `json:"apr_available"`
 	WindowStart    time.Time                           `json:"window_start"`
 	WindowEnd      time.Time                           `json:"window_end"`
+	AsOfEpoch      uint64                              `json:"as_of_epoch"`      // Latest epoch included in Rewards.
+	SyncLagSeconds int64                               `json:"sync_lag_seconds"` // Time since AsOfEpoch ended.
+	Stale          bool                                `json:"stale"`            // SyncLagSeconds exceeds MAX_STALENESS.
+
+	// WindowStartEpoch and WindowEndEpoch bound the window in epochs, both inclusive, so clients
+	// need not derive them from the times and genesis.
+	WindowStartEpoch uint64 `json:"window_start_epoch"`
+	WindowEndEpoch   uint64 `json:"window_end_epoch"`
 }
 
 // rewardsHandler handles reward queries
@@ -298,17 +849,19 @@ type RewardsResponse struct {
 // @Accept       json
 // @Produce      json
 // @Param        request  body   RewardsRequest  true  "Validators request"
+// @Param        fields   query  string          false  "Comma-separated ValidatorReward fields to return (e.g. total_rewards_gwei,project_apr_percent)"
 // @Success      200      {object}  RewardsResponse
 // @Failure      400      {object}  map[string]string
+// @Failure      409      {object}  map[string]interface{}
+// @Failure      413      {object}  map[string]string
+// @Failure      503      {object}  map[string]interface{}
 // @Router       /rewards [post]
 func (s *Server) rewardsHandler(c *gin.Context) {
 	var req RewardsRequest
 
 	// Parse JSON request body
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request body: validators array is required",
-		})
+		bindFailed(c, err, "Invalid request body: validators array is required")
 		return
 	}
 
@@ -319,97 +872,530 @@ func (s *Server) rewardsHandler(c *gin.Context) {
 		return
 	}
 
-	var effectiveBalances map[uint64]int64
-	if s.doraDB != nil {
-		ctx, cancel := s.requestContext(c)
-		balances, err := s.doraDB.EffectiveBalances(ctx, req.Validators)
-		cancel()
+	s.respondRewards(c, req.Validators, req.AsOfEpoch)
+}
+
+// rewardsRangeHandler handles reward queries for a contiguous index range
+// @Summary      Get total rewards (EL+CL) for a range of validator indices
+// @Description  Returns rewards for validators with indices in [from, to] that have rewards in the current window. The range may span at most MAX_REWARDS_RANGE indices.
+// @Tags         Rewards
+// @Produce      json
+// @Param        from  query     int  true  "First validator index (inclusive)"
+// @Param        to    query     int  true  "Last validator index (inclusive)"
+// @Param        as_of_epoch  query  int  false  "Expected cache epoch; 409 when the cache has moved on"
+// @Param        fields  query  string  false  "Comma-separated ValidatorReward fields to return (e.g. total_rewards_gwei,project_apr_percent)"
+// @Success      200   {object}  RewardsResponse
+// @Failure      400   {object}  map[string]string
+// @Failure      409   {object}  map[string]interface{}
+// @Failure      503   {object}  map[string]interface{}
+// @Router       /rewards/range [get]
+func (s *Server) rewardsRangeHandler(c *gin.Context) {
+	from, errFrom := strconv.ParseUint(c.Query("from"), 10, 64)
+	to, errTo := strconv.ParseUint(c.Query("to"), 10, 64)
+	if errFrom != nil || errTo != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to must be validator indices"})
+		return
+	}
+	if from > to {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must not be greater than to"})
+		return
+	}
+	if to-from >= uint64(s.config.MaxRewardsRange) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("range spans more than %d validators", s.config.MaxRewardsRange)})
+		return
+	}
+
+	var asOf *uint64
+	if raw := c.Query("as_of_epoch"); raw != "" {
+		epoch, err := strconv.ParseUint(raw, 10, 64)
 		if err != nil {
-			slog.Error("Failed to load effective balances", "error", err)
-		} else {
-			effectiveBalances = balances
+			c.JSON(http.StatusBadRequest, gin.H{"error": "as_of_epoch must be an epoch number"})
+			return
 		}
+		asOf = &epoch
 	}
 
-	// Get total rewards (EL+CL) for each requested validator
-	validatorRewards := s.rewardsService.GetTotalRewards(req.Validators, effectiveBalances)
-	windowStart, windowEnd := s.rewardsService.GetRewardWindow()
-
-	result := RewardsResponse{
-		ValidatorCount: len(req.Validators),
-		Rewards:        validatorRewards,
-		WindowStart:    windowStart,
-		WindowEnd:      windowEnd,
+	indices := make([]uint64, 0, to-from+1)
+	for idx := from; idx <= to; idx++ {
+		indices = append(indices, idx)
 	}
-	c.JSON(http.StatusOK, result)
+
+	s.respondRewards(c, indices, asOf)
 }
 
-// addressRewardsHandler aggregates validator rewards by withdrawal or deposit addresses.
-// @Summary      Get aggregated validator rewards (EL+CL) per withdrawal or deposit address.
-// @Description  Looks up validators funded by withdrawal or deposit address and returns the summed rewards for those validators. Set include_validator_indices query parameter to true to include active validator indices in the response.
+// ELRewardsResponse lists the EL tips captured for an execution block range.
+type ELRewardsResponse struct {
+	FromBlock    uint64                  `json:"from_block"`
+	ToBlock      uint64                  `json:"to_block"`
+	BlockCount   int                     `json:"block_count"`
+	TotalTipGwei utils.Gwei              `json:"total_tip_gwei"`
+	WindowStart  time.Time               `json:"window_start"`
+	WindowEnd    time.Time               `json:"window_end"`
+	Blocks       []rewards.ELBlockReward `json:"blocks"`
+}
+
+// elRewardsHandler returns EL tips per execution block
+// @Summary      Get EL rewards for an execution block range
+// @Description  Returns the EL tips captured for blocks numbered [from_block, to_block] in the current window, with their slot and proposer. Blocks before the window, not yet synced, missed, or proposed by untracked validators are absent. The range may span at most MAX_EL_BLOCK_RANGE blocks.
 // @Tags         Rewards
-// @Accept       json
 // @Produce      json
-// @Param        request  body   AddressRewardsRequest  true  "Addresses request"
-// @Param        include_validator_indices  query   bool  false  "Include validator indices in response"  default(false)
-// @Success      200      {object}  AddressRewardsResult
-// @Failure      400      {object}  map[string]string
-// @Failure      503      {object}  map[string]string
-// @Router       /rewards/by-address [post]
-func (s *Server) addressRewardsHandler(c *gin.Context) {
-	if !s.ensureDoraDB(c) {
+// @Param        from_block  query     int  true  "First execution block number (inclusive)"
+// @Param        to_block    query     int  true  "Last execution block number (inclusive)"
+// @Success      200         {object}  ELRewardsResponse
+// @Failure      400         {object}  map[string]string
+// @Router       /rewards/el [get]
+func (s *Server) elRewardsHandler(c *gin.Context) {
+	from, errFrom := strconv.ParseUint(c.Query("from_block"), 10, 64)
+	to, errTo := strconv.ParseUint(c.Query("to_block"), 10, 64)
+	if errFrom != nil || errTo != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from_block and to_block must be block numbers"})
+		return
+	}
+	if from > to {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from_block must not be greater than to_block"})
+		return
+	}
+	if to-from >= uint64(s.config.MaxELBlockRange) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("range spans more than %d blocks", s.config.MaxELBlockRange)})
 		return
 	}
 
-	var req AddressRewardsRequest
-	var err error
-	contentType := c.GetHeader("Content-Type")
-	if strings.HasPrefix(contentType, "application/json") {
-		err = c.ShouldBindJSON(&req)
-	} else {
-		err = c.ShouldBind(&req)
+	resp := ELRewardsResponse{
+		FromBlock: from,
+		ToBlock:   to,
+		Blocks:    s.rewardsService.ELRewardsByBlock(from, to),
 	}
+	resp.WindowStart, resp.WindowEnd = s.rewardsService.GetRewardWindow()
+	resp.BlockCount = len(resp.Blocks)
+	for _, b := range resp.Blocks {
+		resp.TotalTipGwei += b.TipGwei
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// respondRewards writes the RewardsResponse for validators, or 409 when asOf is set and the cache
+// is no longer (or not yet) at that epoch.
+// A fields query parameter restricts each ValidatorReward to the named fields.
+func (s *Server) respondRewards(c *gin.Context, validators []uint64, asOf *uint64) {
+	fields, err := parseFields(c, rewards.ValidatorReward{})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request body: address is required",
-		})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "fields: " + err.Error()})
 		return
 	}
 
-	if strings.TrimSpace(req.Address) == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Address cannot be empty",
+	resp := s.rewardsResponse(c, validators)
+	if resp.Stale && s.config.StrictStaleness {
+		setRetryAfter(c, s.config.RetryAfter)
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":            "rewards are stale: live sync is lagging",
+			"as_of_epoch":      resp.AsOfEpoch,
+			"sync_lag_seconds": resp.SyncLagSeconds,
 		})
 		return
 	}
+	if asOf != nil && *asOf != resp.AsOfEpoch {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":       fmt.Sprintf("rewards are at epoch %d, not the requested as_of_epoch %d", resp.AsOfEpoch, *asOf),
+			"as_of_epoch": resp.AsOfEpoch,
+		})
+		return
+	}
+	if fields == nil {
+		c.JSON(http.StatusOK, resp)
+		return
+	}
 
-	ctx, cancel := s.requestContext(c)
-	defer cancel()
+	filtered := make(map[uint64]fieldsView, len(resp.Rewards))
+	for idx, reward := range resp.Rewards {
+		filtered[idx] = fieldsView{value: reward, fields: fields}
+	}
+	c.JSON(http.StatusOK, struct {
+		RewardsResponse
+		Rewards map[uint64]fieldsView `json:"rewards"`
+	}{resp, filtered})
+}
 
-	// 1) Handle withdrawal credentials: e.g.0x0100000000000000000000000988dc1554cf6877508208fff8aab4e5afa11ee3
-	if strings.HasPrefix(req.Address, "0x01") || strings.HasPrefix(req.Address, "0x02") {
-		// withdrawal_credentials: 0x01 (or 0x02) + 11 bytes zero + 20 bytes ETH address
-		// hex: "0x01" or "0x02" (2+2) + 22 zeros (11 bytes) + 40 chars (20 bytes)
-		if len(req.Address) == 66 { // "0x" + 64 hex chars for withdrawal_credentials
-			req.Address = strings.ToLower("0x" + req.Address[26:])
-			slog.Info("withdrawal address", "address", req.Address)
+// rewardsResponse builds the RewardsResponse for the given validators, enriching it with Dora
+// effective balances when available.
+func (s *Server) rewardsResponse(c *gin.Context, validators []uint64) RewardsResponse {
+	var effectiveBalances map[uint64]int64
+	if s.doraDB != nil {
+		ctx, cancel := s.requestContext(c)
+		balances, err := s.doraDB.EffectiveBalances(ctx, validators)
+		cancel()
+		if err != nil {
+			slog.Error("Failed to load effective balances", "error", err)
+		} else {
+			effectiveBalances = balances
 		}
 	}
 
-	currentEpoch := utils.TimeToEpoch(time.Now())
+	// Rewards, window and epoch come from one consistent copy of the cache.
+	snap := s.rewardsService.SnapshotRewards(validators, effectiveBalances)
+	lag := max(time.Since(s.chain().EpochToTime(snap.AsOfEpoch)), 0)
+
+	return RewardsResponse{
+		ValidatorCount:   len(validators),
+		Rewards:          snap.Rewards,
+		AprAvailable:     snap.AprAvailable,
+		WindowStart:      snap.WindowStart,
+		WindowEnd:        snap.WindowEnd,
+		WindowStartEpoch: snap.WindowStartEpoch,
+		WindowEndEpoch:   snap.WindowEndEpoch,
+		AsOfEpoch:        snap.AsOfEpoch,
+		SyncLagSeconds:   int64(lag.Seconds()),
+		Stale:            s.config.MaxStaleness > 0 && lag > s.config.MaxStaleness,
+	}
+}
 
-	details, err := s.doraDB.ValidatorDetailsByAddress(ctx, req.Address)
-	if err != nil {
-		if errors.Is(err, dora.ErrInvalidAddress) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-		slog.Error("Failed to load validators by address", "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load validator details for addresses"})
+// RewardsExportPage is one page of GET /rewards/export in cursor mode.
+type RewardsExportPage struct {
+	Rewards []*rewards.ValidatorReward `json:"rewards"`
+	Limit   int                        `json:"limit"`
+	// NextCursor is the cursor for the following page; it is omitted on the last page.
+	NextCursor *uint64 `json:"next_cursor,omitempty"`
+	// AsOfEpoch is the epoch the cache had reached when the page was read. Pages of one export can
+	// straddle epochs; a change in window_start means the cache was reset and the export should restart.
+	AsOfEpoch   uint64    `json:"as_of_epoch"`
+	WindowStart time.Time `json:"window_start"`
+}
+
+// rewardsExportHandler streams every validator in the current window's cache.
+// @Summary      Export all cached validator rewards
+// @Description  Streams one ValidatorReward per validator in the current window, ordered by index. The default ndjson format writes one JSON object per line; json writes a single array. Effective balances are looked up from Dora in batches when it is available. Each batch is read under the cache lock separately, so an epoch merged mid-export may be reflected only in later batches.
+// @Description  With cursor or limit set, one page of validators with index >= cursor is returned as a JSON RewardsExportPage (rewards, limit, next_cursor, as_of_epoch, window_start) instead; pass next_cursor back until it is omitted. Pages are cut from the sorted set of cached indices, which is taken once per merged epoch, so paging is deterministic by index but only best-effort consistent: pages read in different epochs reflect different epochs, as reported by as_of_epoch.
+// @Tags         Rewards
+// @Produce      json
+// @Produce      application/x-ndjson
+// @Param        format  query  string  false  "Output format"  Enums(ndjson, json)  default(ndjson)
+// @Param        cursor  query  int     false  "Lowest validator index of the page (cursor mode)"
+// @Param        limit   query  int     false  "Page size (cursor mode), capped at MAX_API_LIMIT"  default(100)
+// @Success      200     {array}   rewards.ValidatorReward
+// @Failure      400     {object}  map[string]string
+// @Router       /rewards/export [get]
+func (s *Server) rewardsExportHandler(c *gin.Context) {
+	if c.Query("cursor") != "" || c.Query("limit") != "" {
+		s.rewardsExportPageHandler(c)
 		return
 	}
 
-	allValidatorIndices := make([]uint64, 0, len(details))
+	format := c.DefaultQuery("format", "ndjson")
+	if format != "ndjson" && format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be ndjson or json"})
+		return
+	}
+
+	indices := s.rewardsService.CachedValidatorIndices()
+	apr := s.rewardsService.TotalNetworkRewards().ProjectAprPercent
+
+	if format == "json" {
+		c.Header("Content-Type", "application/json")
+	} else {
+		c.Header("Content-Type", "application/x-ndjson")
+	}
+	c.Status(http.StatusOK)
+
+	// The whole export can outlast WriteTimeout; extend the deadline per batch instead so only a
+	// stalled client is cut off.
+	rc := http.NewResponseController(c.Writer)
+	enc := json.NewEncoder(c.Writer)
+	first := true
+	if format == "json" {
+		_, _ = c.Writer.WriteString("[")
+	}
+	for start := 0; start < len(indices); start += exportBatchSize {
+		batch := indices[start:min(start+exportBatchSize, len(indices))]
+
+		var balances map[uint64]int64
+		if s.doraDB != nil {
+			ctx, cancel := s.requestContext(c)
+			b, err := s.doraDB.EffectiveBalances(ctx, batch)
+			cancel()
+			if err != nil {
+				slog.Warn("Failed to load effective balances for export batch", "error", err)
+			} else {
+				balances = b
+			}
+		}
+
+		if s.config.WriteTimeout > 0 {
+			_ = rc.SetWriteDeadline(time.Now().Add(s.config.WriteTimeout))
+		}
+		for _, r := range s.rewardsService.ValidatorRewards(batch, balances, apr) {
+			if format == "json" && !first {
+				_, _ = c.Writer.WriteString(",")
+			}
+			first = false
+			if err := enc.Encode(r); err != nil {
+				slog.Warn("Aborting rewards export", "error", err)
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+	if format == "json" {
+		_, _ = c.Writer.WriteString("]")
+	}
+}
+
+// rewardsExportPageHandler serves the cursor mode of GET /rewards/export.
+func (s *Server) rewardsExportPageHandler(c *gin.Context) {
+	var cursor uint64
+	if raw := c.Query("cursor"); raw != "" {
+		n, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cursor must be a validator index"})
+			return
+		}
+		cursor = n
+	}
+	limit := s.limitParam(c)
+
+	page, next := pageIndices(s.rewardsService.CachedValidatorIndices(), cursor, limit)
+	var balances map[uint64]int64
+	if s.doraDB != nil && len(page) > 0 {
+		ctx, cancel := s.requestContext(c)
+		b, err := s.doraDB.EffectiveBalances(ctx, page)
+		cancel()
+		if err != nil {
+			slog.Warn("Failed to load effective balances for export page", "error", err)
+		} else {
+			balances = b
+		}
+	}
+
+	// The labels are read before the rewards, so a sync pass landing in between can only make the
+	// rewards newer than as_of_epoch, never older.
+	asOfEpoch := s.rewardsService.LatestSyncEpoch()
+	windowStart, _ := s.rewardsService.GetRewardWindow()
+	apr := s.rewardsService.TotalNetworkRewards().ProjectAprPercent
+	c.JSON(http.StatusOK, RewardsExportPage{
+		Rewards:     s.rewardsService.ValidatorRewards(page, balances, apr),
+		Limit:       limit,
+		NextCursor:  next,
+		AsOfEpoch:   asOfEpoch,
+		WindowStart: windowStart,
+	})
+}
+
+// pageIndices returns up to limit of the ascending indices that are >= cursor, and the cursor of the
+// next page or nil when none remain.
+func pageIndices(indices []uint64, cursor uint64, limit int) ([]uint64, *uint64) {
+	start := sort.Search(len(indices), func(i int) bool { return indices[i] >= cursor })
+	end := min(start+limit, len(indices))
+	if end < len(indices) {
+		next := indices[end]
+		return indices[start:end], &next
+	}
+	return indices[start:end], nil
+}
+
+// aprDistributionHandler reports the spread of per-validator APR in the current window.
+// @Summary      Get the distribution of per-validator APR
+// @Description  Annualises each validator's rewards in the current window against its effective balance and returns min, max and the 10th/25th/50th/75th/90th percentiles. Validators that earned attestation rewards in less than MIN_APR_ACTIVE_FRACTION of the window's epochs are excluded and counted separately. Percentiles are zero while apr_available is false. The result is rebuilt in the background after each synced epoch, so it may lag the newest epoch by one rebuild; until the first rebuild finishes the endpoint answers 503 with Retry-After.
+// @Tags         Rewards
+// @Produce      json
+// @Success      200  {object}  rewards.APRDistribution
+// @Failure      503  {object}  map[string]string
+// @Router       /rewards/apr/distribution [get]
+func (s *Server) aprDistributionHandler(c *gin.Context) {
+	e := s.aprDist.Load()
+	if e == nil {
+		setRetryAfter(c, s.config.RetryAfter)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "APR distribution is not computed yet"})
+		return
+	}
+	c.JSON(http.StatusOK, e.dist)
+}
+
+// addressRewardsHandler aggregates validator rewards by withdrawal or deposit addresses.
+// @Summary      Get aggregated validator rewards (EL+CL) per withdrawal or deposit address.
+// @Description  Looks up validators funded by withdrawal or deposit address and returns the summed rewards for those validators. Set include_validator_indices query parameter to true to include active validator indices in the response. Reward and effective-balance totals cover active validators with rewards in the current window; set include_zero_rewards to true to count active validators without rewards as explicit zeros, so total_effective_balance_gwei covers all active_validator_count validators. When NAME_RESOLVER_URL is configured, an address without a 0x or bls: prefix is treated as a name and resolved first.
+// @Tags         Rewards
+// @Accept       json
+// @Produce      json
+// @Param        request  body   AddressRewardsRequest  true  "Addresses request"
+// @Param        include_validator_indices  query   bool  false  "Include validator indices in response"  default(false)
+// @Param        include_zero_rewards  query   bool  false  "Include active validators without rewards in the current window as zeros"  default(false)
+// @Param        fields   query  string  false  "Comma-separated AddressRewardsResult fields to return (e.g. address,total_rewards_gwei)"
+// @Success      200      {object}  AddressRewardsResult
+// @Failure      400      {object}  map[string]string
+// @Failure      413      {object}  map[string]string
+// @Failure      503      {object}  map[string]string
+// @Router       /rewards/by-address [post]
+func (s *Server) addressRewardsHandler(c *gin.Context) {
+	if !s.ensureDoraDB(c) {
+		return
+	}
+
+	var req AddressRewardsRequest
+	var err error
+	contentType := c.GetHeader("Content-Type")
+	if strings.HasPrefix(contentType, "application/json") {
+		err = c.ShouldBindJSON(&req)
+	} else {
+		err = c.ShouldBind(&req)
+	}
+	if err != nil {
+		bindFailed(c, err, "Invalid request body: address is required")
+		return
+	}
+
+	if strings.TrimSpace(req.Address) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Address cannot be empty",
+		})
+		return
+	}
+	fields, err := parseFields(c, AddressRewardsResult{})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "fields: " + err.Error()})
+		return
+	}
+
+	ctx, cancel := s.requestContext(c)
+	defer cancel()
+
+	req.Address = strings.TrimSpace(req.Address)
+	if s.nameResolver != nil && looksLikeName(req.Address) {
+		resolved, err := s.nameResolver.Resolve(ctx, req.Address)
+		if err == nil {
+			resolved, err = dora.NormalizeAddress(resolved)
+		}
+		if err != nil {
+			slog.Info("Failed to resolve address name", "name", req.Address, "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("could not resolve %q to an address", req.Address)})
+			return
+		}
+		req.Address = resolved
+	}
+	req.Address, err = normalizeAddressInput(req.Address)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	includeIndices, _ := strconv.ParseBool(c.Query("include_validator_indices"))
+	includeZeroRewards, _ := strconv.ParseBool(c.Query("include_zero_rewards"))
+	windowStart, _ := s.rewardsService.GetRewardWindow()
+	cacheKey := addressCacheKey{
+		address:            req.Address,
+		windowStart:        windowStart,
+		epoch:              s.rewardsService.LatestSyncEpoch(),
+		includeIndices:     includeIndices,
+		includeZeroRewards: includeZeroRewards,
+	}
+	if s.addressCache != nil {
+		if cached, ok := s.addressCache.get(cacheKey, time.Now()); ok {
+			c.JSON(http.StatusOK, fieldsView{value: cached, fields: fields})
+			return
+		}
+	}
+
+	details, err := s.doraDB.ValidatorDetailsByAddress(ctx, req.Address)
+	if err != nil {
+		if errors.Is(err, dora.ErrInvalidAddress) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		slog.Error("Failed to load validators by address", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load validator details for addresses"})
+		return
+	}
+
+	if len(details) > 0 {
+		// Only addresses that fund validators are recorded, so arbitrary lookups cannot evict them.
+		s.rewardsService.TrackAddress(req.Address)
+	}
+
+	result := s.aggregateAddressRewards(ctx, details, includeIndices, includeZeroRewards)
+	s.applyFeeRecipientRewards(&result, []string{req.Address}, details)
+	result.Address = req.Address
+	if label, ok := s.lookupDepositorLabel(req.Address); ok {
+		result.DepositorLabel = label
+	}
+	if s.addressCache != nil {
+		s.addressCache.put(cacheKey, result, time.Now())
+	}
+	c.JSON(http.StatusOK, fieldsView{value: result, fields: fields})
+}
+
+// labelRewardsHandler aggregates rewards across every address that shares a depositor label.
+// @Summary      Get rewards aggregated by depositor label
+// @Description  Combines the validators of every address mapped to the label in the depositor labels file (matched case-insensitively) and returns one aggregated result. Validators reachable from several of those addresses are counted once. Shares the ADDRESS_QUERY_CONCURRENCY limit with POST /rewards/by-address; excess requests get 503 with Retry-After.
+// @Tags         Rewards
+// @Produce      json
+// @Param        label                      path      string  true   "Depositor label"
+// @Param        include_validator_indices  query     bool    false  "Include validator indices in the response"
+// @Param        include_zero_rewards       query     bool    false  "Include active validators without rewards in the current window as zeros"
+// @Param        fields                     query     string  false  "Comma-separated response fields to return"
+// @Success      200                        {object}  AddressRewardsResult
+// @Failure      400                        {object}  map[string]string
+// @Failure      404                        {object}  map[string]string
+// @Failure      500                        {object}  map[string]string
+// @Failure      503                        {object}  map[string]string
+// @Router       /rewards/by-label/{label} [get]
+func (s *Server) labelRewardsHandler(c *gin.Context) {
+	if !s.ensureDoraDB(c) {
+		return
+	}
+
+	label, addresses := s.addressesForLabel(strings.TrimSpace(c.Param("label")))
+	if len(addresses) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("unknown label %q", c.Param("label"))})
+		return
+	}
+	fields, err := parseFields(c, AddressRewardsResult{})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "fields: " + err.Error()})
+		return
+	}
+	includeIndices, _ := strconv.ParseBool(c.Query("include_validator_indices"))
+	includeZeroRewards, _ := strconv.ParseBool(c.Query("include_zero_rewards"))
+
+	ctx, cancel := s.requestContext(c)
+	defer cancel()
+
+	var details []dora.ValidatorDetail
+	seen := make(map[uint64]struct{})
+	for _, address := range addresses {
+		found, err := s.doraDB.ValidatorDetailsByAddress(ctx, address)
+		if err != nil {
+			if errors.Is(err, dora.ErrInvalidAddress) {
+				slog.Warn("Skipping invalid labelled address", "label", label, "address", address, "error", err)
+				continue
+			}
+			slog.Error("Failed to load validators by address", "address", address, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load validator details for addresses"})
+			return
+		}
+		for _, d := range found {
+			if _, dup := seen[d.ValidatorIndex]; dup {
+				continue
+			}
+			seen[d.ValidatorIndex] = struct{}{}
+			details = append(details, d)
+		}
+	}
+
+	result := s.aggregateAddressRewards(ctx, details, includeIndices, includeZeroRewards)
+	s.applyFeeRecipientRewards(&result, addresses, details)
+	result.Address = label
+	result.DepositorLabel = label
+	result.Addresses = addresses
+	c.JSON(http.StatusOK, fieldsView{value: result, fields: fields})
+}
+
+// aggregateAddressRewards sums the current-window rewards and the 31-day estimate over validators
+// resolved from one or more addresses. Active validators without cached rewards are left out of the
+// totals unless includeZeroRewards is set. The caller fills in Address and DepositorLabel.
+func (s *Server) aggregateAddressRewards(ctx context.Context, details []dora.ValidatorDetail, includeIndices, includeZeroRewards bool) AddressRewardsResult {
+	details = dedupValidatorDetails(details)
+	chain := s.chain()
+	currentEpoch := chain.TimeToEpoch(time.Now())
+	pending := pendingValidators(chain, details, currentEpoch)
+
+	allValidatorIndices := make([]uint64, 0, len(details))
 	activeValidatorIndices := make([]uint64, 0, len(details))
 
 	effectiveBalances := make(map[uint64]int64, len(details))
@@ -433,30 +1419,47 @@ func (s *Server) addressRewardsHandler(c *gin.Context) {
 			activeValidatorIndices = append(activeValidatorIndices, idx)
 		}
 	}
+	if s.config.BalanceBeaconFallback {
+		s.fillBeaconEffectiveBalances(ctx, details, currentEpoch, effectiveBalances)
+	}
 
 	var (
-		weightedAvgStakeTime int64
-		validatorRewards     map[uint64]*rewards.ValidatorReward
-		windowStart          time.Time
-		windowEnd            time.Time
-		estimatedRewards     float64
+		timeSinceActivation int64
+		timeSinceDeposit    int64
+		validatorRewards    map[uint64]*rewards.ValidatorReward
+		windowStart         time.Time
+		windowEnd           time.Time
+		estimatedRewards    float64
+		estimateAprSource   string
 	)
 
 	var wg sync.WaitGroup
-	wg.Add(3)
+	wg.Add(4)
 
 	go func() {
 		defer wg.Done()
-		if len(allValidatorIndices) == 0 {
+		if len(activeValidatorIndices) == 0 {
 			return
 		}
-		if avg, err := s.doraDB.GetWeightedAverageStakeTime(ctx, activeValidatorIndices); err == nil {
-			weightedAvgStakeTime = avg
+		if avg, err := s.doraDB.GetWeightedAverageStakeTime(ctx, chain, activeValidatorIndices); err == nil {
+			timeSinceActivation = avg
 		} else {
 			slog.Error("Failed to calculate weighted average stake time", "error", err)
 		}
 	}()
 
+	go func() {
+		defer wg.Done()
+		if len(activeValidatorIndices) == 0 {
+			return
+		}
+		if avg, err := s.doraDB.GetWeightedAverageDepositTime(ctx, activeValidatorIndices); err == nil {
+			timeSinceDeposit = avg
+		} else {
+			slog.Error("Failed to calculate weighted average deposit time", "error", err)
+		}
+	}()
+
 	go func() {
 		defer wg.Done()
 		validatorRewards = s.rewardsService.GetTotalRewards(activeValidatorIndices, effectiveBalances)
@@ -472,62 +1475,648 @@ func (s *Server) addressRewardsHandler(c *gin.Context) {
 			slog.Error("Failed to load reward history for APR calculation", "error", err)
 		}
 
-		// Calculate 31-day average APR with outlier removal
-		avgAPR := calculate31DayAverageAPR(history, networkSnapshot)
-		if avgAPR <= 0 {
-			avgAPR = networkSnapshot.ProjectAprPercent
+		// Prefer the set's own daily APR once enough history is retained; large operators can
+		// differ noticeably from the network average.
+		setAPRs, err := s.rewardsService.ValidatorSetAPRHistory(allValidatorIndices, effectiveBalances, maxHistoryDays)
+		if err != nil {
+			slog.Error("Failed to load validator set APR history", "error", err)
+		}
+		avgAPR, ok := validatorSetAverageAPR(setAPRs)
+		estimateAprSource = "validator_set"
+		if !ok {
+			// Calculate 31-day average APR with outlier removal
+			avgAPR = calculate31DayAverageAPR(history, networkSnapshot)
+			if avgAPR <= 0 {
+				avgAPR = networkSnapshot.ProjectAprPercent
+			}
+			estimateAprSource = "network"
 		}
 
 		estimatedRewards = estimateRecentRewardsForValidators(
+			chain,
 			allValidatorIndices,
 			avgAPR,
 			currentEpoch,
-			estimateWindowEpochs(),
+			estimateWindowEpochs(chain),
 			effectiveBalances,
 			depositBalances,
 			lifecycles,
+			s.config.DefaultEffectiveBalanceGwei,
 		)
 	}()
 
 	wg.Wait()
 
 	result := AddressRewardsResult{
-		Address:                  req.Address,
-		ActiveValidatorCount:     len(activeValidatorIndices),
-		WindowStart:              windowStart,
-		WindowEnd:                windowEnd,
-		WeightedAverageStakeTime: weightedAvgStakeTime,
+		ActiveValidatorCount:               len(activeValidatorIndices),
+		PendingValidatorCount:              len(pending),
+		WindowStart:                        windowStart,
+		WindowEnd:                          windowEnd,
+		WeightedAverageStakeTime:           timeSinceActivation,
+		WeightedAverageTimeSinceActivation: timeSinceActivation,
+		WeightedAverageTimeSinceDeposit:    timeSinceDeposit,
 	}
-	includeIndices := c.Query("include_validator_indices")
-	if includeIndices != "" {
-		if parsed, err := strconv.ParseBool(includeIndices); err == nil && parsed {
-			result.ValidatorIndices = allValidatorIndices
-		}
+	if s.config.StakeTimeBasis == config.StakeTimeBasisDeposit {
+		result.WeightedAverageStakeTime = timeSinceDeposit
 	}
-
-	if label, ok := s.lookupDepositorLabel(req.Address); ok {
-		result.DepositorLabel = label
+	if includeIndices {
+		result.ValidatorIndices = &allValidatorIndices
+		result.ValidatorCredentialTypes = make(map[uint64]string, len(details))
+		for _, d := range details {
+			result.ValidatorCredentialTypes[d.ValidatorIndex] = d.CredentialType
+		}
 	}
 
 	for _, idx := range activeValidatorIndices {
 		reward, ok := validatorRewards[idx]
 		if !ok {
+			if includeZeroRewards {
+				result.ZeroRewardValidatorCount++
+				result.TotalEffectiveBalanceGwei += utils.Gwei(effectiveBalances[idx])
+			}
 			continue
 		}
 		result.ClRewardsGwei += reward.ClRewardsGwei
 		result.ElRewardsGwei += reward.ElRewardsGwei
 		result.TotalRewardsGwei += reward.TotalRewardsGwei
+		result.GrossClRewardsGwei += reward.GrossClRewardsGwei
+		result.ClPenaltiesGwei += reward.ClPenaltiesGwei
+		result.InactivityLeakGwei += reward.InactivityLeakGwei
 		result.TotalEffectiveBalanceGwei += reward.EffectiveBalanceGwei
 	}
 	result.EstimatedHistoryRewards31dGwei = estimatedRewards
-	c.JSON(http.StatusOK, result)
+	result.EstimateAprSource = estimateAprSource
+	result.BalanceChangeGwei = s.balanceChangeSincePreviousWindow(allValidatorIndices, effectiveBalances)
+	return result
+}
 
+// dedupValidatorDetails returns details ordered by validator index with one entry per validator, so a
+// validator matched through both its depositor and its withdrawal address is not counted twice.
+func dedupValidatorDetails(details []dora.ValidatorDetail) []dora.ValidatorDetail {
+	seen := make(map[uint64]struct{}, len(details))
+	out := make([]dora.ValidatorDetail, 0, len(details))
+	for _, d := range details {
+		if _, dup := seen[d.ValidatorIndex]; dup {
+			continue
+		}
+		seen[d.ValidatorIndex] = struct{}{}
+		out = append(out, d)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ValidatorIndex < out[j].ValidatorIndex })
+	return out
+}
+
+// balanceChangeSincePreviousWindow compares current effective balances with those recorded when the
+// previous window closed. Validators missing from either side are left out, so activations and exits
+// do not show up as balance changes. It returns nil when nothing can be compared.
+func (s *Server) balanceChangeSincePreviousWindow(validatorIndices []uint64, effectiveBalances map[uint64]int64) *utils.Gwei {
+	previous, err := s.rewardsService.PreviousWindowEffectiveBalances(validatorIndices)
+	if err != nil {
+		slog.Error("Failed to load previous window effective balances", "error", err)
+		return nil
+	}
+
+	var (
+		change   utils.Gwei
+		compared bool
+	)
+	for idx, before := range previous {
+		current, ok := effectiveBalances[idx]
+		if !ok {
+			continue
+		}
+		change += utils.Gwei(current - before)
+		compared = true
+	}
+	if !compared {
+		return nil
+	}
+	return &change
+}
+
+// addressRewardHistoryHandler returns daily reward totals for the validators of an address.
+// @Summary      Get the daily reward series for a withdrawal or deposit address
+// @Description  Sums the retained per-validator totals of each completed cache window (one per day) for the validators currently funded by the address, oldest first. The open window is served by POST /rewards/by-address. At most VALIDATOR_HISTORY_DAYS windows are retained.
+// @Tags         Rewards
+// @Produce      json
+// @Param        address  query     string  true   "Withdrawal/deposit address or withdrawal credentials"
+// @Param        days     query     int     false  "Number of most recent windows to return"  default(30)
+// @Success      200      {object}  AddressRewardHistoryResponse
+// @Failure      400      {object}  map[string]string
+// @Failure      500      {object}  map[string]string
+// @Failure      503      {object}  map[string]string
+// @Router       /rewards/by-address/history [get]
+func (s *Server) addressRewardHistoryHandler(c *gin.Context) {
+	if !s.rewardsService.ValidatorHistoryEnabled() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Per-address history is disabled"})
+		return
+	}
+	if !s.ensureDoraDB(c) {
+		return
+	}
+
+	address := strings.TrimSpace(c.Query("address"))
+	if address == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Address cannot be empty"})
+		return
+	}
+	address, err := normalizeAddressInput(address)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	days := s.config.ValidatorHistoryDays
+	if raw := c.Query("days"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be a positive integer"})
+			return
+		}
+		if n < days {
+			days = n
+		}
+	}
+
+	ctx, cancel := s.requestContext(c)
+	defer cancel()
+
+	details, err := s.doraDB.ValidatorDetailsByAddress(ctx, address)
+	if err != nil {
+		if errors.Is(err, dora.ErrInvalidAddress) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		slog.Error("Failed to load validators by address", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load validator details for addresses"})
+		return
+	}
+
+	indices := make([]uint64, 0, len(details))
+	for _, d := range details {
+		indices = append(indices, d.ValidatorIndex)
+	}
+
+	history, err := s.rewardsService.ValidatorRewardHistory(indices, days)
+	if err != nil {
+		slog.Error("Failed to load validator reward history", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load reward history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, AddressRewardHistoryResponse{
+		Address:        address,
+		Days:           days,
+		ValidatorCount: len(indices),
+		History:        history,
+	})
+}
+
+// pendingValidatorsHandler lists validators funded by an address that are not yet active.
+// @Summary      List validators pending activation for an address
+// @Description  Returns validators whose activation epoch is still in the future, with the estimated activation time when an activation epoch has been assigned.
+// @Tags         Validators
+// @Produce      json
+// @Param        address  query     string  true  "Withdrawal/deposit address or withdrawal credentials"
+// @Success      200      {object}  PendingValidatorsResponse
+// @Failure      400      {object}  map[string]string
+// @Failure      500      {object}  map[string]string
+// @Failure      503      {object}  map[string]string
+// @Router       /validators/pending/by-address [get]
+func (s *Server) pendingValidatorsHandler(c *gin.Context) {
+	if !s.ensureDoraDB(c) {
+		return
+	}
+
+	address := strings.TrimSpace(c.Query("address"))
+	if address == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Address cannot be empty"})
+		return
+	}
+	address, err := normalizeAddressInput(address)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := s.requestContext(c)
+	defer cancel()
+
+	details, err := s.doraDB.ValidatorDetailsByAddress(ctx, address)
+	if err != nil {
+		if errors.Is(err, dora.ErrInvalidAddress) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		slog.Error("Failed to load validators by address", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load validator details for addresses"})
+		return
+	}
+
+	chain := s.chain()
+	pending := pendingValidators(chain, details, chain.TimeToEpoch(time.Now()))
+	c.JSON(http.StatusOK, PendingValidatorsResponse{
+		Address:    address,
+		Count:      len(pending),
+		Validators: pending,
+	})
+}
+
+// credentialTypesHandler summarizes the withdrawal credential prefixes of an address's validators.
+// @Summary      Count an address's validators per withdrawal credential type
+// @Description  Counts the validators funded by the address by withdrawal credential prefix (0x00 BLS, 0x01 execution, 0x02 compounding), e.g. to follow a 0x01 to 0x02 migration.
+// @Tags         Validators
+// @Produce      json
+// @Param        address  query     string  true  "Withdrawal/deposit address or withdrawal credentials"
+// @Success      200      {object}  CredentialTypesResponse
+// @Failure      400      {object}  map[string]string
+// @Failure      500      {object}  map[string]string
+// @Failure      503      {object}  map[string]string
+// @Router       /validators/credential-types/by-address [get]
+func (s *Server) credentialTypesHandler(c *gin.Context) {
+	if !s.ensureDoraDB(c) {
+		return
+	}
+
+	address := strings.TrimSpace(c.Query("address"))
+	if address == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Address cannot be empty"})
+		return
+	}
+	address, err := normalizeAddressInput(address)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := s.requestContext(c)
+	defer cancel()
+
+	details, err := s.doraDB.ValidatorDetailsByAddress(ctx, address)
+	if err != nil {
+		if errors.Is(err, dora.ErrInvalidAddress) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		slog.Error("Failed to load validators by address", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load validator details for addresses"})
+		return
+	}
+
+	c.JSON(http.StatusOK, CredentialTypesResponse{
+		Address:        address,
+		ValidatorCount: len(details),
+		Counts:         credentialTypeCounts(details),
+	})
+}
+
+// skimEstimateHandler estimates when a 0x01 validator's balance will exceed 32 ETH and be skimmed.
+// @Summary      Estimate time until the next skim for a 0x01 validator
+// @Description  Uses the validator's current balance and its CL reward rate over the cache window to estimate how many epochs remain until the balance exceeds 32 ETH. Validators without 0x01 credentials are reported as not applicable.
+// @Tags         Validators
+// @Produce      json
+// @Param        index  query     int  true  "Validator index"
+// @Success      200    {object}  SkimEstimate
+// @Failure      400    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Failure      503    {object}  map[string]string
+// @Router       /validators/skim-estimate [get]
+func (s *Server) skimEstimateHandler(c *gin.Context) {
+	if !s.ensureDoraDB(c) {
+		return
+	}
+
+	index, err := strconv.ParseUint(strings.TrimSpace(c.Query("index")), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "index must be a validator index"})
+		return
+	}
+
+	ctx, cancel := s.requestContext(c)
+	defer cancel()
+
+	creds, err := s.doraDB.WithdrawalCredentials(ctx, index)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Validator not found"})
+			return
+		}
+		slog.Error("Failed to load withdrawal credentials", "validator", index, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load validator"})
+		return
+	}
+
+	est := SkimEstimate{ValidatorIndex: index}
+	if len(creds) > 0 {
+		est.CredentialType = fmt.Sprintf("0x%02x", creds[0])
+	}
+	switch est.CredentialType {
+	case "0x01":
+		est.Applicable = true
+	case "0x02":
+		est.Reason = "not applicable: 0x02 validators compound rewards instead of being skimmed at 32 ETH"
+	default:
+		est.Reason = "not applicable: validator has no execution withdrawal address"
+	}
+	if !est.Applicable {
+		c.JSON(http.StatusOK, est)
+		return
+	}
+
+	balance, err := s.rewardsService.ValidatorBalance(index)
+	if err != nil {
+		slog.Error("Failed to load validator balance", "validator", index, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load validator balance"})
+		return
+	}
+	est.BalanceGwei = utils.Gwei(balance)
+	est.RewardRateGweiPerEpoch, _ = s.rewardsService.ClRewardRate(index)
+	chain := s.chain()
+	estimateSkim(chain, &est, chain.TimeToEpoch(time.Now()))
+
+	c.JSON(http.StatusOK, est)
+}
+
+// slashingEstimateHandler estimates the correlation penalty a slashed validator has still to pay.
+// @Summary      Estimate the remaining slashing penalty of a validator
+// @Description  For a slashed validator, reports the slashing epoch (from the block that included the slashing, or derived from the withdrawable epoch when Dora has not indexed it) and estimates the correlation penalty applied 4096 epochs before its withdrawable epoch from the balance slashed network-wide (validators not yet withdrawable) relative to the total active balance. The figure changes as further validators are slashed before the penalty epoch. Unslashed validators are reported with slashed=false.
+// @Tags         Validators
+// @Produce      json
+// @Param        index  path      int  true  "Validator index"
+// @Success      200    {object}  SlashingEstimate
+// @Failure      400    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Failure      503    {object}  map[string]string
+// @Router       /validators/{index}/slashing-estimate [get]
+func (s *Server) slashingEstimateHandler(c *gin.Context) {
+	if !s.ensureDoraDB(c) {
+		return
+	}
+
+	index, err := strconv.ParseUint(c.Param("index"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "index must be a validator index"})
+		return
+	}
+
+	ctx, cancel := s.requestContext(c)
+	defer cancel()
+
+	v, err := s.doraDB.ValidatorSlashing(ctx, index)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Validator not found"})
+			return
+		}
+		slog.Error("Failed to load validator slashing state", "validator", index, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load validator"})
+		return
+	}
+
+	est := SlashingEstimate{ValidatorIndex: index, Slashed: v.Slashed, EffectiveBalanceGwei: utils.Gwei(v.EffectiveBalance)}
+	if !v.Slashed {
+		est.Reason = "validator is not slashed"
+		c.JSON(http.StatusOK, est)
+		return
+	}
+
+	chain := s.chain()
+	currentEpoch := chain.TimeToEpoch(time.Now())
+	slashed, err := s.doraDB.RecentlySlashedBalance(ctx, currentEpoch)
+	if err != nil {
+		slog.Error("Failed to load slashed balance", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load network slashing data"})
+		return
+	}
+	total, err := s.doraDB.TotalEffectiveBalance(ctx, currentEpoch)
+	if err != nil {
+		slog.Error("Failed to load total effective balance", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load network slashing data"})
+		return
+	}
+	est.NetworkSlashedBalanceGwei = utils.Gwei(slashed)
+	est.TotalActiveBalanceGwei = utils.Gwei(total)
+	var slashedEpoch *uint64
+	if v.SlashedSlot != nil {
+		epoch := *v.SlashedSlot / chain.SlotsPerEpoch
+		slashedEpoch = &epoch
+	}
+	estimateSlashing(chain, &est, slashedEpoch, v.WithdrawableEpoch, currentEpoch)
+
+	c.JSON(http.StatusOK, est)
+}
+
+// attestationDetailHandler compares a validator's attestation rewards with the ideal, per duty.
+// @Summary      Get a validator's actual vs ideal attestation rewards
+// @Description  Sums the validator's source, target and head rewards (net of penalties) over the current window and compares each with the beacon node's ideal_rewards for a perfect validator of the same effective balance, over the epochs it attested in. The effective balance comes from Dora, or DEFAULT_EFFECTIVE_BALANCE_GWEI without it.
+// @Tags         Validators
+// @Produce      json
+// @Param        index  path      int  true  "Validator index"
+// @Success      200    {object}  rewards.AttestationDetail
+// @Failure      400    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Router       /validators/{index}/attestation-detail [get]
+func (s *Server) attestationDetailHandler(c *gin.Context) {
+	index, err := strconv.ParseUint(c.Param("index"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "index must be a validator index"})
+		return
+	}
+
+	var effectiveBalance int64
+	if s.doraDB != nil {
+		ctx, cancel := s.requestContext(c)
+		balances, err := s.doraDB.EffectiveBalances(ctx, []uint64{index})
+		cancel()
+		if err != nil {
+			slog.Error("Failed to load effective balance", "validator", index, "error", err)
+		}
+		effectiveBalance = balances[index]
+	}
+
+	detail, ok := s.rewardsService.AttestationDetail(index, effectiveBalance)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No attestation data for this validator in the current window"})
+		return
+	}
+	c.JSON(http.StatusOK, detail)
+}
+
+// balanceHistoryHandler returns a validator's balance per epoch from Dora's balance snapshots.
+// @Summary      Get a validator's balance history
+// @Description  Returns the balance recorded for each epoch in [from_epoch, to_epoch], oldest first. to_epoch defaults to the current epoch and from_epoch to one day earlier; the range may span at most 10000 epochs. Responds 501 when the Dora schema only keeps the latest balance.
+// @Tags         Validators
+// @Produce      json
+// @Param        index       path      int  true   "Validator index"
+// @Param        from_epoch  query     int  false  "First epoch (inclusive)"
+// @Param        to_epoch    query     int  false  "Last epoch (inclusive)"
+// @Success      200         {object}  BalanceHistoryResponse
+// @Failure      400         {object}  map[string]string
+// @Failure      500         {object}  map[string]string
+// @Failure      501         {object}  map[string]string
+// @Failure      503         {object}  map[string]string
+// @Router       /validators/{index}/balance-history [get]
+func (s *Server) balanceHistoryHandler(c *gin.Context) {
+	if !s.ensureDoraDB(c) {
+		return
+	}
+
+	index, err := strconv.ParseUint(c.Param("index"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "index must be a validator index"})
+		return
+	}
+
+	chain := s.chain()
+	toEpoch := chain.TimeToEpoch(time.Now())
+	if raw := c.Query("to_epoch"); raw != "" {
+		if toEpoch, err = strconv.ParseUint(raw, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to_epoch must be an epoch number"})
+			return
+		}
+	}
+	var fromEpoch uint64
+	if epochsPerDay := uint64((24 * time.Hour).Seconds()) / chain.SecondsPerEpoch(); toEpoch > epochsPerDay {
+		fromEpoch = toEpoch - epochsPerDay
+	}
+	if raw := c.Query("from_epoch"); raw != "" {
+		if fromEpoch, err = strconv.ParseUint(raw, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from_epoch must be an epoch number"})
+			return
+		}
+	}
+	if fromEpoch > toEpoch {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from_epoch must not be greater than to_epoch"})
+		return
+	}
+	if toEpoch-fromEpoch >= maxBalanceHistoryEpochs {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("range spans more than %d epochs", maxBalanceHistoryEpochs)})
+		return
+	}
+
+	ctx, cancel := s.requestContext(c)
+	defer cancel()
+
+	points, err := s.doraDB.ValidatorBalanceHistory(ctx, index, fromEpoch, toEpoch)
+	if err != nil {
+		if errors.Is(err, dora.ErrBalanceHistoryUnavailable) {
+			c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+			return
+		}
+		slog.Error("Failed to load balance history", "validator", index, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load balance history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, BalanceHistoryResponse{
+		ValidatorIndex: index,
+		FromEpoch:      fromEpoch,
+		ToEpoch:        toEpoch,
+		Balances:       points,
+	})
+}
+
+// beaconNodeURL returns the beacon nodes in use, which POST /admin/nodes may have changed since startup.
+func (s *Server) beaconNodeURL() string {
+	if s.rewardsService == nil {
+		return s.config.BeaconNodeURL
+	}
+	return s.rewardsService.BeaconNodeURL()
+}
+
+// fillBeaconEffectiveBalances adds beacon node effective balances for validators that have not exited
+// but have none in Dora, which can lag behind the chain for newly activated validators. Failures are
+// logged and leave the default-balance fallback in place.
+func (s *Server) fillBeaconEffectiveBalances(ctx context.Context, details []dora.ValidatorDetail, currentEpoch uint64, effectiveBalances map[uint64]int64) {
+	var missing []uint64
+	for _, d := range details {
+		if _, ok := effectiveBalances[d.ValidatorIndex]; !ok && d.ExitEpoch > currentEpoch {
+			missing = append(missing, d.ValidatorIndex)
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+
+	balances, err := beacon.FetchEffectiveBalances(ctx, s.beaconNodeURL(), s.config.RequestTimeout, missing)
+	if err != nil {
+		slog.Warn("Failed to fetch effective balances from beacon node", "validators", len(missing), "error", err)
+		return
+	}
+	for idx, b := range balances {
+		if b > 0 {
+			effectiveBalances[idx] = b
+		}
+	}
+}
+
+// normalizeAddressInput maps a user-supplied address to the key validators are grouped by: the
+// execution address for 0x01/0x02 withdrawal credentials, a bls: key for 0x00 credentials, or the
+// lower-cased address itself. Input is case-insensitive, so EIP-55 checksummed addresses match too.
+func normalizeAddressInput(address string) (string, error) {
+	address = strings.TrimSpace(address)
+	if len(address) == 66 { // "0x" + 64 hex chars for withdrawal_credentials
+		_, key, err := dora.DecodeWithdrawalCredential(address)
+		return key, err
+	}
+	prefix := dora.BLSWithdrawalPrefix
+	if len(address) > len(prefix) && strings.EqualFold(address[:len(prefix)], prefix) {
+		_, key, err := dora.DecodeWithdrawalCredential(address[len(prefix):])
+		if err == nil && !strings.HasPrefix(key, prefix) {
+			err = fmt.Errorf("%w: %s does not hold 0x00 withdrawal credentials", dora.ErrInvalidAddress, address)
+		}
+		return key, err
+	}
+	return dora.NormalizeAddress(address)
+}
+
+// credentialTypeCounts counts validators per credential prefix, always reporting 0x00, 0x01 and 0x02.
+func credentialTypeCounts(details []dora.ValidatorDetail) map[string]int {
+	counts := map[string]int{"0x00": 0, "0x01": 0, "0x02": 0}
+	for _, d := range details {
+		counts[d.CredentialType]++
+	}
+	return counts
+}
+
+// pendingValidators returns validators whose activation epoch is after currentEpoch, ordered as given.
+func pendingValidators(chain utils.ChainConfig, details []dora.ValidatorDetail, currentEpoch uint64) []PendingValidator {
+	pending := make([]PendingValidator, 0)
+	for _, d := range details {
+		if d.ActivationEpoch <= currentEpoch {
+			continue
+		}
+		p := PendingValidator{
+			ValidatorIndex:  d.ValidatorIndex,
+			ActivationEpoch: d.ActivationEpoch,
+		}
+		// Validators still in the deposit queue carry FAR_FUTURE_EPOCH until activation is scheduled.
+		if d.ActivationEpoch != farFutureEpoch {
+			t := chain.EpochStartTime(d.ActivationEpoch)
+			p.EstimatedActivationTime = &t
+		}
+		pending = append(pending, p)
+	}
+	return pending
+}
+
+// leaderboardETag versions leaderboard responses by the latest synced epoch; Dora aggregates only move
+// meaningfully when new epochs land.
+func (s *Server) leaderboardETag(c *gin.Context) string {
+	var epoch uint64
+	if s.rewardsService != nil {
+		epoch = s.rewardsService.LatestSyncEpoch()
+	}
+	return etagFor(c, epoch)
 }
 
 func (s *Server) ensureDoraDB(c *gin.Context) bool {
 	if s.doraDB != nil {
 		return true
 	}
+	setRetryAfter(c, s.config.RetryAfter)
 	c.JSON(http.StatusServiceUnavailable, gin.H{
 		"error": "Dora Postgres is not configured/connected",
 	})
@@ -535,29 +2124,61 @@ func (s *Server) ensureDoraDB(c *gin.Context) bool {
 }
 
 func (s *Server) limitParam(c *gin.Context) int {
+	limit, _ := s.cappedLimitParam(c)
+	return limit
+}
+
+// limitParamOr is limitParam for endpoints with their own default instead of DEFAULT_API_LIMIT.
+func (s *Server) limitParamOr(c *gin.Context, def int) int {
+	limit, _ := s.cappedLimitParamOr(c, def)
+	return limit
+}
+
+// cappedLimitParam parses the limit query parameter like limitParam and reports whether it was
+// lowered to MaxAPILimit.
+func (s *Server) cappedLimitParam(c *gin.Context) (int, bool) {
 	limit := s.config.DefaultAPILimit
 	if limit <= 0 {
 		limit = 100
 	}
+	return s.cappedLimitParamOr(c, limit)
+}
 
+func (s *Server) cappedLimitParamOr(c *gin.Context, limit int) (int, bool) {
 	if raw := c.Query("limit"); raw != "" {
 		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
 			limit = parsed
 		}
 	}
-	return limit
+	if maxLimit := s.config.MaxAPILimit; maxLimit > 0 && limit > maxLimit {
+		return maxLimit, true
+	}
+	return limit, false
 }
 
+// chain returns the network the rewards service computes epochs for, so handlers agree with the sync
+// loop; servers without a rewards service (tests) use the default chain.
+func (s *Server) chain() utils.ChainConfig {
+	if s.rewardsService == nil {
+		return utils.DefaultChain()
+	}
+	return s.rewardsService.Chain()
+}
+
+// requestContext bounds one Dora query by DB_QUERY_TIMEOUT. It derives from the request context, which
+// handlerTimeout bounds by REQUEST_TIMEOUT, so a query never outlives the handler's total budget.
 func (s *Server) requestContext(c *gin.Context) (context.Context, context.CancelFunc) {
-	timeout := s.config.RequestTimeout
+	timeout := s.config.DBQueryTimeout
 	if timeout <= 0 {
 		timeout = 10 * time.Second
 	}
 	return context.WithTimeout(c.Request.Context(), timeout)
 }
 
-func (s *Server) respondWithTop(c *gin.Context, defaultSortBy string, fetch func(context.Context, int, string, string) (any, error)) {
-	limit := s.limitParam(c)
+// respondWithTop fetches a leaderboard page and answers with it; filters are echoed into the response
+// so clients can see which ones were applied.
+func (s *Server) respondWithTop(c *gin.Context, defaultSortBy string, filters gin.H, fetch func(context.Context, int, string, string) (any, error)) {
+	limit, capped := s.cappedLimitParam(c)
 	sortBy := strings.TrimSpace(c.Query("sort_by"))
 	if sortBy == "" {
 		sortBy = defaultSortBy
@@ -575,28 +2196,17 @@ func (s *Server) respondWithTop(c *gin.Context, defaultSortBy string, fetch func
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"limit":   limit,
 		"sort_by": sortBy,
 		"order":   order,
 		"results": results,
-	})
-}
-
-// loggingMiddleware logs HTTP requests
-func loggingMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		start := time.Now()
-		path := c.Request.URL.Path
-		query := c.Request.URL.RawQuery
-
-		c.Next()
-
-		latency := time.Since(start)
-		statusCode := c.Writer.Status()
-
-		slog.Info("HTTP request", "method", c.Request.Method, "path", path, "query", query, "status", statusCode, "latency", latency, "ip", c.ClientIP())
 	}
+	if capped {
+		response["limit_capped"] = true
+	}
+	maps.Copy(response, filters)
+	c.JSON(http.StatusOK, response)
 }
 
 // Page handlers
@@ -681,11 +2291,16 @@ func (s *Server) topWithdrawalsTableHandler(c *gin.Context) {
 	if order == "" {
 		order = "desc"
 	}
+	minValidators, err := parseMinValidators(c.Query("min_validators"))
+	if err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{"error": err.Error()})
+		return
+	}
 
 	ctx, cancel := s.requestContext(c)
 	defer cancel()
 
-	stats, err := s.doraDB.TopWithdrawalAddresses(ctx, limit, sortBy, order)
+	stats, err := s.doraDB.TopWithdrawalAddresses(ctx, limit, sortBy, order, minValidators)
 	if err != nil {
 		c.HTML(http.StatusInternalServerError, "error.html", gin.H{"error": err.Error()})
 		return
@@ -698,19 +2313,20 @@ func (s *Server) topWithdrawalsTableHandler(c *gin.Context) {
 		results[i] = map[string]interface{}{
 			"withdrawal_address":             stat.WithdrawalAddress,
 			"label":                          stat.Label,
-			"total_deposit":                  stat.TotalDeposit,
+			"total_deposit":                  int64(stat.TotalDeposit),
 			"validators_total":               stat.ValidatorsTotal,
 			"active":                         stat.Active,
 			"slashed":                        stat.Slashed,
 			"voluntary_exited":               stat.VoluntaryExited,
-			"total_active_effective_balance": stat.TotalActiveEffectiveBalance,
+			"total_active_effective_balance": int64(stat.TotalActiveEffectiveBalance),
 		}
 	}
 
 	data := gin.H{
-		"results": results,
-		"sort_by": sortBy,
-		"order":   order,
+		"results":        results,
+		"sort_by":        sortBy,
+		"order":          order,
+		"min_validators": minValidators,
 	}
 
 	c.HTML(http.StatusOK, "top-withdrawals-table.html", data)
@@ -3,16 +3,24 @@ package server
 import (
 	"beacon-rewards/internal/config"
 	"beacon-rewards/internal/dora"
+	"beacon-rewards/internal/rewards"
+	"beacon-rewards/internal/utils"
 	"context"
 	"encoding/json"
 	"errors"
 	"html/template"
+	"maps"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gin-gonic/gin"
 )
 
@@ -32,6 +40,9 @@ func TestEnsureDoraDB(t *testing.T) {
 	if w.Code != http.StatusServiceUnavailable {
 		t.Fatalf("status = %d, want %d", w.Code, http.StatusServiceUnavailable)
 	}
+	if got := w.Header().Get("Retry-After"); got != "30" {
+		t.Fatalf("Retry-After = %q, want %q", got, "30")
+	}
 
 	// With a non-nil db pointer, it should just return true without writing.
 	w = httptest.NewRecorder()
@@ -68,13 +79,57 @@ func TestLimitParam(t *testing.T) {
 	if limit := s.limitParam(c); limit != 100 {
 		t.Fatalf("limitParam fallback = %d, want 100", limit)
 	}
+	if limit := s.limitParamOr(c, topProposersDefaultLimit); limit != 50 {
+		t.Fatalf("limitParamOr fallback = %d, want 50", limit)
+	}
+}
+
+func TestRespondWithTopCapsLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.DefaultConfig()
+	cfg.MaxAPILimit = 500
+	s := &Server{config: cfg}
+
+	for _, tt := range []struct {
+		query      string
+		wantLimit  int
+		wantCapped bool
+	}{
+		{"?limit=1000000", 500, true},
+		{"?limit=500", 500, false},
+		{"?limit=20", 20, false},
+	} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/deposits/top-deposits"+tt.query, nil)
+
+		var fetched int
+		s.respondWithTop(c, "total_deposit", nil, func(_ context.Context, limit int, _, _ string) (any, error) {
+			fetched = limit
+			return []string{}, nil
+		})
+		if fetched != tt.wantLimit {
+			t.Fatalf("%s: fetched with limit %d, want %d", tt.query, fetched, tt.wantLimit)
+		}
+		var body struct {
+			Limit       int  `json:"limit"`
+			LimitCapped bool `json:"limit_capped"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: decode response: %v", tt.query, err)
+		}
+		if body.Limit != tt.wantLimit || body.LimitCapped != tt.wantCapped {
+			t.Fatalf("%s: response limit=%d capped=%v, want %d %v", tt.query, body.Limit, body.LimitCapped, tt.wantLimit, tt.wantCapped)
+		}
+	}
 }
 
 func TestRequestContextDefaultTimeout(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	cfg := config.DefaultConfig()
-	cfg.RequestTimeout = -1
+	cfg.DBQueryTimeout = -1
 	s := &Server{config: cfg}
 
 	w := httptest.NewRecorder()
@@ -107,7 +162,7 @@ func TestRespondWithTop(t *testing.T) {
 	c.Request = httptest.NewRequest("GET", "/?limit=3&sort_by=validators_total&order=asc", nil)
 
 	called := false
-	s.respondWithTop(c, "total_deposit", func(ctx context.Context, limit int, sortBy, order string) (any, error) {
+	s.respondWithTop(c, "total_deposit", gin.H{"min_validators": int64(2)}, func(ctx context.Context, limit int, sortBy, order string) (any, error) {
 		called = true
 		if limit != 3 || sortBy != "validators_total" || order != "asc" {
 			t.Fatalf("unexpected args: limit=%d sortBy=%s order=%s", limit, sortBy, order)
@@ -135,12 +190,15 @@ func TestRespondWithTop(t *testing.T) {
 	if resp["sort_by"] != "validators_total" || resp["order"] != "asc" {
 		t.Fatalf("unexpected response: %+v", resp)
 	}
+	if resp["min_validators"] != float64(2) {
+		t.Fatalf("response min_validators = %v, want the applied filter 2", resp["min_validators"])
+	}
 
 	// Error path
 	w = httptest.NewRecorder()
 	c, _ = gin.CreateTestContext(w)
 	c.Request = httptest.NewRequest("GET", "/", nil)
-	s.respondWithTop(c, "total_deposit", func(context.Context, int, string, string) (any, error) {
+	s.respondWithTop(c, "total_deposit", nil, func(context.Context, int, string, string) (any, error) {
 		return nil, errors.New("boom")
 	})
 	if w.Code != http.StatusInternalServerError {
@@ -247,3 +305,757 @@ func TestHTMLRenderer(t *testing.T) {
 		t.Fatalf("expected error message for missing template")
 	}
 }
+
+func TestPendingValidators(t *testing.T) {
+	details := []dora.ValidatorDetail{
+		{ValidatorIndex: 1, ActivationEpoch: 5, ExitEpoch: farFutureEpoch},
+		{ValidatorIndex: 2, ActivationEpoch: 120, ExitEpoch: farFutureEpoch},
+		{ValidatorIndex: 3, ActivationEpoch: farFutureEpoch, ExitEpoch: farFutureEpoch},
+	}
+
+	chain := utils.ChainConfig{GenesisTimestamp: 1_800_000_000, SecondsPerSlot: 6, SlotsPerEpoch: 8}
+	pending := pendingValidators(chain, details, 100)
+	if len(pending) != 2 {
+		t.Fatalf("pending count = %d, want 2", len(pending))
+	}
+
+	if pending[0].ValidatorIndex != 2 || pending[0].ActivationEpoch != 120 {
+		t.Fatalf("unexpected first pending validator: %+v", pending[0])
+	}
+	wantTime := time.Unix(1_800_000_000+120*48, 0).UTC()
+	if pending[0].EstimatedActivationTime == nil || !pending[0].EstimatedActivationTime.Equal(wantTime) {
+		t.Fatalf("estimated activation = %v, want %v", pending[0].EstimatedActivationTime, wantTime)
+	}
+
+	if pending[1].ValidatorIndex != 3 || pending[1].EstimatedActivationTime != nil {
+		t.Fatalf("queued validator should have no activation estimate: %+v", pending[1])
+	}
+}
+
+func TestServerChainFollowsRewardsService(t *testing.T) {
+	if got := (&Server{}).chain(); got != utils.DefaultChain() {
+		t.Fatalf("chain without a rewards service = %+v, want the default", got)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	svc := rewards.NewService(cfg)
+	devnet := utils.ChainConfig{GenesisTimestamp: 1_800_000_000, SecondsPerSlot: 6, SlotsPerEpoch: 8}
+	svc.SetChain(devnet)
+	if got := (&Server{config: cfg, rewardsService: svc}).chain(); got != devnet {
+		t.Fatalf("chain = %+v, want the service's %+v", got, devnet)
+	}
+}
+
+func TestLabelRewardsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	s := &Server{config: cfg, rewardsService: rewards.NewService(cfg), doraDB: &dora.DB{}}
+	s.depositorLabels = map[string]string{
+		"0x0988dc1554cf6877508208fff8aab4e5afa11ee3": "Pool A",
+		"0x00000000219ab540356cbb839cbe05303d7705fa": "Pool A",
+		"0x1111111111111111111111111111111111111111": "Exchange B",
+	}
+
+	get := func(label string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/rewards/by-label/x", nil)
+		c.Params = gin.Params{{Key: "label", Value: label}}
+		s.labelRewardsHandler(c)
+		return w
+	}
+
+	w := get("pool a")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	var result AddressRewardsResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	want := []string{"0x00000000219ab540356cbb839cbe05303d7705fa", "0x0988dc1554cf6877508208fff8aab4e5afa11ee3"}
+	if !slices.Equal(result.Addresses, want) || result.DepositorLabel != "Pool A" {
+		t.Fatalf("unexpected label result: %+v", result)
+	}
+
+	if w := get("Unknown"); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown label status = %d, want 404", w.Code)
+	}
+}
+
+func TestCredentialTypeCounts(t *testing.T) {
+	details := []dora.ValidatorDetail{
+		{ValidatorIndex: 1, CredentialType: "0x01"},
+		{ValidatorIndex: 2, CredentialType: "0x02"},
+		{ValidatorIndex: 3, CredentialType: "0x01"},
+		{ValidatorIndex: 4, CredentialType: "0x02"},
+		{ValidatorIndex: 5, CredentialType: "0x02"},
+	}
+
+	counts := credentialTypeCounts(details)
+	want := map[string]int{"0x00": 0, "0x01": 2, "0x02": 3}
+	if !maps.Equal(counts, want) {
+		t.Fatalf("credentialTypeCounts = %v, want %v", counts, want)
+	}
+}
+
+func TestNormalizeAddressInput(t *testing.T) {
+	bls := "0x00f1a2b3c4d5e6f708091a2b0988dc1554cf6877508208fff8aab4e5afa11ee3"
+	cases := []struct {
+		in, want string
+	}{
+		{"0x0100000000000000000000000988dc1554cf6877508208fff8aab4e5afa11ee3", "0x0988dc1554cf6877508208fff8aab4e5afa11ee3"},
+		{"0x0100000000000000000000000988DC1554CF6877508208FFF8AAB4E5AFA11EE3", "0x0988dc1554cf6877508208fff8aab4e5afa11ee3"},
+		{bls, "bls:" + bls},
+		{"BLS:" + bls, "bls:" + bls},
+		{"0x0988dc1554cf6877508208fff8aab4e5afa11ee3", "0x0988dc1554cf6877508208fff8aab4e5afa11ee3"},
+		{" 0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed ", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"},
+	}
+	for _, tc := range cases {
+		if got, err := normalizeAddressInput(tc.in); err != nil || got != tc.want {
+			t.Fatalf("normalizeAddressInput(%q) = (%q, %v), want %q", tc.in, got, err, tc.want)
+		}
+	}
+
+	for _, in := range []string{"0x123", "0xzz88dc1554cf6877508208fff8aab4e5afa11ee3", "bls:0x0100000000000000000000000988dc1554cf6877508208fff8aab4e5afa11ee3"} {
+		if _, err := normalizeAddressInput(in); !errors.Is(err, dora.ErrInvalidAddress) {
+			t.Fatalf("normalizeAddressInput(%q) error = %v, want ErrInvalidAddress", in, err)
+		}
+	}
+}
+
+func TestAddressRewardsHandlerAcceptsChecksummedAddress(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	s := &Server{
+		config:          cfg,
+		rewardsService:  rewards.NewService(cfg),
+		doraDB:          &dora.DB{},
+		depositorLabels: map[string]string{"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed": "Checksum Pool"},
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/rewards/by-address", strings.NewReader(`{"address":"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	s.addressRewardsHandler(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	var result AddressRewardsResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if result.Address != "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed" {
+		t.Fatalf("address = %q, want the lower-cased address", result.Address)
+	}
+	if result.DepositorLabel != "Checksum Pool" {
+		t.Fatalf("depositor label = %q, want it matched case-insensitively", result.DepositorLabel)
+	}
+}
+
+func TestRespondRewardsFlagsStaleData(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	s := &Server{config: cfg, rewardsService: rewards.NewService(cfg)}
+
+	respond := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/rewards/range?from=1&to=2", nil)
+		s.respondRewards(c, []uint64{1, 2}, nil)
+		return w
+	}
+
+	// Nothing has been synced, so the data is as old as genesis.
+	w := respond()
+	var resp RewardsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if w.Code != http.StatusOK || !resp.Stale || resp.SyncLagSeconds <= int64(cfg.MaxStaleness.Seconds()) {
+		t.Fatalf("status = %d, stale = %v, lag = %d; want 200 flagged stale", w.Code, resp.Stale, resp.SyncLagSeconds)
+	}
+
+	cfg.StrictStaleness = true
+	if w := respond(); w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("strict mode: status = %d, Retry-After = %q; want 503 with Retry-After", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	cfg.MaxStaleness = 0
+	if w := respond(); w.Code != http.StatusOK {
+		t.Fatalf("disabled check: status = %d, want 200", w.Code)
+	}
+}
+
+func TestFillBeaconEffectiveBalances(t *testing.T) {
+	var requested []string
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			IDs []string `json:"ids"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		requested = body.IDs
+		_, _ = w.Write([]byte(`{"data":[{"index":"2","validator":{"effective_balance":"33000000000"}}]}`))
+	}))
+	t.Cleanup(node.Close)
+
+	cfg := config.DefaultConfig()
+	cfg.BeaconNodeURL = node.URL
+	s := &Server{config: cfg}
+
+	details := []dora.ValidatorDetail{
+		{ValidatorIndex: 1, EffectiveBalance: 32_000_000_000, ExitEpoch: farFutureEpoch},
+		{ValidatorIndex: 2, ExitEpoch: farFutureEpoch}, // just activated, not yet in Dora
+		{ValidatorIndex: 3, ExitEpoch: 5},              // exited
+	}
+	balances := map[uint64]int64{1: 32_000_000_000}
+	s.fillBeaconEffectiveBalances(context.Background(), details, 10, balances)
+
+	if len(requested) != 1 || requested[0] != "2" {
+		t.Fatalf("requested ids = %v, want only the validator missing from Dora", requested)
+	}
+	if balances[1] != 32_000_000_000 || balances[2] != 33_000_000_000 {
+		t.Fatalf("merged balances = %v", balances)
+	}
+	if _, ok := balances[3]; ok {
+		t.Fatalf("exited validator should keep no balance")
+	}
+}
+
+func TestRewardsRangeHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.MaxRewardsRange = 10
+	s := &Server{config: cfg, rewardsService: rewards.NewService(cfg)}
+
+	tests := []struct {
+		query      string
+		wantStatus int
+		wantCount  int
+	}{
+		{query: "?from=100&to=109", wantStatus: http.StatusOK, wantCount: 10},
+		{query: "?from=7&to=7", wantStatus: http.StatusOK, wantCount: 1},
+		{query: "?from=100&to=110", wantStatus: http.StatusBadRequest},
+		{query: "?from=9&to=8", wantStatus: http.StatusBadRequest},
+		{query: "?from=-1&to=8", wantStatus: http.StatusBadRequest},
+		{query: "?from=1", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/rewards/range"+tt.query, nil)
+		s.rewardsRangeHandler(c)
+
+		if w.Code != tt.wantStatus {
+			t.Fatalf("%s: status = %d, want %d", tt.query, w.Code, tt.wantStatus)
+		}
+		if tt.wantStatus != http.StatusOK {
+			continue
+		}
+		var resp RewardsResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: decode response: %v", tt.query, err)
+		}
+		if resp.ValidatorCount != tt.wantCount {
+			t.Fatalf("%s: validator_count = %d, want %d", tt.query, resp.ValidatorCount, tt.wantCount)
+		}
+	}
+}
+
+func TestELRewardsHandlerBoundsRange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.MaxELBlockRange = 10
+	s := &Server{config: cfg, rewardsService: rewards.NewService(cfg)}
+
+	for query, want := range map[string]int{
+		"?from_block=100&to_block=109": http.StatusOK,
+		"?from_block=100&to_block=110": http.StatusBadRequest,
+		"?from_block=9&to_block=8":     http.StatusBadRequest,
+		"?from_block=1":                http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/rewards/el"+query, nil)
+		s.elRewardsHandler(c)
+
+		if w.Code != want {
+			t.Fatalf("%s: status = %d, want %d", query, w.Code, want)
+		}
+		if want != http.StatusOK {
+			continue
+		}
+		var resp ELRewardsResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: decode response: %v", query, err)
+		}
+		if resp.FromBlock != 100 || resp.ToBlock != 109 || resp.BlockCount != 0 || resp.Blocks == nil {
+			t.Fatalf("%s: unexpected response %+v", query, resp)
+		}
+	}
+}
+
+func TestRewardsHandlerAsOfEpoch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	s := &Server{config: cfg, rewardsService: rewards.NewService(cfg)}
+
+	tests := []struct {
+		body       string
+		wantStatus int
+	}{
+		{body: `{"validators":[1,2]}`, wantStatus: http.StatusOK},
+		{body: `{"validators":[1,2],"as_of_epoch":0}`, wantStatus: http.StatusOK},
+		{body: `{"validators":[1,2],"as_of_epoch":5}`, wantStatus: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/rewards", strings.NewReader(tt.body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		s.rewardsHandler(c)
+
+		if w.Code != tt.wantStatus {
+			t.Fatalf("%s: status = %d, want %d", tt.body, w.Code, tt.wantStatus)
+		}
+		var resp struct {
+			AsOfEpoch *uint64 `json:"as_of_epoch"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: decode response: %v", tt.body, err)
+		}
+		// Both the data and the conflict responses tell the client which epoch the cache is at.
+		if resp.AsOfEpoch == nil || *resp.AsOfEpoch != 0 {
+			t.Fatalf("%s: as_of_epoch = %v, want 0", tt.body, resp.AsOfEpoch)
+		}
+	}
+}
+
+func TestLoadFeeRecipientOwners(t *testing.T) {
+	file := t.TempDir() + "/fee-recipients.yaml"
+	content := `
+"0xAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA": "0xBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBB"
+"0xcccccccccccccccccccccccccccccccccccccccc": "0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+"0xdddddddddddddddddddddddddddddddddddddddd": "0xeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee"
+`
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write fee recipients file: %v", err)
+	}
+
+	owners, err := loadFeeRecipientOwners(file)
+	if err != nil {
+		t.Fatalf("loadFeeRecipientOwners returned error: %v", err)
+	}
+	s := &Server{feeRecipientOwners: owners}
+	got := s.feeRecipientsOf([]string{"0xBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBB"})
+	want := []string{"0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", "0xcccccccccccccccccccccccccccccccccccccccc"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("feeRecipientsOf = %v, want %v", got, want)
+	}
+
+	if err := os.WriteFile(file, []byte(`"0xaaaa": "0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"`), 0o644); err != nil {
+		t.Fatalf("failed to write fee recipients file: %v", err)
+	}
+	if _, err := loadFeeRecipientOwners(file); err == nil {
+		t.Fatalf("expected an error for a malformed fee recipient")
+	}
+}
+
+func TestBalanceChangeSincePreviousWindow(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(dir, "history.jsonl")
+	cfg.ValidatorHistoryFile = filepath.Join(dir, "validator_history.jsonl")
+	cfg.ValidatorHistoryDays = 30
+	s := &Server{config: cfg, rewardsService: rewards.NewService(cfg)}
+
+	if got := s.balanceChangeSincePreviousWindow([]uint64{1}, map[uint64]int64{1: 32_000_000_000}); got != nil {
+		t.Fatalf("without history = %d, want nil", *got)
+	}
+
+	line := `{"window_start":"2024-01-01T00:00:00Z","window_end":"2024-01-02T00:00:00Z","validators":{` +
+		`"1":{"cl":10,"el":0,"effective_balance":32000000000},` +
+		`"2":{"cl":10,"el":0,"effective_balance":32000000000},` +
+		`"3":{"cl":10,"el":0}}}` + "\n"
+	if err := os.WriteFile(cfg.ValidatorHistoryFile, []byte(line), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s.rewardsService = rewards.NewService(cfg)
+
+	// Validator 1 lost 1 ETH, 2 is unchanged, 3 had no recorded balance and 4 is new.
+	current := map[uint64]int64{1: 31_000_000_000, 2: 32_000_000_000, 3: 30_000_000_000, 4: 32_000_000_000}
+	got := s.balanceChangeSincePreviousWindow([]uint64{1, 2, 3, 4}, current)
+	if got == nil || *got != -1_000_000_000 {
+		t.Fatalf("balance change = %v, want -1000000000", got)
+	}
+}
+
+func TestAttestationDetailHandlerStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	s := NewServer(cfg, rewards.NewService(cfg), nil)
+
+	for path, want := range map[string]int{
+		"/validators/abc/attestation-detail": http.StatusBadRequest,
+		"/validators/7/attestation-detail":   http.StatusNotFound, // Nothing synced yet.
+	} {
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != want {
+			t.Fatalf("%s: status = %d, want %d", path, w.Code, want)
+		}
+	}
+}
+
+func TestAggregateAddressRewardsIncludeZeroRewards(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	s := &Server{config: cfg, rewardsService: rewards.NewService(cfg), doraDB: &dora.DB{}}
+
+	// Validator 7 is active but has no rewards in the (empty) cache.
+	details := []dora.ValidatorDetail{
+		{ValidatorIndex: 7, EffectiveBalance: 32_000_000_000, ActivationEpoch: 0, ExitEpoch: math.MaxUint64, CredentialType: "0x01"},
+	}
+
+	result := s.aggregateAddressRewards(context.Background(), details, false, false)
+	if result.ActiveValidatorCount != 1 || result.TotalEffectiveBalanceGwei != 0 || result.ZeroRewardValidatorCount != 0 {
+		t.Fatalf("default: active=%d balance=%d zero=%d, want the uncached validator left out of the totals",
+			result.ActiveValidatorCount, result.TotalEffectiveBalanceGwei, result.ZeroRewardValidatorCount)
+	}
+
+	result = s.aggregateAddressRewards(context.Background(), details, false, true)
+	if result.ActiveValidatorCount != 1 || result.ZeroRewardValidatorCount != 1 {
+		t.Fatalf("include_zero_rewards: active=%d zero=%d, want 1 and 1", result.ActiveValidatorCount, result.ZeroRewardValidatorCount)
+	}
+	if result.TotalEffectiveBalanceGwei != 32_000_000_000 || result.TotalRewardsGwei != 0 {
+		t.Fatalf("include_zero_rewards: balance=%d rewards=%d, want 32 ETH and 0", result.TotalEffectiveBalanceGwei, result.TotalRewardsGwei)
+	}
+}
+
+func TestTopDepositsRouteServesJSON(t *testing.T) {
+	for _, frontend := range []bool{false, true} {
+		cfg := config.DefaultConfig()
+		cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+		cfg.EnableFrontend = frontend
+		s := NewServer(cfg, rewards.NewService(cfg), nil)
+
+		for query, want := range map[string]int{
+			"?min_deposit=lots":   http.StatusBadRequest,
+			"?min_validators=-1":  http.StatusBadRequest,
+			"?min_validators=two": http.StatusBadRequest,
+			"":                    http.StatusServiceUnavailable, // No Dora database in tests.
+		} {
+			for _, path := range []string{"/deposits/top-deposits", "/v1/deposits/top-deposits"} {
+				// There is no top-deposits page, so browsers and HTMX get the same JSON as API clients.
+				for _, headers := range []map[string]string{
+					{"Accept": "application/json"},
+					{"Accept": "text/html,application/xhtml+xml"},
+					{"Accept": "text/html", "HX-Request": "true"},
+					{},
+				} {
+					w := httptest.NewRecorder()
+					req := httptest.NewRequest(http.MethodGet, path+query, nil)
+					for k, v := range headers {
+						req.Header.Set(k, v)
+					}
+					s.router.ServeHTTP(w, req)
+
+					if w.Code != want {
+						t.Fatalf("frontend=%v %s%s %v: status = %d, want %d", frontend, path, query, headers, w.Code, want)
+					}
+					if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+						t.Fatalf("frontend=%v %s%s %v: Content-Type = %q, want JSON", frontend, path, query, headers, ct)
+					}
+					var body map[string]string
+					if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body["error"] == "" {
+						t.Fatalf("frontend=%v %s%s %v: body = %s, want a JSON error", frontend, path, query, headers, w.Body.String())
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestTopDepositsRouteServesResultsToBrowsers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+	mock.ExpectQuery("FROM depositor_data").WithArgs(100).WillReturnRows(sqlmock.NewRows([]string{
+		"depositor_address", "withdrawal_address", "total_deposit", "initial_deposit", "top_up_deposit",
+		"total_active_effective_balance", "validators_total", "slashed", "voluntary_exited", "active",
+	}).AddRow("0xaa", "0xbb", int64(64_000_000_000), int64(64_000_000_000), int64(0), int64(64_000_000_000), 2, 0, 0, 2))
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.EnableFrontend = true
+	s := NewServer(cfg, rewards.NewService(cfg), dora.NewFromConn(db))
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/deposits/top-deposits", nil)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	s.router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		t.Fatalf("status = %d, Content-Type = %q; want 200 JSON", w.Code, w.Header().Get("Content-Type"))
+	}
+	var body TopDepositsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body %s: %v", w.Body.String(), err)
+	}
+	if len(body.Results) != 1 || body.Results[0].DepositorAddress != "0xaa" || body.Results[0].TotalActiveEffectiveBalance != 64_000_000_000 {
+		t.Fatalf("results = %+v, want depositor 0xaa with 64 ETH active", body.Results)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sqlmock expectations: %v", err)
+	}
+}
+
+func TestTopWithdrawalsTableAppliesMinValidators(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+	mock.ExpectQuery(`HAVING COUNT\(DISTINCT v\.validator_index\) >= \$2`).WithArgs(100, int64(5)).WillReturnRows(sqlmock.NewRows([]string{
+		"withdrawal_address", "total_deposit", "total_active_effective_balance",
+		"validators_total", "slashed", "voluntary_exited", "active",
+	}).AddRow("0xbb", int64(160_000_000_000), int64(160_000_000_000), int64(5), int64(0), int64(0), int64(5)))
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.EnableFrontend = true
+	s := NewServer(cfg, rewards.NewService(cfg), dora.NewFromConn(db))
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/deposits/top-withdrawals?min_validators=5", nil)
+	req.Header.Set("HX-Request", "true")
+	s.router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "0xbb") {
+		t.Fatalf("status = %d, body = %s; want 200 listing 0xbb", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sqlmock expectations: %v", err)
+	}
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/deposits/top-withdrawals?min_validators=-1", nil)
+	req.Header.Set("HX-Request", "true")
+	s.router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("negative min_validators status = %d, want 400", w.Code)
+	}
+}
+
+func TestDepositLinksHandler(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	get := func(s *Server, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	noDora := NewServer(cfg, rewards.NewService(cfg), nil)
+	for target, want := range map[string]int{
+		"/deposits/links?sort_by=total_deposit": http.StatusBadRequest,
+		"/deposits/links?order=sideways":        http.StatusBadRequest,
+		"/deposits/links":                       http.StatusServiceUnavailable,
+	} {
+		if w := get(noDora, target); w.Code != want {
+			t.Fatalf("GET %s: status = %d, want %d: %s", target, w.Code, want, w.Body.String())
+		}
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+	mock.ExpectQuery(`ORDER BY withdrawal_address ASC`).WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"depositor_address", "withdrawal_address", "validator_count"}).
+			AddRow("0xaaaa", "0x1111", int64(12)).
+			AddRow("0xbbbb", "0x2222", int64(3)))
+
+	w := get(NewServer(cfg, rewards.NewService(cfg), dora.NewFromConn(db)), "/deposits/links?limit=2&sort_by=withdrawal_address&order=ASC")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	var resp DepositLinksResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	want := []dora.DepositorWithdrawalLink{
+		{DepositorAddress: "0xaaaa", WithdrawalAddress: "0x1111", ValidatorCount: 12},
+		{DepositorAddress: "0xbbbb", WithdrawalAddress: "0x2222", ValidatorCount: 3},
+	}
+	if resp.Limit != 2 || resp.SortBy != "withdrawal_address" || resp.Order != "asc" || !slices.Equal(resp.Results, want) {
+		t.Fatalf("response = %+v, want two links sorted by withdrawal_address asc", resp)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sqlmock expectations: %v", err)
+	}
+}
+
+func TestFineNetworkRewardsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.FineHistoryFile = filepath.Join(t.TempDir(), "fine.jsonl")
+	get := func(s *Server, query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/rewards/network/fine"+query, nil)
+		s.fineNetworkRewardsHandler(c)
+		return w
+	}
+
+	disabled := &Server{config: cfg, rewardsService: rewards.NewService(cfg)}
+	if w := get(disabled, ""); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("disabled: status = %d, want 503", w.Code)
+	}
+
+	enabled := *cfg
+	enabled.FineHistoryInterval = 1
+	s := &Server{config: &enabled, rewardsService: rewards.NewService(&enabled)}
+	for query, want := range map[string]int{
+		"":                http.StatusOK,
+		"?from=10&to=20":  http.StatusOK,
+		"?from=20&to=10":  http.StatusBadRequest,
+		"?from=yesterday": http.StatusBadRequest,
+	} {
+		w := get(s, query)
+		if w.Code != want {
+			t.Fatalf("%q: status = %d, want %d", query, w.Code, want)
+		}
+		if want == http.StatusOK && strings.TrimSpace(w.Body.String()) != "[]" {
+			t.Fatalf("%q: body = %s, want an empty array", query, w.Body.String())
+		}
+	}
+}
+
+func TestNetworkRewardsSummaryHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	history := `{"window_start":"2025-01-01T00:00:00Z","project_apr_percent":3}` + "\n" +
+		`{"window_start":"2025-01-02T00:00:00Z","project_apr_percent":5}` + "\n"
+	if err := os.WriteFile(cfg.RewardsHistoryFile, []byte(history), 0o644); err != nil {
+		t.Fatalf("write history: %v", err)
+	}
+	svc := rewards.NewService(cfg)
+	s := &Server{config: cfg, rewardsService: svc}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/rewards/network/summary", nil)
+	s.networkRewardsSummaryHandler(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if strings.Contains(w.Body.String(), `"history"`) {
+		t.Fatalf("summary must not carry the history: %s", w.Body.String())
+	}
+	var got NetworkRewardsSummary
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	entries, _ := svc.NetworkRewardHistory()
+	if want := calculate31DayAverageAPR(entries, svc.TotalNetworkRewards()); got.Apr31dAvg != want || want <= 0 {
+		t.Fatalf("apr_31d_avg = %v, want %v from the stored history", got.Apr31dAvg, want)
+	}
+	// While warming up the current figures are the last stored day, which is averaged only once.
+	if !got.FromHistory || got.ProjectAprPercent != 5 || got.Apr31dAvg != 4 {
+		t.Fatalf("summary = %+v, want from_history with apr 5 and a 31d average of 4", got)
+	}
+}
+
+func TestAggregateAddressRewardsDedupsValidators(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	s := &Server{config: cfg, rewardsService: rewards.NewService(cfg), doraDB: &dora.DB{}}
+
+	// The address is both the depositor and the withdrawal address of validator 7, so Dora reports it twice.
+	details := []dora.ValidatorDetail{
+		{ValidatorIndex: 7, EffectiveBalance: 32_000_000_000, ExitEpoch: math.MaxUint64, CredentialType: "0x01"},
+		{ValidatorIndex: 3, EffectiveBalance: 32_000_000_000, ExitEpoch: math.MaxUint64, CredentialType: "0x01"},
+		{ValidatorIndex: 7, EffectiveBalance: 32_000_000_000, ExitEpoch: math.MaxUint64, CredentialType: "0x01"},
+	}
+
+	result := s.aggregateAddressRewards(context.Background(), details, true, true)
+	if result.ActiveValidatorCount != 2 {
+		t.Fatalf("active validator count = %d, want 2", result.ActiveValidatorCount)
+	}
+	if result.ValidatorIndices == nil || !slices.Equal(*result.ValidatorIndices, []uint64{3, 7}) {
+		t.Fatalf("validator indices = %v, want [3 7]", result.ValidatorIndices)
+	}
+	if result.TotalEffectiveBalanceGwei != 64_000_000_000 {
+		t.Fatalf("total effective balance = %d, want 64 ETH", result.TotalEffectiveBalanceGwei)
+	}
+}
+
+// Strict JSON clients expect empty lists as [], never null.
+func TestEmptyListsSerializeAsArrays(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.EnableFrontend = false
+	svc := rewards.NewService(cfg)
+	s := NewServer(cfg, svc, &dora.DB{})
+
+	get := func(target string) string {
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: status = %d, want 200", target, w.Code)
+		}
+		return w.Body.String()
+	}
+	for target, want := range map[string]string{
+		"/rewards/network":                    `"history":[]`,
+		"/proposers/top":                      `[]`,
+		"/sync/failed-epochs":                 `[]`,
+		"/rewards/el?from_block=1&to_block=2": `"blocks":[]`,
+		"/validators/pending/by-address?address=0x00000000219ab540356cbb839cbe05303d7705fa": `"validators":[]`,
+	} {
+		if body := get(target); !strings.Contains(body, want) {
+			t.Fatalf("GET %s = %s, want it to contain %s", target, body, want)
+		}
+	}
+
+	result := s.aggregateAddressRewards(context.Background(), nil, true, false)
+	b, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !strings.Contains(string(b), `"validator_indices":[]`) {
+		t.Fatalf("address result = %s, want validator_indices as []", b)
+	}
+	b, _ = json.Marshal(s.aggregateAddressRewards(context.Background(), nil, false, false))
+	if strings.Contains(string(b), "validator_indices") {
+		t.Fatalf("address result = %s, want validator_indices omitted unless requested", b)
+	}
+
+	if h, err := svc.ValidatorRewardHistory(nil, 30); err != nil || h == nil {
+		t.Fatalf("ValidatorRewardHistory = %v, %v; want an empty, non-nil history", h, err)
+	}
+}
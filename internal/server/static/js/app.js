@@ -317,6 +317,7 @@ async function renderTopWithdrawals({ url, ticket, cleaner }) {
         limit: Math.max(1, Number(params.get('limit')) || 30),
         sortBy: params.get('sort_by') || 'total_active_effective_balance',
         order: 'desc',
+        minValidators: Math.max(0, Math.floor(Number(params.get('min_validators'))) || 0),
     };
 
     const sortLabels = {
@@ -342,6 +343,11 @@ async function renderTopWithdrawals({ url, ticket, cleaner }) {
         nextUrl.searchParams.set('limit', state.limit);
         nextUrl.searchParams.set('sort_by', state.sortBy);
         nextUrl.searchParams.set('order', state.order);
+        if (state.minValidators > 0) {
+            nextUrl.searchParams.set('min_validators', state.minValidators);
+        } else {
+            nextUrl.searchParams.delete('min_validators');
+        }
         history.replaceState({}, '', nextUrl);
         url = nextUrl;
     };
@@ -426,7 +432,7 @@ async function renderTopWithdrawals({ url, ticket, cleaner }) {
         tableContainer.innerHTML = '<div class="loading">Loading...</div>';
         let response;
         try {
-            response = await fetch(`/deposits/top-withdrawals?limit=${state.limit}&sort_by=${state.sortBy}&order=${state.order}`, {
+            response = await fetch(`/deposits/top-withdrawals?limit=${state.limit}&sort_by=${state.sortBy}&order=${state.order}&min_validators=${state.minValidators}`, {
                 headers: { Accept: 'application/json' },
             });
         } catch (err) {
@@ -587,8 +593,8 @@ function renderNetworkStats(current, history) {
         <section class="metrics-grid">
             <article class="metric-card accent">
                 <div class="metric-label">Projected APR</div>
-                <div class="metric-value">${Number(current.project_apr_percent || 0).toFixed(3)}%</div>
-                <div class="metric-subtext">Based on the latest reward window</div>
+                <div class="metric-value">${current.apr_available === false ? 'N/A' : `${Number(current.project_apr_percent || 0).toFixed(3)}%`}</div>
+                <div class="metric-subtext">${current.apr_available === false ? 'Reward window too short for an APR estimate' : 'Based on the latest reward window'}</div>
             </article>
             <article class="metric-card">
                 <div class="metric-label">Total rewards (ACE)</div>
@@ -1,17 +1,63 @@
 package server
 
 import (
+	"embed"
+	"errors"
 	"html/template"
+	"io/fs"
 	"log/slog"
 	"math"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 )
 
-// loadTemplates loads HTML templates
-func loadTemplates() (map[string]*template.Template, error) {
+// Templates and static assets are compiled into the binary so the frontend works from any working
+// directory. TEMPLATES_DIR swaps the templates for a directory on disk, and its static/ subdirectory
+// overrides the assets, e.g. during development or for a custom theme.
+var (
+	//go:embed templates/*.html
+	embeddedTemplates embed.FS
+	//go:embed static
+	embeddedStatic embed.FS
+)
+
+// templateFS returns dir when set, otherwise the embedded templates.
+func templateFS(dir string) fs.FS {
+	if dir != "" {
+		return os.DirFS(dir)
+	}
+	sub, _ := fs.Sub(embeddedTemplates, "templates")
+	return sub
+}
+
+// staticFS returns the embedded static assets (css/, js/). With dir set, files under dir/static take
+// precedence; assets the directory does not have are still served from the binary.
+func staticFS(dir string) fs.FS {
+	sub, _ := fs.Sub(embeddedStatic, "static")
+	if dir == "" {
+		return sub
+	}
+	return overlayFS{top: os.DirFS(filepath.Join(dir, "static")), base: sub}
+}
+
+// overlayFS opens names from top, falling back to base for those top does not have.
+type overlayFS struct {
+	top, base fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	f, err := o.top.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return o.base.Open(name)
+	}
+	return f, err
+}
+
+// loadTemplates parses the *.html templates at the top level of fsys.
+func loadTemplates(fsys fs.FS) (map[string]*template.Template, error) {
 	funcMap := template.FuncMap{
 		"formatGweiToAce": func(gwei int64) string {
 			ace := float64(gwei) / 1e9
@@ -43,63 +89,20 @@ func loadTemplates() (map[string]*template.Template, error) {
 		"formatFloat": formatFloat,
 	}
 
-	// Try multiple possible paths
-	possiblePaths := []string{
-		"internal/server/templates/*.html",
-		"./internal/server/templates/*.html",
-		"templates/*.html",
-		"./templates/*.html",
-	}
-
-	var allFiles []string
-	for _, pattern := range possiblePaths {
-		matches, err := filepath.Glob(pattern)
-		if err != nil {
-			continue
-		}
-		if len(matches) > 0 {
-			allFiles = matches
-			slog.Info("Found templates", "pattern", pattern, "count", len(matches))
-			break
-		}
-	}
-
-	// If still no files, try to find templates directory
-	if len(allFiles) == 0 {
-		// Try to find templates relative to current working directory
-		wd, err := os.Getwd()
-		if err == nil {
-			templateDir := filepath.Join(wd, "internal", "server", "templates")
-			if info, err := os.Stat(templateDir); err == nil && info.IsDir() {
-				pattern := filepath.Join(templateDir, "*.html")
-				matches, err := filepath.Glob(pattern)
-				if err == nil && len(matches) > 0 {
-					allFiles = matches
-					slog.Info("Found templates in working directory", "dir", templateDir, "count", len(matches))
-				}
-			}
-		}
+	files, err := fs.Glob(fsys, "*.html")
+	if err != nil {
+		return nil, err
 	}
-
-	if len(allFiles) == 0 {
+	if len(files) == 0 {
 		slog.Warn("No template files found")
 		return nil, nil
 	}
 
-	var baseFile string
-	for _, path := range allFiles {
-		if filepath.Base(path) == "base.html" {
-			baseFile = path
-			break
-		}
-	}
-
 	var baseTemplate *template.Template
-	var err error
-	if baseFile != "" {
-		baseTemplate, err = template.New("base.html").Funcs(funcMap).ParseFiles(baseFile)
+	if slices.Contains(files, "base.html") {
+		baseTemplate, err = template.New("base.html").Funcs(funcMap).ParseFS(fsys, "base.html")
 		if err != nil {
-			slog.Error("Failed to parse base template", "error", err, "file", baseFile)
+			slog.Error("Failed to parse base template", "error", err)
 			return nil, err
 		}
 	} else {
@@ -107,15 +110,14 @@ func loadTemplates() (map[string]*template.Template, error) {
 	}
 
 	templates := make(map[string]*template.Template)
-	for _, path := range allFiles {
-		name := filepath.Base(path)
+	for _, name := range files {
 		if name == "base.html" {
 			continue
 		}
 
-		useBase, err := templateUsesBase(path)
+		useBase, err := templateUsesBase(fsys, name)
 		if err != nil {
-			slog.Error("Failed to inspect template for base usage", "file", path, "error", err)
+			slog.Error("Failed to inspect template for base usage", "file", name, "error", err)
 			return nil, err
 		}
 
@@ -123,18 +125,18 @@ func loadTemplates() (map[string]*template.Template, error) {
 		case useBase && baseTemplate != nil:
 			clone, err := baseTemplate.Clone()
 			if err != nil {
-				slog.Error("Failed to clone base template", "error", err, "file", path)
+				slog.Error("Failed to clone base template", "error", err, "file", name)
 				return nil, err
 			}
-			if _, err := clone.ParseFiles(path); err != nil {
-				slog.Error("Failed to parse template with base", "file", path, "error", err)
+			if _, err := clone.ParseFS(fsys, name); err != nil {
+				slog.Error("Failed to parse template with base", "file", name, "error", err)
 				return nil, err
 			}
 			templates[name] = clone
 		default:
-			tmpl, err := template.New(name).Funcs(funcMap).ParseFiles(path)
+			tmpl, err := template.New(name).Funcs(funcMap).ParseFS(fsys, name)
 			if err != nil {
-				slog.Error("Failed to parse partial template", "file", path, "error", err)
+				slog.Error("Failed to parse partial template", "file", name, "error", err)
 				return nil, err
 			}
 			templates[name] = tmpl
@@ -150,8 +152,8 @@ func loadTemplates() (map[string]*template.Template, error) {
 	return templates, nil
 }
 
-func templateUsesBase(path string) (bool, error) {
-	content, err := os.ReadFile(path)
+func templateUsesBase(fsys fs.FS, name string) (bool, error) {
+	content, err := fs.ReadFile(fsys, name)
 	if err != nil {
 		return false, err
 	}
@@ -3,12 +3,19 @@ package server
 import (
 	"bytes"
 	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"beacon-rewards/internal/config"
+	"beacon-rewards/internal/rewards"
 )
 
 func TestLoadTemplatesSeparatesPages(t *testing.T) {
-	templates, err := loadTemplates()
+	templates, err := loadTemplates(templateFS(""))
 	if err != nil {
 		t.Fatalf("loadTemplates returned error: %v", err)
 	}
@@ -38,7 +45,7 @@ func TestLoadTemplatesSeparatesPages(t *testing.T) {
 }
 
 func TestTopWithdrawalsTableRendersWithdrawalAddress(t *testing.T) {
-	templates, err := loadTemplates()
+	templates, err := loadTemplates(templateFS(""))
 	if err != nil {
 		t.Fatalf("loadTemplates returned error: %v", err)
 	}
@@ -75,6 +82,93 @@ func TestTopWithdrawalsTableRendersWithdrawalAddress(t *testing.T) {
 	}
 }
 
+func TestLoadTemplatesFromOverrideDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"base.html": `{{define "base.html"}}<main>{{block "content" .}}{{end}}</main>{{end}}`,
+		"page.html": `{{template "base.html" .}}{{define "content"}}override page{{end}}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	templates, err := loadTemplates(templateFS(dir))
+	if err != nil {
+		t.Fatalf("loadTemplates returned error: %v", err)
+	}
+	if _, ok := templates["address-rewards.html"]; ok {
+		t.Fatalf("override directory should replace the embedded templates")
+	}
+	if got := renderTemplateToString(t, templates["page.html"], "page.html", nil); got != "<main>override page</main>" {
+		t.Fatalf("rendered override page = %q", got)
+	}
+}
+
+func TestFrontendServesEmbeddedAssetsFromAnyDirectory(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.DepositorLabelsFile = ""
+	s := NewServer(cfg, rewards.NewService(cfg), nil)
+	if !s.frontendEnabled {
+		t.Fatalf("frontend should stay enabled outside the repository root")
+	}
+
+	for _, path := range []string{"/static/css/style.css", "/static/js/app.js"} {
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK || w.Body.Len() == 0 {
+			t.Fatalf("GET %s = %d with %d bytes, want the embedded asset", path, w.Code, w.Body.Len())
+		}
+	}
+}
+
+func TestTemplatesDirOverridesStaticAssets(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"base.html":            `<html>{{block "content" .}}{{end}}</html>`,
+		"error.html":           `{{template "base.html" .}}{{define "content"}}error{{end}}`,
+		"static/css/style.css": "body { color: red; }",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.DepositorLabelsFile = ""
+	cfg.TemplatesDir = dir
+	s := NewServer(cfg, rewards.NewService(cfg), nil)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+	if w := get("/static/css/style.css"); w.Code != http.StatusOK || w.Body.String() != "body { color: red; }" {
+		t.Fatalf("GET /static/css/style.css = %d %q, want the TEMPLATES_DIR copy", w.Code, w.Body.String())
+	}
+	// The theme has no script, so the embedded one is served.
+	if w := get("/static/js/app.js"); w.Code != http.StatusOK || w.Body.Len() == 0 {
+		t.Fatalf("GET /static/js/app.js = %d with %d bytes, want the embedded asset", w.Code, w.Body.Len())
+	}
+}
+
 func renderTemplateToString(t *testing.T, tmpl *template.Template, name string, data any) string {
 	t.Helper()
 	var buf bytes.Buffer
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// timeoutBody is written in place of a handler's response once REQUEST_TIMEOUT has passed.
+var timeoutBody = []byte(`{"error":"request timed out"}`)
+
+// handlerTimeout bounds the whole handler by timeout: the request context carries the deadline, so
+// Dora queries (see requestContext) fail fast once it passes, and whatever the handler writes after
+// that is replaced by a 504. Handlers do a bounded number of Dora queries; work that scales with the
+// whole network must not run under this deadline. The streaming export is exempt, as it extends its
+// own write deadline, and the APR distribution is built in the background (aprDistributionRoutine).
+func handlerTimeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 || unversionedPath(c.FullPath()) == "/rewards/export" {
+			c.Next()
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		w := &timeoutWriter{ResponseWriter: c.Writer, ctx: ctx}
+		c.Writer = w
+		c.Next()
+		w.expired() // A handler that ran out of time without writing still gets its 504.
+	}
+}
+
+// timeoutWriter turns the first write after the deadline into a 504 and drops the handler's output.
+// Handlers run on the request goroutine, so no locking is needed.
+type timeoutWriter struct {
+	gin.ResponseWriter
+	ctx      context.Context
+	timedOut bool
+}
+
+// expired reports whether the handler's output must be dropped, writing the 504 the first time.
+func (w *timeoutWriter) expired() bool {
+	if w.timedOut {
+		return true
+	}
+	if w.ResponseWriter.Written() || !errors.Is(w.ctx.Err(), context.DeadlineExceeded) {
+		return false
+	}
+	w.timedOut = true
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.ResponseWriter.WriteHeader(http.StatusGatewayTimeout)
+	_, _ = w.ResponseWriter.Write(timeoutBody)
+	return true
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	if !w.expired() {
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	if !w.expired() {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	if w.expired() {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+	if w.expired() {
+		return len(s), nil
+	}
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"beacon-rewards/internal/config"
+	"beacon-rewards/internal/dora"
+	"beacon-rewards/internal/rewards"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestHandlerTimeoutAnswers504(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.DefaultConfig()
+	cfg.DBQueryTimeout = time.Hour // The total deadline, not the per-query one, must cut the query off.
+	s := &Server{config: cfg}
+
+	router := gin.New()
+	router.Use(handlerTimeout(50 * time.Millisecond))
+	// slowQuery stands in for a Dora query that only returns once its context is done.
+	router.GET("/slow", func(c *gin.Context) {
+		ctx, cancel := s.requestContext(c)
+		defer cancel()
+		<-ctx.Done()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ctx.Err().Error()})
+	})
+	router.GET("/silent", func(c *gin.Context) { time.Sleep(80 * time.Millisecond) })
+	router.GET("/fast", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"ok": true}) })
+
+	start := time.Now()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if w.Code != http.StatusGatewayTimeout || !strings.Contains(w.Body.String(), "timed out") {
+		t.Fatalf("slow handler: status = %d body = %s, want 504", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "deadline") {
+		t.Fatalf("handler output leaked into the 504: %s", w.Body.String())
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("slow handler took %s, want it cut off near the 50ms deadline", elapsed)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/silent", nil))
+	if w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("handler that wrote nothing: status = %d, want 504", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("fast handler: status = %d, want 200", w.Code)
+	}
+}
+
+func TestHandlerTimeoutCutsOffSlowDoraQuery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	mock.ExpectQuery("SELECT").WillDelayFor(5 * time.Second).WillReturnRows(sqlmock.NewRows([]string{"validator_index"}).AddRow(1))
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.EnableFrontend = false
+	cfg.RequestTimeout = 50 * time.Millisecond
+	cfg.DBQueryTimeout = time.Hour
+	s := NewServer(cfg, rewards.NewService(cfg), dora.NewFromConn(db))
+
+	start := time.Now()
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/depositors/0x00000000219ab540356cbb839cbe05303d7705fa/validators", nil))
+	if w.Code != http.StatusGatewayTimeout || !strings.Contains(w.Body.String(), "timed out") {
+		t.Fatalf("status = %d body = %s, want 504", w.Code, w.Body.String())
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("request took %s, want the query cut off near the 50ms deadline", elapsed)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("query was not issued: %v", err)
+	}
+}
+
+func TestRequestContextUsesDBQueryTimeout(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.DBQueryTimeout = 2 * time.Second
+	s := &Server{config: cfg}
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	ctx, cancel := s.requestContext(c)
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	if !ok || time.Until(deadline) > 2*time.Second {
+		t.Fatalf("deadline in %s, want at most DB_QUERY_TIMEOUT", time.Until(deadline))
+	}
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"beacon-rewards/internal/rewards"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpcomingProposalsResponse lists a validator's block proposals in the current and next epoch.
+type UpcomingProposalsResponse struct {
+	ValidatorIndex uint64 `json:"validator_index"`
+	Epoch          uint64 `json:"epoch"` // Current epoch.
+	// NextEpochChecked is false when the beacon node did not reveal the next epoch's duties yet;
+	// only the rest of the current epoch was checked then.
+	NextEpochChecked bool                       `json:"next_epoch_checked"`
+	Proposals        []rewards.UpcomingProposal `json:"proposals"`
+}
+
+// upcomingProposalsHandler reports whether a validator is about to propose a block.
+// @Summary      List a validator's upcoming block proposals
+// @Description  Checks the beacon node's proposer duties for the current and next epoch and returns the validator's slots that have not started yet, with their start times. Duties are cached per epoch, so only the first query of an epoch reaches the beacon node. Duties further ahead are not known to the chain. When the node does not serve next-epoch duties yet, next_epoch_checked is false and only the current epoch is covered; retry later for the full view.
+// @Tags         Validators
+// @Produce      json
+// @Param        index  path      int  true  "Validator index"
+// @Success      200    {object}  UpcomingProposalsResponse
+// @Failure      400    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /validators/{index}/upcoming-proposals [get]
+func (s *Server) upcomingProposalsHandler(c *gin.Context) {
+	index, err := strconv.ParseUint(c.Param("index"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "index must be a validator index"})
+		return
+	}
+
+	ctx, cancel := s.requestContext(c)
+	defer cancel()
+	now := time.Now()
+	proposals, nextEpochChecked, err := s.rewardsService.UpcomingProposals(ctx, index, now)
+	if err != nil {
+		slog.Error("Failed to load proposer duties", "validator", index, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load proposer duties"})
+		return
+	}
+	c.JSON(http.StatusOK, UpcomingProposalsResponse{
+		ValidatorIndex:   index,
+		Epoch:            s.rewardsService.Chain().TimeToEpoch(now),
+		NextEpochChecked: nextEpochChecked,
+		Proposals:        proposals,
+	})
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"beacon-rewards/internal/config"
+	"beacon-rewards/internal/rewards"
+	"beacon-rewards/internal/utils"
+)
+
+func TestUpcomingProposalsHandler(t *testing.T) {
+	slots := utils.DefaultChain().SlotsPerEpoch
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		epoch, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, "/eth/v1/validator/duties/proposer/"), 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		var duties []string
+		for slot := epoch * slots; slot < (epoch+1)*slots; slot++ {
+			duties = append(duties, fmt.Sprintf(`{"pubkey":"0x","slot":"%d","validator_index":"%d"}`, slot, slot))
+		}
+		_, _ = fmt.Fprintf(w, `{"data":[%s]}`, strings.Join(duties, ","))
+	}))
+	t.Cleanup(node.Close)
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.EnableFrontend = false
+	cfg.BeaconNodeURL = node.URL
+	s := NewServer(cfg, rewards.NewService(cfg), nil)
+
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	if w := get("/validators/abc/upcoming-proposals"); w.Code != http.StatusBadRequest {
+		t.Fatalf("invalid index: status = %d, want 400", w.Code)
+	}
+
+	// Validator 1 proposed in slot 1 long ago and has nothing coming up.
+	w := get("/validators/1/upcoming-proposals")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	var resp UpcomingProposalsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.ValidatorIndex != 1 || !resp.NextEpochChecked || resp.Proposals == nil || len(resp.Proposals) != 0 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+
+	node.Close()
+	if w := get("/validators/1/upcoming-proposals"); w.Code != http.StatusOK {
+		t.Fatalf("beacon node down with cached duties: status = %d, want 200", w.Code)
+	}
+	s = NewServer(cfg, rewards.NewService(cfg), nil)
+	if w := get("/validators/1/upcoming-proposals"); w.Code != http.StatusInternalServerError {
+		t.Fatalf("beacon node down: status = %d, want 500", w.Code)
+	}
+}
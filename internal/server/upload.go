@@ -0,0 +1,105 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxReportedBadLines bounds how many unparseable lines a 400 from POST /rewards/upload lists.
+const maxReportedBadLines = 5
+
+// rewardsUploadHandler handles reward queries for an uploaded list of validator indices
+// @Summary      Get total rewards (EL+CL) for an uploaded file of validator indices
+// @Description  Accepts a multipart "file" field or a plain-text body with one validator index per line. Blank lines and "#" comments are skipped and duplicates are dropped; at most MAX_UPLOAD_VALIDATORS distinct indices are accepted. A 400 lists the first unparseable lines. The response matches POST /rewards.
+// @Tags         Rewards
+// @Accept       multipart/form-data
+// @Accept       plain
+// @Produce      json
+// @Param        file    formData  file    false  "Newline-delimited validator indices"
+// @Param        fields  query     string  false  "Comma-separated ValidatorReward fields to return (e.g. total_rewards_gwei,project_apr_percent)"
+// @Success      200     {object}  RewardsResponse
+// @Failure      400     {object}  map[string]interface{}
+// @Failure      413     {object}  map[string]string
+// @Failure      503     {object}  map[string]interface{}
+// @Router       /rewards/upload [post]
+func (s *Server) rewardsUploadHandler(c *gin.Context) {
+	var body io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			bindFailed(c, err, "Invalid request body: multipart file field is required")
+			return
+		}
+		f, err := header.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "could not read uploaded file"})
+			return
+		}
+		defer f.Close()
+		body = f
+	}
+
+	validators, badLines, err := parseValidatorIndexList(body)
+	if err != nil {
+		bindFailed(c, err, "could not read validator list")
+		return
+	}
+	if len(badLines) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "validator list contains unparseable lines",
+			"bad_lines": badLines,
+		})
+		return
+	}
+	if len(validators) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validator list is empty"})
+		return
+	}
+	if len(validators) > s.config.MaxUploadValidators {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("validator list has %d indices, more than %d", len(validators), s.config.MaxUploadValidators)})
+		return
+	}
+
+	s.respondRewards(c, validators, nil)
+}
+
+// parseValidatorIndexList reads one validator index per line, skipping blank lines and "#" comments
+// (whole-line or trailing) and dropping duplicates while keeping first-seen order. Lines that are
+// not indices are returned as "line N: text", at most maxReportedBadLines of them.
+func parseValidatorIndexList(r io.Reader) ([]uint64, []string, error) {
+	var (
+		validators []uint64
+		badLines   []string
+		seen       = make(map[uint64]struct{})
+	)
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		idx, err := strconv.ParseUint(line, 10, 64)
+		if err != nil {
+			if len(badLines) < maxReportedBadLines {
+				badLines = append(badLines, fmt.Sprintf("line %d: %q", lineNo, line))
+			}
+			continue
+		}
+		if _, dup := seen[idx]; dup {
+			continue
+		}
+		seen[idx] = struct{}{}
+		validators = append(validators, idx)
+	}
+	return validators, badLines, scanner.Err()
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"beacon-rewards/internal/config"
+	"beacon-rewards/internal/rewards"
+)
+
+func TestParseValidatorIndexList(t *testing.T) {
+	input := "# operator A\n10\n\n 11 # trailing comment\n10\n12\r\n"
+	validators, bad, err := parseValidatorIndexList(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parseValidatorIndexList: %v", err)
+	}
+	if len(bad) != 0 {
+		t.Fatalf("bad lines = %v, want none", bad)
+	}
+	if want := []uint64{10, 11, 12}; !slices.Equal(validators, want) {
+		t.Fatalf("validators = %v, want %v", validators, want)
+	}
+
+	input = "1\nfoo\n-2\n3\nx\ny\nz\nw\n"
+	_, bad, _ = parseValidatorIndexList(strings.NewReader(input))
+	if len(bad) != maxReportedBadLines || bad[0] != `line 2: "foo"` || bad[1] != `line 3: "-2"` {
+		t.Fatalf("bad lines = %v, want the first %d starting with line 2", bad, maxReportedBadLines)
+	}
+}
+
+func TestRewardsUploadHandler(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.EnableFrontend = false
+	cfg.MaxUploadValidators = 3
+	s := NewServer(cfg, rewards.NewService(cfg), nil)
+
+	post := func(body *bytes.Buffer, contentType string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/rewards/upload", body)
+		req.Header.Set("Content-Type", contentType)
+		s.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post(bytes.NewBufferString("1\n2\n2\n3\n"), "text/plain")
+	if w.Code != http.StatusOK {
+		t.Fatalf("plain body: status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	var resp RewardsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	var form bytes.Buffer
+	mw := multipart.NewWriter(&form)
+	part, _ := mw.CreateFormFile("file", "validators.txt")
+	_, _ = part.Write([]byte("# mine\n4\n5\n"))
+	_ = mw.Close()
+	if w := post(&form, mw.FormDataContentType()); w.Code != http.StatusOK {
+		t.Fatalf("multipart: status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+
+	w = post(bytes.NewBufferString("1\nabc\n"), "text/plain")
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `line 2`) {
+		t.Fatalf("bad line: status = %d body = %s, want 400 naming line 2", w.Code, w.Body.String())
+	}
+	if w := post(bytes.NewBufferString("1\n2\n3\n4\n"), "text/plain"); w.Code != http.StatusBadRequest {
+		t.Fatalf("over the cap: status = %d, want 400", w.Code)
+	}
+	if w := post(bytes.NewBufferString("# nothing\n"), "text/plain"); w.Code != http.StatusBadRequest {
+		t.Fatalf("empty list: status = %d, want 400", w.Code)
+	}
+}
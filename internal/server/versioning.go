@@ -0,0 +1,31 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// apiVersion is the response shape served under /v1. The unprefixed routes are aliases of it
+	// until a v2 changes a shape; clients that need stability should use the prefixed routes.
+	apiVersion       = "v1"
+	apiVersionPrefix = "/" + apiVersion
+	apiVersionHeader = "X-API-Version"
+)
+
+// apiVersionMiddleware tags every response with the API version that produced it.
+func apiVersionMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header(apiVersionHeader, apiVersion)
+		c.Next()
+	}
+}
+
+// unversionedPath strips the /v1 prefix, so path checks cover both the prefixed and alias routes.
+func unversionedPath(path string) string {
+	if rest, ok := strings.CutPrefix(path, apiVersionPrefix); ok && (rest == "" || rest[0] == '/') {
+		return rest
+	}
+	return path
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"beacon-rewards/internal/config"
+	"beacon-rewards/internal/rewards"
+)
+
+func TestVersionedRoutesAliasUnprefixed(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.EnableFrontend = false
+	s := NewServer(cfg, rewards.NewService(cfg), nil)
+
+	for _, path := range []string{"/health", "/v1/health", "/sync/status", "/v1/sync/status", "/v1/rewards/network"} {
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want 200", path, w.Code)
+		}
+		if got := w.Header().Get(apiVersionHeader); got != "v1" {
+			t.Fatalf("%s: %s = %q, want v1", path, apiVersionHeader, got)
+		}
+	}
+
+	// Maintenance exemptions apply to the prefixed routes too.
+	s.maintenance.Store(true)
+	for path, want := range map[string]int{
+		"/v1/health":       http.StatusOK,
+		"/v1/sync/status":  http.StatusServiceUnavailable,
+		"/v1health/status": http.StatusServiceUnavailable,
+	} {
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != want {
+			t.Fatalf("%s in maintenance: status = %d, want %d", path, w.Code, want)
+		}
+	}
+}
+
+func TestUnversionedPath(t *testing.T) {
+	for path, want := range map[string]string{
+		"/v1/admin/maintenance": "/admin/maintenance",
+		"/v1":                   "",
+		"/v10/health":           "/v10/health",
+		"/health":               "/health",
+	} {
+		if got := unversionedPath(path); got != want {
+			t.Fatalf("unversionedPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestDisabledEndpointsAreNotRegistered(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.EnableFrontend = false
+	cfg.DisabledEndpoints = map[string]struct{}{"/sync/status": {}, "/health": {}}
+	s := NewServer(cfg, rewards.NewService(cfg), nil)
+
+	for path, want := range map[string]int{
+		"/sync/status":        http.StatusNotFound,
+		"/v1/sync/status":     http.StatusNotFound,
+		"/health":             http.StatusOK, // Always served.
+		"/v1/health":          http.StatusOK,
+		"/sync/failed-epochs": http.StatusOK,
+	} {
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != want {
+			t.Fatalf("%s: status = %d, want %d", path, w.Code, want)
+		}
+	}
+}
+
+func TestEndpointSettingsMatchMethodAndWarnOnUnknownRoutes(t *testing.T) {
+	var buf bytes.Buffer
+	original := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(original) })
+
+	cfg := config.DefaultConfig()
+	cfg.RewardsHistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.EnableFrontend = false
+	cfg.DisabledEndpoints = map[string]struct{}{"POST /rewards/by-address": {}, "/rewards/by-adress": {}, "DELETE /sync/status": {}}
+	s := NewServer(cfg, rewards.NewService(cfg), nil)
+
+	for _, tc := range []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodPost, "/rewards/by-address", http.StatusNotFound},
+		{http.MethodGet, "/rewards/by-address/history", http.StatusServiceUnavailable}, // Still registered; no Dora.
+		{http.MethodGet, "/sync/status", http.StatusOK},
+	} {
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+		if w.Code != tc.want {
+			t.Fatalf("%s %s: status = %d, want %d", tc.method, tc.path, w.Code, tc.want)
+		}
+	}
+
+	logs := buf.String()
+	for _, entry := range []string{"/rewards/by-adress", "DELETE /sync/status"} {
+		if !strings.Contains(logs, `"entry":"`+entry+`"`) {
+			t.Fatalf("expected a warning for %q, logs: %s", entry, logs)
+		}
+	}
+	if strings.Contains(logs, `"entry":"POST /rewards/by-address"`) {
+		t.Fatalf("matching entry should not be reported, logs: %s", logs)
+	}
+}
@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// GweiPerEth is the number of gwei in one ETH.
+const GweiPerEth = 1_000_000_000
+
+// Gwei is an amount in gwei. It encodes as a JSON number by default; SetGweiAsString switches
+// encoding to a decimal string for clients (e.g. JavaScript) that lose precision above 2^53.
+type Gwei int64
+
+var gweiAsString atomic.Bool
+
+// SetGweiAsString selects string (true) or number (false) JSON encoding for Gwei values.
+func SetGweiAsString(enabled bool) {
+	gweiAsString.Store(enabled)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (g Gwei) MarshalJSON() ([]byte, error) {
+	n := strconv.FormatInt(int64(g), 10)
+	if gweiAsString.Load() {
+		return []byte(`"` + n + `"`), nil
+	}
+	return []byte(n), nil
+}
+
+// UnmarshalJSON accepts both encodings so stored data stays readable after the setting changes.
+func (g *Gwei) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		b = []byte(s)
+	}
+	n, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return err
+	}
+	*g = Gwei(n)
+	return nil
+}
+
+// ParseGwei parses an amount given either as integer gwei ("100000000000") or as decimal ETH with an
+// "eth" suffix ("100eth", "0.5eth"). ETH values are converted exactly; more than nine decimal places is an error.
+func ParseGwei(raw string) (Gwei, error) {
+	s := strings.ToLower(strings.TrimSpace(raw))
+	if s == "" {
+		return 0, fmt.Errorf("empty amount")
+	}
+
+	eth, ok := strings.CutSuffix(s, "eth")
+	if !ok {
+		n, err := strconv.ParseUint(s, 10, 63)
+		if err != nil {
+			return 0, fmt.Errorf("invalid gwei amount %q", raw)
+		}
+		return Gwei(n), nil
+	}
+
+	whole, frac, _ := strings.Cut(strings.TrimSpace(eth), ".")
+	if whole == "" && frac == "" {
+		return 0, fmt.Errorf("invalid ETH amount %q", raw)
+	}
+	if len(frac) > 9 {
+		return 0, fmt.Errorf("invalid ETH amount %q: more than 9 decimal places", raw)
+	}
+	var wholeGwei, fracGwei uint64
+	if whole != "" {
+		n, err := strconv.ParseUint(whole, 10, 64)
+		if err != nil || n > math.MaxInt64/GweiPerEth {
+			return 0, fmt.Errorf("invalid ETH amount %q", raw)
+		}
+		wholeGwei = n * GweiPerEth
+	}
+	if frac != "" {
+		n, err := strconv.ParseUint(frac+strings.Repeat("0", 9-len(frac)), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid ETH amount %q", raw)
+		}
+		fracGwei = n
+	}
+	if wholeGwei > math.MaxInt64-fracGwei {
+		return 0, fmt.Errorf("invalid ETH amount %q: out of range", raw)
+	}
+	return Gwei(wholeGwei + fracGwei), nil
+}
@@ -1,47 +1,70 @@
 package utils
 
-import (
-	"sync/atomic"
-	"time"
-)
+import "time"
 
 const (
-	SECONDS_PER_SLOT  = 12
-	SLOTS_PER_EPOCH   = 32
-	SECONDS_PER_EPOCH = SECONDS_PER_SLOT * SLOTS_PER_EPOCH
+	// DefaultSecondsPerSlot and DefaultSlotsPerEpoch are the mainnet-style chain parameters used
+	// unless SECONDS_PER_SLOT and SLOTS_PER_EPOCH override them.
+	DefaultSecondsPerSlot uint64 = 12
+	DefaultSlotsPerEpoch  uint64 = 32
 	// DefaultGenesisTimestamp is the default beacon chain genesis time for Endurance Network (2024-03-04 06:00:00 +0000 UTC).
 	DefaultGenesisTimestamp int64 = 1709532000
 )
 
-var genesisTimestamp atomic.Int64
+// ChainConfig holds the chain parameters epoch/slot/time calculations depend on. There is no
+// process-wide chain: the rewards service owns one (see rewards.Service.Chain) and the packages
+// computing epochs for it are handed that value, so one process can serve several networks.
+type ChainConfig struct {
+	GenesisTimestamp int64 // Unix seconds.
+	SecondsPerSlot   uint64
+	SlotsPerEpoch    uint64
+}
 
-func init() {
-	genesisTimestamp.Store(DefaultGenesisTimestamp)
+// SecondsPerEpoch returns the epoch duration in seconds.
+func (c ChainConfig) SecondsPerEpoch() uint64 {
+	return c.SecondsPerSlot * c.SlotsPerEpoch
 }
 
-// SetGenesisTimestamp overrides the global genesis timestamp used for epoch/time calculations.
-func SetGenesisTimestamp(ts int64) {
-	if ts > 0 {
-		genesisTimestamp.Store(ts)
+// TimeToEpoch returns the epoch containing the given time. Times before genesis map to epoch 0.
+func (c ChainConfig) TimeToEpoch(ts time.Time) uint64 {
+	if c.GenesisTimestamp > ts.Unix() {
+		return 0
 	}
+	return uint64(ts.Unix()-c.GenesisTimestamp) / c.SecondsPerEpoch()
 }
 
-// GenesisTimestamp returns the currently configured genesis timestamp.
-func GenesisTimestamp() int64 {
-	return genesisTimestamp.Load()
+// EpochStartTime returns the time the given epoch starts: genesis + epoch * SECONDS_PER_EPOCH.
+// TimeToEpoch(EpochStartTime(e)) == e.
+func (c ChainConfig) EpochStartTime(epoch uint64) time.Time {
+	return time.Unix(c.GenesisTimestamp+int64(epoch)*int64(c.SecondsPerEpoch()), 0).UTC()
 }
 
-// TimeToEpoch returns the epoch of the given time.
-func TimeToEpoch(ts time.Time) uint64 {
-	genesis := genesisTimestamp.Load()
-	if genesis > ts.Unix() {
-		return 0
+// EpochToTime returns the time the given epoch ends, which is the start of the next epoch. Use it for
+// "synced through epoch" boundaries and EpochStartTime for when an epoch begins.
+func (c ChainConfig) EpochToTime(epoch uint64) time.Time {
+	return c.EpochStartTime(epoch + 1)
+}
+
+// DefaultChain returns the Endurance network parameters described by the Default constants.
+func DefaultChain() ChainConfig {
+	return ChainConfig{
+		GenesisTimestamp: DefaultGenesisTimestamp,
+		SecondsPerSlot:   DefaultSecondsPerSlot,
+		SlotsPerEpoch:    DefaultSlotsPerEpoch,
 	}
-	return uint64((ts.Unix() - genesis) / int64(SECONDS_PER_SLOT) / int64(SLOTS_PER_EPOCH))
 }
 
-// EpochToTime returns the time of the given epoch.
-func EpochToTime(epoch uint64) time.Time {
-	genesis := genesisTimestamp.Load()
-	return time.Unix(genesis+(int64(epoch)+1)*int64(SECONDS_PER_EPOCH), 0).UTC()
+// NewChainConfig returns the parameters of a network; zero values fall back to DefaultChain's.
+func NewChainConfig(genesisTimestamp int64, secondsPerSlot, slotsPerEpoch uint64) ChainConfig {
+	c := DefaultChain()
+	if genesisTimestamp > 0 {
+		c.GenesisTimestamp = genesisTimestamp
+	}
+	if secondsPerSlot > 0 {
+		c.SecondsPerSlot = secondsPerSlot
+	}
+	if slotsPerEpoch > 0 {
+		c.SlotsPerEpoch = slotsPerEpoch
+	}
+	return c
 }
@@ -5,35 +5,97 @@ import (
 	"time"
 )
 
-func TestSetGenesisTimestampOverridesCalculations(t *testing.T) {
-	original := GenesisTimestamp()
-	t.Cleanup(func() {
-		SetGenesisTimestamp(original)
-	})
+func TestNewChainConfigFallsBackToDefaults(t *testing.T) {
+	if got := NewChainConfig(0, 0, 0); got != DefaultChain() {
+		t.Fatalf("NewChainConfig(0, 0, 0) = %+v, want %+v", got, DefaultChain())
+	}
+
+	c := NewChainConfig(1_800_000_000, 5, 8)
+	if c.GenesisTimestamp != 1_800_000_000 || c.SecondsPerSlot != 5 || c.SlotsPerEpoch != 8 || c.SecondsPerEpoch() != 40 {
+		t.Fatalf("chain = %+v, want genesis 1800000000 and 5s slots, 8 per epoch", c)
+	}
+	if epoch := c.TimeToEpoch(time.Unix(1_800_000_000+85, 0)); epoch != 2 {
+		t.Fatalf("TimeToEpoch = %d, want 2", epoch)
+	}
+	if got, want := c.EpochToTime(2), time.Unix(1_800_000_000+120, 0).UTC(); !got.Equal(want) {
+		t.Fatalf("EpochToTime = %s, want %s", got, want)
+	}
+}
 
-	newGenesis := int64(1_800_000_000)
-	SetGenesisTimestamp(newGenesis)
+func TestEpochBoundaries(t *testing.T) {
+	c := NewChainConfig(1_800_000_000, 12, 32)
 
-	if got := GenesisTimestamp(); got != newGenesis {
-		t.Fatalf("GenesisTimestamp = %d, want %d", got, newGenesis)
+	genesis := time.Unix(1_800_000_000, 0).UTC()
+	cases := []struct {
+		name string
+		got  time.Time
+		want time.Time
+	}{
+		{"start of epoch 0 is genesis", c.EpochStartTime(0), genesis},
+		{"start of epoch 1", c.EpochStartTime(1), genesis.Add(384 * time.Second)},
+		{"end of epoch 0 is start of epoch 1", c.EpochToTime(0), genesis.Add(384 * time.Second)},
+		{"end of epoch 9", c.EpochToTime(9), genesis.Add(3840 * time.Second)},
+	}
+	for _, tc := range cases {
+		if !tc.got.Equal(tc.want) {
+			t.Fatalf("%s: got %s, want %s", tc.name, tc.got, tc.want)
+		}
 	}
 
-	epoch := TimeToEpoch(time.Unix(newGenesis+int64(SECONDS_PER_EPOCH), 0))
-	if epoch != 1 {
-		t.Fatalf("TimeToEpoch with overridden genesis = %d, want 1", epoch)
+	for _, epoch := range []uint64{0, 1, 225_000} {
+		if got := c.TimeToEpoch(c.EpochStartTime(epoch)); got != epoch {
+			t.Fatalf("TimeToEpoch(EpochStartTime(%d)) = %d", epoch, got)
+		}
+		if got := c.TimeToEpoch(c.EpochToTime(epoch).Add(-time.Second)); got != epoch {
+			t.Fatalf("last second of epoch %d maps to %d", epoch, got)
+		}
+		if got := c.TimeToEpoch(c.EpochToTime(epoch)); got != epoch+1 {
+			t.Fatalf("TimeToEpoch(EpochToTime(%d)) = %d, want %d", epoch, got, epoch+1)
+		}
+	}
+	if got := c.TimeToEpoch(genesis.Add(-time.Hour)); got != 0 {
+		t.Fatalf("pre-genesis time maps to epoch %d, want 0", got)
+	}
+}
+
+func TestParseGwei(t *testing.T) {
+	valid := map[string]Gwei{
+		"100000000000":   100_000_000_000,
+		"0":              0,
+		"100eth":         100 * GweiPerEth,
+		" 32ETH ":        32 * GweiPerEth,
+		"1.5eth":         1_500_000_000,
+		"0.000000001eth": 1,
+		".25eth":         250_000_000,
+		"2.eth":          2 * GweiPerEth,
+		"9223372036eth":  9_223_372_036 * GweiPerEth,
+	}
+	for raw, want := range valid {
+		got, err := ParseGwei(raw)
+		if err != nil || got != want {
+			t.Fatalf("ParseGwei(%q) = (%d, %v), want %d", raw, got, err, want)
+		}
 	}
 
-	epochTime := EpochToTime(0)
-	expected := time.Unix(newGenesis+int64(SECONDS_PER_EPOCH), 0).UTC()
-	if !epochTime.Equal(expected) {
-		t.Fatalf("EpochToTime = %s, want %s", epochTime, expected)
+	for _, raw := range []string{"", "eth", ".eth", "-1", "1.5", "0.0000000001eth", "1e9", "abc", "10gwei", "9223372037eth", "-1eth"} {
+		if got, err := ParseGwei(raw); err == nil {
+			t.Fatalf("ParseGwei(%q) = %d, want error", raw, got)
+		}
 	}
 }
 
-func TestSetGenesisTimestampIgnoresNonPositive(t *testing.T) {
-	original := GenesisTimestamp()
-	SetGenesisTimestamp(0)
-	if got := GenesisTimestamp(); got != original {
-		t.Fatalf("expected genesis timestamp to remain %d when setting 0, got %d", original, got)
+func TestChainConfigsAreIndependent(t *testing.T) {
+	mainnet := ChainConfig{GenesisTimestamp: 1_606_824_023, SecondsPerSlot: 12, SlotsPerEpoch: 32}
+	devnet := ChainConfig{GenesisTimestamp: 1_800_000_000, SecondsPerSlot: 6, SlotsPerEpoch: 8}
+
+	ts := time.Unix(1_800_000_000+100, 0)
+	if got := devnet.TimeToEpoch(ts); got != 2 {
+		t.Fatalf("devnet TimeToEpoch = %d, want 2", got)
+	}
+	if got, want := mainnet.TimeToEpoch(ts), uint64(1_800_000_100-1_606_824_023)/384; got != want {
+		t.Fatalf("mainnet TimeToEpoch = %d, want %d", got, want)
+	}
+	if got, want := devnet.EpochToTime(2), time.Unix(1_800_000_000+144, 0).UTC(); !got.Equal(want) {
+		t.Fatalf("devnet EpochToTime = %s, want %s", got, want)
 	}
 }